@@ -0,0 +1,180 @@
+// Package client is http client of create-nft-go api. It follows internal/ports/http/openapi/openapi.json,
+// operations of spec have methods with the same names
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rom6n/create-nft-go/internal/ports/http/handler"
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	initData   string
+	origin     string
+}
+
+type ClientCfg struct {
+	BaseURL    string
+	HTTPClient *http.Client // http.Client with 30s timeout if nil
+	InitData   string       // telegram init data for user, market, support, roles, reconciliation and v2 routes
+	Origin     string       // origin of mini app, it is checked together with init data
+}
+
+// Error is not 2xx response. Response is decoded error envelope of v2 api, v1 api text is in Response.Message
+type Error struct {
+	StatusCode int
+	Response   handler.ErrorResponse
+}
+
+func (e *Error) Error() string {
+	if e.Response.Code != "" {
+		return fmt.Sprintf("api responded with status %v: %v: %v", e.StatusCode, e.Response.Code, e.Response.Message)
+	}
+	return fmt.Sprintf("api responded with status %v: %v", e.StatusCode, e.Response.Message)
+}
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey makes paid requests sent with ctx replayable, retries with the same key arent charged again
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+func New(cfg ClientCfg) *Client {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		httpClient: httpClient,
+		initData:   cfg.InitData,
+		origin:     cfg.Origin,
+	}
+}
+
+// fileForm is multipart body of upload requests, do sends it as is
+type fileForm struct {
+	contentType string
+	body        []byte
+}
+
+func newFileForm(fields map[string]string, fileName string, data []byte) (*fileForm, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if writeErr := writer.WriteField(name, value); writeErr != nil {
+			return nil, fmt.Errorf("error encoding form: %w", writeErr)
+		}
+	}
+
+	part, partErr := writer.CreateFormFile("file", fileName)
+	if partErr != nil {
+		return nil, fmt.Errorf("error encoding form: %w", partErr)
+	}
+	if _, writeErr := part.Write(data); writeErr != nil {
+		return nil, fmt.Errorf("error encoding form: %w", writeErr)
+	}
+	if closeErr := writer.Close(); closeErr != nil {
+		return nil, fmt.Errorf("error encoding form: %w", closeErr)
+	}
+
+	return &fileForm{contentType: writer.FormDataContentType(), body: body.Bytes()}, nil
+}
+
+// do sends value encoded to json if it isnt nil and decodes json response to result if it isnt nil
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, value any, result any) error {
+	var bodyReader io.Reader
+	contentType := "application/json"
+	if form, ok := value.(*fileForm); ok {
+		bodyReader = bytes.NewReader(form.body)
+		contentType = form.contentType
+	} else if value != nil {
+		encoded, encodeErr := json.Marshal(value)
+		if encodeErr != nil {
+			return fmt.Errorf("error encoding request: %w", encodeErr)
+		}
+		bodyReader = bytes.NewReader(encoded)
+	}
+
+	requestURL := c.baseURL + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	req, reqErr := http.NewRequestWithContext(ctx, method, requestURL, bodyReader)
+	if reqErr != nil {
+		return fmt.Errorf("error creating request: %w", reqErr)
+	}
+	if value != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.initData != "" {
+		req.Header.Set("X-Init-Data", c.initData)
+		req.Header.Set("Origin", c.origin)
+	}
+	if key, ok := ctx.Value(idempotencyKeyCtx{}).(string); ok && key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	resp, doErr := c.httpClient.Do(req)
+	if doErr != nil {
+		return fmt.Errorf("error sending request: %w", doErr)
+	}
+	defer resp.Body.Close()
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return fmt.Errorf("error reading response: %w", readErr)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		if json.Unmarshal(respBody, &apiErr.Response) != nil || apiErr.Response.Code == "" {
+			apiErr.Response = handler.ErrorResponse{Message: string(respBody)}
+		}
+		return apiErr
+	}
+
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+
+	// files are returned as is
+	if raw, ok := result.(*[]byte); ok {
+		*raw = respBody
+		return nil
+	}
+
+	// plain text responses of v1 api
+	if text, ok := result.(*string); ok && !json.Valid(respBody) {
+		*text = string(respBody)
+		return nil
+	}
+
+	if decodeErr := json.Unmarshal(respBody, result); decodeErr != nil {
+		return fmt.Errorf("error decoding response: %w", decodeErr)
+	}
+
+	return nil
+}
+
+func pathID(id int64) string {
+	return fmt.Sprint(id)
+}
+
+func boolQuery(query url.Values, key string, value bool) {
+	query.Set(key, fmt.Sprint(value))
+}
@@ -0,0 +1,798 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/audit"
+	"github.com/rom6n/create-nft-go/internal/domain/deposit"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
+	"github.com/rom6n/create-nft-go/internal/domain/reconciliation"
+	"github.com/rom6n/create-nft-go/internal/domain/royalty"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"github.com/rom6n/create-nft-go/internal/domain/wallet"
+	"github.com/rom6n/create-nft-go/internal/ports/http/handler"
+)
+
+// NftItems is response of batch mint. Error is set if part of items is minted and the rest is refunded,
+// it is text on v1 api and handler.ErrorResponse on v2 api
+type NftItems struct {
+	Items []nftitem.NftItem `json:"items"`
+	Error json.RawMessage   `json:"error,omitempty"`
+}
+
+type Listings struct {
+	Items []listing.Listing `json:"items"`
+}
+
+type Auctions struct {
+	Items []auction.Auction `json:"items"`
+}
+
+type AuctionBids struct {
+	Items []auction.Bid `json:"items"`
+}
+
+type WithdrawNftResponse struct {
+	Address    string `json:"address"`
+	WithdrawTo string `json:"withdraw_to"`
+}
+
+type WithdrawTonResponse struct {
+	Amount     uint64 `json:"amount"`
+	WithdrawTo string `json:"withdraw_to"`
+}
+
+type DeployMarketResponse struct {
+	IsTestnet bool `json:"is_testnet"`
+}
+
+type MarketTonResponse struct {
+	Amount uint64 `json:"amount"`
+}
+
+func (c *Client) Ping(ctx context.Context) (string, error) {
+	var text string
+	return text, c.do(ctx, http.MethodGet, "/ping", nil, nil, &text)
+}
+
+func (c *Client) GetOpenAPI(ctx context.Context) (map[string]any, error) {
+	var spec map[string]any
+	return spec, c.do(ctx, http.MethodGet, "/api/openapi.json", nil, nil, &spec)
+}
+
+// Search finds nft collections and nft items. Attributes are trait_type:value and every one must match
+func (c *Client) Search(ctx context.Context, query search.Query) (*search.Result, error) {
+	values := url.Values{}
+	if query.Text != "" {
+		values.Set("q", query.Text)
+	}
+	if query.Kind != "" {
+		values.Set("kind", string(query.Kind))
+	}
+	if query.IsTestnet != nil {
+		boolQuery(values, "is-testnet", *query.IsTestnet)
+	}
+	for _, attribute := range query.Attributes {
+		values.Add("attribute", attribute.TraitType+":"+attribute.Value)
+	}
+	if query.Offset > 0 {
+		values.Set("offset", fmt.Sprint(query.Offset))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", fmt.Sprint(query.Limit))
+	}
+
+	var result search.Result
+	if err := c.do(ctx, http.MethodGet, "/api/search", values, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Quote(ctx context.Context, req pricing.QuoteRequest) (*pricing.Quote, error) {
+	values := url.Values{}
+	values.Set("operation", string(req.Operation))
+	if req.Items > 0 {
+		values.Set("items", fmt.Sprint(req.Items))
+	}
+	if req.ForwardAmount > 0 {
+		values.Set("forward-amount", fmt.Sprint(req.ForwardAmount))
+	}
+	boolQuery(values, "is-testnet", req.IsTestnet)
+
+	var quote pricing.Quote
+	if err := c.do(ctx, http.MethodGet, "/api/quote", values, nil, &quote); err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+// GetMetadataDocument returns raw metadata document hosted by the app
+func (c *Client) GetMetadataDocument(ctx context.Context, hash string) (json.RawMessage, error) {
+	var document json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/api/metadata/"+url.PathEscape(hash), nil, nil, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// UploadMedia uploads image file of kind, fileName is only sent in form
+func (c *Client) UploadMedia(ctx context.Context, kind media.Kind, fileName string, data []byte) (*media.Media, error) {
+	form, formErr := newFileForm(nil, fileName, data)
+	if formErr != nil {
+		return nil, formErr
+	}
+
+	var result media.Media
+	if err := c.do(ctx, http.MethodPost, "/api/media/upload", url.Values{"kind": {string(kind)}}, form, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetMedia(ctx context.Context, hash string) (*media.Media, error) {
+	var result media.Media
+	if err := c.do(ctx, http.MethodGet, "/api/media/"+url.PathEscape(hash), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetMediaFile returns file stored by the app, key is last segment of media or thumbnail url
+func (c *Client) GetMediaFile(ctx context.Context, key string) ([]byte, error) {
+	var file []byte
+	if err := c.do(ctx, http.MethodGet, "/api/media/files/"+url.PathEscape(key), nil, nil, &file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (c *Client) GetWalletData(ctx context.Context, walletAddress string) (*wallet.Wallet, error) {
+	var result wallet.Wallet
+	if err := c.do(ctx, http.MethodGet, "/api/wallet/get-wallet-data", url.Values{"wallet-address": {walletAddress}}, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) RefreshWalletNftItems(ctx context.Context, walletAddress string) ([]wallet.NftItem, error) {
+	var result []wallet.NftItem
+	return result, c.do(ctx, http.MethodPost, "/api/wallet/refresh-wallet-nft-items", url.Values{"wallet-address": {walletAddress}}, nil, &result)
+}
+
+func (c *Client) GetUserData(ctx context.Context, userID int64) (*user.User, error) {
+	var result user.User
+	if err := c.do(ctx, http.MethodGet, "/api/user/"+pathID(userID), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetUserNftCollections(ctx context.Context, userID int64) ([]nftcollection.NftCollection, error) {
+	var result []nftcollection.NftCollection
+	return result, c.do(ctx, http.MethodGet, "/api/user/nft-collections/"+pathID(userID), nil, nil, &result)
+}
+
+func (c *Client) GetUserNftItems(ctx context.Context, userID int64) ([]nftitem.NftItem, error) {
+	var result []nftitem.NftItem
+	return result, c.do(ctx, http.MethodGet, "/api/user/nft-items/"+pathID(userID), nil, nil, &result)
+}
+
+func (c *Client) GetUserBalanceEntries(ctx context.Context, userID int64) ([]ledger.BalanceEntry, error) {
+	var result []ledger.BalanceEntry
+	return result, c.do(ctx, http.MethodGet, "/api/user/balance-entries/"+pathID(userID), nil, nil, &result)
+}
+
+func (c *Client) GetUserDepositMemo(ctx context.Context, userID int64) (string, error) {
+	var result struct {
+		Memo string `json:"memo"`
+	}
+	return result.Memo, c.do(ctx, http.MethodGet, "/api/user/deposit-memo/"+pathID(userID), nil, nil, &result)
+}
+
+func (c *Client) GetUserRoyaltyEarnings(ctx context.Context, userID int64) ([]royalty.Earnings, error) {
+	var result []royalty.Earnings
+	return result, c.do(ctx, http.MethodGet, "/api/user/royalty-earnings/"+pathID(userID), nil, nil, &result)
+}
+
+// WithdrawUserTON is v1 withdraw, amount is in nano ton
+func (c *Client) WithdrawUserTON(ctx context.Context, userID int64, withdrawTo string, amount uint64, isTestnet bool) (string, error) {
+	values := url.Values{"withdraw-to": {withdrawTo}, "amount": {fmt.Sprint(amount)}}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	var text string
+	return text, c.do(ctx, http.MethodPost, "/api/user/withdraw/"+pathID(userID), values, nil, &text)
+}
+
+// DeployNftCollection is v1 deploy. Onchain or hosted metadata is sent in body if it is set in cfg
+func (c *Client) DeployNftCollection(ctx context.Context, cfg nftcollection.DeployCollectionCfg, isTestnet bool) (*nftcollection.NftCollection, error) {
+	values := url.Values{
+		"royalty-dividend": {fmt.Sprint(cfg.RoyaltyDividend)},
+		"royalty-divisor":  {fmt.Sprint(cfg.RoyaltyDivisor)},
+	}
+	if cfg.OwnerAddress != nil {
+		values.Set("owner-wallet", cfg.OwnerAddress.String())
+	}
+	if cfg.CollectionContent != "" {
+		values.Set("collection-content", cfg.CollectionContent)
+	}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	var body any
+	if cfg.OnchainMetadata != nil {
+		boolQuery(values, "onchain", true)
+		body = cfg.OnchainMetadata
+	}
+	if cfg.HostedMetadata != nil {
+		boolQuery(values, "hosted", true)
+		body = cfg.HostedMetadata
+	}
+
+	var result nftcollection.NftCollection
+	if err := c.do(ctx, http.MethodPost, "/api/nft-collection/deploy", values, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) WithdrawNftCollection(ctx context.Context, collectionAddress string, withdrawTo string, isTestnet bool) (string, error) {
+	values := url.Values{"withdraw-to": {withdrawTo}}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	var text string
+	return text, c.do(ctx, http.MethodPost, "/api/nft-collection/withdraw/"+url.PathEscape(collectionAddress), values, nil, &text)
+}
+
+// ChangeNftCollectionContent is v1 content change. Onchain or hosted metadata is sent in body if it is set in cfg
+func (c *Client) ChangeNftCollectionContent(ctx context.Context, collectionAddress string, cfg nftcollection.ChangeContentCfg, isTestnet bool) (*nftcollection.NftCollectionMetadata, error) {
+	values := url.Values{}
+	if cfg.CollectionContent != "" {
+		values.Set("collection-content", cfg.CollectionContent)
+	}
+	if cfg.RoyaltyDividend != nil && cfg.RoyaltyDivisor != nil {
+		values.Set("royalty-dividend", fmt.Sprint(*cfg.RoyaltyDividend))
+		values.Set("royalty-divisor", fmt.Sprint(*cfg.RoyaltyDivisor))
+	}
+	if cfg.RoyaltyAddress != nil {
+		values.Set("royalty-address", cfg.RoyaltyAddress.String())
+	}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	var body any
+	if cfg.OnchainMetadata != nil {
+		boolQuery(values, "onchain", true)
+		body = cfg.OnchainMetadata
+	}
+	if cfg.HostedMetadata != nil {
+		boolQuery(values, "hosted", true)
+		body = cfg.HostedMetadata
+	}
+
+	var result nftcollection.NftCollectionMetadata
+	if err := c.do(ctx, http.MethodPost, "/api/nft-collection/change-content/"+url.PathEscape(collectionAddress), values, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// MintNftItem is v1 mint. Hosted metadata is sent in body if it is set in cfg
+func (c *Client) MintNftItem(ctx context.Context, collectionAddress string, cfg nftitem.MintNftItemCfg, isTestnet bool) (*nftitem.NftItem, error) {
+	values := url.Values{"nft-collection-address": {collectionAddress}}
+	if cfg.OwnerAddress != nil {
+		values.Set("owner-wallet", cfg.OwnerAddress.String())
+	}
+	if cfg.Content != "" {
+		values.Set("content", cfg.Content)
+	}
+	if cfg.ForwardAmount > 0 {
+		values.Set("forward-amount", fmt.Sprint(cfg.ForwardAmount))
+	}
+	if cfg.ForwardMessage != "" {
+		values.Set("forward-message", cfg.ForwardMessage)
+	}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	var body any
+	if cfg.HostedMetadata != nil {
+		boolQuery(values, "hosted", true)
+		body = cfg.HostedMetadata
+	}
+
+	var result nftitem.NftItem
+	if err := c.do(ctx, http.MethodPost, "/api/nft-item/mint", values, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) WithdrawNftItem(ctx context.Context, itemAddress string, withdrawTo string, isTestnet bool) (string, error) {
+	values := url.Values{"withdraw-to": {withdrawTo}}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	var text string
+	return text, c.do(ctx, http.MethodPost, "/api/nft-item/withdraw/"+url.PathEscape(itemAddress), values, nil, &text)
+}
+
+func (c *Client) BatchMintNftItems(ctx context.Context, collectionAddress string, cfg nftitem.BatchMintNftItemsCfg, isTestnet bool) (*NftItems, error) {
+	values := url.Values{"nft-collection-address": {collectionAddress}}
+	if cfg.OwnerAddress != nil {
+		values.Set("owner-wallet", cfg.OwnerAddress.String())
+	}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	body := map[string]any{"items": cfg.Items}
+
+	var result NftItems
+	if err := c.do(ctx, http.MethodPost, "/api/nft-item/batch-mint", values, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) TransferNftItem(ctx context.Context, itemAddress string, toUserID int64) (*nftitem.Transfer, error) {
+	values := url.Values{"to-user-id": {pathID(toUserID)}}
+
+	var transfer nftitem.Transfer
+	if err := c.do(ctx, http.MethodPost, "/api/nft-item/transfer/"+url.PathEscape(itemAddress), values, nil, &transfer); err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (c *Client) GetNftItemHistory(ctx context.Context, itemAddress string) ([]nftitem.Transfer, error) {
+	var transfers []nftitem.Transfer
+	return transfers, c.do(ctx, http.MethodGet, "/api/nft-item/history/"+url.PathEscape(itemAddress), nil, nil, &transfers)
+}
+
+func listingQuery(query listing.Query) url.Values {
+	values := url.Values{}
+	if query.CollectionAddress != "" {
+		values.Set("collection-address", query.CollectionAddress)
+	}
+	if query.Seller != nil {
+		values.Set("seller", query.Seller.String())
+	}
+	if query.IsTestnet != nil {
+		boolQuery(values, "is-testnet", *query.IsTestnet)
+	}
+	if query.MinPrice > 0 {
+		values.Set("min-price", fmt.Sprint(query.MinPrice))
+	}
+	if query.MaxPrice > 0 {
+		values.Set("max-price", fmt.Sprint(query.MaxPrice))
+	}
+	if query.Sort != "" {
+		values.Set("sort", string(query.Sort))
+	}
+	if query.Offset > 0 {
+		values.Set("offset", fmt.Sprint(query.Offset))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", fmt.Sprint(query.Limit))
+	}
+	return values
+}
+
+func (c *Client) SearchListings(ctx context.Context, query listing.Query) ([]listing.Listing, error) {
+	var listings []listing.Listing
+	return listings, c.do(ctx, http.MethodGet, "/api/listing/search", listingQuery(query), nil, &listings)
+}
+
+func (c *Client) GetListing(ctx context.Context, id uuid.UUID) (*listing.Listing, error) {
+	var result listing.Listing
+	if err := c.do(ctx, http.MethodGet, "/api/listing/"+id.String(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) CreateListing(ctx context.Context, itemAddress string, price uint64) (*listing.Listing, error) {
+	values := url.Values{"nft-item-address": {itemAddress}, "price": {fmt.Sprint(price)}}
+
+	var result listing.Listing
+	if err := c.do(ctx, http.MethodPost, "/api/listing/create", values, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) CancelListing(ctx context.Context, id uuid.UUID) (*listing.Listing, error) {
+	var result listing.Listing
+	if err := c.do(ctx, http.MethodPost, "/api/listing/cancel/"+id.String(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) BuyListing(ctx context.Context, id uuid.UUID) (*listing.Listing, error) {
+	var result listing.Listing
+	if err := c.do(ctx, http.MethodPost, "/api/listing/buy/"+id.String(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func auctionQuery(query auction.Query) url.Values {
+	values := url.Values{}
+	if query.CollectionAddress != "" {
+		values.Set("collection-address", query.CollectionAddress)
+	}
+	if query.Seller != nil {
+		values.Set("seller", query.Seller.String())
+	}
+	if query.IsTestnet != nil {
+		boolQuery(values, "is-testnet", *query.IsTestnet)
+	}
+	if query.Sort != "" {
+		values.Set("sort", string(query.Sort))
+	}
+	if query.Offset > 0 {
+		values.Set("offset", fmt.Sprint(query.Offset))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", fmt.Sprint(query.Limit))
+	}
+	return values
+}
+
+func (c *Client) SearchAuctions(ctx context.Context, query auction.Query) ([]auction.Auction, error) {
+	var auctions []auction.Auction
+	return auctions, c.do(ctx, http.MethodGet, "/api/auction/search", auctionQuery(query), nil, &auctions)
+}
+
+func (c *Client) GetAuction(ctx context.Context, id uuid.UUID) (*auction.Auction, error) {
+	var result auction.Auction
+	if err := c.do(ctx, http.MethodGet, "/api/auction/"+id.String(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetAuctionBids(ctx context.Context, id uuid.UUID) ([]auction.Bid, error) {
+	var bids []auction.Bid
+	return bids, c.do(ctx, http.MethodGet, "/api/auction/bids/"+id.String(), nil, nil, &bids)
+}
+
+func (c *Client) CreateAuction(ctx context.Context, cfg auction.CreateAuctionCfg) (*auction.Auction, error) {
+	values := url.Values{
+		"nft-item-address": {cfg.NftItemAddress},
+		"start-price":      {fmt.Sprint(cfg.StartPrice)},
+		"min-increment":    {fmt.Sprint(cfg.MinIncrement)},
+		"ends-at":          {cfg.EndsAt.Format(time.RFC3339)},
+	}
+	if cfg.Extension > 0 {
+		values.Set("extension-seconds", fmt.Sprint(int64(cfg.Extension/time.Second)))
+	}
+
+	var result auction.Auction
+	if err := c.do(ctx, http.MethodPost, "/api/auction/create", values, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) CancelAuction(ctx context.Context, id uuid.UUID) (*auction.Auction, error) {
+	var result auction.Auction
+	if err := c.do(ctx, http.MethodPost, "/api/auction/cancel/"+id.String(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) PlaceBid(ctx context.Context, id uuid.UUID, amount uint64) (*auction.Auction, error) {
+	values := url.Values{"amount": {fmt.Sprint(amount)}}
+
+	var result auction.Auction
+	if err := c.do(ctx, http.MethodPost, "/api/auction/bid/"+id.String(), values, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) DeployMarketContract(ctx context.Context, isTestnet bool) (string, error) {
+	values := url.Values{}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	var text string
+	return text, c.do(ctx, http.MethodPost, "/api/market/deploy", values, nil, &text)
+}
+
+func (c *Client) DepositMarket(ctx context.Context, amount uint64, isTestnet bool) (string, error) {
+	values := url.Values{"amount": {fmt.Sprint(amount)}}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	var text string
+	return text, c.do(ctx, http.MethodPost, "/api/market/deposit", values, nil, &text)
+}
+
+func (c *Client) WithdrawTonFromMarketContract(ctx context.Context, amount uint64, message string, isTestnet bool) (string, error) {
+	values := url.Values{"amount": {fmt.Sprint(amount)}}
+	if message != "" {
+		values.Set("message", message)
+	}
+	boolQuery(values, "is-testnet", isTestnet)
+
+	var text string
+	return text, c.do(ctx, http.MethodPost, "/api/market/withdraw", values, nil, &text)
+}
+
+func (c *Client) GetUnmatchedDeposits(ctx context.Context) ([]deposit.Deposit, error) {
+	var result []deposit.Deposit
+	return result, c.do(ctx, http.MethodGet, "/api/support/deposits/unmatched", nil, nil, &result)
+}
+
+func (c *Client) AssignDeposit(ctx context.Context, depositID uuid.UUID, userID int64) (string, error) {
+	var text string
+	return text, c.do(ctx, http.MethodPost, "/api/support/deposits/"+depositID.String()+"/assign", url.Values{"user-id": {pathID(userID)}}, nil, &text)
+}
+
+func (c *Client) RefundDeposit(ctx context.Context, depositID uuid.UUID) (string, error) {
+	var text string
+	return text, c.do(ctx, http.MethodPost, "/api/support/deposits/"+depositID.String()+"/refund", nil, nil, &text)
+}
+
+// StartReconciliation starts reconciliation with policy, empty policy only reports mismatches
+func (c *Client) StartReconciliation(ctx context.Context, policy reconciliation.Policy) (*reconciliation.Report, error) {
+	values := url.Values{}
+	if policy != "" {
+		values.Set("policy", string(policy))
+	}
+
+	var result reconciliation.Report
+	if err := c.do(ctx, http.MethodPost, "/api/reconciliation", values, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetReconciliationReports returns last reports, newest first. Zero limit is default of server
+func (c *Client) GetReconciliationReports(ctx context.Context, limit int) ([]reconciliation.Report, error) {
+	values := url.Values{}
+	if limit != 0 {
+		values.Set("limit", fmt.Sprint(limit))
+	}
+
+	var result []reconciliation.Report
+	return result, c.do(ctx, http.MethodGet, "/api/reconciliation/reports", values, nil, &result)
+}
+
+func (c *Client) GetReconciliationReport(ctx context.Context, reportID uuid.UUID) (*reconciliation.Report, error) {
+	var result reconciliation.Report
+	if err := c.do(ctx, http.MethodGet, "/api/reconciliation/reports/"+reportID.String(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GrantRole(ctx context.Context, userID int64, role string, reason string) (*user.User, error) {
+	var result user.User
+	if err := c.do(ctx, http.MethodPost, "/api/roles/"+pathID(userID), url.Values{"role": {role}, "reason": {reason}}, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) RevokeRole(ctx context.Context, userID int64, reason string) (*user.User, error) {
+	var result user.User
+	if err := c.do(ctx, http.MethodDelete, "/api/roles/"+pathID(userID), url.Values{"reason": {reason}}, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetAuditEntries(ctx context.Context, userID int64) ([]audit.Entry, error) {
+	var result []audit.Entry
+	return result, c.do(ctx, http.MethodGet, "/api/roles/"+pathID(userID)+"/audit", nil, nil, &result)
+}
+
+func (c *Client) DeployNftCollectionV2(ctx context.Context, request handler.DeployNftCollectionRequest) (*nftcollection.NftCollection, error) {
+	var result nftcollection.NftCollection
+	if err := c.do(ctx, http.MethodPost, "/api/v2/nft-collections", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) WithdrawNftCollectionV2(ctx context.Context, collectionAddress string, request handler.WithdrawNftRequest) (*WithdrawNftResponse, error) {
+	var result WithdrawNftResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v2/nft-collections/"+url.PathEscape(collectionAddress)+"/withdraw", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ChangeNftCollectionContentV2(ctx context.Context, collectionAddress string, request handler.ChangeNftCollectionContentRequest) (*nftcollection.NftCollectionMetadata, error) {
+	var result nftcollection.NftCollectionMetadata
+	if err := c.do(ctx, http.MethodPost, "/api/v2/nft-collections/"+url.PathEscape(collectionAddress)+"/content", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) MintNftItemV2(ctx context.Context, request handler.MintNftItemRequest) (*nftitem.NftItem, error) {
+	var result nftitem.NftItem
+	if err := c.do(ctx, http.MethodPost, "/api/v2/nft-items", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) BatchMintNftItemsV2(ctx context.Context, request handler.BatchMintNftItemsRequest) (*NftItems, error) {
+	var result NftItems
+	if err := c.do(ctx, http.MethodPost, "/api/v2/nft-items/batch", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) WithdrawNftItemV2(ctx context.Context, itemAddress string, request handler.WithdrawNftRequest) (*WithdrawNftResponse, error) {
+	var result WithdrawNftResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v2/nft-items/"+url.PathEscape(itemAddress)+"/withdraw", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) TransferNftItemV2(ctx context.Context, itemAddress string, request handler.TransferNftItemRequest) (*nftitem.Transfer, error) {
+	var transfer nftitem.Transfer
+	if err := c.do(ctx, http.MethodPost, "/api/v2/nft-items/"+url.PathEscape(itemAddress)+"/transfer", nil, request, &transfer); err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (c *Client) GetNftItemHistoryV2(ctx context.Context, itemAddress string) ([]nftitem.Transfer, error) {
+	var transfers []nftitem.Transfer
+	return transfers, c.do(ctx, http.MethodGet, "/api/v2/nft-items/"+url.PathEscape(itemAddress)+"/history", nil, nil, &transfers)
+}
+
+func (c *Client) SearchListingsV2(ctx context.Context, query listing.Query) (*Listings, error) {
+	var result Listings
+	if err := c.do(ctx, http.MethodGet, "/api/v2/listings", listingQuery(query), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) CreateListingV2(ctx context.Context, request handler.CreateListingRequest) (*listing.Listing, error) {
+	var result listing.Listing
+	if err := c.do(ctx, http.MethodPost, "/api/v2/listings", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetListingV2(ctx context.Context, id uuid.UUID) (*listing.Listing, error) {
+	var result listing.Listing
+	if err := c.do(ctx, http.MethodGet, "/api/v2/listings/"+id.String(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) CancelListingV2(ctx context.Context, id uuid.UUID) (*listing.Listing, error) {
+	var result listing.Listing
+	if err := c.do(ctx, http.MethodPost, "/api/v2/listings/"+id.String()+"/cancel", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) BuyListingV2(ctx context.Context, id uuid.UUID) (*listing.Listing, error) {
+	var result listing.Listing
+	if err := c.do(ctx, http.MethodPost, "/api/v2/listings/"+id.String()+"/buy", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) SearchAuctionsV2(ctx context.Context, query auction.Query) (*Auctions, error) {
+	var result Auctions
+	if err := c.do(ctx, http.MethodGet, "/api/v2/auctions", auctionQuery(query), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) CreateAuctionV2(ctx context.Context, request handler.CreateAuctionRequest) (*auction.Auction, error) {
+	var result auction.Auction
+	if err := c.do(ctx, http.MethodPost, "/api/v2/auctions", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetAuctionV2(ctx context.Context, id uuid.UUID) (*auction.Auction, error) {
+	var result auction.Auction
+	if err := c.do(ctx, http.MethodGet, "/api/v2/auctions/"+id.String(), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetAuctionBidsV2(ctx context.Context, id uuid.UUID) (*AuctionBids, error) {
+	var result AuctionBids
+	if err := c.do(ctx, http.MethodGet, "/api/v2/auctions/"+id.String()+"/bids", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) CancelAuctionV2(ctx context.Context, id uuid.UUID) (*auction.Auction, error) {
+	var result auction.Auction
+	if err := c.do(ctx, http.MethodPost, "/api/v2/auctions/"+id.String()+"/cancel", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) PlaceBidV2(ctx context.Context, id uuid.UUID, request handler.PlaceBidRequest) (*auction.Auction, error) {
+	var result auction.Auction
+	if err := c.do(ctx, http.MethodPost, "/api/v2/auctions/"+id.String()+"/bids", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) UploadMediaV2(ctx context.Context, kind media.Kind, fileName string, data []byte) (*media.Media, error) {
+	form, formErr := newFileForm(map[string]string{"kind": string(kind)}, fileName, data)
+	if formErr != nil {
+		return nil, formErr
+	}
+
+	var result media.Media
+	if err := c.do(ctx, http.MethodPost, "/api/v2/media", nil, form, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) WithdrawUserTONV2(ctx context.Context, request handler.WithdrawTonRequest) (*WithdrawTonResponse, error) {
+	var result WithdrawTonResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v2/user/withdraw", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetUserRoyaltyEarningsV2(ctx context.Context) ([]royalty.Earnings, error) {
+	var result []royalty.Earnings
+	return result, c.do(ctx, http.MethodGet, "/api/v2/user/royalty-earnings", nil, nil, &result)
+}
+
+func (c *Client) DeployMarketContractV2(ctx context.Context, request handler.DeployMarketRequest) (*DeployMarketResponse, error) {
+	var result DeployMarketResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v2/market/deploy", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) DepositMarketV2(ctx context.Context, request handler.MarketTonRequest) (*MarketTonResponse, error) {
+	var result MarketTonResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v2/market/deposit", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) WithdrawTonFromMarketContractV2(ctx context.Context, request handler.MarketTonRequest) (*MarketTonResponse, error) {
+	var result MarketTonResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v2/market/withdraw", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,158 @@
+package auction
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusSettled   Status = "settled"   // nft item is transferred to winner
+	StatusExpired   Status = "expired"   // ended without bids, nft item stays with seller
+	StatusCancelled Status = "cancelled" // cancelled by seller before first bid
+)
+
+var (
+	ErrAlreadyOnAuction = errors.New("nft item is already on auction")
+	ErrNotActive        = errors.New("auction is not active anymore")
+	ErrEnded            = errors.New("auction is ended")
+	ErrNotSeller        = errors.New("user must be an auction's seller")
+	ErrOwnAuction       = errors.New("user cant bid on his own auction")
+	ErrHasBids          = errors.New("auction with bids cant be cancelled")
+	ErrBidTooLow        = errors.New("bid is lower than minimal bid")
+	ErrOutbid           = errors.New("auction got another bid, try again")
+	ErrInvalidParams    = errors.New("auction params are not valid")
+)
+
+const (
+	// MinStartPrice is 0.01 TON
+	MinStartPrice uint64 = 10000000
+	MinDuration          = 5 * time.Minute
+	MaxDuration          = 30 * 24 * time.Hour
+	// DefaultExtension is anti-sniping extension used if it isnt set
+	DefaultExtension = 5 * time.Minute
+	MaxExtension     = 1 * time.Hour
+)
+
+// CreateAuctionCfg is params of new auction, zero Extension means DefaultExtension
+type CreateAuctionCfg struct {
+	NftItemAddress string
+	StartPrice     uint64
+	MinIncrement   uint64
+	EndsAt         time.Time
+	Extension      time.Duration
+}
+
+// Auction is english auction of custodial nft item, bids are held on bidders' balances until they are outbid
+type Auction struct {
+	ID                uuid.UUID  `bson:"_id" json:"id"`
+	NftItemAddress    string     `bson:"nft_item_address" json:"nft_item_address"`
+	NftItemName       string     `bson:"nft_item_name" json:"nft_item_name"`
+	CollectionAddress string     `bson:"collection_address" json:"collection_address"`
+	CollectionName    string     `bson:"collection_name" json:"collection_name"`
+	Seller            uuid.UUID  `bson:"seller" json:"seller"`
+	StartPrice        uint64     `bson:"start_price" json:"start_price"`             // nano ton
+	MinIncrement      uint64     `bson:"min_increment" json:"min_increment"`         // nano ton added to current bid by next one
+	ExtensionSeconds  int64      `bson:"extension_seconds" json:"extension_seconds"` // bid placed this close to end moves end to now + extension
+	CurrentBid        uint64     `bson:"current_bid,omitempty" json:"current_bid,omitempty"`
+	CurrentBidID      *uuid.UUID `bson:"current_bid_id,omitempty" json:"current_bid_id,omitempty"`
+	Bidder            *uuid.UUID `bson:"bidder,omitempty" json:"bidder,omitempty"`
+	BidsCount         int64      `bson:"bids_count" json:"bids_count"`
+	RoyaltyAmount     uint64     `bson:"royalty_amount,omitempty" json:"royalty_amount,omitempty"` // paid to nft collection owner from winning bid
+	PlatformFee       uint64     `bson:"platform_fee,omitempty" json:"platform_fee,omitempty"`
+	Status            Status     `bson:"status" json:"status"`
+	IsTestnet         bool       `bson:"is_testnet" json:"is_testnet"`
+	EndsAt            time.Time  `bson:"ends_at" json:"ends_at"`
+	CreatedAt         time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+func New(nftItem *nftitem.NftItem, startPrice uint64, minIncrement uint64, endsAt time.Time, extension time.Duration) *Auction {
+	now := time.Now()
+	return &Auction{
+		ID:                uuid.New(),
+		NftItemAddress:    nftItem.Address,
+		NftItemName:       nftItem.Metadata.Name,
+		CollectionAddress: nftItem.CollectionAddress,
+		CollectionName:    nftItem.CollectionName,
+		Seller:            nftItem.Owner,
+		StartPrice:        startPrice,
+		MinIncrement:      minIncrement,
+		ExtensionSeconds:  int64(extension / time.Second),
+		Status:            StatusActive,
+		IsTestnet:         nftItem.IsTestnet,
+		EndsAt:            endsAt,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+}
+
+// MinBid is the lowest amount accepted as next bid
+func (a *Auction) MinBid() uint64 {
+	if a.BidsCount == 0 {
+		return a.StartPrice
+	}
+	return a.CurrentBid + a.MinIncrement
+}
+
+// NextEndsAt returns end of auction after bid placed at now, late bids extend auction
+func (a *Auction) NextEndsAt(now time.Time) time.Time {
+	extended := now.Add(time.Duration(a.ExtensionSeconds) * time.Second)
+	if extended.After(a.EndsAt) {
+		return extended
+	}
+	return a.EndsAt
+}
+
+// SellerAmount is credited to seller after settlement
+func (a *Auction) SellerAmount() uint64 {
+	return a.CurrentBid - a.RoyaltyAmount - a.PlatformFee
+}
+
+// Bid is held on bidder's balance until it is outbid or auction is settled
+type Bid struct {
+	ID        uuid.UUID `bson:"_id" json:"id"`
+	AuctionID uuid.UUID `bson:"auction_id" json:"auction_id"`
+	Bidder    uuid.UUID `bson:"bidder" json:"bidder"`
+	Amount    uint64    `bson:"amount" json:"amount"` // nano ton
+	IsTestnet bool      `bson:"is_testnet" json:"is_testnet"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+func NewBid(auction *Auction, bidder uuid.UUID, amount uint64) *Bid {
+	return &Bid{
+		ID:        uuid.New(),
+		AuctionID: auction.ID,
+		Bidder:    bidder,
+		Amount:    amount,
+		IsTestnet: auction.IsTestnet,
+		CreatedAt: time.Now(),
+	}
+}
+
+type Sort string
+
+const (
+	SortEndingSoon Sort = "ending_soon"
+	SortNewest     Sort = "newest"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Query filters active auctions, empty fields are not filtered
+type Query struct {
+	CollectionAddress string
+	Seller            *uuid.UUID
+	IsTestnet         *bool
+	Sort              Sort
+	Offset            int
+	Limit             int
+}
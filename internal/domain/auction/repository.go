@@ -0,0 +1,28 @@
+package auction
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuctionRepository interface {
+	// CreateAuction returns ErrAlreadyOnAuction if nft item has active auction
+	CreateAuction(ctx context.Context, auction *Auction) error
+	GetAuctionByID(ctx context.Context, id uuid.UUID) (*Auction, error)
+	// GetActiveAuctionByNftItem returns mongo.ErrNoDocuments if nft item isnt on auction
+	GetActiveAuctionByNftItem(ctx context.Context, nftItemAddress string) (*Auction, error)
+	GetAuctions(ctx context.Context, query Query) ([]Auction, error)
+	// GetEndedAuctions returns active auctions which end is before now
+	GetEndedAuctions(ctx context.Context, now time.Time) ([]Auction, error)
+	// PlaceBid saves bid as current bid of auction with bidsCount bids and moves its end to endsAt.
+	// Returns ErrOutbid if auction got another bid or is not active anymore
+	PlaceBid(ctx context.Context, bid *Bid, bidsCount int64, endsAt time.Time) error
+	GetBids(ctx context.Context, auctionID uuid.UUID) ([]Bid, error)
+	// CloseAuction moves active auction without new bids to settled, expired or cancelled status.
+	// Returns ErrNotActive if auction is not active anymore or got another bid
+	CloseAuction(ctx context.Context, auction *Auction) error
+	// WithTransaction runs fn in transaction, other repositories called with txCtx join it
+	WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
+}
@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoAuctionRepo struct {
+	client             *mongo.Client
+	dbName             string
+	collectionName     string
+	bidsCollectionName string
+	timeout            time.Duration
+}
+
+type AuctionRepoCfg struct {
+	DBName             string
+	CollectionName     string
+	BidsCollectionName string
+	Timeout            time.Duration
+}
+
+func NewAuctionRepo(client *mongo.Client, cfg AuctionRepoCfg) auction.AuctionRepository {
+	repo := &mongoAuctionRepo{
+		client:             client,
+		dbName:             cfg.DBName,
+		collectionName:     cfg.CollectionName,
+		bidsCollectionName: cfg.BidsCollectionName,
+		timeout:            cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating auctions indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoAuctionRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoAuctionRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoAuctionRepo) getBidsCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.bidsCollectionName)
+}
+
+func (r *mongoAuctionRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	if _, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{
+			// nft item can have only one active auction
+			Keys: bson.D{{Key: "nft_item_address", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "status", Value: auction.StatusActive}}),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "ends_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "seller", Value: 1}, {Key: "created_at", Value: -1}}},
+	}); indexErr != nil {
+		return indexErr
+	}
+
+	_, indexErr := r.getBidsCollection().Indexes().CreateOne(dbCtx, mongo.IndexModel{
+		Keys: bson.D{{Key: "auction_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+
+	return indexErr
+}
+
+func (r *mongoAuctionRepo) CreateAuction(ctx context.Context, newAuction *auction.Auction) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	if _, insertErr := r.getCollection().InsertOne(dbCtx, *newAuction); insertErr != nil {
+		if mongo.IsDuplicateKeyError(insertErr) {
+			return auction.ErrAlreadyOnAuction
+		}
+		return fmt.Errorf("error inserting auction: %w", insertErr)
+	}
+
+	return nil
+}
+
+func (r *mongoAuctionRepo) GetAuctionByID(ctx context.Context, id uuid.UUID) (*auction.Auction, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundAuction auction.Auction
+	if decodeErr := r.getCollection().FindOne(dbCtx, bson.D{{Key: "_id", Value: id}}).Decode(&foundAuction); decodeErr != nil {
+		return nil, fmt.Errorf("auction decode error after searching: %w", decodeErr)
+	}
+
+	return &foundAuction, nil
+}
+
+func (r *mongoAuctionRepo) GetActiveAuctionByNftItem(ctx context.Context, nftItemAddress string) (*auction.Auction, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundAuction auction.Auction
+	filter := bson.D{{Key: "nft_item_address", Value: nftItemAddress}, {Key: "status", Value: auction.StatusActive}}
+	if decodeErr := r.getCollection().FindOne(dbCtx, filter).Decode(&foundAuction); decodeErr != nil {
+		return nil, fmt.Errorf("auction decode error after searching: %w", decodeErr)
+	}
+
+	return &foundAuction, nil
+}
+
+func (r *mongoAuctionRepo) GetAuctions(ctx context.Context, query auction.Query) ([]auction.Auction, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	filter := bson.D{{Key: "status", Value: auction.StatusActive}}
+	if query.CollectionAddress != "" {
+		filter = append(filter, bson.E{Key: "collection_address", Value: query.CollectionAddress})
+	}
+	if query.Seller != nil {
+		filter = append(filter, bson.E{Key: "seller", Value: *query.Seller})
+	}
+	if query.IsTestnet != nil {
+		filter = append(filter, bson.E{Key: "is_testnet", Value: *query.IsTestnet})
+	}
+
+	sort := bson.D{{Key: "ends_at", Value: 1}}
+	if query.Sort == auction.SortNewest {
+		sort = bson.D{{Key: "created_at", Value: -1}}
+	}
+
+	findOptions := options.Find().SetSort(sort).SetSkip(int64(query.Offset)).SetLimit(int64(query.Limit))
+
+	foundAuctions := []auction.Auction{}
+	cursor, findErr := r.getCollection().Find(dbCtx, filter, findOptions)
+	if findErr != nil {
+		return nil, fmt.Errorf("auctions find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundAuctions); decodeErr != nil {
+		return nil, fmt.Errorf("auctions decode error: %v", decodeErr)
+	}
+
+	return foundAuctions, nil
+}
+
+func (r *mongoAuctionRepo) GetEndedAuctions(ctx context.Context, now time.Time) ([]auction.Auction, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	filter := bson.D{{Key: "status", Value: auction.StatusActive}, {Key: "ends_at", Value: bson.D{{Key: "$lte", Value: now}}}}
+	findOptions := options.Find().SetSort(bson.D{{Key: "ends_at", Value: 1}})
+
+	foundAuctions := []auction.Auction{}
+	cursor, findErr := r.getCollection().Find(dbCtx, filter, findOptions)
+	if findErr != nil {
+		return nil, fmt.Errorf("ended auctions find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundAuctions); decodeErr != nil {
+		return nil, fmt.Errorf("ended auctions decode error: %v", decodeErr)
+	}
+
+	return foundAuctions, nil
+}
+
+func (r *mongoAuctionRepo) PlaceBid(ctx context.Context, bid *auction.Bid, bidsCount int64, endsAt time.Time) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	// guard: bid is accepted only if nobody has bid since bidder saw the auction and it isnt ended
+	filter := bson.D{
+		{Key: "_id", Value: bid.AuctionID},
+		{Key: "status", Value: auction.StatusActive},
+		{Key: "bids_count", Value: bidsCount},
+		{Key: "ends_at", Value: bson.D{{Key: "$gt", Value: bid.CreatedAt}}},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "current_bid", Value: bid.Amount},
+			{Key: "current_bid_id", Value: bid.ID},
+			{Key: "bidder", Value: bid.Bidder},
+			{Key: "ends_at", Value: endsAt},
+			{Key: "updated_at", Value: bid.CreatedAt},
+		}},
+		{Key: "$inc", Value: bson.D{{Key: "bids_count", Value: 1}}},
+	}
+
+	result, updErr := r.getCollection().UpdateOne(dbCtx, filter, update)
+	if updErr != nil {
+		return fmt.Errorf("error placing bid: %w", updErr)
+	}
+
+	if result.MatchedCount == 0 {
+		return auction.ErrOutbid
+	}
+
+	if _, insertErr := r.getBidsCollection().InsertOne(dbCtx, *bid); insertErr != nil {
+		return fmt.Errorf("error inserting bid: %w", insertErr)
+	}
+
+	return nil
+}
+
+func (r *mongoAuctionRepo) GetBids(ctx context.Context, auctionID uuid.UUID) ([]auction.Bid, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	foundBids := []auction.Bid{}
+	cursor, findErr := r.getBidsCollection().Find(dbCtx, bson.D{{Key: "auction_id", Value: auctionID}}, findOptions)
+	if findErr != nil {
+		return nil, fmt.Errorf("bids find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundBids); decodeErr != nil {
+		return nil, fmt.Errorf("bids decode error: %v", decodeErr)
+	}
+
+	return foundBids, nil
+}
+
+func (r *mongoAuctionRepo) CloseAuction(ctx context.Context, closedAuction *auction.Auction) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	closedAuction.UpdatedAt = time.Now()
+
+	set := bson.D{
+		{Key: "status", Value: closedAuction.Status},
+		{Key: "updated_at", Value: closedAuction.UpdatedAt},
+	}
+	if closedAuction.Status == auction.StatusSettled {
+		set = append(set,
+			bson.E{Key: "royalty_amount", Value: closedAuction.RoyaltyAmount},
+			bson.E{Key: "platform_fee", Value: closedAuction.PlatformFee},
+		)
+	}
+
+	// guard: auction could get a bid or be closed concurrently
+	filter := bson.D{
+		{Key: "_id", Value: closedAuction.ID},
+		{Key: "status", Value: auction.StatusActive},
+		{Key: "bids_count", Value: closedAuction.BidsCount},
+	}
+	result, updErr := r.getCollection().UpdateOne(dbCtx, filter, bson.D{{Key: "$set", Value: set}})
+	if updErr != nil {
+		return fmt.Errorf("error closing auction: %w", updErr)
+	}
+
+	if result.MatchedCount == 0 {
+		return auction.ErrNotActive
+	}
+
+	return nil
+}
+
+func (r *mongoAuctionRepo) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return storage.WithTransaction(ctx, r.client, fn)
+}
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Action string
+
+const (
+	ActionGrantRole  Action = "grant_role"
+	ActionRevokeRole Action = "revoke_role"
+)
+
+// SystemActorID is actor of actions done by app itself, like first super admin set from SUPER_ADMIN_ID
+const SystemActorID int64 = 0
+
+// Entry is an admin action which can't be changed or removed after it is written
+type Entry struct {
+	ID           uuid.UUID `bson:"_id" json:"id"`
+	Action       Action    `bson:"action" json:"action"`
+	ActorID      int64     `bson:"actor_id" json:"actor_id"` // telegram id of admin
+	TargetUserID int64     `bson:"target_user_id" json:"target_user_id"`
+	Before       string    `bson:"before" json:"before"`
+	After        string    `bson:"after" json:"after"`
+	Reason       string    `bson:"reason" json:"reason"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+}
+
+func NewEntry(action Action, actorID int64, targetUserID int64, before string, after string, reason string) *Entry {
+	return &Entry{
+		ID:           uuid.New(),
+		Action:       action,
+		ActorID:      actorID,
+		TargetUserID: targetUserID,
+		Before:       before,
+		After:        after,
+		Reason:       reason,
+		CreatedAt:    time.Now(),
+	}
+}
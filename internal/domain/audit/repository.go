@@ -0,0 +1,11 @@
+package audit
+
+import "context"
+
+type AuditRepository interface {
+	CreateEntry(ctx context.Context, entry *Entry) error
+	// GetEntriesByTargetUserID returns entries about user, newest first
+	GetEntriesByTargetUserID(ctx context.Context, userID int64) ([]Entry, error)
+	// WithTransaction runs fn in transaction, other repositories called with txCtx join it
+	WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/audit"
+	"github.com/rom6n/create-nft-go/internal/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoAuditRepo struct {
+	client         *mongo.Client
+	dbName         string
+	collectionName string
+	timeout        time.Duration
+}
+
+type AuditRepoCfg struct {
+	DBName         string
+	CollectionName string
+	Timeout        time.Duration
+}
+
+func NewAuditRepo(client *mongo.Client, cfg AuditRepoCfg) audit.AuditRepository {
+	repo := &mongoAuditRepo{
+		client:         client,
+		dbName:         cfg.DBName,
+		collectionName: cfg.CollectionName,
+		timeout:        cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating audit indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoAuditRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoAuditRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoAuditRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "target_user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+
+	return indexErr
+}
+
+func (r *mongoAuditRepo) CreateEntry(ctx context.Context, entry *audit.Entry) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	_, insertErr := r.getCollection().InsertOne(dbCtx, *entry)
+	return insertErr
+}
+
+func (r *mongoAuditRepo) GetEntriesByTargetUserID(ctx context.Context, userID int64) ([]audit.Entry, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	foundedEntries := []audit.Entry{}
+	cursor, findErr := r.getCollection().Find(dbCtx, bson.D{{Key: "target_user_id", Value: userID}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if findErr != nil {
+		return nil, fmt.Errorf("audit entries find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedEntries); decodeErr != nil {
+		return nil, fmt.Errorf("audit entries decode error: %v", decodeErr)
+	}
+
+	return foundedEntries, nil
+}
+
+func (r *mongoAuditRepo) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return storage.WithTransaction(ctx, r.client, fn)
+}
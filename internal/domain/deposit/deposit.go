@@ -0,0 +1,54 @@
+package deposit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusCredited  Status = "credited"  // deposit memo matched and ton added to user's balance
+	StatusUnmatched Status = "unmatched" // deposit is waiting for admin to assign or refund it
+	StatusAssigned  Status = "assigned"  // admin added unmatched deposit to user's balance
+	StatusRefunding Status = "refunding" // refund transfer is sent, it is settled by its message on chain
+	StatusRefunded  Status = "refunded"  // ton returned to sender
+)
+
+type Deposit struct {
+	ID          uuid.UUID `bson:"_id" json:"id"`
+	TxHash      string    `bson:"tx_hash" json:"tx_hash"` // hex, also used as ledger reference id
+	LT          uint64    `bson:"lt" json:"lt"`
+	FromAddress string    `bson:"from_address" json:"from_address"`
+	Amount      uint64    `bson:"amount" json:"amount"`
+	Comment     string    `bson:"comment" json:"comment"`
+	UserUUID    uuid.UUID `bson:"user_uuid" json:"user_uuid"` // zero for unmatched deposits
+	IsTestnet   bool      `bson:"is_testnet" json:"is_testnet"`
+	Status      Status    `bson:"status" json:"status"`
+	Error       string    `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+func New(txHash string, lt uint64, fromAddress string, amount uint64, comment string, isTestnet bool) *Deposit {
+	now := time.Now()
+	return &Deposit{
+		ID:          uuid.New(),
+		TxHash:      txHash,
+		LT:          lt,
+		FromAddress: fromAddress,
+		Amount:      amount,
+		Comment:     comment,
+		IsTestnet:   isTestnet,
+		Status:      StatusUnmatched,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// RefundComment is text of refund transfer. Deposit id makes body of transfer message unique, so it can be found on chain
+func (d *Deposit) RefundComment() string {
+	return fmt.Sprintf("Refund of unmatched deposit %v", d.ID)
+}
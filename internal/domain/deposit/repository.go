@@ -0,0 +1,30 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrStatusChanged    = errors.New("deposit status was changed by someone else")
+	ErrAlreadyProcessed = errors.New("deposit transaction is already processed")
+)
+
+type DepositRepository interface {
+	// CreateDeposit returns ErrAlreadyProcessed if deposit with the same transaction hash exists
+	CreateDeposit(ctx context.Context, deposit *Deposit) error
+	GetDepositByID(ctx context.Context, id uuid.UUID) (*Deposit, error)
+	// GetDepositsByStatus returns deposits with the status from the oldest to the newest
+	GetDepositsByStatus(ctx context.Context, status Status) ([]Deposit, error)
+	// AssignDeposit moves unmatched deposit to assigned status with given user.
+	// Returns ErrStatusChanged if deposit is not unmatched anymore
+	AssignDeposit(ctx context.Context, id uuid.UUID, userUuid uuid.UUID) error
+	// UpdateDepositStatus moves deposit from one status to another.
+	// Returns ErrStatusChanged if deposit is not in from status anymore
+	UpdateDepositStatus(ctx context.Context, id uuid.UUID, from Status, to Status, errorMsg string) error
+	// GetLastProcessedLT returns LT of the last processed transaction of account or 0 if account was never listened
+	GetLastProcessedLT(ctx context.Context, account string) (uint64, error)
+	SaveLastProcessedLT(ctx context.Context, account string, lt uint64) error
+}
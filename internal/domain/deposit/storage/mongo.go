@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/deposit"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoDepositRepo struct {
+	client                *mongo.Client
+	dbName                string
+	collectionName        string
+	cursorsCollectionName string
+	timeout               time.Duration
+}
+
+type DepositRepoCfg struct {
+	DBName                string
+	CollectionName        string
+	CursorsCollectionName string // last processed LT of every listened account
+	Timeout               time.Duration
+}
+
+type listenerCursor struct {
+	Account         string    `bson:"_id"`
+	LastProcessedLT uint64    `bson:"last_processed_lt"`
+	UpdatedAt       time.Time `bson:"updated_at"`
+}
+
+func NewDepositRepo(client *mongo.Client, cfg DepositRepoCfg) deposit.DepositRepository {
+	repo := &mongoDepositRepo{
+		client:                client,
+		dbName:                cfg.DBName,
+		collectionName:        cfg.CollectionName,
+		cursorsCollectionName: cfg.CursorsCollectionName,
+		timeout:               cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating deposits indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoDepositRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoDepositRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoDepositRepo) getCursorsCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.cursorsCollectionName)
+}
+
+func (r *mongoDepositRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{
+			// every transaction is processed only once
+			Keys:    bson.D{{Key: "tx_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+
+	return indexErr
+}
+
+func (r *mongoDepositRepo) CreateDeposit(ctx context.Context, newDeposit *deposit.Deposit) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	if _, insertErr := r.getCollection().InsertOne(dbCtx, *newDeposit); insertErr != nil {
+		if mongo.IsDuplicateKeyError(insertErr) {
+			return deposit.ErrAlreadyProcessed
+		}
+		return fmt.Errorf("error inserting deposit: %v", insertErr)
+	}
+
+	return nil
+}
+
+func (r *mongoDepositRepo) GetDepositByID(ctx context.Context, id uuid.UUID) (*deposit.Deposit, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundDeposit deposit.Deposit
+	if findErr := r.getCollection().FindOne(dbCtx, bson.D{{Key: "_id", Value: id}}).Decode(&foundDeposit); findErr != nil {
+		return nil, findErr
+	}
+
+	return &foundDeposit, nil
+}
+
+func (r *mongoDepositRepo) GetDepositsByStatus(ctx context.Context, status deposit.Status) ([]deposit.Deposit, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundedDeposits []deposit.Deposit
+	cursor, findErr := r.getCollection().Find(dbCtx, bson.D{{Key: "status", Value: status}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if findErr != nil {
+		return nil, fmt.Errorf("deposits find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedDeposits); decodeErr != nil {
+		return nil, fmt.Errorf("deposits decode error after find: %v", decodeErr)
+	}
+
+	return foundedDeposits, nil
+}
+
+func (r *mongoDepositRepo) AssignDeposit(ctx context.Context, id uuid.UUID, userUuid uuid.UUID) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	result, updErr := r.getCollection().UpdateOne(dbCtx,
+		bson.D{{Key: "_id", Value: id}, {Key: "status", Value: deposit.StatusUnmatched}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: deposit.StatusAssigned},
+			{Key: "user_uuid", Value: userUuid},
+			{Key: "updated_at", Value: time.Now()},
+		}}},
+	)
+	if updErr != nil {
+		return fmt.Errorf("error assigning deposit %v: %v", id, updErr)
+	}
+
+	if result.MatchedCount == 0 {
+		return deposit.ErrStatusChanged
+	}
+
+	return nil
+}
+
+func (r *mongoDepositRepo) UpdateDepositStatus(ctx context.Context, id uuid.UUID, from deposit.Status, to deposit.Status, errorMsg string) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	update := bson.D{{Key: "status", Value: to}, {Key: "updated_at", Value: time.Now()}}
+	if errorMsg != "" {
+		update = append(update, bson.E{Key: "error", Value: errorMsg})
+	}
+
+	result, updErr := r.getCollection().UpdateOne(dbCtx,
+		bson.D{{Key: "_id", Value: id}, {Key: "status", Value: from}},
+		bson.D{{Key: "$set", Value: update}},
+	)
+	if updErr != nil {
+		return fmt.Errorf("error updating deposit %v status to %v: %v", id, to, updErr)
+	}
+
+	if result.MatchedCount == 0 {
+		return deposit.ErrStatusChanged
+	}
+
+	return nil
+}
+
+func (r *mongoDepositRepo) GetLastProcessedLT(ctx context.Context, account string) (uint64, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var cursor listenerCursor
+	if findErr := r.getCursorsCollection().FindOne(dbCtx, bson.D{{Key: "_id", Value: account}}).Decode(&cursor); findErr != nil {
+		if errors.Is(findErr, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("error getting last processed lt of %v: %v", account, findErr)
+	}
+
+	return cursor.LastProcessedLT, nil
+}
+
+func (r *mongoDepositRepo) SaveLastProcessedLT(ctx context.Context, account string, lt uint64) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	// cursor never goes back
+	_, updErr := r.getCursorsCollection().UpdateOne(dbCtx,
+		bson.D{{Key: "_id", Value: account}},
+		bson.D{
+			{Key: "$max", Value: bson.D{{Key: "last_processed_lt", Value: lt}}},
+			{Key: "$set", Value: bson.D{{Key: "updated_at", Value: time.Now()}}},
+		},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if updErr != nil {
+		return fmt.Errorf("error saving last processed lt of %v: %v", account, updErr)
+	}
+
+	return nil
+}
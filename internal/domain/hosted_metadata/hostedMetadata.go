@@ -0,0 +1,39 @@
+package hostedmetadata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var ErrTooLarge = errors.New("metadata document is too large")
+
+// MaxDocumentSize limits json of one document, image data should be uploaded as link instead
+const MaxDocumentSize = 256 * 1024
+
+type Kind string
+
+const (
+	KindNftCollection Kind = "nft_collection"
+	KindNftItem       Kind = "nft_item"
+)
+
+// Document is metadata json served by the app instead of creator's hosting.
+// It is addressed by sha256 of its content, so its link never changes what it serves
+type Document struct {
+	Hash      string    `bson:"_id" json:"hash"`
+	Kind      Kind      `bson:"kind" json:"kind"`
+	Content   []byte    `bson:"content" json:"-"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+func NewDocument(kind Kind, content []byte) *Document {
+	hash := sha256.Sum256(content)
+	return &Document{
+		Hash:      hex.EncodeToString(hash[:]),
+		Kind:      kind,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+}
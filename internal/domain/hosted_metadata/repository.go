@@ -0,0 +1,9 @@
+package hostedmetadata
+
+import "context"
+
+type HostedMetadataRepository interface {
+	// SaveDocument stores document once, saving the same content again does nothing
+	SaveDocument(ctx context.Context, document *Document) error
+	GetDocument(ctx context.Context, hash string) (*Document, error)
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	hostedmetadata "github.com/rom6n/create-nft-go/internal/domain/hosted_metadata"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type mongoHostedMetadataRepo struct {
+	client         *mongo.Client
+	dbName         string
+	collectionName string
+	timeout        time.Duration
+}
+
+type HostedMetadataRepoCfg struct {
+	DBName         string
+	CollectionName string
+	Timeout        time.Duration
+}
+
+func NewHostedMetadataRepo(client *mongo.Client, cfg HostedMetadataRepoCfg) hostedmetadata.HostedMetadataRepository {
+	return &mongoHostedMetadataRepo{
+		client:         client,
+		dbName:         cfg.DBName,
+		collectionName: cfg.CollectionName,
+		timeout:        cfg.Timeout,
+	}
+}
+
+func (r *mongoHostedMetadataRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoHostedMetadataRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoHostedMetadataRepo) SaveDocument(ctx context.Context, document *hostedmetadata.Document) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	// same hash means same content, document is already served
+	if _, insertErr := r.getCollection().InsertOne(dbCtx, *document); insertErr != nil && !mongo.IsDuplicateKeyError(insertErr) {
+		return fmt.Errorf("error inserting metadata document: %w", insertErr)
+	}
+
+	return nil
+}
+
+func (r *mongoHostedMetadataRepo) GetDocument(ctx context.Context, hash string) (*hostedmetadata.Document, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var document hostedmetadata.Document
+	if decodeErr := r.getCollection().FindOne(dbCtx, bson.D{{Key: "_id", Value: hash}}).Decode(&document); decodeErr != nil {
+		return nil, fmt.Errorf("metadata document decode error after searching: %w", decodeErr)
+	}
+
+	return &document, nil
+}
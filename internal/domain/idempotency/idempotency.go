@@ -0,0 +1,40 @@
+package idempotency
+
+import (
+	"time"
+)
+
+type Status string
+
+const (
+	StatusInProgress Status = "in_progress" // first request is being handled
+	StatusCompleted  Status = "completed"   // response is stored and replayed on retries
+)
+
+// records expire after TTL, a key can be reused then
+const TTL = 24 * time.Hour
+
+// Record is the first response of a paid request with Idempotency-Key header
+type Record struct {
+	UserID      int64     `bson:"user_id"`
+	Key         string    `bson:"key"`
+	RequestHash string    `bson:"request_hash"` // method, path, query and body of the first request
+	Status      Status    `bson:"status"`
+	StatusCode  int       `bson:"status_code,omitempty"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+	UpdatedAt   time.Time `bson:"updated_at"`
+}
+
+func New(userID int64, key string, requestHash string) *Record {
+	now := time.Now()
+	return &Record{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      StatusInProgress,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
@@ -0,0 +1,18 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrKeyExists = errors.New("idempotency key is already used by user")
+
+type IdempotencyRepository interface {
+	// CreateRecord returns ErrKeyExists if user already has record with the key
+	CreateRecord(ctx context.Context, record *Record) error
+	GetRecord(ctx context.Context, userID int64, key string) (*Record, error)
+	// CompleteRecord stores response of in progress record
+	CompleteRecord(ctx context.Context, userID int64, key string, statusCode int, contentType string, body []byte) error
+	// DeleteRecord releases the key if request failed before any response, so it can be retried
+	DeleteRecord(ctx context.Context, userID int64, key string) error
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/idempotency"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoIdempotencyRepo struct {
+	client         *mongo.Client
+	dbName         string
+	collectionName string
+	timeout        time.Duration
+}
+
+type IdempotencyRepoCfg struct {
+	DBName         string
+	CollectionName string
+	Timeout        time.Duration
+}
+
+func NewIdempotencyRepo(client *mongo.Client, cfg IdempotencyRepoCfg) idempotency.IdempotencyRepository {
+	repo := &mongoIdempotencyRepo{
+		client:         client,
+		dbName:         cfg.DBName,
+		collectionName: cfg.CollectionName,
+		timeout:        cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating idempotency indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoIdempotencyRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoIdempotencyRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoIdempotencyRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{
+			// key is unique per user only
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(idempotency.TTL.Seconds())),
+		},
+	})
+
+	return indexErr
+}
+
+func recordFilter(userID int64, key string) bson.D {
+	return bson.D{{Key: "user_id", Value: userID}, {Key: "key", Value: key}}
+}
+
+func (r *mongoIdempotencyRepo) CreateRecord(ctx context.Context, record *idempotency.Record) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	if _, insertErr := r.getCollection().InsertOne(dbCtx, *record); insertErr != nil {
+		if mongo.IsDuplicateKeyError(insertErr) {
+			return idempotency.ErrKeyExists
+		}
+		return fmt.Errorf("error inserting idempotency record: %v", insertErr)
+	}
+
+	return nil
+}
+
+func (r *mongoIdempotencyRepo) GetRecord(ctx context.Context, userID int64, key string) (*idempotency.Record, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundRecord idempotency.Record
+	if findErr := r.getCollection().FindOne(dbCtx, recordFilter(userID, key)).Decode(&foundRecord); findErr != nil {
+		return nil, findErr
+	}
+
+	return &foundRecord, nil
+}
+
+func (r *mongoIdempotencyRepo) CompleteRecord(ctx context.Context, userID int64, key string, statusCode int, contentType string, body []byte) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	filter := append(recordFilter(userID, key), bson.E{Key: "status", Value: idempotency.StatusInProgress})
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: idempotency.StatusCompleted},
+		{Key: "status_code", Value: statusCode},
+		{Key: "content_type", Value: contentType},
+		{Key: "body", Value: body},
+		{Key: "updated_at", Value: time.Now()},
+	}}}
+
+	result, updateErr := r.getCollection().UpdateOne(dbCtx, filter, update)
+	if updateErr != nil {
+		return fmt.Errorf("error completing idempotency record: %v", updateErr)
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (r *mongoIdempotencyRepo) DeleteRecord(ctx context.Context, userID int64, key string) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	filter := append(recordFilter(userID, key), bson.E{Key: "status", Value: idempotency.StatusInProgress})
+	if _, deleteErr := r.getCollection().DeleteOne(dbCtx, filter); deleteErr != nil {
+		return fmt.Errorf("error deleting idempotency record: %v", deleteErr)
+	}
+
+	return nil
+}
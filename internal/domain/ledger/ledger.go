@@ -0,0 +1,63 @@
+package ledger
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EntryType string
+
+const (
+	EntryTypeDeposit    EntryType = "deposit"
+	EntryTypeDebit      EntryType = "debit"
+	EntryTypeRefund     EntryType = "refund"
+	EntryTypeFee        EntryType = "fee"
+	EntryTypeWithdrawal EntryType = "withdrawal"
+	EntryTypePurchase   EntryType = "purchase" // buyer pays price of listing or auction
+	EntryTypeSale       EntryType = "sale"     // seller gets price without royalty and platform fee
+	EntryTypeRoyalty    EntryType = "royalty"  // nft collection owner gets royalty of sale
+	EntryTypeHold       EntryType = "hold"     // bid of auction is held on bidder's balance
+	EntryTypeRelease    EntryType = "release"  // held bid is returned when outbid
+)
+
+var (
+	ErrNotEnoughBalance    = errors.New("not enough ton on user's balance")
+	ErrEntryAlreadyApplied = errors.New("balance entry with this reference is already applied")
+)
+
+type BalanceEntry struct {
+	ID          uuid.UUID `bson:"_id" json:"id"`
+	UserUUID    uuid.UUID `bson:"user_uuid" json:"user_uuid"`
+	Type        EntryType `bson:"type" json:"type"`
+	Amount      uint64    `bson:"amount" json:"amount"`
+	ReferenceID string    `bson:"reference_id" json:"reference_id"` // tx hash, nft address, withdrawal, listing, auction or bid id
+	IsTestnet   bool      `bson:"is_testnet" json:"is_testnet"`     // network of the balance entry is applied to
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Reconciliation compares cached user's balance with the sum of his ledger entries in one network
+type Reconciliation struct {
+	UserUUID      uuid.UUID `json:"user_uuid"`
+	IsTestnet     bool      `json:"is_testnet"`
+	UserNanoTon   uint64    `json:"user_nano_ton"`
+	LedgerNanoTon int64     `json:"ledger_nano_ton"`
+	Drift         int64     `json:"drift"`
+}
+
+func (t EntryType) IsCredit() bool {
+	return t == EntryTypeDeposit || t == EntryTypeRefund || t == EntryTypeSale || t == EntryTypeRoyalty || t == EntryTypeRelease
+}
+
+func NewBalanceEntry(userUuid uuid.UUID, entryType EntryType, amount uint64, referenceID string, isTestnet bool) *BalanceEntry {
+	return &BalanceEntry{
+		ID:          uuid.New(),
+		UserUUID:    userUuid,
+		Type:        entryType,
+		Amount:      amount,
+		ReferenceID: referenceID,
+		IsTestnet:   isTestnet,
+		CreatedAt:   time.Now(),
+	}
+}
@@ -0,0 +1,15 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type LedgerRepository interface {
+	// Apply atomically appends entries to the ledger and changes users' balances.
+	// Returns ErrNotEnoughBalance if any debit is bigger than user's balance, nothing is applied then
+	Apply(ctx context.Context, entries ...*BalanceEntry) error
+	GetEntriesByUserUuid(ctx context.Context, userUuid uuid.UUID) ([]BalanceEntry, error)
+	ReconcileUserBalance(ctx context.Context, userUuid uuid.UUID, isTestnet bool) (*Reconciliation, error)
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"github.com/rom6n/create-nft-go/internal/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoLedgerRepo struct {
+	client              *mongo.Client
+	dbName              string
+	collectionName      string
+	usersCollectionName string
+	timeout             time.Duration
+}
+
+type LedgerRepoCfg struct {
+	DBName              string
+	CollectionName      string
+	UsersCollectionName string
+	Timeout             time.Duration
+}
+
+func NewLedgerRepo(client *mongo.Client, cfg LedgerRepoCfg) ledger.LedgerRepository {
+	repo := &mongoLedgerRepo{
+		client:              client,
+		dbName:              cfg.DBName,
+		collectionName:      cfg.CollectionName,
+		usersCollectionName: cfg.UsersCollectionName,
+		timeout:             cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating balance entries indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoLedgerRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoLedgerRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoLedgerRepo) getUsersCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.usersCollectionName)
+}
+
+func (r *mongoLedgerRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_uuid", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			// every deposit transaction is credited and every failed operation is refunded only once
+			Keys: bson.D{{Key: "type", Value: 1}, {Key: "reference_id", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "type", Value: bson.D{{Key: "$in", Value: bson.A{ledger.EntryTypeDeposit, ledger.EntryTypeRefund}}}}}),
+		},
+	})
+
+	return indexErr
+}
+
+func (r *mongoLedgerRepo) Apply(ctx context.Context, entries ...*ledger.BalanceEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	// entries join transaction of caller if there is one
+	return storage.WithTransaction(dbCtx, r.client, func(txCtx context.Context) error {
+		return applyEntries(txCtx, r.getUsersCollection(), r.getCollection(), entries)
+	})
+}
+
+// balanceCollection changes balances of user documents, implemented by *mongo.Collection
+type balanceCollection interface {
+	UpdateOne(ctx context.Context, filter any, update any, opts ...options.Lister[options.UpdateOneOptions]) (*mongo.UpdateResult, error)
+}
+
+// entriesCollection stores balance entries, implemented by *mongo.Collection
+type entriesCollection interface {
+	InsertMany(ctx context.Context, documents any, opts ...options.Lister[options.InsertManyOptions]) (*mongo.InsertManyResult, error)
+}
+
+// applyEntries changes balances and records entries. It must run in transaction, otherwise balances changed before an error stay changed
+func applyEntries(ctx context.Context, users balanceCollection, entriesColl entriesCollection, entries []*ledger.BalanceEntry) error {
+	for _, entry := range entries {
+		filter, update := balanceUpdate(entry)
+
+		result, updErr := users.UpdateOne(ctx, filter, update)
+		if updErr != nil {
+			return fmt.Errorf("error applying %v entry to user uuid %v's balance: %w", entry.Type, entry.UserUUID, updErr)
+		}
+
+		if result.MatchedCount == 0 {
+			if entry.Type.IsCredit() {
+				return fmt.Errorf("user uuid %v not found", entry.UserUUID)
+			}
+			return ledger.ErrNotEnoughBalance
+		}
+	}
+
+	if _, insertErr := entriesColl.InsertMany(ctx, entries); insertErr != nil {
+		if mongo.IsDuplicateKeyError(insertErr) {
+			return ledger.ErrEntryAlreadyApplied
+		}
+		return fmt.Errorf("error inserting balance entries: %w", insertErr)
+	}
+
+	return nil
+}
+
+// balanceUpdate returns filter and update of user document changing its balance by entry.
+// Debit filter matches only balance not less than amount, so concurrent debits cant make it negative
+func balanceUpdate(entry *ledger.BalanceEntry) (filter bson.D, update bson.D) {
+	balanceField := user.BalanceField(entry.IsTestnet)
+	filter = bson.D{{Key: "_id", Value: entry.UserUUID}}
+	delta := int64(entry.Amount)
+	if !entry.Type.IsCredit() {
+		filter = append(filter, bson.E{Key: balanceField, Value: bson.D{{Key: "$gte", Value: int64(entry.Amount)}}})
+		delta = -delta
+	}
+
+	return filter, bson.D{{Key: "$inc", Value: bson.D{{Key: balanceField, Value: delta}}}}
+}
+
+func (r *mongoLedgerRepo) GetEntriesByUserUuid(ctx context.Context, userUuid uuid.UUID) ([]ledger.BalanceEntry, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	collection := r.getCollection()
+
+	var foundedEntries []ledger.BalanceEntry
+	cursor, findErr := collection.Find(dbCtx, bson.D{{Key: "user_uuid", Value: userUuid}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if findErr != nil {
+		return nil, fmt.Errorf("balance entries find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedEntries); decodeErr != nil {
+		return nil, fmt.Errorf("balance entries decode error after find: %v", decodeErr)
+	}
+
+	return foundedEntries, nil
+}
+
+func (r *mongoLedgerRepo) ReconcileUserBalance(ctx context.Context, userUuid uuid.UUID, isTestnet bool) (*ledger.Reconciliation, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundUser user.User
+	if findErr := r.getUsersCollection().FindOne(dbCtx, bson.D{{Key: "_id", Value: userUuid}}).Decode(&foundUser); findErr != nil {
+		return nil, fmt.Errorf("error getting user uuid %v: %w", userUuid, findErr)
+	}
+
+	// entries written before balances were split have no is_testnet and belong to testnet
+	networkFilter := bson.E{Key: "is_testnet", Value: false}
+	if isTestnet {
+		networkFilter = bson.E{Key: "is_testnet", Value: bson.D{{Key: "$ne", Value: false}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "user_uuid", Value: userUuid}, networkFilter}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$type"},
+			{Key: "total", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+		}}},
+	}
+
+	cursor, aggregateErr := r.getCollection().Aggregate(dbCtx, pipeline)
+	if aggregateErr != nil {
+		return nil, fmt.Errorf("balance entries aggregate error: %v", aggregateErr)
+	}
+
+	var totals []struct {
+		Type  ledger.EntryType `bson:"_id"`
+		Total int64            `bson:"total"`
+	}
+	if decodeErr := cursor.All(dbCtx, &totals); decodeErr != nil {
+		return nil, fmt.Errorf("balance entries decode error after aggregate: %v", decodeErr)
+	}
+
+	var ledgerNanoTon int64
+	for _, total := range totals {
+		if total.Type.IsCredit() {
+			ledgerNanoTon += total.Total
+		} else {
+			ledgerNanoTon -= total.Total
+		}
+	}
+
+	return &ledger.Reconciliation{
+		UserUUID:      userUuid,
+		IsTestnet:     isTestnet,
+		UserNanoTon:   foundUser.Balance(isTestnet),
+		LedgerNanoTon: ledgerNanoTon,
+		Drift:         int64(foundUser.Balance(isTestnet)) - ledgerNanoTon,
+	}, nil
+}
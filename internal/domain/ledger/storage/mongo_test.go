@@ -0,0 +1,250 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// fakeUsersCollection matches and increments balances like mongo does with filter and update of balanceUpdate.
+// Each update is atomic like single document update of mongo
+type fakeUsersCollection struct {
+	mu       sync.Mutex
+	balances map[uuid.UUID]map[string]int64
+}
+
+func (c *fakeUsersCollection) UpdateOne(_ context.Context, filter any, update any, _ ...options.Lister[options.UpdateOneOptions]) (*mongo.UpdateResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var balances map[string]int64
+	for _, e := range filter.(bson.D) {
+		if e.Key == "_id" {
+			balances = c.balances[e.Value.(uuid.UUID)]
+			continue
+		}
+		guard := e.Value.(bson.D)[0]
+		if balances == nil || guard.Key != "$gte" || balances[e.Key] < guard.Value.(int64) {
+			return &mongo.UpdateResult{}, nil
+		}
+	}
+	if balances == nil {
+		return &mongo.UpdateResult{}, nil
+	}
+
+	for _, e := range update.(bson.D)[0].Value.(bson.D) {
+		balances[e.Key] += e.Value.(int64)
+	}
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+// fakeEntriesCollection rejects deposit and refund entries with reference already stored like unique index does
+type fakeEntriesCollection struct {
+	mu      sync.Mutex
+	entries []*ledger.BalanceEntry
+}
+
+func (c *fakeEntriesCollection) InsertMany(_ context.Context, documents any, _ ...options.Lister[options.InsertManyOptions]) (*mongo.InsertManyResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := documents.([]*ledger.BalanceEntry)
+	for _, entry := range entries {
+		if entry.Type != ledger.EntryTypeDeposit && entry.Type != ledger.EntryTypeRefund {
+			continue
+		}
+		for _, stored := range c.entries {
+			if stored.Type == entry.Type && stored.ReferenceID == entry.ReferenceID {
+				return nil, mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: "E11000 duplicate key error"}}}
+			}
+		}
+	}
+	c.entries = append(c.entries, entries...)
+	return &mongo.InsertManyResult{}, nil
+}
+
+func TestBalanceUpdate(t *testing.T) {
+	userUuid := uuid.New()
+
+	tests := map[string]struct {
+		entry      *ledger.BalanceEntry
+		wantFilter bson.D
+		wantUpdate bson.D
+	}{
+		"credit isnt guarded": {
+			entry:      ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDeposit, 100, "tx", true),
+			wantFilter: bson.D{{Key: "_id", Value: userUuid}},
+			wantUpdate: bson.D{{Key: "$inc", Value: bson.D{{Key: user.TestnetBalanceField, Value: int64(100)}}}},
+		},
+		"debit is guarded by amount": {
+			entry: ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDebit, 100, "op", true),
+			wantFilter: bson.D{
+				{Key: "_id", Value: userUuid},
+				{Key: user.TestnetBalanceField, Value: bson.D{{Key: "$gte", Value: int64(100)}}},
+			},
+			wantUpdate: bson.D{{Key: "$inc", Value: bson.D{{Key: user.TestnetBalanceField, Value: int64(-100)}}}},
+		},
+		"mainnet debit changes mainnet balance": {
+			entry: ledger.NewBalanceEntry(userUuid, ledger.EntryTypeWithdrawal, 100, "withdrawal", false),
+			wantFilter: bson.D{
+				{Key: "_id", Value: userUuid},
+				{Key: user.MainnetBalanceField, Value: bson.D{{Key: "$gte", Value: int64(100)}}},
+			},
+			wantUpdate: bson.D{{Key: "$inc", Value: bson.D{{Key: user.MainnetBalanceField, Value: int64(-100)}}}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter, update := balanceUpdate(test.entry)
+
+			if !reflect.DeepEqual(filter, test.wantFilter) {
+				t.Fatalf("want filter %v, have %v", test.wantFilter, filter)
+			}
+			if !reflect.DeepEqual(update, test.wantUpdate) {
+				t.Fatalf("want update %v, have %v", test.wantUpdate, update)
+			}
+		})
+	}
+}
+
+func TestApplyEntries(t *testing.T) {
+	userUuid := uuid.New()
+
+	tests := map[string]struct {
+		storedEntries []*ledger.BalanceEntry
+		entries       []*ledger.BalanceEntry
+		wantErr       error
+		wantBalance   int64
+		wantEntries   int
+	}{
+		"credit": {
+			entries:     []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDeposit, 50, "tx", true)},
+			wantBalance: 150,
+			wantEntries: 1,
+		},
+		"debit of whole balance": {
+			entries:     []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDebit, 100, "op", true)},
+			wantBalance: 0,
+			wantEntries: 1,
+		},
+		"debit and fee": {
+			entries: []*ledger.BalanceEntry{
+				ledger.NewBalanceEntry(userUuid, ledger.EntryTypeWithdrawal, 60, "withdrawal", true),
+				ledger.NewBalanceEntry(userUuid, ledger.EntryTypeFee, 40, "withdrawal", true),
+			},
+			wantBalance: 0,
+			wantEntries: 2,
+		},
+		"not enough balance": {
+			entries:     []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDebit, 101, "op", true)},
+			wantErr:     ledger.ErrNotEnoughBalance,
+			wantBalance: 100,
+		},
+		"not enough balance of other network": {
+			entries:     []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDebit, 1, "op", false)},
+			wantErr:     ledger.ErrNotEnoughBalance,
+			wantBalance: 100,
+		},
+		"duplicate deposit": {
+			storedEntries: []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDeposit, 50, "tx", true)},
+			entries:       []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDeposit, 50, "tx", true)},
+			wantErr:       ledger.ErrEntryAlreadyApplied,
+			wantBalance:   150, // rolled back by transaction in mongo
+			wantEntries:   1,
+		},
+		"duplicate refund": {
+			storedEntries: []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeRefund, 50, "op", true)},
+			entries:       []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeRefund, 50, "op", true)},
+			wantErr:       ledger.ErrEntryAlreadyApplied,
+			wantBalance:   150,
+			wantEntries:   1,
+		},
+		"debits with same reference": {
+			storedEntries: []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDebit, 50, "op", true)},
+			entries:       []*ledger.BalanceEntry{ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDebit, 50, "op", true)},
+			wantBalance:   50,
+			wantEntries:   2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			users := &fakeUsersCollection{balances: map[uuid.UUID]map[string]int64{userUuid: {user.TestnetBalanceField: 100}}}
+			entriesColl := &fakeEntriesCollection{entries: test.storedEntries}
+
+			applyErr := applyEntries(context.Background(), users, entriesColl, test.entries)
+
+			if !errors.Is(applyErr, test.wantErr) {
+				t.Fatalf("want error %v, have %v", test.wantErr, applyErr)
+			}
+			if balance := users.balances[userUuid][user.TestnetBalanceField]; balance != test.wantBalance {
+				t.Fatalf("want balance %v, have %v", test.wantBalance, balance)
+			}
+			if len(entriesColl.entries) != test.wantEntries {
+				t.Fatalf("want %v entries, have %v", test.wantEntries, len(entriesColl.entries))
+			}
+		})
+	}
+}
+
+func TestApplyEntriesUnknownUser(t *testing.T) {
+	users := &fakeUsersCollection{balances: map[uuid.UUID]map[string]int64{}}
+
+	applyErr := applyEntries(context.Background(), users, &fakeEntriesCollection{}, []*ledger.BalanceEntry{
+		ledger.NewBalanceEntry(uuid.New(), ledger.EntryTypeDeposit, 50, "tx", true),
+	})
+
+	if applyErr == nil || errors.Is(applyErr, ledger.ErrNotEnoughBalance) {
+		t.Fatalf("want user not found error, have %v", applyErr)
+	}
+}
+
+func TestApplyEntriesConcurrentDebits(t *testing.T) {
+	userUuid := uuid.New()
+	users := &fakeUsersCollection{balances: map[uuid.UUID]map[string]int64{userUuid: {user.TestnetBalanceField: 100}}}
+	entriesColl := &fakeEntriesCollection{}
+
+	const debits = 10
+	errs := make([]error, debits)
+	var wg sync.WaitGroup
+	for i := range debits {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = applyEntries(context.Background(), users, entriesColl, []*ledger.BalanceEntry{
+				ledger.NewBalanceEntry(userUuid, ledger.EntryTypeDebit, 30, uuid.NewString(), true),
+			})
+		}()
+	}
+	wg.Wait()
+
+	applied := 0
+	for _, applyErr := range errs {
+		switch {
+		case applyErr == nil:
+			applied++
+		case !errors.Is(applyErr, ledger.ErrNotEnoughBalance):
+			t.Fatalf("want ledger.ErrNotEnoughBalance, have %v", applyErr)
+		}
+	}
+
+	if applied != 3 {
+		t.Fatalf("want 3 applied debits, have %v", applied)
+	}
+	if balance := users.balances[userUuid][user.TestnetBalanceField]; balance != 10 {
+		t.Fatalf("want balance 10, have %v", balance)
+	}
+	if len(entriesColl.entries) != applied {
+		t.Fatalf("want %v entries, have %v", applied, len(entriesColl.entries))
+	}
+}
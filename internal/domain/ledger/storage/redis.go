@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	userstorage "github.com/rom6n/create-nft-go/internal/domain/user/storage"
+)
+
+type userCacheInvalidatingLedgerRepo struct {
+	ledger.LedgerRepository
+	client redis.UniversalClient
+}
+
+// NewUserCacheInvalidatingLedgerRepo removes cached users after their balances are changed by next repository
+func NewUserCacheInvalidatingLedgerRepo(next ledger.LedgerRepository, client redis.UniversalClient) ledger.LedgerRepository {
+	return &userCacheInvalidatingLedgerRepo{
+		LedgerRepository: next,
+		client:           client,
+	}
+}
+
+func (r *userCacheInvalidatingLedgerRepo) Apply(ctx context.Context, entries ...*ledger.BalanceEntry) error {
+	if applyErr := r.LedgerRepository.Apply(ctx, entries...); applyErr != nil {
+		return applyErr
+	}
+
+	invalidated := make(map[uuid.UUID]struct{}, len(entries))
+	for _, entry := range entries {
+		if _, ok := invalidated[entry.UserUUID]; ok {
+			continue
+		}
+		invalidated[entry.UserUUID] = struct{}{}
+
+		userstorage.InvalidateCachedUser(ctx, r.client, entry.UserUUID)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	userstorage "github.com/rom6n/create-nft-go/internal/domain/user/storage"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type fakeUserRepo struct {
+	users map[int64]*user.User
+	gets  int
+}
+
+func (r *fakeUserRepo) GetUserByID(_ context.Context, userID int64) (*user.User, error) {
+	r.gets++
+	foundUser, ok := r.users[userID]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	copied := *foundUser
+	return &copied, nil
+}
+
+func (r *fakeUserRepo) CreateUser(_ context.Context, newUser *user.User) error {
+	r.users[newUser.ID] = newUser
+	return nil
+}
+
+func (r *fakeUserRepo) SetUserRole(_ context.Context, _ int64, _ string) (*user.User, error) {
+	return nil, errors.New("not implemented")
+}
+
+// fakeLedgerRepo changes balances of fakeUserRepo users like mongo ledger changes user documents
+type fakeLedgerRepo struct {
+	users    *fakeUserRepo
+	applyErr error
+}
+
+func (r *fakeLedgerRepo) Apply(_ context.Context, entries ...*ledger.BalanceEntry) error {
+	if r.applyErr != nil {
+		return r.applyErr
+	}
+	for _, entry := range entries {
+		for _, foundUser := range r.users.users {
+			if foundUser.UUID == entry.UserUUID {
+				foundUser.TestnetNanoTon += entry.Amount
+			}
+		}
+	}
+	return nil
+}
+
+func (r *fakeLedgerRepo) GetEntriesByUserUuid(_ context.Context, _ uuid.UUID) ([]ledger.BalanceEntry, error) {
+	return nil, nil
+}
+
+func (r *fakeLedgerRepo) ReconcileUserBalance(_ context.Context, _ uuid.UUID, _ bool) (*ledger.Reconciliation, error) {
+	return nil, nil
+}
+
+func newTestCache(t *testing.T) (*miniredis.Miniredis, *fakeUserRepo, *fakeLedgerRepo, user.UserRepository, ledger.LedgerRepository) {
+	t.Helper()
+	server, runErr := miniredis.Run()
+	if runErr != nil {
+		t.Fatalf("error running miniredis: %v", runErr)
+	}
+	t.Cleanup(server.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	users := &fakeUserRepo{users: map[int64]*user.User{}}
+	nextLedger := &fakeLedgerRepo{users: users}
+	return server, users, nextLedger,
+		userstorage.NewCachedUserRepo(users, client, userstorage.UserCacheCfg{TTL: time.Minute}),
+		NewUserCacheInvalidatingLedgerRepo(nextLedger, client)
+}
+
+func newTestUser(users *fakeUserRepo, userID int64) *user.User {
+	newUser := user.NewUser(uuid.New(), userID, 0, user.RoleUser, 0, 0)
+	users.users[userID] = &newUser
+	return &newUser
+}
+
+func TestApplyInvalidatesCachedUsers(t *testing.T) {
+	server, users, _, userRepo, ledgerRepo := newTestCache(t)
+	ctx := context.Background()
+	first := newTestUser(users, 1)
+	second := newTestUser(users, 2)
+	userRepo.GetUserByID(ctx, 1)
+	userRepo.GetUserByID(ctx, 2)
+
+	applyErr := ledgerRepo.Apply(ctx,
+		ledger.NewBalanceEntry(first.UUID, ledger.EntryTypeDeposit, 10, "first", true),
+		ledger.NewBalanceEntry(first.UUID, ledger.EntryTypeDeposit, 5, "second", true),
+		ledger.NewBalanceEntry(second.UUID, ledger.EntryTypeDeposit, 7, "third", true),
+	)
+	if applyErr != nil {
+		t.Fatalf("Apply() error = %v", applyErr)
+	}
+	if len(server.Keys()) != 0 {
+		t.Fatalf("stale users are cached: %v", server.Keys())
+	}
+
+	foundUser, getErr := userRepo.GetUserByID(ctx, 1)
+	if getErr != nil {
+		t.Fatalf("GetUserByID() error = %v", getErr)
+	}
+	if foundUser.TestnetNanoTon != 15 {
+		t.Fatalf("GetUserByID() balance = %v, want 15", foundUser.TestnetNanoTon)
+	}
+}
+
+func TestFailedApplyKeepsCachedUsers(t *testing.T) {
+	server, users, nextLedger, userRepo, ledgerRepo := newTestCache(t)
+	ctx := context.Background()
+	cachedUser := newTestUser(users, 1)
+	userRepo.GetUserByID(ctx, 1)
+	nextLedger.applyErr = ledger.ErrNotEnoughBalance
+
+	applyErr := ledgerRepo.Apply(ctx, ledger.NewBalanceEntry(cachedUser.UUID, ledger.EntryTypeDebit, 10, "debit", true))
+	if !errors.Is(applyErr, ledger.ErrNotEnoughBalance) {
+		t.Fatalf("Apply() error = %v, want %v", applyErr, ledger.ErrNotEnoughBalance)
+	}
+	if len(server.Keys()) == 0 {
+		t.Fatalf("cached user is removed after failed apply")
+	}
+
+	userRepo.GetUserByID(ctx, 1)
+	if users.gets != 1 {
+		t.Fatalf("users repository is read %v times, want 1", users.gets)
+	}
+}
@@ -0,0 +1,93 @@
+package listing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusSold      Status = "sold"
+	StatusCancelled Status = "cancelled"
+)
+
+var (
+	ErrAlreadyListed = errors.New("nft item is already listed")
+	ErrNotActive     = errors.New("listing is not active anymore")
+	ErrNotSeller     = errors.New("user must be a listing's seller")
+	ErrOwnListing    = errors.New("user cant buy his own listing")
+	ErrInvalidPrice  = errors.New("price of listing is not valid")
+)
+
+// MinPrice is 0.01 TON
+const MinPrice uint64 = 10000000
+
+// Listing is a fixed price offer of custodial nft item to other users of the app
+type Listing struct {
+	ID                uuid.UUID  `bson:"_id" json:"id"`
+	NftItemAddress    string     `bson:"nft_item_address" json:"nft_item_address"`
+	NftItemName       string     `bson:"nft_item_name" json:"nft_item_name"`
+	CollectionAddress string     `bson:"collection_address" json:"collection_address"`
+	CollectionName    string     `bson:"collection_name" json:"collection_name"`
+	Seller            uuid.UUID  `bson:"seller" json:"seller"`
+	Buyer             *uuid.UUID `bson:"buyer,omitempty" json:"buyer,omitempty"`
+	Price             uint64     `bson:"price" json:"price"`                                       // nano ton paid by buyer
+	RoyaltyAmount     uint64     `bson:"royalty_amount,omitempty" json:"royalty_amount,omitempty"` // paid to nft collection owner from price
+	PlatformFee       uint64     `bson:"platform_fee,omitempty" json:"platform_fee,omitempty"`
+	Status            Status     `bson:"status" json:"status"`
+	IsTestnet         bool       `bson:"is_testnet" json:"is_testnet"`
+	CreatedAt         time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+func New(nftItem *nftitem.NftItem, price uint64) *Listing {
+	now := time.Now()
+	return &Listing{
+		ID:                uuid.New(),
+		NftItemAddress:    nftItem.Address,
+		NftItemName:       nftItem.Metadata.Name,
+		CollectionAddress: nftItem.CollectionAddress,
+		CollectionName:    nftItem.CollectionName,
+		Seller:            nftItem.Owner,
+		Price:             price,
+		Status:            StatusActive,
+		IsTestnet:         nftItem.IsTestnet,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+}
+
+// SellerAmount is credited to seller after sale
+func (l *Listing) SellerAmount() uint64 {
+	return l.Price - l.RoyaltyAmount - l.PlatformFee
+}
+
+type Sort string
+
+const (
+	SortNewest    Sort = "newest"
+	SortPriceAsc  Sort = "price_asc"
+	SortPriceDesc Sort = "price_desc"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Query filters active listings, empty fields are not filtered
+type Query struct {
+	CollectionAddress string
+	Seller            *uuid.UUID
+	IsTestnet         *bool
+	MinPrice          uint64
+	MaxPrice          uint64
+	Sort              Sort
+	Offset            int
+	Limit             int
+}
@@ -0,0 +1,21 @@
+package listing
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ListingRepository interface {
+	// CreateListing returns ErrAlreadyListed if nft item has active listing
+	CreateListing(ctx context.Context, listing *Listing) error
+	GetListingByID(ctx context.Context, id uuid.UUID) (*Listing, error)
+	// GetActiveListingByNftItem returns mongo.ErrNoDocuments if nft item isnt listed
+	GetActiveListingByNftItem(ctx context.Context, nftItemAddress string) (*Listing, error)
+	GetListings(ctx context.Context, query Query) ([]Listing, error)
+	// CloseListing moves active listing to sold or cancelled status, buyer and fees are saved for sold one.
+	// Returns ErrNotActive if listing is not active anymore
+	CloseListing(ctx context.Context, listing *Listing) error
+	// WithTransaction runs fn in transaction, other repositories called with txCtx join it
+	WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
+}
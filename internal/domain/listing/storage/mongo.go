@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	"github.com/rom6n/create-nft-go/internal/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoListingRepo struct {
+	client         *mongo.Client
+	dbName         string
+	collectionName string
+	timeout        time.Duration
+}
+
+type ListingRepoCfg struct {
+	DBName         string
+	CollectionName string
+	Timeout        time.Duration
+}
+
+func NewListingRepo(client *mongo.Client, cfg ListingRepoCfg) listing.ListingRepository {
+	repo := &mongoListingRepo{
+		client:         client,
+		dbName:         cfg.DBName,
+		collectionName: cfg.CollectionName,
+		timeout:        cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating listings indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoListingRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoListingRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoListingRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{
+			// nft item can have only one active listing
+			Keys: bson.D{{Key: "nft_item_address", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "status", Value: listing.StatusActive}}),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "is_testnet", Value: 1}, {Key: "collection_address", Value: 1}, {Key: "price", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "seller", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+
+	return indexErr
+}
+
+func (r *mongoListingRepo) CreateListing(ctx context.Context, newListing *listing.Listing) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	if _, insertErr := r.getCollection().InsertOne(dbCtx, *newListing); insertErr != nil {
+		if mongo.IsDuplicateKeyError(insertErr) {
+			return listing.ErrAlreadyListed
+		}
+		return fmt.Errorf("error inserting listing: %w", insertErr)
+	}
+
+	return nil
+}
+
+func (r *mongoListingRepo) GetListingByID(ctx context.Context, id uuid.UUID) (*listing.Listing, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundListing listing.Listing
+	if decodeErr := r.getCollection().FindOne(dbCtx, bson.D{{Key: "_id", Value: id}}).Decode(&foundListing); decodeErr != nil {
+		return nil, fmt.Errorf("listing decode error after searching: %w", decodeErr)
+	}
+
+	return &foundListing, nil
+}
+
+func (r *mongoListingRepo) GetActiveListingByNftItem(ctx context.Context, nftItemAddress string) (*listing.Listing, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundListing listing.Listing
+	filter := bson.D{{Key: "nft_item_address", Value: nftItemAddress}, {Key: "status", Value: listing.StatusActive}}
+	if decodeErr := r.getCollection().FindOne(dbCtx, filter).Decode(&foundListing); decodeErr != nil {
+		return nil, fmt.Errorf("listing decode error after searching: %w", decodeErr)
+	}
+
+	return &foundListing, nil
+}
+
+func (r *mongoListingRepo) GetListings(ctx context.Context, query listing.Query) ([]listing.Listing, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	filter := bson.D{{Key: "status", Value: listing.StatusActive}}
+	if query.CollectionAddress != "" {
+		filter = append(filter, bson.E{Key: "collection_address", Value: query.CollectionAddress})
+	}
+	if query.Seller != nil {
+		filter = append(filter, bson.E{Key: "seller", Value: *query.Seller})
+	}
+	if query.IsTestnet != nil {
+		filter = append(filter, bson.E{Key: "is_testnet", Value: *query.IsTestnet})
+	}
+
+	priceFilter := bson.D{}
+	if query.MinPrice > 0 {
+		priceFilter = append(priceFilter, bson.E{Key: "$gte", Value: query.MinPrice})
+	}
+	if query.MaxPrice > 0 {
+		priceFilter = append(priceFilter, bson.E{Key: "$lte", Value: query.MaxPrice})
+	}
+	if len(priceFilter) > 0 {
+		filter = append(filter, bson.E{Key: "price", Value: priceFilter})
+	}
+
+	sort := bson.D{{Key: "created_at", Value: -1}}
+	switch query.Sort {
+	case listing.SortPriceAsc:
+		sort = bson.D{{Key: "price", Value: 1}, {Key: "created_at", Value: -1}}
+	case listing.SortPriceDesc:
+		sort = bson.D{{Key: "price", Value: -1}, {Key: "created_at", Value: -1}}
+	}
+
+	findOptions := options.Find().SetSort(sort).SetSkip(int64(query.Offset)).SetLimit(int64(query.Limit))
+
+	foundListings := []listing.Listing{}
+	cursor, findErr := r.getCollection().Find(dbCtx, filter, findOptions)
+	if findErr != nil {
+		return nil, fmt.Errorf("listings find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundListings); decodeErr != nil {
+		return nil, fmt.Errorf("listings decode error: %v", decodeErr)
+	}
+
+	return foundListings, nil
+}
+
+func (r *mongoListingRepo) CloseListing(ctx context.Context, closedListing *listing.Listing) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	closedListing.UpdatedAt = time.Now()
+
+	set := bson.D{
+		{Key: "status", Value: closedListing.Status},
+		{Key: "updated_at", Value: closedListing.UpdatedAt},
+	}
+	if closedListing.Status == listing.StatusSold {
+		set = append(set,
+			bson.E{Key: "buyer", Value: closedListing.Buyer},
+			bson.E{Key: "royalty_amount", Value: closedListing.RoyaltyAmount},
+			bson.E{Key: "platform_fee", Value: closedListing.PlatformFee},
+		)
+	}
+
+	// guard: listing could be bought or cancelled concurrently
+	filter := bson.D{{Key: "_id", Value: closedListing.ID}, {Key: "status", Value: listing.StatusActive}}
+	result, updErr := r.getCollection().UpdateOne(dbCtx, filter, bson.D{{Key: "$set", Value: set}})
+	if updErr != nil {
+		return fmt.Errorf("error closing listing: %w", updErr)
+	}
+
+	if result.MatchedCount == 0 {
+		return listing.ErrNotActive
+	}
+
+	return nil
+}
+
+func (r *mongoListingRepo) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return storage.WithTransaction(ctx, r.client, fn)
+}
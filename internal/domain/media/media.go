@@ -0,0 +1,135 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnsupportedType   = errors.New("media type is not supported")
+	ErrTooLarge          = errors.New("media file is too large")
+	ErrInvalidDimensions = errors.New("media dimensions are not allowed")
+	ErrInvalidKind       = errors.New("unknown media kind")
+	ErrBlobNotFound      = errors.New("blob isnt in blob store")
+)
+
+// MaxFileSize limits uploaded file, body limit of media upload routes is bigger
+const MaxFileSize = 8 * 1024 * 1024
+
+// ThumbnailSizes are longest sides of generated thumbnails in px, sizes not smaller than original are skipped
+var ThumbnailSizes = []int{128, 256, 512}
+
+// extensions of supported mime types, thumbnails of jpeg are jpeg and png for others to keep transparency
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+type Kind string
+
+const (
+	KindNftCollectionImage Kind = "nft_collection_image"
+	KindNftCollectionCover Kind = "nft_collection_cover"
+	KindNftItemImage       Kind = "nft_item_image"
+)
+
+// Limits are allowed dimensions of media kind in px
+type Limits struct {
+	MinWidth  int
+	MinHeight int
+	MaxWidth  int
+	MaxHeight int
+}
+
+func (k Kind) Limits() (Limits, error) {
+	switch k {
+	case KindNftCollectionImage, KindNftItemImage:
+		return Limits{MinWidth: 64, MinHeight: 64, MaxWidth: 4096, MaxHeight: 4096}, nil
+	case KindNftCollectionCover:
+		// covers are wide banners
+		return Limits{MinWidth: 320, MinHeight: 80, MaxWidth: 6144, MaxHeight: 4096}, nil
+	default:
+		return Limits{}, fmt.Errorf("%w: %v", ErrInvalidKind, k)
+	}
+}
+
+func (l Limits) Check(width, height int) error {
+	if width < l.MinWidth || height < l.MinHeight || width > l.MaxWidth || height > l.MaxHeight {
+		return fmt.Errorf("%w: %vx%v, must be from %vx%v to %vx%v", ErrInvalidDimensions, width, height, l.MinWidth, l.MinHeight, l.MaxWidth, l.MaxHeight)
+	}
+	return nil
+}
+
+type Thumbnail struct {
+	Size   int    `bson:"size" json:"size"`
+	Width  int    `bson:"width" json:"width"`
+	Height int    `bson:"height" json:"height"`
+	URL    string `bson:"url" json:"url"`
+}
+
+// Media is image uploaded to the app. It is addressed by sha256 of its file, the same file is stored once
+type Media struct {
+	Hash       string      `bson:"_id" json:"hash"`
+	MimeType   string      `bson:"mime_type" json:"mime_type"`
+	Size       int         `bson:"size" json:"size"`
+	Width      int         `bson:"width" json:"width"`
+	Height     int         `bson:"height" json:"height"`
+	URL        string      `bson:"url" json:"url"`
+	Thumbnails []Thumbnail `bson:"thumbnails" json:"thumbnails"`
+	Uploader   uuid.UUID   `bson:"uploader" json:"uploader"`
+	CreatedAt  time.Time   `bson:"created_at" json:"created_at"`
+}
+
+func New(hash string, mimeType string, size int, width int, height int, uploader uuid.UUID) *Media {
+	return &Media{
+		Hash:       hash,
+		MimeType:   mimeType,
+		Size:       size,
+		Width:      width,
+		Height:     height,
+		Thumbnails: []Thumbnail{},
+		Uploader:   uploader,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// Extension returns file extension of supported mime type
+func Extension(mimeType string) (string, error) {
+	extension, ok := extensions[mimeType]
+	if !ok {
+		return "", fmt.Errorf("%w: %v, must be jpeg, png, gif or webp", ErrUnsupportedType, mimeType)
+	}
+	return extension, nil
+}
+
+// ThumbnailMimeType returns mime type thumbnails of media are encoded with
+func ThumbnailMimeType(mimeType string) string {
+	if mimeType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// ContentType returns mime type of blob key made by OriginalKey or ThumbnailKey
+func ContentType(key string) string {
+	for mimeType, extension := range extensions {
+		if strings.HasSuffix(key, "."+extension) {
+			return mimeType
+		}
+	}
+	return "application/octet-stream"
+}
+
+func OriginalKey(hash string, mimeType string) string {
+	return hash + "." + extensions[mimeType]
+}
+
+func ThumbnailKey(hash string, size int, mimeType string) string {
+	return fmt.Sprintf("%v_%v.%v", hash, size, extensions[ThumbnailMimeType(mimeType)])
+}
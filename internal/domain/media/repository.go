@@ -0,0 +1,17 @@
+package media
+
+import "context"
+
+type MediaRepository interface {
+	// SaveMedia stores media once, saving the same file again does nothing
+	SaveMedia(ctx context.Context, media *Media) error
+	GetMediaByHash(ctx context.Context, hash string) (*Media, error)
+}
+
+// BlobStore keeps files of media, every implementation gives public url of stored file
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns ErrBlobNotFound if there is no file under key
+	Get(ctx context.Context, key string) ([]byte, error)
+	URL(key string) string
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+)
+
+type fileSystemBlobStore struct {
+	dir     string
+	baseURL string
+}
+
+type FileSystemBlobStoreCfg struct {
+	Dir     string // directory files are written to, it is created if missing
+	BaseURL string // url files are served by, file url is BaseURL/key
+}
+
+// NewFileSystemBlobStore keeps files on local disk, the app serves them itself
+func NewFileSystemBlobStore(cfg FileSystemBlobStoreCfg) (media.BlobStore, error) {
+	if mkdirErr := os.MkdirAll(cfg.Dir, 0o755); mkdirErr != nil {
+		return nil, fmt.Errorf("error creating blob store directory: %w", mkdirErr)
+	}
+
+	return &fileSystemBlobStore{
+		dir:     cfg.Dir,
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+	}, nil
+}
+
+func (s *fileSystemBlobStore) path(key string) (string, error) {
+	// key comes from request on serving, it mustnt leave directory
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid blob key: %v", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
+
+func (s *fileSystemBlobStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, pathErr := s.path(key)
+	if pathErr != nil {
+		return pathErr
+	}
+
+	// file is written aside and renamed, so it is never served half written
+	tmpFile, createErr := os.CreateTemp(s.dir, ".upload-*")
+	if createErr != nil {
+		return fmt.Errorf("error creating blob file: %w", createErr)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, writeErr := tmpFile.Write(data); writeErr != nil {
+		tmpFile.Close()
+		return fmt.Errorf("error writing blob file: %w", writeErr)
+	}
+	if closeErr := tmpFile.Close(); closeErr != nil {
+		return fmt.Errorf("error writing blob file: %w", closeErr)
+	}
+
+	if renameErr := os.Rename(tmpFile.Name(), path); renameErr != nil {
+		return fmt.Errorf("error saving blob file: %w", renameErr)
+	}
+
+	return nil
+}
+
+func (s *fileSystemBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, pathErr := s.path(key)
+	if pathErr != nil {
+		return nil, fmt.Errorf("%w: %v", media.ErrBlobNotFound, pathErr)
+	}
+
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		if errors.Is(readErr, fs.ErrNotExist) {
+			return nil, media.ErrBlobNotFound
+		}
+		return nil, fmt.Errorf("error reading blob file: %w", readErr)
+	}
+
+	return data, nil
+}
+
+func (s *fileSystemBlobStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
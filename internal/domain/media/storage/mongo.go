@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type mongoMediaRepo struct {
+	client         *mongo.Client
+	dbName         string
+	collectionName string
+	timeout        time.Duration
+}
+
+type MediaRepoCfg struct {
+	DBName         string
+	CollectionName string
+	Timeout        time.Duration
+}
+
+func NewMediaRepo(client *mongo.Client, cfg MediaRepoCfg) media.MediaRepository {
+	return &mongoMediaRepo{
+		client:         client,
+		dbName:         cfg.DBName,
+		collectionName: cfg.CollectionName,
+		timeout:        cfg.Timeout,
+	}
+}
+
+func (r *mongoMediaRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoMediaRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoMediaRepo) SaveMedia(ctx context.Context, newMedia *media.Media) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	// same hash means same file, it is uploaded concurrently
+	if _, insertErr := r.getCollection().InsertOne(dbCtx, *newMedia); insertErr != nil && !mongo.IsDuplicateKeyError(insertErr) {
+		return fmt.Errorf("error inserting media: %w", insertErr)
+	}
+
+	return nil
+}
+
+func (r *mongoMediaRepo) GetMediaByHash(ctx context.Context, hash string) (*media.Media, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundMedia media.Media
+	if decodeErr := r.getCollection().FindOne(dbCtx, bson.D{{Key: "_id", Value: hash}}).Decode(&foundMedia); decodeErr != nil {
+		return nil, fmt.Errorf("media decode error after searching: %w", decodeErr)
+	}
+
+	return &foundMedia, nil
+}
@@ -1,13 +1,21 @@
 package nftcollection
 
 import (
+	"errors"
+
 	"github.com/google/uuid"
 	"github.com/xssnick/tonutils-go/address"
 )
 
+var (
+	ErrNotCollectionOwner = errors.New("user must be an nft collection's owner")
+	ErrNotCustodial       = errors.New("marketplace contract must be an nft collection's owner")
+)
+
 type NftCollectionMetadata struct {
 	Name         string   `bson:"name" json:"name"`
 	Image        string   `bson:"image" json:"image"`
+	ImageData    []byte   `bson:"image_data,omitempty" json:"image_data,omitempty"`
 	CoverImage   string   `bson:"cover_image" json:"cover_image"`
 	Description  string   `bson:"description" json:"description"`
 	ExternalUrl  string   `bson:"external_url" json:"external_url"`
@@ -16,11 +24,28 @@ type NftCollectionMetadata struct {
 	Marketplace  string   `bson:"marketplace" json:"marketplace"`
 }
 
+// Royalty is royalty params of nft collection, Dividend / Divisor of sale price goes to collection owner
+type Royalty struct {
+	Dividend uint16 `bson:"dividend" json:"dividend"`
+	Divisor  uint16 `bson:"divisor" json:"divisor"`
+	Address  string `bson:"address,omitempty" json:"address,omitempty"` // royalty receiver on chain
+}
+
+// Amount returns royalty of sale price, invalid params give no royalty
+func (r *Royalty) Amount(price uint64) uint64 {
+	if r.Divisor == 0 || r.Dividend > r.Divisor {
+		return 0
+	}
+	// price is split to not overflow on big prices
+	return price/uint64(r.Divisor)*uint64(r.Dividend) + price%uint64(r.Divisor)*uint64(r.Dividend)/uint64(r.Divisor)
+}
+
 type NftCollection struct {
 	Address       string                `bson:"_id" json:"address"`
 	NextItemIndex int64                 `bson:"next_item_index" json:"next_item_index"`
 	Owner         uuid.UUID             `bson:"owner" json:"owner"`
-	Metadata      NftCollectionMetadata `bson:"metadata" json:"metadata"` // под вопросом как метадата будет приходить
+	Metadata      NftCollectionMetadata `bson:"metadata" json:"metadata"`                   // под вопросом как метадата будет приходить
+	Royalty       *Royalty              `bson:"royalty,omitempty" json:"royalty,omitempty"` // nil for collections stored before royalty params were saved
 	IsTestnet     bool                  `bson:"is_testnet" json:"is_testnet"`
 }
 
@@ -30,16 +55,30 @@ type DeployCollectionCfg struct {
 	CollectionContent string
 	RoyaltyDividend   uint16
 	RoyaltyDivisor    uint16
+	OnchainMetadata   *NftCollectionMetadata // if set, collection content is stored onchain instead of CollectionContent link
+	HostedMetadata    *NftCollectionMetadata // if set, it is hosted by the app and its link is used as CollectionContent
 	// next item index always is 1
 	// nft item code
 }
 
-func New(address string, ownerUuid uuid.UUID, metadata *NftCollectionMetadata, isTestnet bool) *NftCollection {
+// ChangeContentCfg is new content and royalty params of deployed nft collection
+type ChangeContentCfg struct {
+	CommonContent     string
+	CollectionContent string
+	OnchainMetadata   *NftCollectionMetadata // if set, collection content is stored onchain instead of CollectionContent link
+	HostedMetadata    *NftCollectionMetadata // if set, it is hosted by the app and its link is used as CollectionContent
+	RoyaltyDividend   *uint16                // nil to keep current royalty params
+	RoyaltyDivisor    *uint16
+	RoyaltyAddress    *address.Address // nil to keep current royalty address
+}
+
+func New(address string, ownerUuid uuid.UUID, metadata *NftCollectionMetadata, royalty *Royalty, isTestnet bool) *NftCollection {
 	return &NftCollection{
 		Address:       address,
 		NextItemIndex: 1,
 		Owner:         ownerUuid,
 		Metadata:      *metadata,
+		Royalty:       royalty,
 		IsTestnet:     isTestnet,
 	}
 }
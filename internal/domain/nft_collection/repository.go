@@ -9,7 +9,11 @@ import (
 type NftCollectionRepository interface {
 	CreateNftCollection(ctx context.Context, collection *NftCollection) error
 	DeleteNftCollection(ctx context.Context, collectionAddress string) error
+	UpdateNftCollectionMetadata(ctx context.Context, collectionAddress string, metadata *NftCollectionMetadata) error
+	UpdateNftCollectionRoyalty(ctx context.Context, collectionAddress string, royalty *Royalty) error
 	GetNftCollectionByAddress(ctx context.Context, collectionAddress string) (*NftCollection, error)
 	GetNftCollectionsByOwnerUuid(ctx context.Context, uuid uuid.UUID) ([]NftCollection, error)
+	// GetNftCollectionsByNetwork returns all custodial nft collections of testnet or mainnet
+	GetNftCollectionsByNetwork(ctx context.Context, isTestnet bool) ([]NftCollection, error)
 }
 
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"log"
+
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+type indexedNftCollectionRepo struct {
+	nftcollection.NftCollectionRepository
+	searchRepo search.SearchRepository
+}
+
+// NewIndexedNftCollectionRepo mirrors nft collections created, updated and deleted by next repository to search index.
+// Index errors are logged only, database stays the source of truth. Inside transaction index is changed after commit
+func NewIndexedNftCollectionRepo(next nftcollection.NftCollectionRepository, searchRepo search.SearchRepository) nftcollection.NftCollectionRepository {
+	return &indexedNftCollectionRepo{
+		NftCollectionRepository: next,
+		searchRepo:              searchRepo,
+	}
+}
+
+func (r *indexedNftCollectionRepo) CreateNftCollection(ctx context.Context, collection *nftcollection.NftCollection) error {
+	if createErr := r.NftCollectionRepository.CreateNftCollection(ctx, collection); createErr != nil {
+		return createErr
+	}
+
+	r.index(ctx, search.NewNftCollectionDocument(collection))
+	return nil
+}
+
+func (r *indexedNftCollectionRepo) DeleteNftCollection(ctx context.Context, collectionAddress string) error {
+	if delErr := r.NftCollectionRepository.DeleteNftCollection(ctx, collectionAddress); delErr != nil {
+		return delErr
+	}
+
+	storage.AfterCommit(ctx, func(ctx context.Context) {
+		if indexErr := r.searchRepo.DeleteDocuments(ctx, collectionAddress); indexErr != nil {
+			log.Printf("Error removing nft collection %v from search index: %v\n", collectionAddress, indexErr)
+		}
+	})
+
+	return nil
+}
+
+func (r *indexedNftCollectionRepo) UpdateNftCollectionMetadata(ctx context.Context, collectionAddress string, metadata *nftcollection.NftCollectionMetadata) error {
+	if updateErr := r.NftCollectionRepository.UpdateNftCollectionMetadata(ctx, collectionAddress, metadata); updateErr != nil {
+		return updateErr
+	}
+
+	collection, getErr := r.NftCollectionRepository.GetNftCollectionByAddress(ctx, collectionAddress)
+	if getErr != nil {
+		log.Printf("Error getting nft collection %v to reindex: %v\n", collectionAddress, getErr)
+		return nil
+	}
+
+	r.index(ctx, search.NewNftCollectionDocument(collection))
+	return nil
+}
+
+func (r *indexedNftCollectionRepo) index(ctx context.Context, document *search.Document) {
+	storage.AfterCommit(ctx, func(ctx context.Context) {
+		if indexErr := r.searchRepo.IndexDocuments(ctx, document); indexErr != nil {
+			log.Printf("Error indexing nft collection %v: %v\n", document.Address, indexErr)
+		}
+	})
+}
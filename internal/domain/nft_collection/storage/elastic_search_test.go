@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+	searchstorage "github.com/rom6n/create-nft-go/internal/domain/search/storage"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+// fakeElasticSearch keeps documents changed by bulk requests of existing index
+type fakeElasticSearch struct {
+	mu        sync.Mutex
+	documents map[string]search.Document
+	available bool
+}
+
+func (f *fakeElasticSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.available {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var action map[string]struct {
+			ID string `json:"_id"`
+		}
+		json.Unmarshal(scanner.Bytes(), &action)
+
+		if index, ok := action["index"]; ok {
+			scanner.Scan()
+			var document search.Document
+			json.Unmarshal(scanner.Bytes(), &document)
+			f.documents[index.ID] = document
+		}
+		if del, ok := action["delete"]; ok {
+			delete(f.documents, del.ID)
+		}
+	}
+	w.Write([]byte(`{"errors":false,"items":[]}`))
+}
+
+func newTestIndexedRepo(t *testing.T) (*fakeElasticSearch, *fakeNftCollectionRepo, nftcollection.NftCollectionRepository) {
+	t.Helper()
+	fake := &fakeElasticSearch{documents: map[string]search.Document{}, available: true}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	searchRepo := searchstorage.NewSearchRepo(storage.NewElasticSearchClientWithURL(server.URL, server.Client()), searchstorage.SearchRepoCfg{
+		IndexName: "test-index",
+		Timeout:   5 * time.Second,
+	})
+	next := &fakeNftCollectionRepo{collections: map[string]nftcollection.NftCollection{}}
+	return fake, next, NewIndexedNftCollectionRepo(next, searchRepo)
+}
+
+func TestIndexedNftCollectionRepoCreateNftCollection(t *testing.T) {
+	fake, next, repo := newTestIndexedRepo(t)
+	collection := newTestNftCollection("collection", uuid.New())
+
+	if createErr := repo.CreateNftCollection(context.Background(), collection); createErr != nil {
+		t.Fatalf("CreateNftCollection() error = %v", createErr)
+	}
+	if _, ok := next.collections["collection"]; !ok {
+		t.Fatalf("nft collection isnt created by next repository")
+	}
+
+	document, ok := fake.documents["collection"]
+	if !ok {
+		t.Fatalf("nft collection isnt indexed")
+	}
+	if document.Kind != search.KindNftCollection || document.Owner != collection.Owner || document.Name != "collection" {
+		t.Fatalf("indexed document = %v", document)
+	}
+}
+
+func TestIndexedNftCollectionRepoUpdateNftCollectionMetadata(t *testing.T) {
+	fake, _, repo := newTestIndexedRepo(t)
+	ctx := context.Background()
+	repo.CreateNftCollection(ctx, newTestNftCollection("collection", uuid.New()))
+
+	updateErr := repo.UpdateNftCollectionMetadata(ctx, "collection", &nftcollection.NftCollectionMetadata{Name: "renamed", Description: "new description"})
+	if updateErr != nil {
+		t.Fatalf("UpdateNftCollectionMetadata() error = %v", updateErr)
+	}
+	if document := fake.documents["collection"]; document.Name != "renamed" || document.Description != "new description" {
+		t.Fatalf("indexed document = %v", document)
+	}
+}
+
+func TestIndexedNftCollectionRepoDeleteNftCollection(t *testing.T) {
+	fake, _, repo := newTestIndexedRepo(t)
+	ctx := context.Background()
+	repo.CreateNftCollection(ctx, newTestNftCollection("collection", uuid.New()))
+
+	if delErr := repo.DeleteNftCollection(ctx, "collection"); delErr != nil {
+		t.Fatalf("DeleteNftCollection() error = %v", delErr)
+	}
+	if _, ok := fake.documents["collection"]; ok {
+		t.Fatalf("nft collection isnt removed from index")
+	}
+}
+
+func TestIndexedNftCollectionRepoIgnoresIndexErrors(t *testing.T) {
+	fake, next, repo := newTestIndexedRepo(t)
+	ctx := context.Background()
+	fake.available = false
+
+	if createErr := repo.CreateNftCollection(ctx, newTestNftCollection("collection", uuid.New())); createErr != nil {
+		t.Fatalf("CreateNftCollection() error = %v", createErr)
+	}
+	if updateErr := repo.UpdateNftCollectionMetadata(ctx, "collection", &nftcollection.NftCollectionMetadata{Name: "renamed"}); updateErr != nil {
+		t.Fatalf("UpdateNftCollectionMetadata() error = %v", updateErr)
+	}
+	if next.collections["collection"].Metadata.Name != "renamed" {
+		t.Fatalf("database isnt changed when index is unavailable")
+	}
+}
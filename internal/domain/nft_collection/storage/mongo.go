@@ -73,6 +73,42 @@ func (v *nftCollectionRepo) DeleteNftCollection(ctx context.Context, collectionA
 	return nil
 }
 
+func (v *nftCollectionRepo) UpdateNftCollectionMetadata(ctx context.Context, collectionAddress string, metadata *nftcollection.NftCollectionMetadata) error {
+	dbCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	collection := v.getCollection()
+
+	result, updateErr := collection.UpdateOne(dbCtx, bson.D{{Key: "_id", Value: collectionAddress}}, bson.D{{Key: "$set", Value: bson.D{{Key: "metadata", Value: *metadata}}}})
+	if updateErr != nil {
+		return updateErr
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (v *nftCollectionRepo) UpdateNftCollectionRoyalty(ctx context.Context, collectionAddress string, royalty *nftcollection.Royalty) error {
+	dbCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	collection := v.getCollection()
+
+	result, updateErr := collection.UpdateOne(dbCtx, bson.D{{Key: "_id", Value: collectionAddress}}, bson.D{{Key: "$set", Value: bson.D{{Key: "royalty", Value: *royalty}}}})
+	if updateErr != nil {
+		return updateErr
+	}
+
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
 func (v *nftCollectionRepo) GetNftCollectionByAddress(ctx context.Context, collectionAddress string) (*nftcollection.NftCollection, error) {
 	dbCtx, cancel := v.getContext(ctx)
 	defer cancel()
@@ -82,12 +118,29 @@ func (v *nftCollectionRepo) GetNftCollectionByAddress(ctx context.Context, colle
 	var foundedCollection nftcollection.NftCollection
 	decodeErr := collection.FindOne(dbCtx, bson.D{{Key: "_id", Value: collectionAddress}}).Decode(&foundedCollection)
 	if decodeErr != nil {
-		return &foundedCollection, fmt.Errorf("nft collection decode error after seaching: %v", decodeErr)
+		return &foundedCollection, fmt.Errorf("nft collection decode error after seaching: %w", decodeErr)
 	}
 
 	return &foundedCollection, nil
 }
 
+func (v *nftCollectionRepo) GetNftCollectionsByNetwork(ctx context.Context, isTestnet bool) ([]nftcollection.NftCollection, error) {
+	dbCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	var foundedCollections []nftcollection.NftCollection
+	cursor, findErr := v.getCollection().Find(dbCtx, bson.D{{Key: "is_testnet", Value: isTestnet}})
+	if findErr != nil {
+		return nil, fmt.Errorf("nft collections find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedCollections); decodeErr != nil {
+		return nil, fmt.Errorf("nft collections decode error after find: %v", decodeErr)
+	}
+
+	return foundedCollections, nil
+}
+
 func (v *nftCollectionRepo) GetNftCollectionsByOwnerUuid(ctx context.Context, uuid uuid.UUID) ([]nftcollection.NftCollection, error) {
 	dbCtx, cancel := v.getContext(ctx)
 	defer cancel()
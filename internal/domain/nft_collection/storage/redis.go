@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+type cachedNftCollectionRepo struct {
+	next   nftcollection.NftCollectionRepository
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+type NftCollectionCacheCfg struct {
+	TTL time.Duration
+}
+
+// NewCachedNftCollectionRepo returns read-through cache over next repository
+func NewCachedNftCollectionRepo(next nftcollection.NftCollectionRepository, client redis.UniversalClient, cfg NftCollectionCacheCfg) nftcollection.NftCollectionRepository {
+	return &cachedNftCollectionRepo{
+		next:   next,
+		client: client,
+		ttl:    cfg.TTL,
+	}
+}
+
+func nftCollectionCacheKey(collectionAddress string) string {
+	return fmt.Sprintf("nft_collection:%v", collectionAddress)
+}
+
+func ownerNftCollectionsCacheKey(ownerUuid uuid.UUID) string {
+	return fmt.Sprintf("nft_collections:owner:%v", ownerUuid)
+}
+
+func (r *cachedNftCollectionRepo) CreateNftCollection(ctx context.Context, collection *nftcollection.NftCollection) error {
+	if createErr := r.next.CreateNftCollection(ctx, collection); createErr != nil {
+		return createErr
+	}
+
+	storage.DeleteCached(ctx, r.client, nftCollectionCacheKey(collection.Address), ownerNftCollectionsCacheKey(collection.Owner))
+	return nil
+}
+
+func (r *cachedNftCollectionRepo) DeleteNftCollection(ctx context.Context, collectionAddress string) error {
+	// owner is needed to invalidate his collections list
+	collection, getErr := r.GetNftCollectionByAddress(ctx, collectionAddress)
+
+	if delErr := r.next.DeleteNftCollection(ctx, collectionAddress); delErr != nil {
+		return delErr
+	}
+
+	keys := []string{nftCollectionCacheKey(collectionAddress)}
+	if getErr == nil {
+		keys = append(keys, ownerNftCollectionsCacheKey(collection.Owner))
+	}
+	storage.DeleteCached(ctx, r.client, keys...)
+
+	return nil
+}
+
+func (r *cachedNftCollectionRepo) UpdateNftCollectionMetadata(ctx context.Context, collectionAddress string, metadata *nftcollection.NftCollectionMetadata) error {
+	// owner is needed to invalidate his collections list
+	collection, getErr := r.GetNftCollectionByAddress(ctx, collectionAddress)
+
+	if updateErr := r.next.UpdateNftCollectionMetadata(ctx, collectionAddress, metadata); updateErr != nil {
+		return updateErr
+	}
+
+	keys := []string{nftCollectionCacheKey(collectionAddress)}
+	if getErr == nil {
+		keys = append(keys, ownerNftCollectionsCacheKey(collection.Owner))
+	}
+	storage.DeleteCached(ctx, r.client, keys...)
+
+	return nil
+}
+
+func (r *cachedNftCollectionRepo) UpdateNftCollectionRoyalty(ctx context.Context, collectionAddress string, royalty *nftcollection.Royalty) error {
+	collection, getErr := r.GetNftCollectionByAddress(ctx, collectionAddress)
+
+	if updateErr := r.next.UpdateNftCollectionRoyalty(ctx, collectionAddress, royalty); updateErr != nil {
+		return updateErr
+	}
+
+	keys := []string{nftCollectionCacheKey(collectionAddress)}
+	if getErr == nil {
+		keys = append(keys, ownerNftCollectionsCacheKey(collection.Owner))
+	}
+	storage.DeleteCached(ctx, r.client, keys...)
+
+	return nil
+}
+
+func (r *cachedNftCollectionRepo) GetNftCollectionByAddress(ctx context.Context, collectionAddress string) (*nftcollection.NftCollection, error) {
+	if cachedCollection, ok := storage.GetCached[nftcollection.NftCollection](ctx, r.client, nftCollectionCacheKey(collectionAddress)); ok {
+		return cachedCollection, nil
+	}
+
+	collection, dbErr := r.next.GetNftCollectionByAddress(ctx, collectionAddress)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	storage.SetCached(ctx, r.client, nftCollectionCacheKey(collectionAddress), *collection, r.ttl)
+	return collection, nil
+}
+
+func (r *cachedNftCollectionRepo) GetNftCollectionsByOwnerUuid(ctx context.Context, uuid uuid.UUID) ([]nftcollection.NftCollection, error) {
+	if cachedCollections, ok := storage.GetCached[[]nftcollection.NftCollection](ctx, r.client, ownerNftCollectionsCacheKey(uuid)); ok {
+		return *cachedCollections, nil
+	}
+
+	collections, dbErr := r.next.GetNftCollectionsByOwnerUuid(ctx, uuid)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	storage.SetCached(ctx, r.client, ownerNftCollectionsCacheKey(uuid), collections, r.ttl)
+	return collections, nil
+}
+
+// GetNftCollectionsByNetwork isnt cached, it is used by reconciliation which needs database state
+func (r *cachedNftCollectionRepo) GetNftCollectionsByNetwork(ctx context.Context, isTestnet bool) ([]nftcollection.NftCollection, error) {
+	return r.next.GetNftCollectionsByNetwork(ctx, isTestnet)
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type fakeNftCollectionRepo struct {
+	collections map[string]nftcollection.NftCollection
+	gets        int
+	ownerGets   int
+}
+
+func (r *fakeNftCollectionRepo) CreateNftCollection(_ context.Context, collection *nftcollection.NftCollection) error {
+	r.collections[collection.Address] = *collection
+	return nil
+}
+
+func (r *fakeNftCollectionRepo) DeleteNftCollection(_ context.Context, collectionAddress string) error {
+	delete(r.collections, collectionAddress)
+	return nil
+}
+
+func (r *fakeNftCollectionRepo) UpdateNftCollectionMetadata(_ context.Context, collectionAddress string, metadata *nftcollection.NftCollectionMetadata) error {
+	collection, ok := r.collections[collectionAddress]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	collection.Metadata = *metadata
+	r.collections[collectionAddress] = collection
+	return nil
+}
+
+func (r *fakeNftCollectionRepo) UpdateNftCollectionRoyalty(_ context.Context, collectionAddress string, royalty *nftcollection.Royalty) error {
+	collection, ok := r.collections[collectionAddress]
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	collection.Royalty = royalty
+	r.collections[collectionAddress] = collection
+	return nil
+}
+
+func (r *fakeNftCollectionRepo) GetNftCollectionByAddress(_ context.Context, collectionAddress string) (*nftcollection.NftCollection, error) {
+	r.gets++
+	collection, ok := r.collections[collectionAddress]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &collection, nil
+}
+
+func (r *fakeNftCollectionRepo) GetNftCollectionsByOwnerUuid(_ context.Context, ownerUuid uuid.UUID) ([]nftcollection.NftCollection, error) {
+	r.ownerGets++
+	var result []nftcollection.NftCollection
+	for _, collection := range r.collections {
+		if collection.Owner == ownerUuid {
+			result = append(result, collection)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeNftCollectionRepo) GetNftCollectionsByNetwork(_ context.Context, isTestnet bool) ([]nftcollection.NftCollection, error) {
+	var result []nftcollection.NftCollection
+	for _, collection := range r.collections {
+		if collection.IsTestnet == isTestnet {
+			result = append(result, collection)
+		}
+	}
+	return result, nil
+}
+
+func newTestCache(t *testing.T) (*miniredis.Miniredis, *fakeNftCollectionRepo, nftcollection.NftCollectionRepository) {
+	t.Helper()
+	server, runErr := miniredis.Run()
+	if runErr != nil {
+		t.Fatalf("error running miniredis: %v", runErr)
+	}
+	t.Cleanup(server.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	next := &fakeNftCollectionRepo{collections: map[string]nftcollection.NftCollection{}}
+	return server, next, NewCachedNftCollectionRepo(next, client, NftCollectionCacheCfg{TTL: time.Minute})
+}
+
+func newTestNftCollection(address string, owner uuid.UUID) *nftcollection.NftCollection {
+	return nftcollection.New(address, owner, &nftcollection.NftCollectionMetadata{Name: "collection"}, &nftcollection.Royalty{}, true)
+}
+
+func TestCachedNftCollectionRepoGetNftCollectionByAddress(t *testing.T) {
+	server, next, repo := newTestCache(t)
+	ctx := context.Background()
+	collection := newTestNftCollection("collection", uuid.New())
+	next.collections["collection"] = *collection
+
+	for i := 0; i < 2; i++ {
+		foundCollection, getErr := repo.GetNftCollectionByAddress(ctx, "collection")
+		if getErr != nil {
+			t.Fatalf("GetNftCollectionByAddress() error = %v", getErr)
+		}
+		if foundCollection.Owner != collection.Owner || foundCollection.Metadata.Name != "collection" {
+			t.Fatalf("GetNftCollectionByAddress() = %v", foundCollection)
+		}
+	}
+	if next.gets != 1 {
+		t.Fatalf("next repository is read %v times, want 1", next.gets)
+	}
+	if ttl := server.TTL(nftCollectionCacheKey("collection")); ttl != time.Minute {
+		t.Fatalf("ttl = %v, want %v", ttl, time.Minute)
+	}
+}
+
+func TestCachedNftCollectionRepoDoesntCacheErrors(t *testing.T) {
+	server, _, repo := newTestCache(t)
+
+	if _, getErr := repo.GetNftCollectionByAddress(context.Background(), "missing"); !errors.Is(getErr, mongo.ErrNoDocuments) {
+		t.Fatalf("GetNftCollectionByAddress() error = %v, want %v", getErr, mongo.ErrNoDocuments)
+	}
+	if len(server.Keys()) != 0 {
+		t.Fatalf("cached keys: %v", server.Keys())
+	}
+}
+
+func TestCachedNftCollectionRepoGetNftCollectionsByOwnerUuid(t *testing.T) {
+	_, next, repo := newTestCache(t)
+	ctx := context.Background()
+	owner := uuid.New()
+	next.collections["collection"] = *newTestNftCollection("collection", owner)
+
+	for i := 0; i < 2; i++ {
+		collections, getErr := repo.GetNftCollectionsByOwnerUuid(ctx, owner)
+		if getErr != nil {
+			t.Fatalf("GetNftCollectionsByOwnerUuid() error = %v", getErr)
+		}
+		if len(collections) != 1 {
+			t.Fatalf("GetNftCollectionsByOwnerUuid() = %v", collections)
+		}
+	}
+	if next.ownerGets != 1 {
+		t.Fatalf("next repository is read %v times, want 1", next.ownerGets)
+	}
+}
+
+func TestCachedNftCollectionRepoCreateNftCollectionInvalidates(t *testing.T) {
+	_, next, repo := newTestCache(t)
+	ctx := context.Background()
+	owner := uuid.New()
+
+	repo.GetNftCollectionsByOwnerUuid(ctx, owner)
+	if createErr := repo.CreateNftCollection(ctx, newTestNftCollection("collection", owner)); createErr != nil {
+		t.Fatalf("CreateNftCollection() error = %v", createErr)
+	}
+
+	collections, getErr := repo.GetNftCollectionsByOwnerUuid(ctx, owner)
+	if getErr != nil {
+		t.Fatalf("GetNftCollectionsByOwnerUuid() error = %v", getErr)
+	}
+	if len(collections) != 1 || next.ownerGets != 2 {
+		t.Fatalf("GetNftCollectionsByOwnerUuid() = %v after %v reads", collections, next.ownerGets)
+	}
+}
+
+func TestCachedNftCollectionRepoUpdatesInvalidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(ctx context.Context, repo nftcollection.NftCollectionRepository) error
+		check  func(collection *nftcollection.NftCollection) bool
+	}{
+		{
+			name: "metadata",
+			update: func(ctx context.Context, repo nftcollection.NftCollectionRepository) error {
+				return repo.UpdateNftCollectionMetadata(ctx, "collection", &nftcollection.NftCollectionMetadata{Name: "renamed"})
+			},
+			check: func(collection *nftcollection.NftCollection) bool { return collection.Metadata.Name == "renamed" },
+		},
+		{
+			name: "royalty",
+			update: func(ctx context.Context, repo nftcollection.NftCollectionRepository) error {
+				return repo.UpdateNftCollectionRoyalty(ctx, "collection", &nftcollection.Royalty{Dividend: 5, Divisor: 100})
+			},
+			check: func(collection *nftcollection.NftCollection) bool {
+				return collection.Royalty != nil && collection.Royalty.Dividend == 5
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, next, repo := newTestCache(t)
+			ctx := context.Background()
+			owner := uuid.New()
+			next.collections["collection"] = *newTestNftCollection("collection", owner)
+			repo.GetNftCollectionByAddress(ctx, "collection")
+			repo.GetNftCollectionsByOwnerUuid(ctx, owner)
+
+			if updateErr := test.update(ctx, repo); updateErr != nil {
+				t.Fatalf("update error = %v", updateErr)
+			}
+			if len(server.Keys()) != 0 {
+				t.Fatalf("stale keys are cached: %v", server.Keys())
+			}
+
+			collection, getErr := repo.GetNftCollectionByAddress(ctx, "collection")
+			if getErr != nil {
+				t.Fatalf("GetNftCollectionByAddress() error = %v", getErr)
+			}
+			if !test.check(collection) {
+				t.Fatalf("GetNftCollectionByAddress() = %v is stale", collection)
+			}
+		})
+	}
+}
+
+func TestCachedNftCollectionRepoDeleteNftCollectionInvalidates(t *testing.T) {
+	server, next, repo := newTestCache(t)
+	ctx := context.Background()
+	owner := uuid.New()
+	next.collections["collection"] = *newTestNftCollection("collection", owner)
+	repo.GetNftCollectionByAddress(ctx, "collection")
+	repo.GetNftCollectionsByOwnerUuid(ctx, owner)
+
+	if delErr := repo.DeleteNftCollection(ctx, "collection"); delErr != nil {
+		t.Fatalf("DeleteNftCollection() error = %v", delErr)
+	}
+	if len(server.Keys()) != 0 {
+		t.Fatalf("stale keys are cached: %v", server.Keys())
+	}
+	if _, getErr := repo.GetNftCollectionByAddress(ctx, "collection"); !errors.Is(getErr, mongo.ErrNoDocuments) {
+		t.Fatalf("GetNftCollectionByAddress() error = %v, want %v", getErr, mongo.ErrNoDocuments)
+	}
+}
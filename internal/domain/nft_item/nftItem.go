@@ -1,10 +1,20 @@
 package nftitem
 
 import (
+	"errors"
+
 	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/media"
 	"github.com/xssnick/tonutils-go/address"
 )
 
+var (
+	ErrNotItemOwner       = errors.New("user must be an nft item's owner")
+	ErrNotCustodial       = errors.New("marketplace contract must be an nft item's owner")
+	ErrInvalidBatchSize   = errors.New("invalid count of nft items in batch")
+	ErrCollectionNotFound = errors.New("nft collection isnt in database")
+)
+
 type Attribute struct {
 	TraitType string `bson:"trait_type" json:"trait_type"`
 	Value     string `bson:"value" json:"value"`
@@ -13,6 +23,7 @@ type Attribute struct {
 type NftItemMetadata struct {
 	Name        string      `bson:"name" json:"name"`
 	Image       string      `bson:"image" json:"image"`
+	ImageData   []byte      `bson:"image_data,omitempty" json:"image_data,omitempty"`
 	Attributes  []Attribute `bson:"attributes" json:"attributes"`
 	Description string      `bson:"description" json:"description"`
 	ExternalUrl string      `bson:"external_url" json:"external_url"`
@@ -23,16 +34,32 @@ type MintNftItemCfg struct {
 	Content        string
 	ForwardAmount  uint64
 	ForwardMessage string
+	HostedMetadata *NftItemMetadata // if set, it is hosted by the app and its link is used as Content
+}
+
+// max items in one batch mint request
+const MaxBatchMintItems = 250
+
+type BatchMintItem struct {
+	Content        string           `json:"content"`
+	HostedMetadata *NftItemMetadata `json:"hosted_metadata"` // if set, it is hosted by the app and its link is used as Content
+}
+
+type BatchMintNftItemsCfg struct {
+	OwnerAddress *address.Address
+	Items        []BatchMintItem
 }
 
 type NftItem struct {
-	Address           string          `bson:"_id" json:"address"`
-	Index             int64           `bson:"index" json:"index"`
-	CollectionAddress string          `bson:"collection_address" json:"collection_address"`
-	CollectionName    string          `bson:"collection_name" json:"collection_name"`
-	Owner             uuid.UUID       `bson:"owner" json:"owner"`
-	Metadata          NftItemMetadata `bson:"metadata" json:"metadata"` // под вопросом как метадата будет приходить
-	IsTestnet         bool            `bson:"is_testnet" json:"is_testnet"`
+	Address           string            `bson:"_id" json:"address"`
+	Index             int64             `bson:"index" json:"index"`
+	CollectionAddress string            `bson:"collection_address" json:"collection_address"`
+	CollectionName    string            `bson:"collection_name" json:"collection_name"`
+	Owner             uuid.UUID         `bson:"owner" json:"owner"`
+	Metadata          NftItemMetadata   `bson:"metadata" json:"metadata"`                         // под вопросом как метадата будет приходить
+	Thumbnails        []media.Thumbnail `bson:"thumbnails,omitempty" json:"thumbnails,omitempty"` // set if metadata image is uploaded to the app
+	IsTestnet         bool              `bson:"is_testnet" json:"is_testnet"`
+	Withdrawing       bool              `bson:"withdrawing,omitempty" json:"withdrawing,omitempty"` // set until withdraw fails or nft item is deleted after it
 }
 
 func New(address string, index int64, collectionAddress string, collectionName string, owner uuid.UUID, metadata *NftItemMetadata, isTestnet bool) *NftItem {
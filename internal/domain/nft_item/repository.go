@@ -9,8 +9,21 @@ import (
 type NftItemRepository interface {
 	CreateNftItem(ctx context.Context, nftItem *NftItem) error
 	GetNftItemsByOwnerUuid(ctx context.Context, uuid uuid.UUID) ([]NftItem, error)
+	// GetNftItemsByNetwork returns all custodial nft items of testnet or mainnet
+	GetNftItemsByNetwork(ctx context.Context, isTestnet bool) ([]NftItem, error)
 	GetNftItemByAddress(ctx context.Context, nftItemAddress string) (*NftItem, error)
 	DeleteNftItem(ctx context.Context, nftItemAddress string) error
+	// MarkNftItemWithdrawing marks nft item of owner as being withdrawn before withdraw message is sent.
+	// Returns ErrWithdrawPending if it is marked already and ErrNotItemOwner if it isnt owned by ownerUuid
+	MarkNftItemWithdrawing(ctx context.Context, nftItemAddress string, ownerUuid uuid.UUID) error
+	// UnmarkNftItemWithdrawing returns nft item to owner after withdraw failed
+	UnmarkNftItemWithdrawing(ctx context.Context, nftItemAddress string) error
+	// TransferNftItem atomically changes owner of nft item and writes transfer to history.
+	// Returns ErrNotItemOwner if nft item isnt owned by transfer.FromUUID anymore
+	// and ErrWithdrawPending if it is being withdrawn, nothing is changed then
+	TransferNftItem(ctx context.Context, transfer *Transfer) (*NftItem, error)
+	// GetNftItemTransfers returns transfers of nft item, newest first
+	GetNftItemTransfers(ctx context.Context, nftItemAddress string) ([]Transfer, error)
 }
 
 //Основные коды ошибкок
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"log"
+
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+type indexedNftItemRepo struct {
+	nftitem.NftItemRepository
+	searchRepo search.SearchRepository
+}
+
+// NewIndexedNftItemRepo mirrors nft items created and deleted by next repository to search index.
+// Index errors are logged only, database stays the source of truth. Inside transaction index is changed after commit
+func NewIndexedNftItemRepo(next nftitem.NftItemRepository, searchRepo search.SearchRepository) nftitem.NftItemRepository {
+	return &indexedNftItemRepo{
+		NftItemRepository: next,
+		searchRepo:        searchRepo,
+	}
+}
+
+func (r *indexedNftItemRepo) CreateNftItem(ctx context.Context, nftItem *nftitem.NftItem) error {
+	if createErr := r.NftItemRepository.CreateNftItem(ctx, nftItem); createErr != nil {
+		return createErr
+	}
+
+	document := search.NewNftItemDocument(nftItem)
+	storage.AfterCommit(ctx, func(ctx context.Context) {
+		if indexErr := r.searchRepo.IndexDocuments(ctx, document); indexErr != nil {
+			log.Printf("Error indexing nft item %v: %v\n", document.Address, indexErr)
+		}
+	})
+
+	return nil
+}
+
+func (r *indexedNftItemRepo) DeleteNftItem(ctx context.Context, nftItemAddress string) error {
+	if delErr := r.NftItemRepository.DeleteNftItem(ctx, nftItemAddress); delErr != nil {
+		return delErr
+	}
+
+	storage.AfterCommit(ctx, func(ctx context.Context) {
+		if indexErr := r.searchRepo.DeleteDocuments(ctx, nftItemAddress); indexErr != nil {
+			log.Printf("Error removing nft item %v from search index: %v\n", nftItemAddress, indexErr)
+		}
+	})
+
+	return nil
+}
+
+func (r *indexedNftItemRepo) TransferNftItem(ctx context.Context, transfer *nftitem.Transfer) (*nftitem.NftItem, error) {
+	nftItem, transferErr := r.NftItemRepository.TransferNftItem(ctx, transfer)
+	if transferErr != nil {
+		return nil, transferErr
+	}
+
+	// owner is a field of search document
+	document := search.NewNftItemDocument(nftItem)
+	storage.AfterCommit(ctx, func(ctx context.Context) {
+		if indexErr := r.searchRepo.IndexDocuments(ctx, document); indexErr != nil {
+			log.Printf("Error reindexing nft item %v: %v\n", document.Address, indexErr)
+		}
+	})
+
+	return nftItem, nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+	searchstorage "github.com/rom6n/create-nft-go/internal/domain/search/storage"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+// fakeElasticSearch keeps documents changed by bulk requests of existing index
+type fakeElasticSearch struct {
+	mu        sync.Mutex
+	documents map[string]search.Document
+	deleted   []string
+	available bool
+}
+
+func (f *fakeElasticSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.available {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var action map[string]struct {
+			ID string `json:"_id"`
+		}
+		json.Unmarshal(scanner.Bytes(), &action)
+
+		if index, ok := action["index"]; ok {
+			scanner.Scan()
+			var document search.Document
+			json.Unmarshal(scanner.Bytes(), &document)
+			f.documents[index.ID] = document
+		}
+		if del, ok := action["delete"]; ok {
+			delete(f.documents, del.ID)
+			f.deleted = append(f.deleted, del.ID)
+		}
+	}
+	w.Write([]byte(`{"errors":false,"items":[]}`))
+}
+
+func newTestIndexedRepo(t *testing.T) (*fakeElasticSearch, *fakeNftItemRepo, nftitem.NftItemRepository) {
+	t.Helper()
+	fake := &fakeElasticSearch{documents: map[string]search.Document{}, available: true}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	searchRepo := searchstorage.NewSearchRepo(storage.NewElasticSearchClientWithURL(server.URL, server.Client()), searchstorage.SearchRepoCfg{
+		IndexName: "test-index",
+		Timeout:   5 * time.Second,
+	})
+	next := &fakeNftItemRepo{nftItems: map[string]nftitem.NftItem{}}
+	return fake, next, NewIndexedNftItemRepo(next, searchRepo)
+}
+
+func TestIndexedNftItemRepoCreateNftItem(t *testing.T) {
+	fake, next, repo := newTestIndexedRepo(t)
+	nftItem := newTestNftItem("item", uuid.New())
+	nftItem.Metadata.Attributes = []nftitem.Attribute{{TraitType: "color", Value: "red"}}
+
+	if createErr := repo.CreateNftItem(context.Background(), nftItem); createErr != nil {
+		t.Fatalf("CreateNftItem() error = %v", createErr)
+	}
+	if _, ok := next.nftItems["item"]; !ok {
+		t.Fatalf("nft item isnt created by next repository")
+	}
+
+	document, ok := fake.documents["item"]
+	if !ok {
+		t.Fatalf("nft item isnt indexed")
+	}
+	if document.Kind != search.KindNftItem || document.Owner != nftItem.Owner || document.CollectionName != "Collection" ||
+		len(document.Attributes) != 1 || document.Attributes[0].Value != "red" {
+		t.Fatalf("indexed document = %v", document)
+	}
+}
+
+func TestIndexedNftItemRepoDeleteNftItem(t *testing.T) {
+	fake, _, repo := newTestIndexedRepo(t)
+	ctx := context.Background()
+	repo.CreateNftItem(ctx, newTestNftItem("item", uuid.New()))
+
+	if delErr := repo.DeleteNftItem(ctx, "item"); delErr != nil {
+		t.Fatalf("DeleteNftItem() error = %v", delErr)
+	}
+	if _, ok := fake.documents["item"]; ok || len(fake.deleted) != 1 {
+		t.Fatalf("nft item isnt removed from index, deleted %v", fake.deleted)
+	}
+}
+
+func TestIndexedNftItemRepoTransferNftItemReindexesOwner(t *testing.T) {
+	fake, _, repo := newTestIndexedRepo(t)
+	ctx := context.Background()
+	from, to := uuid.New(), uuid.New()
+	repo.CreateNftItem(ctx, newTestNftItem("item", from))
+
+	if _, transferErr := repo.TransferNftItem(ctx, nftitem.NewTransfer("item", from, to, true)); transferErr != nil {
+		t.Fatalf("TransferNftItem() error = %v", transferErr)
+	}
+	if owner := fake.documents["item"].Owner; owner != to {
+		t.Fatalf("indexed owner = %v, want %v", owner, to)
+	}
+}
+
+func TestIndexedNftItemRepoFailedTransferIsntIndexed(t *testing.T) {
+	fake, _, repo := newTestIndexedRepo(t)
+	ctx := context.Background()
+	owner := uuid.New()
+	repo.CreateNftItem(ctx, newTestNftItem("item", owner))
+
+	if _, transferErr := repo.TransferNftItem(ctx, nftitem.NewTransfer("item", uuid.New(), uuid.New(), true)); !errors.Is(transferErr, nftitem.ErrNotItemOwner) {
+		t.Fatalf("TransferNftItem() error = %v, want %v", transferErr, nftitem.ErrNotItemOwner)
+	}
+	if indexedOwner := fake.documents["item"].Owner; indexedOwner != owner {
+		t.Fatalf("indexed owner = %v, want %v", indexedOwner, owner)
+	}
+}
+
+func TestIndexedNftItemRepoIgnoresIndexErrors(t *testing.T) {
+	fake, next, repo := newTestIndexedRepo(t)
+	ctx := context.Background()
+	fake.available = false
+
+	if createErr := repo.CreateNftItem(ctx, newTestNftItem("item", uuid.New())); createErr != nil {
+		t.Fatalf("CreateNftItem() error = %v", createErr)
+	}
+	if delErr := repo.DeleteNftItem(ctx, "item"); delErr != nil {
+		t.Fatalf("DeleteNftItem() error = %v", delErr)
+	}
+	if len(next.nftItems) != 0 {
+		t.Fatalf("database isnt changed when index is unavailable")
+	}
+}
@@ -2,35 +2,48 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/storage"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type nftItemRepo struct {
-	client         *mongo.Client
-	dbName         string
-	collectionName string
-	timeout        time.Duration
+	client                  *mongo.Client
+	dbName                  string
+	collectionName          string
+	transfersCollectionName string
+	timeout                 time.Duration
 }
 
 type NftItemRepoCfg struct {
-	DBName         string
-	CollectionName string
-	Timeout        time.Duration
+	DBName                  string
+	CollectionName          string
+	TransfersCollectionName string
+	Timeout                 time.Duration
 }
 
 func NewNftItemRepo(client *mongo.Client, cfg NftItemRepoCfg) nftitem.NftItemRepository {
-	return &nftItemRepo{
-		client:         client,
-		dbName:         cfg.DBName,
-		collectionName: cfg.CollectionName,
-		timeout:        cfg.Timeout,
+	repo := &nftItemRepo{
+		client:                  client,
+		dbName:                  cfg.DBName,
+		collectionName:          cfg.CollectionName,
+		transfersCollectionName: cfg.TransfersCollectionName,
+		timeout:                 cfg.Timeout,
 	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating nft item transfers indexes: %v\n", indexErr)
+	}
+
+	return repo
 }
 
 func (v *nftItemRepo) GetClient() *mongo.Client {
@@ -41,6 +54,21 @@ func (v *nftItemRepo) getCollection() *mongo.Collection {
 	return v.client.Database(v.dbName).Collection(v.collectionName)
 }
 
+func (v *nftItemRepo) getTransfersCollection() *mongo.Collection {
+	return v.client.Database(v.dbName).Collection(v.transfersCollectionName)
+}
+
+func (v *nftItemRepo) createIndexes() error {
+	dbCtx, cancel := v.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := v.getTransfersCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "nft_item_address", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+
+	return indexErr
+}
+
 func (v *nftItemRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(ctx, v.timeout)
 }
@@ -78,6 +106,23 @@ func (v *nftItemRepo) GetNftItemsByOwnerUuid(ctx context.Context, uuid uuid.UUID
 	return foundedCollections, nil
 }
 
+func (v *nftItemRepo) GetNftItemsByNetwork(ctx context.Context, isTestnet bool) ([]nftitem.NftItem, error) {
+	dbCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	var foundedNftItems []nftitem.NftItem
+	cursor, findErr := v.getCollection().Find(dbCtx, bson.D{{Key: "is_testnet", Value: isTestnet}})
+	if findErr != nil {
+		return nil, fmt.Errorf("nft items find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedNftItems); decodeErr != nil {
+		return nil, fmt.Errorf("nft items decode error after find: %v", decodeErr)
+	}
+
+	return foundedNftItems, nil
+}
+
 func (v *nftItemRepo) GetNftItemByAddress(ctx context.Context, nftItemAddress string) (*nftitem.NftItem, error) {
 	dbCtx, cancel := v.getContext(ctx)
 	defer cancel()
@@ -87,7 +132,7 @@ func (v *nftItemRepo) GetNftItemByAddress(ctx context.Context, nftItemAddress st
 	var foundedNftItem nftitem.NftItem
 	decodeErr := collection.FindOne(dbCtx, bson.D{{Key: "_id", Value: nftItemAddress}}).Decode(&foundedNftItem)
 	if decodeErr != nil {
-		return &foundedNftItem, fmt.Errorf("nft item decode error after seaching: %v", decodeErr)
+		return &foundedNftItem, fmt.Errorf("nft item decode error after seaching: %w", decodeErr)
 	}
 
 	return &foundedNftItem, nil
@@ -105,3 +150,94 @@ func (v *nftItemRepo) DeleteNftItem(ctx context.Context, nftItemAddress string)
 
 	return nil
 }
+
+func (v *nftItemRepo) MarkNftItemWithdrawing(ctx context.Context, nftItemAddress string, ownerUuid uuid.UUID) error {
+	dbCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	// guard: nft item can be withdrawn once and only by its owner
+	filter := bson.D{{Key: "_id", Value: nftItemAddress}, {Key: "owner", Value: ownerUuid}, {Key: "withdrawing", Value: bson.D{{Key: "$ne", Value: true}}}}
+	result, updErr := v.getCollection().UpdateOne(dbCtx, filter, bson.D{{Key: "$set", Value: bson.D{{Key: "withdrawing", Value: true}}}})
+	if updErr != nil {
+		return fmt.Errorf("error marking nft item as withdrawing: %w", updErr)
+	}
+
+	if result.MatchedCount == 0 {
+		return v.notTransferableErr(dbCtx, nftItemAddress)
+	}
+
+	return nil
+}
+
+func (v *nftItemRepo) UnmarkNftItemWithdrawing(ctx context.Context, nftItemAddress string) error {
+	dbCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if _, updErr := v.getCollection().UpdateOne(dbCtx, bson.D{{Key: "_id", Value: nftItemAddress}}, bson.D{{Key: "$unset", Value: bson.D{{Key: "withdrawing", Value: ""}}}}); updErr != nil {
+		return fmt.Errorf("error unmarking nft item as withdrawing: %w", updErr)
+	}
+
+	return nil
+}
+
+// notTransferableErr explains why nft item isnt matched by owner and withdrawing guard
+func (v *nftItemRepo) notTransferableErr(ctx context.Context, nftItemAddress string) error {
+	var foundedNftItem nftitem.NftItem
+	if decodeErr := v.getCollection().FindOne(ctx, bson.D{{Key: "_id", Value: nftItemAddress}}).Decode(&foundedNftItem); decodeErr != nil && !errors.Is(decodeErr, mongo.ErrNoDocuments) {
+		return fmt.Errorf("nft item decode error after seaching: %w", decodeErr)
+	}
+
+	if foundedNftItem.Withdrawing {
+		return nftitem.ErrWithdrawPending
+	}
+	return nftitem.ErrNotItemOwner
+}
+
+func (v *nftItemRepo) TransferNftItem(ctx context.Context, transfer *nftitem.Transfer) (*nftitem.NftItem, error) {
+	dbCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	// transfer joins transaction of caller if there is one, marketplace sells nft items this way
+	var transferredNftItem nftitem.NftItem
+	txErr := storage.WithTransaction(dbCtx, v.client, func(txCtx context.Context) error {
+		// guard: owner could be changed by concurrent transfer or nft item could be taken by withdraw
+		filter := bson.D{{Key: "_id", Value: transfer.NftItemAddress}, {Key: "owner", Value: transfer.FromUUID}, {Key: "withdrawing", Value: bson.D{{Key: "$ne", Value: true}}}}
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "owner", Value: transfer.ToUUID}}}}
+
+		decodeErr := v.getCollection().FindOneAndUpdate(txCtx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&transferredNftItem)
+		if decodeErr != nil {
+			if errors.Is(decodeErr, mongo.ErrNoDocuments) {
+				return v.notTransferableErr(txCtx, transfer.NftItemAddress)
+			}
+			return fmt.Errorf("error changing nft item owner: %w", decodeErr)
+		}
+
+		if _, insertErr := v.getTransfersCollection().InsertOne(txCtx, *transfer); insertErr != nil {
+			return fmt.Errorf("error inserting nft item transfer: %w", insertErr)
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	return &transferredNftItem, nil
+}
+
+func (v *nftItemRepo) GetNftItemTransfers(ctx context.Context, nftItemAddress string) ([]nftitem.Transfer, error) {
+	dbCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	foundedTransfers := []nftitem.Transfer{}
+	cursor, findErr := v.getTransfersCollection().Find(dbCtx, bson.D{{Key: "nft_item_address", Value: nftItemAddress}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if findErr != nil {
+		return nil, fmt.Errorf("nft item transfers find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedTransfers); decodeErr != nil {
+		return nil, fmt.Errorf("nft item transfers decode error: %v", decodeErr)
+	}
+
+	return foundedTransfers, nil
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+type cachedNftItemRepo struct {
+	next   nftitem.NftItemRepository
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+type NftItemCacheCfg struct {
+	TTL time.Duration
+}
+
+// NewCachedNftItemRepo returns read-through cache over next repository
+func NewCachedNftItemRepo(next nftitem.NftItemRepository, client redis.UniversalClient, cfg NftItemCacheCfg) nftitem.NftItemRepository {
+	return &cachedNftItemRepo{
+		next:   next,
+		client: client,
+		ttl:    cfg.TTL,
+	}
+}
+
+func nftItemCacheKey(nftItemAddress string) string {
+	return fmt.Sprintf("nft_item:%v", nftItemAddress)
+}
+
+func ownerNftItemsCacheKey(ownerUuid uuid.UUID) string {
+	return fmt.Sprintf("nft_items:owner:%v", ownerUuid)
+}
+
+func (r *cachedNftItemRepo) CreateNftItem(ctx context.Context, nftItem *nftitem.NftItem) error {
+	if createErr := r.next.CreateNftItem(ctx, nftItem); createErr != nil {
+		return createErr
+	}
+
+	storage.DeleteCached(ctx, r.client, nftItemCacheKey(nftItem.Address), ownerNftItemsCacheKey(nftItem.Owner))
+	return nil
+}
+
+func (r *cachedNftItemRepo) DeleteNftItem(ctx context.Context, nftItemAddress string) error {
+	// owner is needed to invalidate his nft items list
+	nftItem, getErr := r.GetNftItemByAddress(ctx, nftItemAddress)
+
+	if delErr := r.next.DeleteNftItem(ctx, nftItemAddress); delErr != nil {
+		return delErr
+	}
+
+	keys := []string{nftItemCacheKey(nftItemAddress)}
+	if getErr == nil {
+		keys = append(keys, ownerNftItemsCacheKey(nftItem.Owner))
+	}
+	storage.DeleteCached(ctx, r.client, keys...)
+
+	return nil
+}
+
+func (r *cachedNftItemRepo) MarkNftItemWithdrawing(ctx context.Context, nftItemAddress string, ownerUuid uuid.UUID) error {
+	if markErr := r.next.MarkNftItemWithdrawing(ctx, nftItemAddress, ownerUuid); markErr != nil {
+		return markErr
+	}
+
+	storage.DeleteCached(ctx, r.client, nftItemCacheKey(nftItemAddress), ownerNftItemsCacheKey(ownerUuid))
+	return nil
+}
+
+func (r *cachedNftItemRepo) UnmarkNftItemWithdrawing(ctx context.Context, nftItemAddress string) error {
+	// owner is needed to invalidate his nft items list
+	nftItem, getErr := r.GetNftItemByAddress(ctx, nftItemAddress)
+
+	if unmarkErr := r.next.UnmarkNftItemWithdrawing(ctx, nftItemAddress); unmarkErr != nil {
+		return unmarkErr
+	}
+
+	keys := []string{nftItemCacheKey(nftItemAddress)}
+	if getErr == nil {
+		keys = append(keys, ownerNftItemsCacheKey(nftItem.Owner))
+	}
+	storage.DeleteCached(ctx, r.client, keys...)
+
+	return nil
+}
+
+func (r *cachedNftItemRepo) TransferNftItem(ctx context.Context, transfer *nftitem.Transfer) (*nftitem.NftItem, error) {
+	nftItem, transferErr := r.next.TransferNftItem(ctx, transfer)
+	if transferErr != nil {
+		return nil, transferErr
+	}
+
+	storage.DeleteCached(ctx, r.client, nftItemCacheKey(transfer.NftItemAddress), ownerNftItemsCacheKey(transfer.FromUUID), ownerNftItemsCacheKey(transfer.ToUUID))
+	return nftItem, nil
+}
+
+func (r *cachedNftItemRepo) GetNftItemTransfers(ctx context.Context, nftItemAddress string) ([]nftitem.Transfer, error) {
+	return r.next.GetNftItemTransfers(ctx, nftItemAddress)
+}
+
+func (r *cachedNftItemRepo) GetNftItemByAddress(ctx context.Context, nftItemAddress string) (*nftitem.NftItem, error) {
+	if cachedNftItem, ok := storage.GetCached[nftitem.NftItem](ctx, r.client, nftItemCacheKey(nftItemAddress)); ok {
+		return cachedNftItem, nil
+	}
+
+	nftItem, dbErr := r.next.GetNftItemByAddress(ctx, nftItemAddress)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	storage.SetCached(ctx, r.client, nftItemCacheKey(nftItemAddress), *nftItem, r.ttl)
+	return nftItem, nil
+}
+
+func (r *cachedNftItemRepo) GetNftItemsByOwnerUuid(ctx context.Context, uuid uuid.UUID) ([]nftitem.NftItem, error) {
+	if cachedNftItems, ok := storage.GetCached[[]nftitem.NftItem](ctx, r.client, ownerNftItemsCacheKey(uuid)); ok {
+		return *cachedNftItems, nil
+	}
+
+	nftItems, dbErr := r.next.GetNftItemsByOwnerUuid(ctx, uuid)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	storage.SetCached(ctx, r.client, ownerNftItemsCacheKey(uuid), nftItems, r.ttl)
+	return nftItems, nil
+}
+
+// GetNftItemsByNetwork isnt cached, it is used by reconciliation which needs database state
+func (r *cachedNftItemRepo) GetNftItemsByNetwork(ctx context.Context, isTestnet bool) ([]nftitem.NftItem, error) {
+	return r.next.GetNftItemsByNetwork(ctx, isTestnet)
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type fakeNftItemRepo struct {
+	nftItems  map[string]nftitem.NftItem
+	transfers []nftitem.Transfer
+	gets      int
+	ownerGets int
+}
+
+func (r *fakeNftItemRepo) CreateNftItem(_ context.Context, nftItem *nftitem.NftItem) error {
+	r.nftItems[nftItem.Address] = *nftItem
+	return nil
+}
+
+func (r *fakeNftItemRepo) GetNftItemsByOwnerUuid(_ context.Context, ownerUuid uuid.UUID) ([]nftitem.NftItem, error) {
+	r.ownerGets++
+	var result []nftitem.NftItem
+	for _, nftItem := range r.nftItems {
+		if nftItem.Owner == ownerUuid {
+			result = append(result, nftItem)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeNftItemRepo) GetNftItemsByNetwork(_ context.Context, isTestnet bool) ([]nftitem.NftItem, error) {
+	var result []nftitem.NftItem
+	for _, nftItem := range r.nftItems {
+		if nftItem.IsTestnet == isTestnet {
+			result = append(result, nftItem)
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeNftItemRepo) GetNftItemByAddress(_ context.Context, nftItemAddress string) (*nftitem.NftItem, error) {
+	r.gets++
+	nftItem, ok := r.nftItems[nftItemAddress]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &nftItem, nil
+}
+
+func (r *fakeNftItemRepo) DeleteNftItem(_ context.Context, nftItemAddress string) error {
+	delete(r.nftItems, nftItemAddress)
+	return nil
+}
+
+func (r *fakeNftItemRepo) MarkNftItemWithdrawing(_ context.Context, nftItemAddress string, ownerUuid uuid.UUID) error {
+	nftItem, ok := r.nftItems[nftItemAddress]
+	if !ok || nftItem.Owner != ownerUuid {
+		return nftitem.ErrNotItemOwner
+	}
+	if nftItem.Withdrawing {
+		return nftitem.ErrWithdrawPending
+	}
+	nftItem.Withdrawing = true
+	r.nftItems[nftItemAddress] = nftItem
+	return nil
+}
+
+func (r *fakeNftItemRepo) UnmarkNftItemWithdrawing(_ context.Context, nftItemAddress string) error {
+	if nftItem, ok := r.nftItems[nftItemAddress]; ok {
+		nftItem.Withdrawing = false
+		r.nftItems[nftItemAddress] = nftItem
+	}
+	return nil
+}
+
+func (r *fakeNftItemRepo) TransferNftItem(_ context.Context, transfer *nftitem.Transfer) (*nftitem.NftItem, error) {
+	nftItem, ok := r.nftItems[transfer.NftItemAddress]
+	if !ok || nftItem.Owner != transfer.FromUUID {
+		return nil, nftitem.ErrNotItemOwner
+	}
+	if nftItem.Withdrawing {
+		return nil, nftitem.ErrWithdrawPending
+	}
+	nftItem.Owner = transfer.ToUUID
+	r.nftItems[transfer.NftItemAddress] = nftItem
+	r.transfers = append(r.transfers, *transfer)
+	return &nftItem, nil
+}
+
+func (r *fakeNftItemRepo) GetNftItemTransfers(_ context.Context, _ string) ([]nftitem.Transfer, error) {
+	return r.transfers, nil
+}
+
+func newTestCache(t *testing.T) (*miniredis.Miniredis, *fakeNftItemRepo, nftitem.NftItemRepository) {
+	t.Helper()
+	server, runErr := miniredis.Run()
+	if runErr != nil {
+		t.Fatalf("error running miniredis: %v", runErr)
+	}
+	t.Cleanup(server.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	next := &fakeNftItemRepo{nftItems: map[string]nftitem.NftItem{}}
+	return server, next, NewCachedNftItemRepo(next, client, NftItemCacheCfg{TTL: time.Minute})
+}
+
+func newTestNftItem(address string, owner uuid.UUID) *nftitem.NftItem {
+	return nftitem.New(address, 1, "collection", "Collection", owner, &nftitem.NftItemMetadata{Name: "item", Attributes: []nftitem.Attribute{}}, true)
+}
+
+func TestCachedNftItemRepoGetNftItemByAddress(t *testing.T) {
+	server, next, repo := newTestCache(t)
+	ctx := context.Background()
+	nftItem := newTestNftItem("item", uuid.New())
+	next.nftItems["item"] = *nftItem
+
+	for i := 0; i < 2; i++ {
+		foundNftItem, getErr := repo.GetNftItemByAddress(ctx, "item")
+		if getErr != nil {
+			t.Fatalf("GetNftItemByAddress() error = %v", getErr)
+		}
+		if foundNftItem.Address != "item" || foundNftItem.Owner != nftItem.Owner || foundNftItem.Metadata.Name != "item" {
+			t.Fatalf("GetNftItemByAddress() = %v", foundNftItem)
+		}
+	}
+	if next.gets != 1 {
+		t.Fatalf("next repository is read %v times, want 1", next.gets)
+	}
+	if ttl := server.TTL(nftItemCacheKey("item")); ttl != time.Minute {
+		t.Fatalf("ttl = %v, want %v", ttl, time.Minute)
+	}
+}
+
+func TestCachedNftItemRepoDoesntCacheErrors(t *testing.T) {
+	server, _, repo := newTestCache(t)
+
+	if _, getErr := repo.GetNftItemByAddress(context.Background(), "missing"); !errors.Is(getErr, mongo.ErrNoDocuments) {
+		t.Fatalf("GetNftItemByAddress() error = %v, want %v", getErr, mongo.ErrNoDocuments)
+	}
+	if len(server.Keys()) != 0 {
+		t.Fatalf("cached keys: %v", server.Keys())
+	}
+}
+
+func TestCachedNftItemRepoGetNftItemsByOwnerUuid(t *testing.T) {
+	_, next, repo := newTestCache(t)
+	ctx := context.Background()
+	owner := uuid.New()
+	next.nftItems["item"] = *newTestNftItem("item", owner)
+
+	for i := 0; i < 2; i++ {
+		nftItems, getErr := repo.GetNftItemsByOwnerUuid(ctx, owner)
+		if getErr != nil {
+			t.Fatalf("GetNftItemsByOwnerUuid() error = %v", getErr)
+		}
+		if len(nftItems) != 1 || nftItems[0].Address != "item" {
+			t.Fatalf("GetNftItemsByOwnerUuid() = %v", nftItems)
+		}
+	}
+	if next.ownerGets != 1 {
+		t.Fatalf("next repository is read %v times, want 1", next.ownerGets)
+	}
+}
+
+func TestCachedNftItemRepoCreateNftItemInvalidates(t *testing.T) {
+	_, next, repo := newTestCache(t)
+	ctx := context.Background()
+	owner := uuid.New()
+
+	if nftItems, _ := repo.GetNftItemsByOwnerUuid(ctx, owner); len(nftItems) != 0 {
+		t.Fatalf("GetNftItemsByOwnerUuid() = %v, want empty", nftItems)
+	}
+	if createErr := repo.CreateNftItem(ctx, newTestNftItem("item", owner)); createErr != nil {
+		t.Fatalf("CreateNftItem() error = %v", createErr)
+	}
+
+	nftItems, getErr := repo.GetNftItemsByOwnerUuid(ctx, owner)
+	if getErr != nil {
+		t.Fatalf("GetNftItemsByOwnerUuid() error = %v", getErr)
+	}
+	if len(nftItems) != 1 || next.ownerGets != 2 {
+		t.Fatalf("GetNftItemsByOwnerUuid() = %v after %v reads", nftItems, next.ownerGets)
+	}
+}
+
+func TestCachedNftItemRepoTransferNftItemInvalidates(t *testing.T) {
+	server, next, repo := newTestCache(t)
+	ctx := context.Background()
+	from, to := uuid.New(), uuid.New()
+	next.nftItems["item"] = *newTestNftItem("item", from)
+
+	// fill cache of nft item and both owners
+	if _, getErr := repo.GetNftItemByAddress(ctx, "item"); getErr != nil {
+		t.Fatalf("GetNftItemByAddress() error = %v", getErr)
+	}
+	repo.GetNftItemsByOwnerUuid(ctx, from)
+	repo.GetNftItemsByOwnerUuid(ctx, to)
+
+	if _, transferErr := repo.TransferNftItem(ctx, nftitem.NewTransfer("item", from, to, true)); transferErr != nil {
+		t.Fatalf("TransferNftItem() error = %v", transferErr)
+	}
+	if len(server.Keys()) != 0 {
+		t.Fatalf("stale keys are cached: %v", server.Keys())
+	}
+
+	nftItem, getErr := repo.GetNftItemByAddress(ctx, "item")
+	if getErr != nil {
+		t.Fatalf("GetNftItemByAddress() error = %v", getErr)
+	}
+	if nftItem.Owner != to {
+		t.Fatalf("GetNftItemByAddress() owner = %v, want %v", nftItem.Owner, to)
+	}
+	if toItems, _ := repo.GetNftItemsByOwnerUuid(ctx, to); len(toItems) != 1 {
+		t.Fatalf("GetNftItemsByOwnerUuid() of recipient = %v", toItems)
+	}
+}
+
+func TestCachedNftItemRepoFailedTransferKeepsCache(t *testing.T) {
+	server, next, repo := newTestCache(t)
+	ctx := context.Background()
+	owner := uuid.New()
+	next.nftItems["item"] = *newTestNftItem("item", owner)
+	repo.GetNftItemByAddress(ctx, "item")
+
+	if _, transferErr := repo.TransferNftItem(ctx, nftitem.NewTransfer("item", uuid.New(), owner, true)); !errors.Is(transferErr, nftitem.ErrNotItemOwner) {
+		t.Fatalf("TransferNftItem() error = %v, want %v", transferErr, nftitem.ErrNotItemOwner)
+	}
+	if !server.Exists(nftItemCacheKey("item")) {
+		t.Fatalf("nft item is removed from cache after failed transfer")
+	}
+}
+
+func TestCachedNftItemRepoDeleteNftItemInvalidates(t *testing.T) {
+	server, next, repo := newTestCache(t)
+	ctx := context.Background()
+	owner := uuid.New()
+	next.nftItems["item"] = *newTestNftItem("item", owner)
+	repo.GetNftItemByAddress(ctx, "item")
+	repo.GetNftItemsByOwnerUuid(ctx, owner)
+
+	if delErr := repo.DeleteNftItem(ctx, "item"); delErr != nil {
+		t.Fatalf("DeleteNftItem() error = %v", delErr)
+	}
+	if len(server.Keys()) != 0 {
+		t.Fatalf("stale keys are cached: %v", server.Keys())
+	}
+	if _, getErr := repo.GetNftItemByAddress(ctx, "item"); !errors.Is(getErr, mongo.ErrNoDocuments) {
+		t.Fatalf("GetNftItemByAddress() error = %v, want %v", getErr, mongo.ErrNoDocuments)
+	}
+}
+
+func TestCachedNftItemRepoMarkNftItemWithdrawingInvalidates(t *testing.T) {
+	server, next, repo := newTestCache(t)
+	ctx := context.Background()
+	owner := uuid.New()
+	next.nftItems["item"] = *newTestNftItem("item", owner)
+
+	repo.GetNftItemByAddress(ctx, "item")
+	repo.GetNftItemsByOwnerUuid(ctx, owner)
+
+	if markErr := repo.MarkNftItemWithdrawing(ctx, "item", owner); markErr != nil {
+		t.Fatalf("MarkNftItemWithdrawing() error = %v", markErr)
+	}
+	if len(server.Keys()) != 0 {
+		t.Fatalf("stale keys are cached: %v", server.Keys())
+	}
+
+	nftItem, _ := repo.GetNftItemByAddress(ctx, "item")
+	if !nftItem.Withdrawing {
+		t.Fatalf("GetNftItemByAddress() withdrawing = false after mark")
+	}
+	if _, transferErr := repo.TransferNftItem(ctx, nftitem.NewTransfer("item", owner, uuid.New(), true)); !errors.Is(transferErr, nftitem.ErrWithdrawPending) {
+		t.Fatalf("TransferNftItem() error = %v, want %v", transferErr, nftitem.ErrWithdrawPending)
+	}
+
+	if unmarkErr := repo.UnmarkNftItemWithdrawing(ctx, "item"); unmarkErr != nil {
+		t.Fatalf("UnmarkNftItemWithdrawing() error = %v", unmarkErr)
+	}
+	if nftItem, _ = repo.GetNftItemByAddress(ctx, "item"); nftItem.Withdrawing {
+		t.Fatalf("GetNftItemByAddress() withdrawing = true after unmark")
+	}
+}
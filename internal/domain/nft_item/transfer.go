@@ -0,0 +1,36 @@
+package nftitem
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRecipientNotFound = errors.New("recipient of nft item isnt in database")
+	ErrSelfTransfer      = errors.New("nft item cant be transferred to its owner")
+	ErrWithdrawPending   = errors.New("nft item is being withdrawn")
+)
+
+// Transfer is change of nft item's owner inside the app by gift or sale, owner on chain stays service wallet
+type Transfer struct {
+	ID             uuid.UUID `bson:"_id" json:"id"`
+	NftItemAddress string    `bson:"nft_item_address" json:"nft_item_address"`
+	FromUUID       uuid.UUID `bson:"from_uuid" json:"from_uuid"`
+	ToUUID         uuid.UUID `bson:"to_uuid" json:"to_uuid"`
+	Price          uint64    `bson:"price,omitempty" json:"price,omitempty"` // nano ton paid by new owner if nft item is sold
+	IsTestnet      bool      `bson:"is_testnet" json:"is_testnet"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+}
+
+func NewTransfer(nftItemAddress string, fromUuid uuid.UUID, toUuid uuid.UUID, isTestnet bool) *Transfer {
+	return &Transfer{
+		ID:             uuid.New(),
+		NftItemAddress: nftItemAddress,
+		FromUUID:       fromUuid,
+		ToUUID:         toUuid,
+		IsTestnet:      isTestnet,
+		CreatedAt:      time.Now(),
+	}
+}
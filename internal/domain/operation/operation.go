@@ -0,0 +1,63 @@
+package operation
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Kind string
+
+const (
+	KindDeployNftCollection        Kind = "deploy_nft_collection"
+	KindMintNftItem                Kind = "mint_nft_item"
+	KindBatchMintNftItems          Kind = "batch_mint_nft_items"
+	KindWithdrawNftItem            Kind = "withdraw_nft_item"
+	KindWithdrawNftCollection      Kind = "withdraw_nft_collection"
+	KindChangeNftCollectionContent Kind = "change_nft_collection_content"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // message is sent, waiting for transaction on destination
+	StatusConfirmed Status = "confirmed" // destination transaction is successful
+	StatusFailed    Status = "failed"    // destination transaction failed or bounced, refund is not done yet
+	StatusRefunded  Status = "refunded"  // failed and ton returned to user's balance
+)
+
+// Operation is an outgoing wallet message which result is tracked on chain
+type Operation struct {
+	ID              uuid.UUID `bson:"_id" json:"id"`
+	Kind            Kind      `bson:"kind" json:"kind"`
+	UserUUID        uuid.UUID `bson:"user_uuid" json:"user_uuid"`
+	Destination     string    `bson:"destination" json:"destination"`
+	DeployedAddress string    `bson:"deployed_address,omitempty" json:"deployed_address,omitempty"` // account which must be active after success
+	MessageHash     string    `bson:"message_hash" json:"message_hash"`                             // hex hash of outgoing message body
+	RefundAmount    uint64    `bson:"refund_amount" json:"refund_amount"`
+	ReferenceID     string    `bson:"reference_id" json:"reference_id"`                         // nft collection or nft item address, batch id for batch mint
+	ItemAddresses   []string  `bson:"item_addresses,omitempty" json:"item_addresses,omitempty"` // nft items deployed by batch mint message
+	IsTestnet       bool      `bson:"is_testnet" json:"is_testnet"`
+	Status          Status    `bson:"status" json:"status"`
+	Error           string    `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt       time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+func New(kind Kind, userUuid uuid.UUID, destination string, messageHash []byte, refundAmount uint64, referenceID string, isTestnet bool) *Operation {
+	now := time.Now()
+	return &Operation{
+		ID:           uuid.New(),
+		Kind:         kind,
+		UserUUID:     userUuid,
+		Destination:  destination,
+		MessageHash:  hex.EncodeToString(messageHash),
+		RefundAmount: refundAmount,
+		ReferenceID:  referenceID,
+		IsTestnet:    isTestnet,
+		Status:       StatusPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
@@ -0,0 +1,23 @@
+package operation
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrStatusChanged = errors.New("operation status was changed by someone else")
+
+type OperationRepository interface {
+	CreateOperation(ctx context.Context, operation *Operation) error
+	// GetOperationsByStatus returns operations with the status from the oldest to the newest
+	GetOperationsByStatus(ctx context.Context, status Status) ([]Operation, error)
+	// GetOperationsByNftItem returns mint, batch mint and withdraw operations of nft item, newest first
+	GetOperationsByNftItem(ctx context.Context, nftItemAddress string) ([]Operation, error)
+	// UpdateOperationStatus moves operation from one status to another.
+	// Returns ErrStatusChanged if operation is not in from status anymore
+	UpdateOperationStatus(ctx context.Context, id uuid.UUID, from Status, to Status, errorMsg string) error
+	// WithTransaction runs fn in transaction, other repositories called with txCtx join it
+	WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
+}
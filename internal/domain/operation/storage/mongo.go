@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/rom6n/create-nft-go/internal/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoOperationRepo struct {
+	client         *mongo.Client
+	dbName         string
+	collectionName string
+	timeout        time.Duration
+}
+
+type OperationRepoCfg struct {
+	DBName         string
+	CollectionName string
+	Timeout        time.Duration
+}
+
+func NewOperationRepo(client *mongo.Client, cfg OperationRepoCfg) operation.OperationRepository {
+	repo := &mongoOperationRepo{
+		client:         client,
+		dbName:         cfg.DBName,
+		collectionName: cfg.CollectionName,
+		timeout:        cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating operations indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoOperationRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoOperationRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoOperationRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "reference_id", Value: 1}}},
+		{Keys: bson.D{{Key: "item_addresses", Value: 1}}},
+	})
+
+	return indexErr
+}
+
+func (r *mongoOperationRepo) CreateOperation(ctx context.Context, operation *operation.Operation) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	_, insertErr := r.getCollection().InsertOne(dbCtx, *operation)
+	return insertErr
+}
+
+func (r *mongoOperationRepo) GetOperationsByStatus(ctx context.Context, status operation.Status) ([]operation.Operation, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundedOperations []operation.Operation
+	cursor, findErr := r.getCollection().Find(dbCtx, bson.D{{Key: "status", Value: status}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if findErr != nil {
+		return nil, fmt.Errorf("operations find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedOperations); decodeErr != nil {
+		return nil, fmt.Errorf("operations decode error after find: %v", decodeErr)
+	}
+
+	return foundedOperations, nil
+}
+
+func (r *mongoOperationRepo) GetOperationsByNftItem(ctx context.Context, nftItemAddress string) ([]operation.Operation, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	// batch mint references batch id, its nft items are in item_addresses
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "reference_id", Value: nftItemAddress}},
+		bson.D{{Key: "item_addresses", Value: nftItemAddress}},
+	}}}
+
+	var foundedOperations []operation.Operation
+	cursor, findErr := r.getCollection().Find(dbCtx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if findErr != nil {
+		return nil, fmt.Errorf("operations find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedOperations); decodeErr != nil {
+		return nil, fmt.Errorf("operations decode error after find: %v", decodeErr)
+	}
+
+	return foundedOperations, nil
+}
+
+func (r *mongoOperationRepo) UpdateOperationStatus(ctx context.Context, id uuid.UUID, from operation.Status, to operation.Status, errorMsg string) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	update := bson.D{{Key: "status", Value: to}, {Key: "updated_at", Value: time.Now()}}
+	if errorMsg != "" {
+		update = append(update, bson.E{Key: "error", Value: errorMsg})
+	}
+
+	result, updErr := r.getCollection().UpdateOne(dbCtx,
+		bson.D{{Key: "_id", Value: id}, {Key: "status", Value: from}},
+		bson.D{{Key: "$set", Value: update}},
+	)
+	if updErr != nil {
+		return fmt.Errorf("error updating operation %v status to %v: %v", id, to, updErr)
+	}
+
+	if result.MatchedCount == 0 {
+		return operation.ErrStatusChanged
+	}
+
+	return nil
+}
+
+func (r *mongoOperationRepo) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return storage.WithTransaction(ctx, r.client, fn)
+}
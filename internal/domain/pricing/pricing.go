@@ -0,0 +1,125 @@
+package pricing
+
+import (
+	"errors"
+	"time"
+)
+
+type Operation string
+
+const (
+	OperationDeployNftCollection        Operation = "deploy_nft_collection"
+	OperationMintNftItem                Operation = "mint_nft_item"
+	OperationBatchMintNftItems          Operation = "batch_mint_nft_items"
+	OperationWithdrawNftCollection      Operation = "withdraw_nft_collection"
+	OperationWithdrawNftItem            Operation = "withdraw_nft_item"
+	OperationChangeNftCollectionContent Operation = "change_nft_collection_content"
+)
+
+var (
+	ErrUnknownOperation = errors.New("unknown priced operation")
+	ErrInvalidItems     = errors.New("count of nft items must be positive")
+)
+
+func IsValidOperation(op Operation) bool {
+	switch op {
+	case OperationDeployNftCollection, OperationMintNftItem, OperationBatchMintNftItems, OperationWithdrawNftCollection, OperationWithdrawNftItem, OperationChangeNftCollectionContent:
+		return true
+	}
+	return false
+}
+
+// Amounts are attached to messages in nano ton, they pay for gas and storage of contracts
+type Amounts struct {
+	DeployNftCollection        uint64 // sent to new nft collection
+	MintNftItem                uint64 // sent to nft collection for op=1
+	MintNftItemGas             uint64 // kept by nft collection from MintNftItem, the rest goes to nft item
+	BatchMintNftItem           uint64 // sent to every nft item of op=2
+	BatchMintNftItemGas        uint64 // kept by nft collection for every nft item of op=2
+	WithdrawNftCollection      uint64 // sent to nft collection for op=3
+	ChangeNftCollectionContent uint64 // sent to nft collection for op=4
+	WithdrawNftItem            uint64 // sent to nft item for transfer
+	WithdrawNftItemForward     uint64 // forwarded by nft item to new owner from WithdrawNftItem
+}
+
+var DefaultAmounts = Amounts{
+	DeployNftCollection:        50000000,
+	MintNftItem:                70000000,
+	MintNftItemGas:             10000000,
+	BatchMintNftItem:           50000000,
+	BatchMintNftItemGas:        10000000,
+	WithdrawNftCollection:      10000000,
+	ChangeNftCollectionContent: 10000000,
+	WithdrawNftItem:            30000000,
+	WithdrawNftItemForward:     10000000,
+}
+
+// NetworkPrices are basechain gas and forward prices from config params 21 and 25.
+// Prices per gas unit, bit and cell are multiplied by 2^16 as in config
+type NetworkPrices struct {
+	GasPrice     uint64 `json:"gas_price"`
+	FlatGasLimit uint64 `json:"flat_gas_limit"`
+	FlatGasPrice uint64 `json:"flat_gas_price"`
+	LumpPrice    uint64 `json:"lump_price"`
+	BitPrice     uint64 `json:"bit_price"`
+	CellPrice    uint64 `json:"cell_price"`
+}
+
+// DefaultNetworkPrices are basechain prices of mainnet, they are used until config is fetched
+var DefaultNetworkPrices = NetworkPrices{
+	GasPrice:     26214400,
+	FlatGasLimit: 100,
+	FlatGasPrice: 40000,
+	LumpPrice:    400000,
+	BitPrice:     26214400,
+	CellPrice:    2621440000,
+}
+
+func divCeil(a, b uint64) uint64 {
+	return (a + b - 1) / b
+}
+
+func (p NetworkPrices) GasFee(gasUsed uint64) uint64 {
+	if gasUsed <= p.FlatGasLimit {
+		return p.FlatGasPrice
+	}
+	return p.FlatGasPrice + divCeil(p.GasPrice*(gasUsed-p.FlatGasLimit), 1<<16)
+}
+
+// ForwardFee is fee of message with bits and cells without root cell
+func (p NetworkPrices) ForwardFee(bits, cells uint64) uint64 {
+	return p.LumpPrice + divCeil(p.BitPrice*bits+p.CellPrice*cells, 1<<16)
+}
+
+type QuoteRequest struct {
+	Operation     Operation
+	Items         int    // nft items of batch mint, 1 for other operations
+	ForwardAmount uint64 // forward amount of minted nft item
+	IsTestnet     bool
+}
+
+// Quote is price user is charged for operation in nano ton. Total is refunded if operation fails
+type Quote struct {
+	Operation     Operation `json:"operation"`
+	Items         int       `json:"items"`
+	IsTestnet     bool      `json:"is_testnet"`
+	MessageAmount uint64    `json:"message_amount"`            // attached to all messages of operation
+	NftItemAmount uint64    `json:"nft_item_amount,omitempty"` // sent to every minted nft item, forward amount included
+	ForwardAmount uint64    `json:"forward_amount,omitempty"`  // forwarded to owner of nft item
+	NetworkFee    uint64    `json:"network_fee"`               // wallet gas and forward fees of messages
+	ServiceFee    uint64    `json:"service_fee"`
+	Total         uint64    `json:"total"`
+	ExpiresAt     time.Time `json:"expires_at"` // network prices are refreshed after it
+}
+
+func (q *Quote) Fee() uint64 {
+	return q.NetworkFee + q.ServiceFee
+}
+
+// ItemMessageAmount is part of MessageAmount attached for one nft item of batch mint
+func (q *Quote) ItemMessageAmount() uint64 {
+	if q.Items <= 1 {
+		return q.MessageAmount
+	}
+	return q.MessageAmount / uint64(q.Items)
+}
@@ -0,0 +1,106 @@
+package reconciliation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAlreadyRunning = errors.New("reconciliation is already running")
+	ErrUnknownPolicy  = errors.New("unknown reconciliation repair policy")
+	// ErrOwnerNotResolved is repair error of nft item missing in database which owner isnt found in operations and transfers
+	ErrOwnerNotResolved = errors.New("owner of nft item isnt resolved from operations and transfers")
+)
+
+type Entity string
+
+const (
+	EntityNftCollection Entity = "nft_collection"
+	EntityNftItem       Entity = "nft_item"
+)
+
+type MismatchKind string
+
+const (
+	MismatchMissingOnChain MismatchKind = "missing_on_chain" // in database, but contract isnt active on chain
+	MismatchForeignOwner   MismatchKind = "foreign_owner"    // in database, but owner on chain isnt service wallet
+	MismatchMissingInDB    MismatchKind = "missing_in_db"    // nft item of custodial collection is owned by service wallet, but isnt in database
+)
+
+// Policy is what reconciliation repairs. Entities with pending operations, listings or auctions are never repaired
+type Policy string
+
+const (
+	PolicyReport         Policy = "report"          // mismatches are only reported
+	PolicyRemoveStale    Policy = "remove_stale"    // entities missing on chain or owned by someone else are removed from database
+	PolicyRestoreMissing Policy = "restore_missing" // nft items missing in database are added to owner resolved from operations and transfers
+	PolicyRepairAll      Policy = "repair_all"      // remove_stale and restore_missing
+)
+
+func ParsePolicy(rawPolicy string) (Policy, error) {
+	switch policy := Policy(rawPolicy); policy {
+	case PolicyReport, PolicyRemoveStale, PolicyRestoreMissing, PolicyRepairAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %v", ErrUnknownPolicy, rawPolicy)
+	}
+}
+
+// Repairs reports if mismatch of kind is repaired by policy
+func (p Policy) Repairs(kind MismatchKind) bool {
+	switch kind {
+	case MismatchMissingOnChain, MismatchForeignOwner:
+		return p == PolicyRemoveStale || p == PolicyRepairAll
+	case MismatchMissingInDB:
+		return p == PolicyRestoreMissing || p == PolicyRepairAll
+	default:
+		return false
+	}
+}
+
+type Mismatch struct {
+	Entity            Entity       `bson:"entity" json:"entity"`
+	Kind              MismatchKind `bson:"kind" json:"kind"`
+	Address           string       `bson:"address" json:"address"`
+	CollectionAddress string       `bson:"collection_address,omitempty" json:"collection_address,omitempty"` // only for nft items
+	Index             int64        `bson:"index" json:"index"`                                               // only for nft items
+	OwnerUUID         uuid.UUID    `bson:"owner_uuid" json:"owner_uuid"`                                     // owner in database, owner resolved from operations and transfers for nft items missing in database
+	OnchainOwner      string       `bson:"onchain_owner,omitempty" json:"onchain_owner,omitempty"`
+	IsTestnet         bool         `bson:"is_testnet" json:"is_testnet"`
+	Repaired          bool         `bson:"repaired" json:"repaired"`
+	RepairError       string       `bson:"repair_error,omitempty" json:"repair_error,omitempty"`
+}
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed" // reconciliation stopped, mismatches found before it are kept
+)
+
+type Report struct {
+	ID                    uuid.UUID  `bson:"_id" json:"id"`
+	Policy                Policy     `bson:"policy" json:"policy"`
+	Status                Status     `bson:"status" json:"status"`
+	CheckedNftCollections int        `bson:"checked_nft_collections" json:"checked_nft_collections"`
+	CheckedNftItems       int        `bson:"checked_nft_items" json:"checked_nft_items"`
+	FailedChecks          int        `bson:"failed_checks" json:"failed_checks"` // lite server errors, entities are checked again on next run
+	Mismatches            []Mismatch `bson:"mismatches" json:"mismatches"`
+	Error                 string     `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt             time.Time  `bson:"started_at" json:"started_at"`
+	FinishedAt            time.Time  `bson:"finished_at" json:"finished_at"` // zero while running
+}
+
+func NewReport(policy Policy) *Report {
+	return &Report{
+		ID:         uuid.New(),
+		Policy:     policy,
+		Status:     StatusRunning,
+		Mismatches: []Mismatch{},
+		StartedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,15 @@
+package reconciliation
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ReconciliationRepository interface {
+	// SaveReport creates report or replaces it with the same id
+	SaveReport(ctx context.Context, report *Report) error
+	GetReport(ctx context.Context, id uuid.UUID) (*Report, error)
+	// GetReports returns last reports, newest first
+	GetReports(ctx context.Context, limit int64) ([]Report, error)
+}
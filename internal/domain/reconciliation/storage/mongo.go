@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/reconciliation"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoReconciliationRepo struct {
+	client         *mongo.Client
+	dbName         string
+	collectionName string
+	timeout        time.Duration
+}
+
+type ReconciliationRepoCfg struct {
+	DBName         string
+	CollectionName string
+	Timeout        time.Duration
+}
+
+func NewReconciliationRepo(client *mongo.Client, cfg ReconciliationRepoCfg) reconciliation.ReconciliationRepository {
+	repo := &mongoReconciliationRepo{
+		client:         client,
+		dbName:         cfg.DBName,
+		collectionName: cfg.CollectionName,
+		timeout:        cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating reconciliation reports indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoReconciliationRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoReconciliationRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoReconciliationRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "started_at", Value: -1}}},
+	})
+
+	return indexErr
+}
+
+func (r *mongoReconciliationRepo) SaveReport(ctx context.Context, report *reconciliation.Report) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	if _, replaceErr := r.getCollection().ReplaceOne(dbCtx, bson.D{{Key: "_id", Value: report.ID}}, *report, options.Replace().SetUpsert(true)); replaceErr != nil {
+		return fmt.Errorf("error saving reconciliation report %v: %v", report.ID, replaceErr)
+	}
+
+	return nil
+}
+
+func (r *mongoReconciliationRepo) GetReport(ctx context.Context, id uuid.UUID) (*reconciliation.Report, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundReport reconciliation.Report
+	if findErr := r.getCollection().FindOne(dbCtx, bson.D{{Key: "_id", Value: id}}).Decode(&foundReport); findErr != nil {
+		return nil, findErr
+	}
+
+	return &foundReport, nil
+}
+
+func (r *mongoReconciliationRepo) GetReports(ctx context.Context, limit int64) ([]reconciliation.Report, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	foundedReports := []reconciliation.Report{}
+	cursor, findErr := r.getCollection().Find(dbCtx, bson.D{}, options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(limit))
+	if findErr != nil {
+		return nil, fmt.Errorf("reconciliation reports find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedReports); decodeErr != nil {
+		return nil, fmt.Errorf("reconciliation reports decode error: %v", decodeErr)
+	}
+
+	return foundedReports, nil
+}
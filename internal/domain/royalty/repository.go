@@ -0,0 +1,16 @@
+package royalty
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type RoyaltyRepository interface {
+	// CreateAccrual returns ErrAlreadyAccrued if royalty of the sale is already accrued
+	CreateAccrual(ctx context.Context, accrual *Accrual) error
+	// GetEarningsByCreator sums accruals of creator by nft collection, the most earning collection first
+	GetEarningsByCreator(ctx context.Context, creator uuid.UUID) ([]Earnings, error)
+	// WithTransaction runs fn in transaction, other repositories called with txCtx join it
+	WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
+}
@@ -0,0 +1,59 @@
+package royalty
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrAlreadyAccrued = errors.New("royalty of this sale is already accrued")
+
+// Sale is a paid internal transfer of nft item, royalty of its nft collection is accrued from price
+type Sale struct {
+	NftItemAddress    string
+	CollectionAddress string
+	Seller            uuid.UUID
+	Price             uint64 // nano ton
+	ReferenceID       string // listing id
+	IsTestnet         bool
+}
+
+// Accrual is royalty credited to nft collection owner from internal sale
+type Accrual struct {
+	ID                uuid.UUID `bson:"_id" json:"id"`
+	CollectionAddress string    `bson:"collection_address" json:"collection_address"`
+	NftItemAddress    string    `bson:"nft_item_address" json:"nft_item_address"`
+	Creator           uuid.UUID `bson:"creator" json:"creator"` // owner of nft collection in the app
+	Seller            uuid.UUID `bson:"seller" json:"seller"`
+	SalePrice         uint64    `bson:"sale_price" json:"sale_price"`
+	Amount            uint64    `bson:"amount" json:"amount"`
+	ReferenceID       string    `bson:"reference_id" json:"reference_id"`
+	IsTestnet         bool      `bson:"is_testnet" json:"is_testnet"`
+	CreatedAt         time.Time `bson:"created_at" json:"created_at"`
+}
+
+func NewAccrual(sale Sale, creator uuid.UUID, amount uint64) *Accrual {
+	return &Accrual{
+		ID:                uuid.New(),
+		CollectionAddress: sale.CollectionAddress,
+		NftItemAddress:    sale.NftItemAddress,
+		Creator:           creator,
+		Seller:            sale.Seller,
+		SalePrice:         sale.Price,
+		Amount:            amount,
+		ReferenceID:       sale.ReferenceID,
+		IsTestnet:         sale.IsTestnet,
+		CreatedAt:         time.Now(),
+	}
+}
+
+// Earnings is royalty earned by creator from one nft collection
+type Earnings struct {
+	CollectionAddress string    `bson:"_id" json:"collection_address"`
+	IsTestnet         bool      `bson:"is_testnet" json:"is_testnet"`
+	Sales             int64     `bson:"sales" json:"sales"`
+	Volume            uint64    `bson:"volume" json:"volume"` // nano ton of sales which paid royalty
+	Amount            uint64    `bson:"amount" json:"amount"` // nano ton credited to creator
+	LastAccruedAt     time.Time `bson:"last_accrued_at" json:"last_accrued_at"`
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/royalty"
+	"github.com/rom6n/create-nft-go/internal/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoRoyaltyRepo struct {
+	client         *mongo.Client
+	dbName         string
+	collectionName string
+	timeout        time.Duration
+}
+
+type RoyaltyRepoCfg struct {
+	DBName         string
+	CollectionName string
+	Timeout        time.Duration
+}
+
+func NewRoyaltyRepo(client *mongo.Client, cfg RoyaltyRepoCfg) royalty.RoyaltyRepository {
+	repo := &mongoRoyaltyRepo{
+		client:         client,
+		dbName:         cfg.DBName,
+		collectionName: cfg.CollectionName,
+		timeout:        cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating royalty accruals indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoRoyaltyRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoRoyaltyRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoRoyaltyRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{
+			// royalty of every sale is accrued only once
+			Keys:    bson.D{{Key: "reference_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "creator", Value: 1}, {Key: "collection_address", Value: 1}}},
+	})
+
+	return indexErr
+}
+
+func (r *mongoRoyaltyRepo) CreateAccrual(ctx context.Context, accrual *royalty.Accrual) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	if _, insertErr := r.getCollection().InsertOne(dbCtx, *accrual); insertErr != nil {
+		if mongo.IsDuplicateKeyError(insertErr) {
+			return royalty.ErrAlreadyAccrued
+		}
+		return fmt.Errorf("error inserting royalty accrual: %w", insertErr)
+	}
+
+	return nil
+}
+
+func (r *mongoRoyaltyRepo) GetEarningsByCreator(ctx context.Context, creator uuid.UUID) ([]royalty.Earnings, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "creator", Value: creator}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$collection_address"},
+			{Key: "is_testnet", Value: bson.D{{Key: "$first", Value: "$is_testnet"}}},
+			{Key: "sales", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "volume", Value: bson.D{{Key: "$sum", Value: "$sale_price"}}},
+			{Key: "amount", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+			{Key: "last_accrued_at", Value: bson.D{{Key: "$max", Value: "$created_at"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "amount", Value: -1}}}},
+	}
+
+	cursor, aggregateErr := r.getCollection().Aggregate(dbCtx, pipeline)
+	if aggregateErr != nil {
+		return nil, fmt.Errorf("royalty accruals aggregate error: %v", aggregateErr)
+	}
+
+	earnings := []royalty.Earnings{}
+	if decodeErr := cursor.All(dbCtx, &earnings); decodeErr != nil {
+		return nil, fmt.Errorf("royalty earnings decode error after aggregate: %v", decodeErr)
+	}
+
+	return earnings, nil
+}
+
+func (r *mongoRoyaltyRepo) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return storage.WithTransaction(ctx, r.client, fn)
+}
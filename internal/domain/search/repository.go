@@ -0,0 +1,9 @@
+package search
+
+import "context"
+
+type SearchRepository interface {
+	IndexDocuments(ctx context.Context, documents ...*Document) error
+	DeleteDocuments(ctx context.Context, addresses ...string) error
+	Search(ctx context.Context, query Query) (*Result, error)
+}
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"github.com/google/uuid"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+)
+
+type Kind string
+
+const (
+	KindNftCollection Kind = "nft_collection"
+	KindNftItem       Kind = "nft_item"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Document is nft collection or nft item as it is stored in search index
+type Document struct {
+	Kind              Kind                `json:"kind"`
+	Address           string              `json:"address"`
+	Name              string              `json:"name"`
+	Description       string              `json:"description"`
+	Image             string              `json:"image"`
+	CollectionAddress string              `json:"collection_address,omitempty"`
+	CollectionName    string              `json:"collection_name,omitempty"`
+	Attributes        []nftitem.Attribute `json:"attributes,omitempty"`
+	Owner             uuid.UUID           `json:"owner"`
+	IsTestnet         bool                `json:"is_testnet"`
+}
+
+type Query struct {
+	Text       string              // full-text query over names, descriptions and collection names
+	Kind       Kind                // empty for both kinds
+	Attributes []nftitem.Attribute // every attribute must match
+	IsTestnet  *bool               // nil for both networks
+	Offset     int
+	Limit      int
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Facet is counts of attribute values of found nft items
+type Facet struct {
+	TraitType string       `json:"trait_type"`
+	Count     int64        `json:"count"`
+	Values    []FacetValue `json:"values"`
+}
+
+type Result struct {
+	Total     int64      `json:"total"`
+	Documents []Document `json:"documents"`
+	Facets    []Facet    `json:"facets"`
+}
+
+func NewNftCollectionDocument(collection *nftcollection.NftCollection) *Document {
+	return &Document{
+		Kind:        KindNftCollection,
+		Address:     collection.Address,
+		Name:        collection.Metadata.Name,
+		Description: collection.Metadata.Description,
+		Image:       collection.Metadata.Image,
+		Owner:       collection.Owner,
+		IsTestnet:   collection.IsTestnet,
+	}
+}
+
+func NewNftItemDocument(nftItem *nftitem.NftItem) *Document {
+	return &Document{
+		Kind:              KindNftItem,
+		Address:           nftItem.Address,
+		Name:              nftItem.Metadata.Name,
+		Description:       nftItem.Metadata.Description,
+		Image:             nftItem.Metadata.Image,
+		CollectionAddress: nftItem.CollectionAddress,
+		CollectionName:    nftItem.CollectionName,
+		Attributes:        nftItem.Metadata.Attributes,
+		Owner:             nftItem.Owner,
+		IsTestnet:         nftItem.IsTestnet,
+	}
+}
@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+const facetsLimit = 50
+
+type elasticSearchRepo struct {
+	client    *storage.ElasticSearchClient
+	indexName string
+	timeout   time.Duration
+}
+
+type SearchRepoCfg struct {
+	IndexName string
+	Timeout   time.Duration
+}
+
+func NewSearchRepo(client *storage.ElasticSearchClient, cfg SearchRepoCfg) search.SearchRepository {
+	repo := &elasticSearchRepo{
+		client:    client,
+		indexName: cfg.IndexName,
+		timeout:   cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndex(); indexErr != nil {
+		log.Printf("Error creating search index: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *elasticSearchRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *elasticSearchRepo) indexPath(suffix string) string {
+	return "/" + url.PathEscape(r.indexName) + suffix
+}
+
+func (r *elasticSearchRepo) createIndex() error {
+	esCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	existsErr := r.client.Request(esCtx, http.MethodHead, r.indexPath(""), "", nil, nil)
+	if existsErr == nil {
+		return nil
+	}
+
+	var esErr *storage.ElasticSearchError
+	if !errors.As(existsErr, &esErr) || esErr.StatusCode != http.StatusNotFound {
+		return existsErr
+	}
+
+	keyword := map[string]any{"type": "keyword"}
+	text := map[string]any{"type": "text"}
+
+	mappings := map[string]any{
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				"kind":               keyword,
+				"address":            keyword,
+				"name":               text,
+				"description":        text,
+				"image":              map[string]any{"type": "keyword", "index": false},
+				"collection_address": keyword,
+				"collection_name":    text,
+				"owner":              keyword,
+				"is_testnet":         map[string]any{"type": "boolean"},
+				// nested keeps trait type and value of one attribute together for filters and facets
+				"attributes": map[string]any{
+					"type": "nested",
+					"properties": map[string]any{
+						"trait_type": keyword,
+						"value":      keyword,
+					},
+				},
+			},
+		},
+	}
+
+	return r.client.RequestJSON(esCtx, http.MethodPut, r.indexPath(""), mappings, nil)
+}
+
+type bulkResponse struct {
+	Errors bool                         `json:"errors"`
+	Items  []map[string]bulkItemOutcome `json:"items"`
+}
+
+type bulkItemOutcome struct {
+	ID     string          `json:"_id"`
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error"`
+}
+
+func (r *elasticSearchRepo) bulk(ctx context.Context, body []byte) error {
+	esCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var resp bulkResponse
+	if bulkErr := r.client.Request(esCtx, http.MethodPost, r.indexPath("/_bulk"), "application/x-ndjson", body, &resp); bulkErr != nil {
+		return bulkErr
+	}
+
+	if !resp.Errors {
+		return nil
+	}
+
+	var failed []string
+	for _, item := range resp.Items {
+		for action, outcome := range item {
+			// deleting of not indexed document isnt an error
+			if action == "delete" && outcome.Status == http.StatusNotFound {
+				continue
+			}
+			if outcome.Status >= 300 {
+				failed = append(failed, fmt.Sprintf("%v %v: %s", action, outcome.ID, outcome.Error))
+			}
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("error bulk indexing documents: %v", strings.Join(failed, "; "))
+}
+
+func (r *elasticSearchRepo) IndexDocuments(ctx context.Context, documents ...*search.Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+
+	for _, document := range documents {
+		action := map[string]any{"index": map[string]any{"_id": document.Address}}
+		if encodeErr := encoder.Encode(action); encodeErr != nil {
+			return fmt.Errorf("error encoding bulk action: %w", encodeErr)
+		}
+		if encodeErr := encoder.Encode(document); encodeErr != nil {
+			return fmt.Errorf("error encoding document %v: %w", document.Address, encodeErr)
+		}
+	}
+
+	return r.bulk(ctx, body.Bytes())
+}
+
+func (r *elasticSearchRepo) DeleteDocuments(ctx context.Context, addresses ...string) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+
+	for _, address := range addresses {
+		action := map[string]any{"delete": map[string]any{"_id": address}}
+		if encodeErr := encoder.Encode(action); encodeErr != nil {
+			return fmt.Errorf("error encoding bulk action: %w", encodeErr)
+		}
+	}
+
+	return r.bulk(ctx, body.Bytes())
+}
+
+type searchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Source search.Document `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Attributes struct {
+			TraitTypes struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int64  `json:"doc_count"`
+					Values   struct {
+						Buckets []struct {
+							Key      string `json:"key"`
+							DocCount int64  `json:"doc_count"`
+						} `json:"buckets"`
+					} `json:"values"`
+				} `json:"buckets"`
+			} `json:"trait_types"`
+		} `json:"attributes"`
+	} `json:"aggregations"`
+}
+
+func buildSearchRequest(query search.Query) map[string]any {
+	must := []any{}
+	if query.Text != "" {
+		must = append(must, map[string]any{
+			"multi_match": map[string]any{
+				"query":  query.Text,
+				"fields": []string{"name^3", "collection_name^2", "description"},
+			},
+		})
+	} else {
+		must = append(must, map[string]any{"match_all": map[string]any{}})
+	}
+
+	filter := []any{}
+	if query.Kind != "" {
+		filter = append(filter, map[string]any{"term": map[string]any{"kind": query.Kind}})
+	}
+	if query.IsTestnet != nil {
+		filter = append(filter, map[string]any{"term": map[string]any{"is_testnet": *query.IsTestnet}})
+	}
+	for _, attribute := range query.Attributes {
+		filter = append(filter, map[string]any{
+			"nested": map[string]any{
+				"path": "attributes",
+				"query": map[string]any{
+					"bool": map[string]any{
+						"filter": []any{
+							map[string]any{"term": map[string]any{"attributes.trait_type": attribute.TraitType}},
+							map[string]any{"term": map[string]any{"attributes.value": attribute.Value}},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	return map[string]any{
+		"from":             query.Offset,
+		"size":             query.Limit,
+		"track_total_hits": true,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"must":   must,
+				"filter": filter,
+			},
+		},
+		"aggs": map[string]any{
+			"attributes": map[string]any{
+				"nested": map[string]any{"path": "attributes"},
+				"aggs": map[string]any{
+					"trait_types": map[string]any{
+						"terms": map[string]any{"field": "attributes.trait_type", "size": facetsLimit},
+						"aggs": map[string]any{
+							"values": map[string]any{
+								"terms": map[string]any{"field": "attributes.value", "size": facetsLimit},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *elasticSearchRepo) Search(ctx context.Context, query search.Query) (*search.Result, error) {
+	esCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var resp searchResponse
+	if searchErr := r.client.RequestJSON(esCtx, http.MethodPost, r.indexPath("/_search"), buildSearchRequest(query), &resp); searchErr != nil {
+		return nil, searchErr
+	}
+
+	result := &search.Result{
+		Total:     resp.Hits.Total.Value,
+		Documents: make([]search.Document, 0, len(resp.Hits.Hits)),
+		Facets:    make([]search.Facet, 0, len(resp.Aggregations.Attributes.TraitTypes.Buckets)),
+	}
+
+	for _, hit := range resp.Hits.Hits {
+		result.Documents = append(result.Documents, hit.Source)
+	}
+
+	for _, traitBucket := range resp.Aggregations.Attributes.TraitTypes.Buckets {
+		facet := search.Facet{
+			TraitType: traitBucket.Key,
+			Count:     traitBucket.DocCount,
+			Values:    make([]search.FacetValue, 0, len(traitBucket.Values.Buckets)),
+		}
+		for _, valueBucket := range traitBucket.Values.Buckets {
+			facet.Values = append(facet.Values, search.FacetValue{
+				Value: valueBucket.Key,
+				Count: valueBucket.DocCount,
+			})
+		}
+		result.Facets = append(result.Facets, facet)
+	}
+
+	return result, nil
+}
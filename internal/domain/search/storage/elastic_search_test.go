@@ -0,0 +1,321 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+const testIndexName = "test-index"
+
+// fakeElasticSearch serves index creation, bulk and search endpoints of one index
+type fakeElasticSearch struct {
+	mu          sync.Mutex
+	indexExists bool
+	mappings    map[string]any
+	documents   map[string]search.Document
+	lastQuery   map[string]any
+	failBulkIDs map[string]bool // documents which bulk fails to index
+}
+
+func newFakeElasticSearch(t *testing.T) (*fakeElasticSearch, *storage.ElasticSearchClient) {
+	t.Helper()
+	fake := &fakeElasticSearch{documents: map[string]search.Document{}, failBulkIDs: map[string]bool{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, storage.NewElasticSearchClientWithURL(server.URL, server.Client())
+}
+
+func (f *fakeElasticSearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodHead && r.URL.Path == "/"+testIndexName:
+		if !f.indexExists {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut && r.URL.Path == "/"+testIndexName:
+		f.indexExists = true
+		json.Unmarshal(body, &f.mappings)
+		w.Write([]byte(`{"acknowledged":true}`))
+	case r.Method == http.MethodPost && r.URL.Path == "/"+testIndexName+"/_bulk":
+		if r.Header.Get("Content-Type") != "application/x-ndjson" {
+			http.Error(w, "bulk body must be ndjson", http.StatusBadRequest)
+			return
+		}
+		f.bulk(w, body)
+	case r.Method == http.MethodPost && r.URL.Path == "/"+testIndexName+"/_search":
+		f.lastQuery = map[string]any{}
+		json.Unmarshal(body, &f.lastQuery)
+		f.search(w)
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeElasticSearch) bulk(w http.ResponseWriter, body []byte) {
+	type outcome struct {
+		ID     string         `json:"_id"`
+		Status int            `json:"status"`
+		Error  map[string]any `json:"error,omitempty"`
+	}
+	var items []map[string]outcome
+	hasErrors := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var action map[string]struct {
+			ID string `json:"_id"`
+		}
+		json.Unmarshal(scanner.Bytes(), &action)
+
+		if index, ok := action["index"]; ok {
+			scanner.Scan()
+			result := outcome{ID: index.ID, Status: http.StatusCreated}
+			if f.failBulkIDs[index.ID] {
+				result = outcome{ID: index.ID, Status: http.StatusBadRequest, Error: map[string]any{"type": "mapper_parsing_exception"}}
+				hasErrors = true
+			} else {
+				var document search.Document
+				json.Unmarshal(scanner.Bytes(), &document)
+				f.documents[index.ID] = document
+			}
+			items = append(items, map[string]outcome{"index": result})
+		}
+		if del, ok := action["delete"]; ok {
+			result := outcome{ID: del.ID, Status: http.StatusOK}
+			if _, exists := f.documents[del.ID]; !exists {
+				result.Status = http.StatusNotFound
+				hasErrors = true
+			}
+			delete(f.documents, del.ID)
+			items = append(items, map[string]outcome{"delete": result})
+		}
+	}
+
+	response, _ := json.Marshal(map[string]any{"errors": hasErrors, "items": items})
+	w.Write(response)
+}
+
+func (f *fakeElasticSearch) search(w http.ResponseWriter) {
+	type bucket struct {
+		Key      string `json:"key"`
+		DocCount int64  `json:"doc_count"`
+		Values   *struct {
+			Buckets []bucket `json:"buckets"`
+		} `json:"values,omitempty"`
+	}
+
+	// every document is a hit, query building is checked by lastQuery
+	hits := []map[string]any{}
+	traitCounts := map[string]map[string]int64{}
+	for _, document := range f.documents {
+		hits = append(hits, map[string]any{"_source": document})
+		for _, attribute := range document.Attributes {
+			if traitCounts[attribute.TraitType] == nil {
+				traitCounts[attribute.TraitType] = map[string]int64{}
+			}
+			traitCounts[attribute.TraitType][attribute.Value]++
+		}
+	}
+
+	traitBuckets := []bucket{}
+	for traitType, values := range traitCounts {
+		traitBucket := bucket{Key: traitType, Values: &struct {
+			Buckets []bucket `json:"buckets"`
+		}{}}
+		for value, count := range values {
+			traitBucket.DocCount += count
+			traitBucket.Values.Buckets = append(traitBucket.Values.Buckets, bucket{Key: value, DocCount: count})
+		}
+		traitBuckets = append(traitBuckets, traitBucket)
+	}
+
+	response, _ := json.Marshal(map[string]any{
+		"hits": map[string]any{
+			"total": map[string]any{"value": len(hits)},
+			"hits":  hits,
+		},
+		"aggregations": map[string]any{
+			"attributes": map[string]any{
+				"trait_types": map[string]any{"buckets": traitBuckets},
+			},
+		},
+	})
+	w.Write(response)
+}
+
+func newTestSearchRepo(t *testing.T) (*fakeElasticSearch, search.SearchRepository) {
+	t.Helper()
+	fake, client := newFakeElasticSearch(t)
+	return fake, NewSearchRepo(client, SearchRepoCfg{IndexName: testIndexName, Timeout: 5 * time.Second})
+}
+
+func newTestDocument(address string, attributes ...nftitem.Attribute) *search.Document {
+	return &search.Document{
+		Kind:              search.KindNftItem,
+		Address:           address,
+		Name:              "item " + address,
+		CollectionAddress: "collection",
+		CollectionName:    "Collection",
+		Attributes:        attributes,
+		Owner:             uuid.New(),
+		IsTestnet:         true,
+	}
+}
+
+func TestNewSearchRepoCreatesIndex(t *testing.T) {
+	fake, _ := newTestSearchRepo(t)
+
+	if !fake.indexExists {
+		t.Fatalf("index isnt created")
+	}
+	properties := fake.mappings["mappings"].(map[string]any)["properties"].(map[string]any)
+	if attributes := properties["attributes"].(map[string]any); attributes["type"] != "nested" {
+		t.Fatalf("attributes mapping = %v, want nested", attributes)
+	}
+}
+
+func TestNewSearchRepoKeepsExistingIndex(t *testing.T) {
+	fake, client := newFakeElasticSearch(t)
+	fake.indexExists = true
+
+	NewSearchRepo(client, SearchRepoCfg{IndexName: testIndexName, Timeout: 5 * time.Second})
+
+	if fake.mappings != nil {
+		t.Fatalf("existing index is recreated")
+	}
+}
+
+func TestIndexAndDeleteDocuments(t *testing.T) {
+	fake, repo := newTestSearchRepo(t)
+	ctx := context.Background()
+
+	if indexErr := repo.IndexDocuments(ctx, newTestDocument("first"), newTestDocument("second")); indexErr != nil {
+		t.Fatalf("IndexDocuments() error = %v", indexErr)
+	}
+	if len(fake.documents) != 2 || fake.documents["first"].Name != "item first" {
+		t.Fatalf("indexed documents = %v", fake.documents)
+	}
+
+	// deleting of not indexed document isnt an error
+	if delErr := repo.DeleteDocuments(ctx, "first", "missing"); delErr != nil {
+		t.Fatalf("DeleteDocuments() error = %v", delErr)
+	}
+	if _, ok := fake.documents["first"]; ok || len(fake.documents) != 1 {
+		t.Fatalf("indexed documents after delete = %v", fake.documents)
+	}
+}
+
+func TestIndexDocumentsReportsFailedItems(t *testing.T) {
+	fake, repo := newTestSearchRepo(t)
+	fake.failBulkIDs["broken"] = true
+
+	indexErr := repo.IndexDocuments(context.Background(), newTestDocument("first"), newTestDocument("broken"))
+	if indexErr == nil || !strings.Contains(indexErr.Error(), "index broken") {
+		t.Fatalf("IndexDocuments() error = %v, want failed item broken", indexErr)
+	}
+	if _, ok := fake.documents["first"]; !ok {
+		t.Fatalf("document which didnt fail isnt indexed")
+	}
+}
+
+func TestEmptyBulkIsntSent(t *testing.T) {
+	_, client := newFakeElasticSearch(t)
+	repo := &elasticSearchRepo{client: client, indexName: "missing-index", timeout: 5 * time.Second}
+
+	if indexErr := repo.IndexDocuments(context.Background()); indexErr != nil {
+		t.Fatalf("IndexDocuments() error = %v", indexErr)
+	}
+	if delErr := repo.DeleteDocuments(context.Background()); delErr != nil {
+		t.Fatalf("DeleteDocuments() error = %v", delErr)
+	}
+}
+
+func TestElasticSearchErrorStatus(t *testing.T) {
+	_, client := newFakeElasticSearch(t)
+	repo := &elasticSearchRepo{client: client, indexName: "missing-index", timeout: 5 * time.Second}
+
+	_, searchErr := repo.Search(context.Background(), search.Query{Limit: search.DefaultLimit})
+	esErr, ok := searchErr.(*storage.ElasticSearchError)
+	if !ok || esErr.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("Search() error = %v, want elasticsearch error", searchErr)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	fake, repo := newTestSearchRepo(t)
+	ctx := context.Background()
+	isTestnet := true
+	repo.IndexDocuments(ctx,
+		newTestDocument("first", nftitem.Attribute{TraitType: "color", Value: "red"}),
+		newTestDocument("second", nftitem.Attribute{TraitType: "color", Value: "red"}),
+	)
+
+	result, searchErr := repo.Search(ctx, search.Query{
+		Text:       "item",
+		Kind:       search.KindNftItem,
+		Attributes: []nftitem.Attribute{{TraitType: "color", Value: "red"}},
+		IsTestnet:  &isTestnet,
+		Offset:     10,
+		Limit:      5,
+	})
+	if searchErr != nil {
+		t.Fatalf("Search() error = %v", searchErr)
+	}
+
+	if result.Total != 2 || len(result.Documents) != 2 {
+		t.Fatalf("Search() = %v documents of %v", len(result.Documents), result.Total)
+	}
+	if len(result.Facets) != 1 || result.Facets[0].TraitType != "color" || result.Facets[0].Values[0] != (search.FacetValue{Value: "red", Count: 2}) {
+		t.Fatalf("Search() facets = %v", result.Facets)
+	}
+
+	if fake.lastQuery["from"] != float64(10) || fake.lastQuery["size"] != float64(5) {
+		t.Fatalf("query pagination = %v %v", fake.lastQuery["from"], fake.lastQuery["size"])
+	}
+	boolQuery := fake.lastQuery["query"].(map[string]any)["bool"].(map[string]any)
+	must := boolQuery["must"].([]any)[0].(map[string]any)
+	if must["multi_match"].(map[string]any)["query"] != "item" {
+		t.Fatalf("full-text query = %v", must)
+	}
+	// kind, network and attribute filters
+	if filters := boolQuery["filter"].([]any); len(filters) != 3 {
+		t.Fatalf("query filters = %v", filters)
+	}
+}
+
+func TestSearchWithoutText(t *testing.T) {
+	fake, repo := newTestSearchRepo(t)
+
+	result, searchErr := repo.Search(context.Background(), search.Query{Limit: search.DefaultLimit})
+	if searchErr != nil {
+		t.Fatalf("Search() error = %v", searchErr)
+	}
+	if result.Total != 0 || result.Documents == nil || result.Facets == nil {
+		t.Fatalf("Search() = %v, want empty result", result)
+	}
+
+	boolQuery := fake.lastQuery["query"].(map[string]any)["bool"].(map[string]any)
+	if _, ok := boolQuery["must"].([]any)[0].(map[string]any)["match_all"]; !ok {
+		t.Fatalf("query without text = %v, want match_all", boolQuery["must"])
+	}
+	if filters := boolQuery["filter"].([]any); len(filters) != 0 {
+		t.Fatalf("query filters = %v, want none", filters)
+	}
+}
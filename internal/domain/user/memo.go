@@ -0,0 +1,43 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+)
+
+const depositMemoPrefix = "BN"
+
+var ErrInvalidDepositMemo = errors.New("invalid deposit memo")
+
+func depositMemoChecksum(userID int64) string {
+	sum := crc32.ChecksumIEEE([]byte(strconv.FormatInt(userID, 10)))
+	return fmt.Sprintf("%04X", sum&0xFFFF)
+}
+
+// DepositMemo returns comment user must attach to deposit transfer: BN-<user id>-<checksum>.
+// Checksum makes typo in user ID almost never credit another user
+func DepositMemo(userID int64) string {
+	return fmt.Sprintf("%v-%v-%v", depositMemoPrefix, userID, depositMemoChecksum(userID))
+}
+
+// ParseDepositMemo returns user ID from deposit memo or ErrInvalidDepositMemo if memo is malformed or checksum doesn't match
+func ParseDepositMemo(memo string) (int64, error) {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(memo)), "-")
+	if len(parts) != 3 || parts[0] != depositMemoPrefix {
+		return 0, ErrInvalidDepositMemo
+	}
+
+	userID, parseErr := strconv.ParseInt(parts[1], 10, 64)
+	if parseErr != nil {
+		return 0, ErrInvalidDepositMemo
+	}
+
+	if parts[2] != depositMemoChecksum(userID) {
+		return 0, ErrInvalidDepositMemo
+	}
+
+	return userID, nil
+}
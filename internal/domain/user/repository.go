@@ -2,14 +2,13 @@ package user
 
 import (
 	"context"
-
-	"github.com/google/uuid"
 )
 
 type UserRepository interface {
 	GetUserByID(ctx context.Context, userID int64) (*User, error)
 	CreateUser(ctx context.Context, user *User) error
-	UpdateUserBalance(ctx context.Context, userUuid uuid.UUID, newNanoTon uint64) error
+	// SetUserRole changes user's role and returns user as it was before change
+	SetUserRole(ctx context.Context, userID int64, role string) (*User, error)
 }
 
 //Основные коды ошибкок
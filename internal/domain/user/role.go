@@ -0,0 +1,35 @@
+package user
+
+import "errors"
+
+const (
+	RoleUser        = "user"
+	RoleSupport     = "support"      // reviews deposits and users
+	RoleMarketAdmin = "market_admin" // moves ton of marketplace contract
+	RoleSuperAdmin  = "super_admin"  // has every role and grants roles
+)
+
+var ErrUnknownRole = errors.New("unknown role")
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleSupport, RoleMarketAdmin, RoleSuperAdmin:
+		return true
+	}
+	return false
+}
+
+// HasRole reports if user has any of roles. Super admin has every role
+func (u *User) HasRole(roles ...string) bool {
+	if u.Role == RoleSuperAdmin {
+		return true
+	}
+
+	for _, role := range roles {
+		if u.Role == role {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,13 +2,12 @@ package user
 
 import (
 	"context"
-	"fmt"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/rom6n/create-nft-go/internal/domain/user"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type mongoUserRepo struct {
@@ -66,14 +65,21 @@ func (v *mongoUserRepo) CreateUser(ctx context.Context, user *user.User) error {
 	return insertErr
 }
 
-func (v *mongoUserRepo) UpdateUserBalance(ctx context.Context, userUuid uuid.UUID, newNanoTon uint64) error {
-	dbCtx, cancel := v.getContext(ctx)
+func (r *mongoUserRepo) SetUserRole(ctx context.Context, userID int64, role string) (*user.User, error) {
+	dbCtx, cancel := r.getContext(ctx)
 	defer cancel()
 
-	collection := v.getCollection()
-	_, updErr := collection.UpdateOne(dbCtx, bson.D{{Key: "_id", Value: userUuid}}, bson.D{{Key: "$set", Value: bson.D{{Key: "nano_ton", Value: newNanoTon}}}})
-	if updErr != nil {
-		return fmt.Errorf("error update user uuid %v's balance: %v", userUuid, updErr)
+	userCollection := r.getCollection()
+
+	var previous user.User
+
+	if updateErr := userCollection.FindOneAndUpdate(dbCtx,
+		bson.D{{Key: "id", Value: userID}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "role", Value: role}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&previous); updateErr != nil {
+		return nil, updateErr
 	}
-	return nil
+
+	return &previous, nil
 }
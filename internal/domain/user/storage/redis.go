@@ -0,0 +1,89 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+type cachedUserRepo struct {
+	next   user.UserRepository
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+type UserCacheCfg struct {
+	TTL time.Duration
+}
+
+// NewCachedUserRepo returns read-through cache over next repository
+func NewCachedUserRepo(next user.UserRepository, client redis.UniversalClient, cfg UserCacheCfg) user.UserRepository {
+	return &cachedUserRepo{
+		next:   next,
+		client: client,
+		ttl:    cfg.TTL,
+	}
+}
+
+func userIDCacheKey(userID int64) string {
+	return fmt.Sprintf("user:id:%v", userID)
+}
+
+// points to user id, so user can be invalidated by uuid
+func userUuidCacheKey(userUuid uuid.UUID) string {
+	return fmt.Sprintf("user:uuid:%v", userUuid)
+}
+
+func (r *cachedUserRepo) GetUserByID(ctx context.Context, userID int64) (*user.User, error) {
+	if cachedUser, ok := storage.GetCached[user.User](ctx, r.client, userIDCacheKey(userID)); ok {
+		return cachedUser, nil
+	}
+
+	foundUser, dbErr := r.next.GetUserByID(ctx, userID)
+	if dbErr != nil {
+		return nil, dbErr
+	}
+
+	storage.SetCached(ctx, r.client, userIDCacheKey(userID), *foundUser, r.ttl)
+	storage.SetCached(ctx, r.client, userUuidCacheKey(foundUser.UUID), userID, r.ttl)
+
+	return foundUser, nil
+}
+
+func (r *cachedUserRepo) CreateUser(ctx context.Context, user *user.User) error {
+	if createErr := r.next.CreateUser(ctx, user); createErr != nil {
+		return createErr
+	}
+
+	storage.DeleteCached(ctx, r.client, userIDCacheKey(user.ID), userUuidCacheKey(user.UUID))
+	return nil
+}
+
+func (r *cachedUserRepo) SetUserRole(ctx context.Context, userID int64, role string) (*user.User, error) {
+	previous, updateErr := r.next.SetUserRole(ctx, userID, role)
+	if updateErr != nil {
+		return nil, updateErr
+	}
+
+	storage.DeleteCached(ctx, r.client, userIDCacheKey(userID), userUuidCacheKey(previous.UUID))
+	return previous, nil
+}
+
+// InvalidateCachedUser removes cached user with uuid. It is used by writers which change users not through UserRepository.
+// Inside transaction user is removed after commit
+func InvalidateCachedUser(ctx context.Context, client redis.UniversalClient, userUuid uuid.UUID) {
+	storage.AfterCommit(ctx, func(ctx context.Context) {
+		userID, ok := storage.GetCached[int64](ctx, client, userUuidCacheKey(userUuid))
+		if !ok {
+			// user is not cached
+			return
+		}
+
+		storage.DeleteCached(ctx, client, userUuidCacheKey(userUuid), userIDCacheKey(*userID))
+	})
+}
@@ -0,0 +1,175 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type fakeUserRepo struct {
+	users map[int64]user.User
+	gets  int
+}
+
+func (r *fakeUserRepo) GetUserByID(_ context.Context, userID int64) (*user.User, error) {
+	r.gets++
+	foundUser, ok := r.users[userID]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &foundUser, nil
+}
+
+func (r *fakeUserRepo) CreateUser(_ context.Context, newUser *user.User) error {
+	r.users[newUser.ID] = *newUser
+	return nil
+}
+
+func (r *fakeUserRepo) SetUserRole(_ context.Context, userID int64, role string) (*user.User, error) {
+	previous, ok := r.users[userID]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	updated := previous
+	updated.Role = role
+	r.users[userID] = updated
+	return &previous, nil
+}
+
+func newTestCache(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient, *fakeUserRepo, user.UserRepository) {
+	t.Helper()
+	server, runErr := miniredis.Run()
+	if runErr != nil {
+		t.Fatalf("error running miniredis: %v", runErr)
+	}
+	t.Cleanup(server.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	next := &fakeUserRepo{users: map[int64]user.User{}}
+	return server, client, next, NewCachedUserRepo(next, client, UserCacheCfg{TTL: time.Minute})
+}
+
+func TestCachedUserRepoGetUserByID(t *testing.T) {
+	server, _, next, repo := newTestCache(t)
+	ctx := context.Background()
+	cachedUser := user.NewUser(uuid.New(), 1, 0, user.RoleUser, 10, 20)
+	next.users[1] = cachedUser
+
+	// miss reads next repository and caches user
+	foundUser, getErr := repo.GetUserByID(ctx, 1)
+	if getErr != nil {
+		t.Fatalf("GetUserByID() error = %v", getErr)
+	}
+	if *foundUser != cachedUser || next.gets != 1 {
+		t.Fatalf("GetUserByID() = %v after %v reads", *foundUser, next.gets)
+	}
+	if ttl := server.TTL(userIDCacheKey(1)); ttl != time.Minute {
+		t.Fatalf("ttl = %v, want %v", ttl, time.Minute)
+	}
+
+	// hit doesnt read next repository
+	foundUser, getErr = repo.GetUserByID(ctx, 1)
+	if getErr != nil {
+		t.Fatalf("GetUserByID() error = %v", getErr)
+	}
+	if *foundUser != cachedUser || next.gets != 1 {
+		t.Fatalf("GetUserByID() = %v after %v reads", *foundUser, next.gets)
+	}
+}
+
+func TestCachedUserRepoDoesntCacheErrors(t *testing.T) {
+	server, _, next, repo := newTestCache(t)
+
+	if _, getErr := repo.GetUserByID(context.Background(), 1); !errors.Is(getErr, mongo.ErrNoDocuments) {
+		t.Fatalf("GetUserByID() error = %v, want %v", getErr, mongo.ErrNoDocuments)
+	}
+	if len(server.Keys()) != 0 || next.gets != 1 {
+		t.Fatalf("cached keys %v after %v reads", server.Keys(), next.gets)
+	}
+}
+
+func TestCachedUserRepoSetUserRoleInvalidates(t *testing.T) {
+	_, _, next, repo := newTestCache(t)
+	ctx := context.Background()
+	next.users[1] = user.NewUser(uuid.New(), 1, 0, user.RoleUser, 0, 0)
+
+	if _, getErr := repo.GetUserByID(ctx, 1); getErr != nil {
+		t.Fatalf("GetUserByID() error = %v", getErr)
+	}
+	if _, setErr := repo.SetUserRole(ctx, 1, user.RoleSupport); setErr != nil {
+		t.Fatalf("SetUserRole() error = %v", setErr)
+	}
+
+	foundUser, getErr := repo.GetUserByID(ctx, 1)
+	if getErr != nil {
+		t.Fatalf("GetUserByID() error = %v", getErr)
+	}
+	if foundUser.Role != user.RoleSupport || next.gets != 2 {
+		t.Fatalf("GetUserByID() role = %v after %v reads", foundUser.Role, next.gets)
+	}
+}
+
+func TestCachedUserRepoCreateUserInvalidates(t *testing.T) {
+	server, _, next, repo := newTestCache(t)
+	ctx := context.Background()
+	newUser := user.NewUser(uuid.New(), 1, 0, user.RoleUser, 0, 0)
+	server.Set(userIDCacheKey(1), "stale")
+	server.Set(userUuidCacheKey(newUser.UUID), "stale")
+
+	if createErr := repo.CreateUser(ctx, &newUser); createErr != nil {
+		t.Fatalf("CreateUser() error = %v", createErr)
+	}
+	if len(server.Keys()) != 0 {
+		t.Fatalf("stale keys are cached: %v", server.Keys())
+	}
+	if _, ok := next.users[1]; !ok {
+		t.Fatalf("user isnt created by next repository")
+	}
+}
+
+func TestInvalidateCachedUser(t *testing.T) {
+	server, client, next, repo := newTestCache(t)
+	ctx := context.Background()
+	cachedUser := user.NewUser(uuid.New(), 1, 0, user.RoleUser, 10, 0)
+	next.users[1] = cachedUser
+
+	if _, getErr := repo.GetUserByID(ctx, 1); getErr != nil {
+		t.Fatalf("GetUserByID() error = %v", getErr)
+	}
+
+	// balance is changed by ledger, not through user repository
+	updated := cachedUser
+	updated.TestnetNanoTon = 5
+	next.users[1] = updated
+	InvalidateCachedUser(ctx, client, cachedUser.UUID)
+
+	if len(server.Keys()) != 0 {
+		t.Fatalf("user is still cached: %v", server.Keys())
+	}
+	foundUser, getErr := repo.GetUserByID(ctx, 1)
+	if getErr != nil {
+		t.Fatalf("GetUserByID() error = %v", getErr)
+	}
+	if foundUser.TestnetNanoTon != 5 {
+		t.Fatalf("GetUserByID() balance = %v, want 5", foundUser.TestnetNanoTon)
+	}
+}
+
+func TestInvalidateCachedUserNotCached(t *testing.T) {
+	server, client, _, _ := newTestCache(t)
+
+	InvalidateCachedUser(context.Background(), client, uuid.New())
+
+	if len(server.Keys()) != 0 {
+		t.Fatalf("keys are cached: %v", server.Keys())
+	}
+}
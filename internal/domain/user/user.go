@@ -2,20 +2,42 @@ package user
 
 import "github.com/google/uuid"
 
+// balances are stored per network so testnet ton can't pay for mainnet operations
+const (
+	TestnetBalanceField = "nano_ton" // deposits were testnet only before balances were split
+	MainnetBalanceField = "mainnet_nano_ton"
+)
+
 type User struct {
-	UUID    uuid.UUID `bson:"_id" json:"uuid"`
-	ID      int64     `bson:"id" json:"id"`
-	Level   int32     `bson:"level" json:"level"`
-	Role    string    `bson:"role" json:"role"`
-	NanoTon uint64    `bson:"nano_ton" json:"nano_ton"`
+	UUID           uuid.UUID `bson:"_id" json:"uuid"`
+	ID             int64     `bson:"id" json:"id"`
+	Level          int32     `bson:"level" json:"level"`
+	Role           string    `bson:"role" json:"role"`
+	TestnetNanoTon uint64    `bson:"nano_ton" json:"nano_ton"` // testnet balance, json name is kept for existing clients
+	MainnetNanoTon uint64    `bson:"mainnet_nano_ton" json:"mainnet_nano_ton"`
 }
 
-func NewUser(UUID uuid.UUID, ID int64, level int32, role string, nanoTon uint64) User {
+func NewUser(UUID uuid.UUID, ID int64, level int32, role string, testnetNanoTon uint64, mainnetNanoTon uint64) User {
 	return User{
-		UUID:    UUID,
-		ID:      ID,
-		Level:   level,
-		Role:    role,
-		NanoTon: nanoTon,
+		UUID:           UUID,
+		ID:             ID,
+		Level:          level,
+		Role:           role,
+		TestnetNanoTon: testnetNanoTon,
+		MainnetNanoTon: mainnetNanoTon,
+	}
+}
+
+func (u *User) Balance(isTestnet bool) uint64 {
+	if isTestnet {
+		return u.TestnetNanoTon
+	}
+	return u.MainnetNanoTon
+}
+
+func BalanceField(isTestnet bool) string {
+	if isTestnet {
+		return TestnetBalanceField
 	}
+	return MainnetBalanceField
 }
@@ -0,0 +1,22 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrStatusChanged = errors.New("withdrawal status was changed by someone else")
+
+type WithdrawalRepository interface {
+	CreateWithdrawal(ctx context.Context, withdrawal *Withdrawal) error
+	// GetWithdrawalsByStatus returns withdrawals with the status from the oldest to the newest
+	GetWithdrawalsByStatus(ctx context.Context, status Status) ([]Withdrawal, error)
+	GetWithdrawalsByUserUuid(ctx context.Context, userUuid uuid.UUID) ([]Withdrawal, error)
+	// UpdateWithdrawalStatus moves withdrawal from one status to another.
+	// Returns ErrStatusChanged if withdrawal is not in from status anymore
+	UpdateWithdrawalStatus(ctx context.Context, id uuid.UUID, from Status, to Status, errorMsg string) error
+	// WithTransaction runs fn in transaction, other repositories called with txCtx join it
+	WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/withdrawal"
+	"github.com/rom6n/create-nft-go/internal/storage"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoWithdrawalRepo struct {
+	client         *mongo.Client
+	dbName         string
+	collectionName string
+	timeout        time.Duration
+}
+
+type WithdrawalRepoCfg struct {
+	DBName         string
+	CollectionName string
+	Timeout        time.Duration
+}
+
+func NewWithdrawalRepo(client *mongo.Client, cfg WithdrawalRepoCfg) withdrawal.WithdrawalRepository {
+	repo := &mongoWithdrawalRepo{
+		client:         client,
+		dbName:         cfg.DBName,
+		collectionName: cfg.CollectionName,
+		timeout:        cfg.Timeout,
+	}
+
+	if indexErr := repo.createIndexes(); indexErr != nil {
+		log.Printf("Error creating withdrawals indexes: %v\n", indexErr)
+	}
+
+	return repo
+}
+
+func (r *mongoWithdrawalRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, r.timeout)
+}
+
+func (r *mongoWithdrawalRepo) getCollection() *mongo.Collection {
+	return r.client.Database(r.dbName).Collection(r.collectionName)
+}
+
+func (r *mongoWithdrawalRepo) createIndexes() error {
+	dbCtx, cancel := r.getContext(context.Background())
+	defer cancel()
+
+	_, indexErr := r.getCollection().Indexes().CreateMany(dbCtx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_uuid", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+
+	return indexErr
+}
+
+func (r *mongoWithdrawalRepo) CreateWithdrawal(ctx context.Context, withdrawal *withdrawal.Withdrawal) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	_, insertErr := r.getCollection().InsertOne(dbCtx, *withdrawal)
+	return insertErr
+}
+
+func (r *mongoWithdrawalRepo) GetWithdrawalsByStatus(ctx context.Context, status withdrawal.Status) ([]withdrawal.Withdrawal, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundedWithdrawals []withdrawal.Withdrawal
+	cursor, findErr := r.getCollection().Find(dbCtx, bson.D{{Key: "status", Value: status}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if findErr != nil {
+		return nil, fmt.Errorf("withdrawals find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedWithdrawals); decodeErr != nil {
+		return nil, fmt.Errorf("withdrawals decode error after find: %v", decodeErr)
+	}
+
+	return foundedWithdrawals, nil
+}
+
+func (r *mongoWithdrawalRepo) GetWithdrawalsByUserUuid(ctx context.Context, userUuid uuid.UUID) ([]withdrawal.Withdrawal, error) {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	var foundedWithdrawals []withdrawal.Withdrawal
+	cursor, findErr := r.getCollection().Find(dbCtx, bson.D{{Key: "user_uuid", Value: userUuid}}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if findErr != nil {
+		return nil, fmt.Errorf("withdrawals find error: %v", findErr)
+	}
+
+	if decodeErr := cursor.All(dbCtx, &foundedWithdrawals); decodeErr != nil {
+		return nil, fmt.Errorf("withdrawals decode error after find: %v", decodeErr)
+	}
+
+	return foundedWithdrawals, nil
+}
+
+func (r *mongoWithdrawalRepo) UpdateWithdrawalStatus(ctx context.Context, id uuid.UUID, from withdrawal.Status, to withdrawal.Status, errorMsg string) error {
+	dbCtx, cancel := r.getContext(ctx)
+	defer cancel()
+
+	update := bson.D{{Key: "status", Value: to}, {Key: "updated_at", Value: time.Now()}}
+	if errorMsg != "" {
+		update = append(update, bson.E{Key: "error", Value: errorMsg})
+	}
+
+	result, updErr := r.getCollection().UpdateOne(dbCtx,
+		bson.D{{Key: "_id", Value: id}, {Key: "status", Value: from}},
+		bson.D{{Key: "$set", Value: update}},
+	)
+	if updErr != nil {
+		return fmt.Errorf("error updating withdrawal %v status to %v: %v", id, to, updErr)
+	}
+
+	if result.MatchedCount == 0 {
+		return withdrawal.ErrStatusChanged
+	}
+
+	return nil
+}
+
+func (r *mongoWithdrawalRepo) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	return storage.WithTransaction(ctx, r.client, fn)
+}
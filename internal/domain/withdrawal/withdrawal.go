@@ -0,0 +1,49 @@
+package withdrawal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // user's balance is reduced, waiting for the queue
+	StatusSent      Status = "sent"      // transfer is taken by the queue, it is settled by its message on chain
+	StatusConfirmed Status = "confirmed" // transfer is in blockchain
+	StatusFailed    Status = "failed"    // transfer failed, refund is not done yet
+	StatusRefunded  Status = "refunded"  // transfer failed, ton returned to user's balance
+)
+
+type Withdrawal struct {
+	ID         uuid.UUID `bson:"_id" json:"id"`
+	UserUUID   uuid.UUID `bson:"user_uuid" json:"user_uuid"`
+	Amount     uint64    `bson:"amount" json:"amount"`
+	WithdrawTo string    `bson:"withdraw_to" json:"withdraw_to"`
+	IsTestnet  bool      `bson:"is_testnet" json:"is_testnet"`
+	Status     Status    `bson:"status" json:"status"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+func New(userUuid uuid.UUID, amount uint64, withdrawTo string, isTestnet bool) *Withdrawal {
+	now := time.Now()
+	return &Withdrawal{
+		ID:         uuid.New(),
+		UserUUID:   userUuid,
+		Amount:     amount,
+		WithdrawTo: withdrawTo,
+		IsTestnet:  isTestnet,
+		Status:     StatusPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// Comment is text of withdrawal transfer. Withdrawal id makes body of transfer message unique, so it can be found on chain
+func (w *Withdrawal) Comment() string {
+	return fmt.Sprintf("Thanks for using Build NFT tma. Withdrawal %v", w.ID)
+}
@@ -0,0 +1,48 @@
+package ton
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rom6n/create-nft-go/internal/domain/wallet"
+	"github.com/rom6n/create-nft-go/internal/storage"
+)
+
+type cachedTonApiRepo struct {
+	next   TonApiRepository
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+type TonApiCacheCfg struct {
+	TTL time.Duration // keep it short, wallet refresh gets cached items until ttl is over
+}
+
+// NewCachedTonApiRepo returns read-through cache over next repository
+func NewCachedTonApiRepo(next TonApiRepository, client redis.UniversalClient, cfg TonApiCacheCfg) TonApiRepository {
+	return &cachedTonApiRepo{
+		next:   next,
+		client: client,
+		ttl:    cfg.TTL,
+	}
+}
+
+func walletNftItemsCacheKey(walletAddress string) string {
+	return fmt.Sprintf("tonapi:wallet_nft_items:%v", walletAddress)
+}
+
+func (r *cachedTonApiRepo) GetWalletNftItems(ctx context.Context, walletAddress string) ([]wallet.NftItem, error) {
+	if cachedNftItems, ok := storage.GetCached[[]wallet.NftItem](ctx, r.client, walletNftItemsCacheKey(walletAddress)); ok {
+		return *cachedNftItems, nil
+	}
+
+	nftItems, apiErr := r.next.GetWalletNftItems(ctx, walletAddress)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	storage.SetCached(ctx, r.client, walletNftItemsCacheKey(walletAddress), nftItems, r.ttl)
+	return nftItems, nil
+}
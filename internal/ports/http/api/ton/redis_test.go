@@ -0,0 +1,84 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/redis/go-redis/v9"
+	"github.com/rom6n/create-nft-go/internal/domain/wallet"
+)
+
+type fakeTonApiRepo struct {
+	nftItems map[string][]wallet.NftItem
+	err      error
+	gets     int
+}
+
+func (r *fakeTonApiRepo) GetWalletNftItems(_ context.Context, walletAddress string) ([]wallet.NftItem, error) {
+	r.gets++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.nftItems[walletAddress], nil
+}
+
+func newTestCache(t *testing.T) (*miniredis.Miniredis, *fakeTonApiRepo, TonApiRepository) {
+	t.Helper()
+	server, runErr := miniredis.Run()
+	if runErr != nil {
+		t.Fatalf("error running miniredis: %v", runErr)
+	}
+	t.Cleanup(server.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	next := &fakeTonApiRepo{nftItems: map[string][]wallet.NftItem{}}
+	return server, next, NewCachedTonApiRepo(next, client, TonApiCacheCfg{TTL: 30 * time.Second})
+}
+
+func TestCachedTonApiRepoGetWalletNftItems(t *testing.T) {
+	server, next, repo := newTestCache(t)
+	ctx := context.Background()
+	next.nftItems["wallet"] = []wallet.NftItem{{Address: "item", Owner: "wallet"}}
+
+	for i := 0; i < 2; i++ {
+		nftItems, getErr := repo.GetWalletNftItems(ctx, "wallet")
+		if getErr != nil {
+			t.Fatalf("GetWalletNftItems() error = %v", getErr)
+		}
+		if len(nftItems) != 1 || nftItems[0].Address != "item" {
+			t.Fatalf("GetWalletNftItems() = %v", nftItems)
+		}
+	}
+	if next.gets != 1 {
+		t.Fatalf("tonapi is requested %v times, want 1", next.gets)
+	}
+	if ttl := server.TTL(walletNftItemsCacheKey("wallet")); ttl != 30*time.Second {
+		t.Fatalf("ttl = %v, want %v", ttl, 30*time.Second)
+	}
+
+	// wallet refresh gets fresh items after ttl
+	server.FastForward(30 * time.Second)
+	if _, getErr := repo.GetWalletNftItems(ctx, "wallet"); getErr != nil {
+		t.Fatalf("GetWalletNftItems() error = %v", getErr)
+	}
+	if next.gets != 2 {
+		t.Fatalf("tonapi is requested %v times after ttl, want 2", next.gets)
+	}
+}
+
+func TestCachedTonApiRepoDoesntCacheErrors(t *testing.T) {
+	server, next, repo := newTestCache(t)
+	next.err = errors.New("tonapi is unavailable")
+
+	if _, getErr := repo.GetWalletNftItems(context.Background(), "wallet"); !errors.Is(getErr, next.err) {
+		t.Fatalf("GetWalletNftItems() error = %v, want %v", getErr, next.err)
+	}
+	if len(server.Keys()) != 0 {
+		t.Fatalf("cached keys: %v", server.Keys())
+	}
+}
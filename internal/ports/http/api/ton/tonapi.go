@@ -63,7 +63,6 @@ func (r *tonapiTonApiRepo) getContext(ctx context.Context) (context.Context, con
 	return context.WithTimeout(ctx, r.timeout)
 }
 
-
 func (r *tonapiTonApiRepo) GetWalletNftItems(ctx context.Context, walletAddress string) ([]wallet.NftItem, error) {
 	apiCtx, cancel := r.getContext(ctx)
 	defer cancel()
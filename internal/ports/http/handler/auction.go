@@ -0,0 +1,311 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	auctionservice "github.com/rom6n/create-nft-go/internal/service/auction_service"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+type AuctionHandler struct {
+	AuctionService auctionservice.AuctionServiceRepository
+}
+
+// parseAuctionQuery reads filters of auctions search shared by v1 and v2:
+// ?collection-address=EQ...&seller=uuid&is-testnet=true&sort=ending_soon&offset=0&limit=20
+func parseAuctionQuery(c *fiber.Ctx) (auction.Query, error) {
+	query := auction.Query{
+		CollectionAddress: c.Query("collection-address"),
+		Sort:              auction.Sort(c.Query("sort", string(auction.SortEndingSoon))),
+		Offset:            c.QueryInt("offset", 0),
+		Limit:             c.QueryInt("limit", auction.DefaultLimit),
+	}
+
+	if seller := c.Query("seller"); seller != "" {
+		sellerUuid, parseErr := uuid.Parse(seller)
+		if parseErr != nil {
+			return query, errors.New("seller must be an uuid")
+		}
+		query.Seller = &sellerUuid
+	}
+
+	if isTest := c.Query("is-testnet"); isTest != "" {
+		isTestnet, parseBoolErr := strconv.ParseBool(isTest)
+		if parseBoolErr != nil {
+			return query, fmt.Errorf("is-testnet must be bool: %v", parseBoolErr)
+		}
+		query.IsTestnet = &isTestnet
+	}
+
+	if query.Sort != auction.SortEndingSoon && query.Sort != auction.SortNewest {
+		return query, fmt.Errorf("sort must be %v or %v", auction.SortEndingSoon, auction.SortNewest)
+	}
+
+	return query, nil
+}
+
+func (v *AuctionHandler) SearchAuctions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query, queryErr := parseAuctionQuery(c)
+		if queryErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(queryErr.Error())
+		}
+
+		auctions, searchErr := v.AuctionService.SearchAuctions(c.Context(), query)
+		if searchErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error searching auctions: %v", searchErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(auctions)
+	}
+}
+
+func (v *AuctionHandler) GetAuction() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		auctionID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Auction ID must be an uuid")
+		}
+
+		foundAuction, auctionErr := v.AuctionService.GetAuction(c.Context(), auctionID)
+		if auctionErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error getting auction: %v", auctionErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(foundAuction)
+	}
+}
+
+func (v *AuctionHandler) GetAuctionBids() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		auctionID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Auction ID must be an uuid")
+		}
+
+		bids, bidsErr := v.AuctionService.GetAuctionBids(c.Context(), auctionID)
+		if bidsErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error getting auction bids: %v", bidsErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(bids)
+	}
+}
+
+// ?nft-item-address=EQ...&start-price=1000000000&min-increment=100000000&ends-at=2025-01-02T15:04:05Z&extension-seconds=300&owner-id=123
+func (v *AuctionHandler) CreateAuction() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, c.Query("owner-id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		cfg := auction.CreateAuctionCfg{NftItemAddress: c.Query("nft-item-address")}
+		if _, parseAddrErr := address.ParseAddr(cfg.NftItemAddress); parseAddrErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("nft item is not valid address")
+		}
+
+		startPrice, parseErr := strconv.ParseUint(c.Query("start-price"), 10, 64)
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse start price to uint: %v", parseErr))
+		}
+		cfg.StartPrice = startPrice
+
+		minIncrement, parseErr := strconv.ParseUint(c.Query("min-increment"), 10, 64)
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse min increment to uint: %v", parseErr))
+		}
+		cfg.MinIncrement = minIncrement
+
+		endsAt, parseTimeErr := time.Parse(time.RFC3339, c.Query("ends-at"))
+		if parseTimeErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("ends-at must be RFC3339 time: %v", parseTimeErr))
+		}
+		cfg.EndsAt = endsAt
+
+		if extensionStr := c.Query("extension-seconds"); extensionStr != "" {
+			extension, parseIntErr := strconv.ParseInt(extensionStr, 10, 64)
+			if parseIntErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse extension seconds to int: %v", parseIntErr))
+			}
+			cfg.Extension = time.Duration(extension) * time.Second
+		}
+
+		newAuction, createErr := v.AuctionService.CreateAuction(c.Context(), cfg, ownerID)
+		if createErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error creating auction: %v", createErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(newAuction)
+	}
+}
+
+func (v *AuctionHandler) CancelAuction() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, c.Query("owner-id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		auctionID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Auction ID must be an uuid")
+		}
+
+		cancelledAuction, cancelErr := v.AuctionService.CancelAuction(c.Context(), auctionID, ownerID)
+		if cancelErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error cancelling auction: %v", cancelErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(cancelledAuction)
+	}
+}
+
+// ?amount=1000000000&owner-id=123
+func (v *AuctionHandler) PlaceBid() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bidderID, authErr := actingUserID(c, c.Query("owner-id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		auctionID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Auction ID must be an uuid")
+		}
+
+		amount, parseUintErr := strconv.ParseUint(c.Query("amount"), 10, 64)
+		if parseUintErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse amount to uint: %v", parseUintErr))
+		}
+
+		updatedAuction, bidErr := v.AuctionService.PlaceBid(c.Context(), auctionID, amount, bidderID)
+		if bidErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error placing bid: %v", bidErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(updatedAuction)
+	}
+}
+
+func (v *AuctionHandler) SearchAuctionsV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query, queryErr := parseAuctionQuery(c)
+		if queryErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, queryErr.Error(), nil)
+		}
+
+		auctions, searchErr := v.AuctionService.SearchAuctions(c.Context(), query)
+		if searchErr != nil {
+			return sendServiceErrorV2(c, searchErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"items": auctions})
+	}
+}
+
+func (v *AuctionHandler) GetAuctionV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		auctionID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "auction id must be an uuid", nil)
+		}
+
+		foundAuction, auctionErr := v.AuctionService.GetAuction(c.Context(), auctionID)
+		if auctionErr != nil {
+			return sendServiceErrorV2(c, auctionErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(foundAuction)
+	}
+}
+
+func (v *AuctionHandler) GetAuctionBidsV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		auctionID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "auction id must be an uuid", nil)
+		}
+
+		bids, bidsErr := v.AuctionService.GetAuctionBids(c.Context(), auctionID)
+		if bidsErr != nil {
+			return sendServiceErrorV2(c, bidsErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"items": bids})
+	}
+}
+
+func (v *AuctionHandler) CreateAuctionV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		var body CreateAuctionRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		newAuction, createErr := v.AuctionService.CreateAuction(c.Context(), body.cfg(), ownerID)
+		if createErr != nil {
+			return sendServiceErrorV2(c, createErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(newAuction)
+	}
+}
+
+func (v *AuctionHandler) CancelAuctionV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		auctionID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "auction id must be an uuid", nil)
+		}
+
+		cancelledAuction, cancelErr := v.AuctionService.CancelAuction(c.Context(), auctionID, ownerID)
+		if cancelErr != nil {
+			return sendServiceErrorV2(c, cancelErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(cancelledAuction)
+	}
+}
+
+func (v *AuctionHandler) PlaceBidV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bidderID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		auctionID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "auction id must be an uuid", nil)
+		}
+
+		var body PlaceBidRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		updatedAuction, bidErr := v.AuctionService.PlaceBid(c.Context(), auctionID, body.Amount, bidderID)
+		if bidErr != nil {
+			return sendServiceErrorV2(c, bidErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(updatedAuction)
+	}
+}
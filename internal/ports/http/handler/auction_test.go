@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// fakeAuctionService records arguments of the last call and answers with auction, auctions and bids or err
+type fakeAuctionService struct {
+	auction  *auction.Auction
+	auctions []auction.Auction
+	bids     []auction.Bid
+	err      error
+
+	query  auction.Query
+	cfg    auction.CreateAuctionCfg
+	id     uuid.UUID
+	amount uint64
+	userID int64
+}
+
+func (s *fakeAuctionService) CreateAuction(_ context.Context, cfg auction.CreateAuctionCfg, sellerID int64) (*auction.Auction, error) {
+	s.cfg, s.userID = cfg, sellerID
+	return s.auction, s.err
+}
+
+func (s *fakeAuctionService) CancelAuction(_ context.Context, id uuid.UUID, sellerID int64) (*auction.Auction, error) {
+	s.id, s.userID = id, sellerID
+	return s.auction, s.err
+}
+
+func (s *fakeAuctionService) PlaceBid(_ context.Context, id uuid.UUID, amount uint64, bidderID int64) (*auction.Auction, error) {
+	s.id, s.amount, s.userID = id, amount, bidderID
+	return s.auction, s.err
+}
+
+func (s *fakeAuctionService) GetAuction(_ context.Context, id uuid.UUID) (*auction.Auction, error) {
+	s.id = id
+	return s.auction, s.err
+}
+
+func (s *fakeAuctionService) GetAuctionBids(_ context.Context, id uuid.UUID) ([]auction.Bid, error) {
+	s.id = id
+	return s.bids, s.err
+}
+
+func (s *fakeAuctionService) SearchAuctions(_ context.Context, query auction.Query) ([]auction.Auction, error) {
+	s.query = query
+	return s.auctions, s.err
+}
+
+func (s *fakeAuctionService) Run(context.Context) {}
+
+func newAuctionTestApp(service *fakeAuctionService) *fiber.App {
+	h := &AuctionHandler{AuctionService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Get("/api/auction/search", h.SearchAuctions())
+		app.Get("/api/auction/:id", h.GetAuction())
+		app.Get("/api/auction/bids/:id", h.GetAuctionBids())
+		app.Post("/api/auction/create", h.CreateAuction())
+		app.Post("/api/auction/cancel/:id", h.CancelAuction())
+		app.Post("/api/auction/bid/:id", h.PlaceBid())
+
+		app.Get("/api/v2/auctions", h.SearchAuctionsV2())
+		app.Post("/api/v2/auctions", h.CreateAuctionV2())
+		app.Get("/api/v2/auctions/:id", h.GetAuctionV2())
+		app.Get("/api/v2/auctions/:id/bids", h.GetAuctionBidsV2())
+		app.Post("/api/v2/auctions/:id/cancel", h.CancelAuctionV2())
+		app.Post("/api/v2/auctions/:id/bids", h.PlaceBidV2())
+	})
+}
+
+func TestSearchAuctions(t *testing.T) {
+	service := &fakeAuctionService{auctions: []auction.Auction{{ID: uuid.New()}}}
+	app := newAuctionTestApp(service)
+	seller := uuid.New()
+
+	resp := send(t, app, http.MethodGet, "/api/auction/search?collection-address=collection&seller="+seller.String()+"&is-testnet=true&sort=newest&offset=20&limit=10", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var auctions []auction.Auction
+	resp.decode(t, &auctions)
+	if len(auctions) != 1 {
+		t.Fatalf("auctions = %v", auctions)
+	}
+
+	query := service.query
+	if query.CollectionAddress != "collection" || *query.Seller != seller || !*query.IsTestnet ||
+		query.Sort != auction.SortNewest || query.Offset != 20 || query.Limit != 10 {
+		t.Fatalf("query = %+v", query)
+	}
+}
+
+func TestSearchAuctionsDefaults(t *testing.T) {
+	service := &fakeAuctionService{}
+	app := newAuctionTestApp(service)
+
+	send(t, app, http.MethodGet, "/api/auction/search", nil).expectStatus(t, fiber.StatusOK)
+
+	if query := service.query; query.Sort != auction.SortEndingSoon || query.Limit != auction.DefaultLimit || query.Seller != nil || query.IsTestnet != nil {
+		t.Fatalf("query = %+v", query)
+	}
+}
+
+func TestSearchAuctionsV2(t *testing.T) {
+	service := &fakeAuctionService{auctions: []auction.Auction{{ID: uuid.New()}, {ID: uuid.New()}}}
+	app := newAuctionTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/v2/auctions?is-testnet=false", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var page struct {
+		Items []auction.Auction `json:"items"`
+	}
+	resp.decode(t, &page)
+	if len(page.Items) != 2 || *service.query.IsTestnet {
+		t.Fatalf("items = %v, query = %+v", page.Items, service.query)
+	}
+}
+
+func TestSearchAuctionsRejectsInvalidQuery(t *testing.T) {
+	app := newAuctionTestApp(&fakeAuctionService{})
+
+	for _, query := range []string{"seller=bob", "is-testnet=maybe", "sort=price_asc"} {
+		t.Run(query, func(t *testing.T) {
+			send(t, app, http.MethodGet, "/api/auction/search?"+query, nil).expectStatus(t, fiber.StatusBadRequest)
+			send(t, app, http.MethodGet, "/api/v2/auctions?"+query, nil).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+		})
+	}
+}
+
+func TestGetAuction(t *testing.T) {
+	id := uuid.New()
+	service := &fakeAuctionService{auction: &auction.Auction{ID: id}}
+	app := newAuctionTestApp(service)
+
+	for _, target := range []string{"/api/auction/" + id.String(), "/api/v2/auctions/" + id.String()} {
+		resp := send(t, app, http.MethodGet, target, nil)
+		resp.expectStatus(t, fiber.StatusOK)
+
+		var found auction.Auction
+		resp.decode(t, &found)
+		if found.ID != id || service.id != id {
+			t.Fatalf("%v: auction = %v, requested %v", target, found.ID, service.id)
+		}
+	}
+}
+
+func TestGetAuctionErrors(t *testing.T) {
+	service := &fakeAuctionService{err: mongo.ErrNoDocuments}
+	app := newAuctionTestApp(service)
+
+	send(t, app, http.MethodGet, "/api/auction/not-uuid", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodGet, "/api/v2/auctions/not-uuid", nil).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+
+	send(t, app, http.MethodGet, "/api/auction/"+uuid.NewString(), nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodGet, "/api/v2/auctions/"+uuid.NewString(), nil).expectError(t, fiber.StatusNotFound, CodeNotFound)
+}
+
+func TestGetAuctionBids(t *testing.T) {
+	id := uuid.New()
+	service := &fakeAuctionService{bids: []auction.Bid{{ID: uuid.New(), AuctionID: id, Amount: 5}}}
+	app := newAuctionTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/auction/bids/"+id.String(), nil)
+	resp.expectStatus(t, fiber.StatusOK)
+	var bids []auction.Bid
+	resp.decode(t, &bids)
+	if len(bids) != 1 || service.id != id {
+		t.Fatalf("bids = %v of %v", bids, service.id)
+	}
+
+	resp = send(t, app, http.MethodGet, "/api/v2/auctions/"+id.String()+"/bids", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+	var page struct {
+		Items []auction.Bid `json:"items"`
+	}
+	resp.decode(t, &page)
+	if len(page.Items) != 1 || page.Items[0].Amount != 5 {
+		t.Fatalf("items = %v", page.Items)
+	}
+
+	send(t, app, http.MethodGet, "/api/auction/bids/not-uuid", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodGet, "/api/v2/auctions/not-uuid/bids", nil).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+}
+
+func TestCreateAuction(t *testing.T) {
+	service := &fakeAuctionService{auction: &auction.Auction{ID: uuid.New()}}
+	app := newAuctionTestApp(service)
+
+	resp := send(t, app, http.MethodPost, "/api/auction/create?nft-item-address="+testNftItemAddress+
+		"&start-price=1000000000&min-increment=100000000&ends-at=2030-01-02T15:04:05Z&extension-seconds=300", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	cfg := service.cfg
+	if cfg.NftItemAddress != testNftItemAddress || cfg.StartPrice != 1000000000 || cfg.MinIncrement != 100000000 ||
+		!cfg.EndsAt.Equal(time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)) || cfg.Extension != 5*time.Minute || service.userID != testUserID {
+		t.Fatalf("cfg = %+v of user %v", cfg, service.userID)
+	}
+}
+
+func TestCreateAuctionRejectsInvalidQuery(t *testing.T) {
+	app := newAuctionTestApp(&fakeAuctionService{})
+	valid := "nft-item-address=" + testNftItemAddress + "&start-price=1&min-increment=1&ends-at=2030-01-02T15:04:05Z"
+
+	tests := map[string]struct {
+		query  string
+		status int
+	}{
+		"foreign owner":     {valid + "&owner-id=1", fiber.StatusForbidden},
+		"invalid address":   {"nft-item-address=item&start-price=1&min-increment=1&ends-at=2030-01-02T15:04:05Z", fiber.StatusBadRequest},
+		"invalid price":     {"nft-item-address=" + testNftItemAddress + "&start-price=-1&min-increment=1&ends-at=2030-01-02T15:04:05Z", fiber.StatusBadRequest},
+		"invalid ends at":   {"nft-item-address=" + testNftItemAddress + "&start-price=1&min-increment=1&ends-at=tomorrow", fiber.StatusBadRequest},
+		"invalid extension": {valid + "&extension-seconds=long", fiber.StatusBadRequest},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			send(t, app, http.MethodPost, "/api/auction/create?"+tt.query, nil).expectStatus(t, tt.status)
+		})
+	}
+
+	send(t, app, http.MethodPost, "/api/auction/create?"+valid, nil, anonymous).expectStatus(t, fiber.StatusUnauthorized)
+}
+
+func TestCreateAuctionV2(t *testing.T) {
+	service := &fakeAuctionService{auction: &auction.Auction{ID: uuid.New()}}
+	app := newAuctionTestApp(service)
+	extension := int64(60)
+
+	send(t, app, http.MethodPost, "/api/v2/auctions", CreateAuctionRequest{
+		NftItemAddress:   testNftItemAddress,
+		StartPrice:       auction.MinStartPrice,
+		MinIncrement:     1,
+		EndsAt:           time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC),
+		ExtensionSeconds: &extension,
+	}).expectStatus(t, fiber.StatusOK)
+
+	if cfg := service.cfg; cfg.NftItemAddress != testNftItemAddress || cfg.StartPrice != auction.MinStartPrice || cfg.Extension != time.Minute {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+}
+
+func TestCreateAuctionV2RejectsInvalidBody(t *testing.T) {
+	app := newAuctionTestApp(&fakeAuctionService{})
+
+	send(t, app, http.MethodPost, "/api/v2/auctions", "{").expectError(t, fiber.StatusBadRequest, CodeInvalidBody)
+
+	errResp := send(t, app, http.MethodPost, "/api/v2/auctions", CreateAuctionRequest{NftItemAddress: "item"}).
+		expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	for _, field := range []string{"nft_item_address", "start_price", "min_increment", "ends_at"} {
+		if _, ok := errResp.Details[field]; !ok {
+			t.Fatalf("details = %v, want %v", errResp.Details, field)
+		}
+	}
+
+	send(t, app, http.MethodPost, "/api/v2/auctions", CreateAuctionRequest{}, anonymous).expectError(t, fiber.StatusUnauthorized, CodeUnauthorized)
+}
+
+func TestCancelAuction(t *testing.T) {
+	id := uuid.New()
+	service := &fakeAuctionService{auction: &auction.Auction{ID: id, Status: auction.StatusCancelled}}
+	app := newAuctionTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/auction/cancel/"+id.String(), nil).expectStatus(t, fiber.StatusOK)
+	if service.id != id || service.userID != testUserID {
+		t.Fatalf("cancelled %v by %v", service.id, service.userID)
+	}
+
+	send(t, app, http.MethodPost, "/api/v2/auctions/"+id.String()+"/cancel", nil).expectStatus(t, fiber.StatusOK)
+
+	service.err = auction.ErrHasBids
+	send(t, app, http.MethodPost, "/api/auction/cancel/"+id.String(), nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodPost, "/api/v2/auctions/"+id.String()+"/cancel", nil).expectError(t, fiber.StatusConflict, CodeConflict)
+
+	send(t, app, http.MethodPost, "/api/auction/cancel/not-uuid", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/v2/auctions/not-uuid/cancel", nil).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+}
+
+func TestPlaceBid(t *testing.T) {
+	id := uuid.New()
+	service := &fakeAuctionService{auction: &auction.Auction{ID: id}}
+	app := newAuctionTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/auction/bid/"+id.String()+"?amount=2000000000", nil).expectStatus(t, fiber.StatusOK)
+	if service.id != id || service.amount != 2000000000 || service.userID != testUserID {
+		t.Fatalf("bid %v on %v by %v", service.amount, service.id, service.userID)
+	}
+
+	send(t, app, http.MethodPost, "/api/v2/auctions/"+id.String()+"/bids", PlaceBidRequest{Amount: 3000000000}).expectStatus(t, fiber.StatusOK)
+	if service.amount != 3000000000 {
+		t.Fatalf("v2 bid = %v", service.amount)
+	}
+}
+
+func TestPlaceBidErrors(t *testing.T) {
+	id := uuid.New()
+	service := &fakeAuctionService{}
+	app := newAuctionTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/auction/bid/"+id.String()+"?amount=ton", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/auction/bid/"+id.String()+"?amount=1&owner-id=1", nil).expectStatus(t, fiber.StatusForbidden)
+	send(t, app, http.MethodPost, "/api/v2/auctions/"+id.String()+"/bids", PlaceBidRequest{}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	send(t, app, http.MethodPost, "/api/v2/auctions/"+id.String()+"/bids", PlaceBidRequest{Amount: 1}, anonymous).expectError(t, fiber.StatusUnauthorized, CodeUnauthorized)
+
+	service.err = auction.ErrBidTooLow
+	send(t, app, http.MethodPost, "/api/v2/auctions/"+id.String()+"/bids", PlaceBidRequest{Amount: 1}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodPost, "/api/auction/bid/"+id.String()+"?amount=1", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+const initDataUserKey = "init_data_user"
+
+var (
+	ErrNotAuthorized = errors.New("no telegram user in init data")
+	ErrForeignUser   = errors.New("requested user is not the authorized telegram user")
+	ErrInvalidUserID = errors.New("user id must be an int")
+)
+
+// SetInitDataUser stores telegram user authorized by init data for handlers
+func SetInitDataUser(c *fiber.Ctx, user *initdata.User) {
+	c.Locals(initDataUserKey, user)
+}
+
+// InitDataUser returns telegram user authorized by init data
+func InitDataUser(c *fiber.Ctx) (*initdata.User, bool) {
+	user, ok := c.Locals(initDataUserKey).(*initdata.User)
+	return user, ok && user != nil
+}
+
+// actingUserID returns id of authorized telegram user. Requested id is optional, but it must be the same user if set
+func actingUserID(c *fiber.Ctx, requestedID string) (int64, error) {
+	user, ok := InitDataUser(c)
+	if !ok {
+		return 0, ErrNotAuthorized
+	}
+
+	if requestedID == "" {
+		return user.ID, nil
+	}
+
+	userID, parseErr := strconv.ParseInt(requestedID, 0, 64)
+	if parseErr != nil {
+		return 0, ErrInvalidUserID
+	}
+
+	if userID != user.ID {
+		return 0, ErrForeignUser
+	}
+
+	return userID, nil
+}
+
+func sendAuthError(c *fiber.Ctx, authErr error) error {
+	switch {
+	case errors.Is(authErr, ErrInvalidUserID):
+		return c.Status(fiber.StatusBadRequest).SendString("User ID must be an int")
+	case errors.Is(authErr, ErrForeignUser):
+		return c.Status(fiber.StatusForbidden).SendString("Forbidden: user id is not the authorized user")
+	default:
+		return c.Status(fiber.StatusUnauthorized).SendString("Unauthorized: no telegram user")
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/deposit"
+	depositservice "github.com/rom6n/create-nft-go/internal/service/deposit_service"
+)
+
+type DepositHandler struct {
+	DepositService depositservice.DepositServiceRepository
+}
+
+func (v *DepositHandler) GetUnmatchedDeposits() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		deposits, dbErr := v.DepositService.GetUnmatchedDeposits(ctx)
+		if dbErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while getting unmatched deposits: %v", dbErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(deposits)
+	}
+}
+
+func (v *DepositHandler) AssignDeposit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		depositID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Deposit ID must be an uuid")
+		}
+
+		userID, parseErr := strconv.ParseInt(c.Query("user-id"), 0, 64)
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("user-id is required and must be an int")
+		}
+
+		if assignErr := v.DepositService.AssignDeposit(ctx, depositID, userID); assignErr != nil {
+			if errors.Is(assignErr, deposit.ErrStatusChanged) {
+				return c.Status(fiber.StatusConflict).SendString("Deposit is not unmatched")
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while assigning deposit: %v", assignErr))
+		}
+
+		return c.Status(fiber.StatusOK).SendString("Deposit is assigned")
+	}
+}
+
+func (v *DepositHandler) RefundDeposit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		depositID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Deposit ID must be an uuid")
+		}
+
+		if refundErr := v.DepositService.RefundDeposit(ctx, depositID); refundErr != nil {
+			if errors.Is(refundErr, deposit.ErrStatusChanged) {
+				return c.Status(fiber.StatusConflict).SendString("Deposit is not unmatched")
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while refunding deposit: %v", refundErr))
+		}
+
+		return c.Status(fiber.StatusOK).SendString("Deposit is refunded")
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/deposit"
+)
+
+// fakeDepositService records arguments of the last call and answers with deposits or err
+type fakeDepositService struct {
+	deposits []deposit.Deposit
+	err      error
+
+	depositID uuid.UUID
+	userID    int64
+}
+
+func (s *fakeDepositService) ListenDeposits(context.Context) {}
+
+func (s *fakeDepositService) GetUnmatchedDeposits(context.Context) ([]deposit.Deposit, error) {
+	return s.deposits, s.err
+}
+
+func (s *fakeDepositService) AssignDeposit(_ context.Context, depositID uuid.UUID, userID int64) error {
+	s.depositID, s.userID = depositID, userID
+	return s.err
+}
+
+func (s *fakeDepositService) RefundDeposit(_ context.Context, depositID uuid.UUID) error {
+	s.depositID = depositID
+	return s.err
+}
+
+func newDepositTestApp(service *fakeDepositService) *fiber.App {
+	h := &DepositHandler{DepositService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Get("/api/support/deposits/unmatched", h.GetUnmatchedDeposits())
+		app.Post("/api/support/deposits/:id/assign", h.AssignDeposit())
+		app.Post("/api/support/deposits/:id/refund", h.RefundDeposit())
+	})
+}
+
+func TestGetUnmatchedDeposits(t *testing.T) {
+	service := &fakeDepositService{deposits: []deposit.Deposit{{ID: uuid.New(), Amount: 5}}}
+	app := newDepositTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/support/deposits/unmatched", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var deposits []deposit.Deposit
+	resp.decode(t, &deposits)
+	if len(deposits) != 1 || deposits[0].Amount != 5 {
+		t.Fatalf("deposits = %v", deposits)
+	}
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/support/deposits/unmatched", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestAssignDeposit(t *testing.T) {
+	service := &fakeDepositService{}
+	app := newDepositTestApp(service)
+	id := uuid.New()
+
+	send(t, app, http.MethodPost, "/api/support/deposits/"+id.String()+"/assign?user-id=42", nil).expectStatus(t, fiber.StatusOK)
+	if service.depositID != id || service.userID != 42 {
+		t.Fatalf("assigned %v to %v", service.depositID, service.userID)
+	}
+
+	send(t, app, http.MethodPost, "/api/support/deposits/not-uuid/assign?user-id=42", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/support/deposits/"+id.String()+"/assign", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = deposit.ErrStatusChanged
+	send(t, app, http.MethodPost, "/api/support/deposits/"+id.String()+"/assign?user-id=42", nil).expectStatus(t, fiber.StatusConflict)
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodPost, "/api/support/deposits/"+id.String()+"/assign?user-id=42", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestRefundDeposit(t *testing.T) {
+	service := &fakeDepositService{}
+	app := newDepositTestApp(service)
+	id := uuid.New()
+
+	send(t, app, http.MethodPost, "/api/support/deposits/"+id.String()+"/refund", nil).expectStatus(t, fiber.StatusOK)
+	if service.depositID != id {
+		t.Fatalf("refunded %v, want %v", service.depositID, id)
+	}
+
+	send(t, app, http.MethodPost, "/api/support/deposits/not-uuid/refund", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = deposit.ErrStatusChanged
+	send(t, app, http.MethodPost, "/api/support/deposits/"+id.String()+"/refund", nil).expectStatus(t, fiber.StatusConflict)
+
+	service.err = errors.New("wallet is unavailable")
+	send(t, app, http.MethodPost, "/api/support/deposits/"+id.String()+"/refund", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
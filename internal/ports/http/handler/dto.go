@@ -0,0 +1,438 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/utils/contentlink"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+// validationDetails collects invalid fields of request body, field name -> reason
+type validationDetails map[string]string
+
+func (d validationDetails) add(field, reason string) {
+	if _, ok := d[field]; !ok {
+		d[field] = reason
+	}
+}
+
+func (d validationDetails) address(field, value string, required bool) *address.Address {
+	if value == "" {
+		if required {
+			d.add(field, "is required")
+		}
+		return nil
+	}
+
+	parsed, parseErr := address.ParseAddr(value)
+	if parseErr != nil {
+		d.add(field, "is not valid address")
+		return nil
+	}
+
+	return parsed
+}
+
+// metadata checks metadata given in request body against TEP-64, field is path of metadata in request body
+func (d validationDetails) metadata(field string, metadata any, validate func([]byte) error) {
+	rawMetadata, marshalErr := json.Marshal(metadata)
+	if marshalErr != nil {
+		d.add(field, "is not valid metadata")
+		return
+	}
+
+	var metadataErr *tep64.Error
+	if errors.As(validate(rawMetadata), &metadataErr) {
+		for metadataField, reason := range metadataErr.Fields {
+			d.add(field+"."+metadataField, reason)
+		}
+	}
+}
+
+func (d validationDetails) isTestnet(value *bool) {
+	if value == nil {
+		d.add("is_testnet", "is required")
+	}
+}
+
+// rawBodyValidator is body which checks json keys its struct doesnt decode
+type rawBodyValidator interface {
+	validateRaw(d validationDetails, rawBody []byte)
+}
+
+// parseBodyV2 decodes json body and validates it. Error response is already sent if it returns false
+func parseBodyV2(c *fiber.Ctx, body interface{ validate(validationDetails) }) (bool, error) {
+	if parseErr := c.BodyParser(body); parseErr != nil {
+		return false, sendErrorV2(c, fiber.StatusBadRequest, CodeInvalidBody, fmt.Sprintf("request body is not valid json: %v", parseErr), nil)
+	}
+
+	details := validationDetails{}
+	body.validate(details)
+	if rawBody, ok := body.(rawBodyValidator); ok {
+		rawBody.validateRaw(details, c.Body())
+	}
+	if len(details) > 0 {
+		return false, sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "request body is not valid", details)
+	}
+
+	return true, nil
+}
+
+type DeployNftCollectionRequest struct {
+	OwnerWallet       string                               `json:"owner_wallet"`
+	CollectionContent string                               `json:"collection_content"`
+	OnchainMetadata   *nftcollection.NftCollectionMetadata `json:"onchain_metadata"`
+	HostedMetadata    *nftcollection.NftCollectionMetadata `json:"hosted_metadata"`
+	RoyaltyDividend   *uint16                              `json:"royalty_dividend"`
+	RoyaltyDivisor    *uint16                              `json:"royalty_divisor"`
+	IsTestnet         *bool                                `json:"is_testnet"`
+
+	ownerAddress *address.Address
+}
+
+func (r *DeployNftCollectionRequest) validate(d validationDetails) {
+	r.ownerAddress = d.address("owner_wallet", r.OwnerWallet, false)
+	validateCollectionContent(d, r.CollectionContent, r.OnchainMetadata, r.HostedMetadata)
+
+	if r.RoyaltyDividend == nil {
+		d.add("royalty_dividend", "is required")
+	}
+	if r.RoyaltyDivisor == nil {
+		d.add("royalty_divisor", "is required")
+	}
+	if r.RoyaltyDividend != nil && r.RoyaltyDivisor != nil {
+		validateRoyalty(d, *r.RoyaltyDividend, *r.RoyaltyDivisor)
+	}
+
+	d.isTestnet(r.IsTestnet)
+}
+
+func (r *DeployNftCollectionRequest) cfg() nftcollection.DeployCollectionCfg {
+	return nftcollection.DeployCollectionCfg{
+		OwnerAddress:      r.ownerAddress,
+		CommonContent:     "https://", // common content will always start with https://
+		CollectionContent: r.CollectionContent,
+		RoyaltyDividend:   *r.RoyaltyDividend,
+		RoyaltyDivisor:    *r.RoyaltyDivisor,
+		OnchainMetadata:   r.OnchainMetadata,
+		HostedMetadata:    r.HostedMetadata,
+	}
+}
+
+type ChangeNftCollectionContentRequest struct {
+	CollectionContent string                               `json:"collection_content"`
+	OnchainMetadata   *nftcollection.NftCollectionMetadata `json:"onchain_metadata"`
+	HostedMetadata    *nftcollection.NftCollectionMetadata `json:"hosted_metadata"`
+	RoyaltyDividend   *uint16                              `json:"royalty_dividend"` // royalty params are kept if not set
+	RoyaltyDivisor    *uint16                              `json:"royalty_divisor"`
+	RoyaltyAddress    string                               `json:"royalty_address"`
+	IsTestnet         *bool                                `json:"is_testnet"`
+
+	royaltyAddress *address.Address
+}
+
+func (r *ChangeNftCollectionContentRequest) validate(d validationDetails) {
+	validateCollectionContent(d, r.CollectionContent, r.OnchainMetadata, r.HostedMetadata)
+
+	if (r.RoyaltyDividend == nil) != (r.RoyaltyDivisor == nil) {
+		d.add("royalty_divisor", "must be changed together with royalty_dividend")
+	}
+	if r.RoyaltyDividend != nil && r.RoyaltyDivisor != nil {
+		validateRoyalty(d, *r.RoyaltyDividend, *r.RoyaltyDivisor)
+	}
+
+	r.royaltyAddress = d.address("royalty_address", r.RoyaltyAddress, false)
+	d.isTestnet(r.IsTestnet)
+}
+
+func (r *ChangeNftCollectionContentRequest) cfg() nftcollection.ChangeContentCfg {
+	return nftcollection.ChangeContentCfg{
+		CommonContent:     "https://", // common content will always start with https://
+		CollectionContent: r.CollectionContent,
+		OnchainMetadata:   r.OnchainMetadata,
+		HostedMetadata:    r.HostedMetadata,
+		RoyaltyDividend:   r.RoyaltyDividend,
+		RoyaltyDivisor:    r.RoyaltyDivisor,
+		RoyaltyAddress:    r.royaltyAddress,
+	}
+}
+
+func validateCollectionContent(d validationDetails, content string, onchainMetadata, hostedMetadata *nftcollection.NftCollectionMetadata) {
+	if onchainMetadata == nil && hostedMetadata == nil && content == "" {
+		d.add("collection_content", "collection content link, onchain or hosted metadata is required")
+	}
+	if content != "" && !contentlink.IsSupported(content) {
+		d.add("collection_content", "must be https, ipfs or tonstorage link")
+	}
+	if onchainMetadata != nil && hostedMetadata != nil {
+		d.add("hosted_metadata", "cant be set together with onchain_metadata")
+	}
+	if onchainMetadata != nil {
+		d.metadata("onchain_metadata", onchainMetadata, tep64.ValidateNftCollectionMetadata)
+	}
+	if hostedMetadata != nil {
+		d.metadata("hosted_metadata", hostedMetadata, tep64.ValidateNftCollectionMetadata)
+	}
+}
+
+func validateRoyalty(d validationDetails, dividend, divisor uint16) {
+	if divisor == 0 {
+		d.add("royalty_divisor", "must be greater than zero")
+	} else if dividend > divisor {
+		d.add("royalty_dividend", "must not be greater than royalty_divisor")
+	}
+}
+
+type MintNftItemRequest struct {
+	NftCollectionAddress string                   `json:"nft_collection_address"`
+	OwnerWallet          string                   `json:"owner_wallet"`
+	Content              string                   `json:"content"`
+	HostedMetadata       *nftitem.NftItemMetadata `json:"hosted_metadata"`
+	ForwardAmount        uint64                   `json:"forward_amount"`
+	ForwardMessage       string                   `json:"forward_message"`
+	IsTestnet            *bool                    `json:"is_testnet"`
+
+	nftCollectionAddress *address.Address
+	ownerAddress         *address.Address
+}
+
+func (r *MintNftItemRequest) validate(d validationDetails) {
+	r.nftCollectionAddress = d.address("nft_collection_address", r.NftCollectionAddress, true)
+	r.ownerAddress = d.address("owner_wallet", r.OwnerWallet, false)
+
+	validateItemContent(d, "", r.Content, r.HostedMetadata)
+
+	d.isTestnet(r.IsTestnet)
+}
+
+func (r *MintNftItemRequest) validateRaw(d validationDetails, rawBody []byte) {
+	rejectOnchainItemMetadata(d, rawBody)
+}
+
+func (r *MintNftItemRequest) cfg() nftitem.MintNftItemCfg {
+	return nftitem.MintNftItemCfg{
+		OwnerAddress:   r.ownerAddress,
+		Content:        r.Content,
+		ForwardAmount:  r.ForwardAmount,
+		ForwardMessage: r.ForwardMessage,
+		HostedMetadata: r.HostedMetadata,
+	}
+}
+
+// onchainItemMetadataReason explains why onchain_metadata of nft items is rejected
+const onchainItemMetadataReason = "isnt supported for nft items: standard nft collection returns item content only as link of its common content, use hosted_metadata"
+
+// onchainItemMetadataFields returns paths of onchain_metadata in body of nft item mint or batch mint
+func onchainItemMetadataFields(rawBody []byte) []string {
+	var body struct {
+		OnchainMetadata json.RawMessage `json:"onchain_metadata"`
+		Items           []struct {
+			OnchainMetadata json.RawMessage `json:"onchain_metadata"`
+		} `json:"items"`
+	}
+	// body which isnt json is reported by body parser
+	if unmarshErr := json.Unmarshal(rawBody, &body); unmarshErr != nil {
+		return nil
+	}
+
+	var fields []string
+	if body.OnchainMetadata != nil {
+		fields = append(fields, "onchain_metadata")
+	}
+	for i, item := range body.Items {
+		if item.OnchainMetadata != nil {
+			fields = append(fields, fmt.Sprintf("items[%v].onchain_metadata", i))
+		}
+	}
+	return fields
+}
+
+func rejectOnchainItemMetadata(d validationDetails, rawBody []byte) {
+	for _, field := range onchainItemMetadataFields(rawBody) {
+		d.add(field, onchainItemMetadataReason)
+	}
+}
+
+// validateItemContent checks content of nft item, prefix is path of the item in request body
+func validateItemContent(d validationDetails, prefix string, content string, hostedMetadata *nftitem.NftItemMetadata) {
+	if hostedMetadata == nil && content == "" {
+		d.add(prefix+"content", "content link or hosted metadata is required")
+	}
+	// http content cant follow https:// common content of collection
+	if content != "" && (!contentlink.IsSupported(content) || strings.HasPrefix(content, contentlink.SchemeHTTP)) {
+		d.add(prefix+"content", "must be https, ipfs or tonstorage link")
+	}
+	if hostedMetadata != nil {
+		d.metadata(prefix+"hosted_metadata", hostedMetadata, tep64.ValidateNftItemMetadata)
+	}
+}
+
+// BatchMintItems is body of v1 batch mint, its other parameters are in query
+type BatchMintItems struct {
+	Items []nftitem.BatchMintItem `json:"items"`
+}
+
+type BatchMintNftItemsRequest struct {
+	NftCollectionAddress string                  `json:"nft_collection_address"`
+	OwnerWallet          string                  `json:"owner_wallet"`
+	Items                []nftitem.BatchMintItem `json:"items"`
+	IsTestnet            *bool                   `json:"is_testnet"`
+
+	nftCollectionAddress *address.Address
+	ownerAddress         *address.Address
+}
+
+func (r *BatchMintNftItemsRequest) validate(d validationDetails) {
+	r.nftCollectionAddress = d.address("nft_collection_address", r.NftCollectionAddress, true)
+	r.ownerAddress = d.address("owner_wallet", r.OwnerWallet, false)
+
+	if len(r.Items) == 0 || len(r.Items) > nftitem.MaxBatchMintItems {
+		d.add("items", fmt.Sprintf("from 1 to %v items are required", nftitem.MaxBatchMintItems))
+	}
+	for i, item := range r.Items {
+		validateItemContent(d, fmt.Sprintf("items[%v].", i), item.Content, item.HostedMetadata)
+	}
+
+	d.isTestnet(r.IsTestnet)
+}
+
+func (r *BatchMintNftItemsRequest) validateRaw(d validationDetails, rawBody []byte) {
+	rejectOnchainItemMetadata(d, rawBody)
+}
+
+func (r *BatchMintNftItemsRequest) cfg() nftitem.BatchMintNftItemsCfg {
+	return nftitem.BatchMintNftItemsCfg{
+		OwnerAddress: r.ownerAddress,
+		Items:        r.Items,
+	}
+}
+
+// WithdrawNftRequest is body of nft collection and nft item withdraws
+type WithdrawNftRequest struct {
+	WithdrawTo string `json:"withdraw_to"`
+	IsTestnet  *bool  `json:"is_testnet"`
+
+	withdrawTo *address.Address
+}
+
+func (r *WithdrawNftRequest) validate(d validationDetails) {
+	r.withdrawTo = d.address("withdraw_to", r.WithdrawTo, true)
+	d.isTestnet(r.IsTestnet)
+}
+
+type TransferNftItemRequest struct {
+	ToUserID int64 `json:"to_user_id"` // telegram id of recipient
+}
+
+func (r *TransferNftItemRequest) validate(d validationDetails) {
+	if r.ToUserID <= 0 {
+		d.add("to_user_id", "must be telegram id of user")
+	}
+}
+
+type CreateListingRequest struct {
+	NftItemAddress string `json:"nft_item_address"`
+	Price          uint64 `json:"price"` // nano ton
+}
+
+func (r *CreateListingRequest) validate(d validationDetails) {
+	d.address("nft_item_address", r.NftItemAddress, true)
+	if r.Price < listing.MinPrice {
+		d.add("price", fmt.Sprintf("must be at least %v nano ton", listing.MinPrice))
+	}
+}
+
+type CreateAuctionRequest struct {
+	NftItemAddress   string    `json:"nft_item_address"`
+	StartPrice       uint64    `json:"start_price"`   // nano ton
+	MinIncrement     uint64    `json:"min_increment"` // nano ton
+	EndsAt           time.Time `json:"ends_at"`
+	ExtensionSeconds *int64    `json:"extension_seconds"` // anti-sniping extension, default is used if omitted
+}
+
+func (r *CreateAuctionRequest) validate(d validationDetails) {
+	d.address("nft_item_address", r.NftItemAddress, true)
+	if r.StartPrice < auction.MinStartPrice {
+		d.add("start_price", fmt.Sprintf("must be at least %v nano ton", auction.MinStartPrice))
+	}
+	if r.MinIncrement == 0 {
+		d.add("min_increment", "must be greater than zero")
+	}
+	if r.EndsAt.IsZero() {
+		d.add("ends_at", "is required")
+	}
+	if r.ExtensionSeconds != nil && (*r.ExtensionSeconds < 0 || time.Duration(*r.ExtensionSeconds)*time.Second > auction.MaxExtension) {
+		d.add("extension_seconds", fmt.Sprintf("must be from 0 to %v", int64(auction.MaxExtension.Seconds())))
+	}
+}
+
+func (r *CreateAuctionRequest) cfg() auction.CreateAuctionCfg {
+	cfg := auction.CreateAuctionCfg{
+		NftItemAddress: r.NftItemAddress,
+		StartPrice:     r.StartPrice,
+		MinIncrement:   r.MinIncrement,
+		EndsAt:         r.EndsAt,
+	}
+	if r.ExtensionSeconds != nil {
+		cfg.Extension = time.Duration(*r.ExtensionSeconds) * time.Second
+	}
+	return cfg
+}
+
+type PlaceBidRequest struct {
+	Amount uint64 `json:"amount"` // nano ton
+}
+
+func (r *PlaceBidRequest) validate(d validationDetails) {
+	if r.Amount == 0 {
+		d.add("amount", "must be greater than zero")
+	}
+}
+
+type WithdrawTonRequest struct {
+	WithdrawTo string `json:"withdraw_to"`
+	Amount     uint64 `json:"amount"` // nano ton
+	IsTestnet  *bool  `json:"is_testnet"`
+
+	withdrawTo *address.Address
+}
+
+func (r *WithdrawTonRequest) validate(d validationDetails) {
+	r.withdrawTo = d.address("withdraw_to", r.WithdrawTo, true)
+	if r.Amount == 0 {
+		d.add("amount", "must be greater than zero")
+	}
+	d.isTestnet(r.IsTestnet)
+}
+
+type DeployMarketRequest struct {
+	IsTestnet *bool `json:"is_testnet"`
+}
+
+func (r *DeployMarketRequest) validate(d validationDetails) {
+	d.isTestnet(r.IsTestnet)
+}
+
+// MarketTonRequest is body of marketplace contract deposit and withdraw
+type MarketTonRequest struct {
+	Amount    uint64 `json:"amount"`  // nano ton
+	Message   string `json:"message"` // only for withdraw
+	IsTestnet *bool  `json:"is_testnet"`
+}
+
+func (r *MarketTonRequest) validate(d validationDetails) {
+	if r.Amount == 0 {
+		d.add("amount", "must be greater than zero")
+	}
+	d.isTestnet(r.IsTestnet)
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/deposit"
+	hostedmetadata "github.com/rom6n/create-nft-go/internal/domain/hosted_metadata"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"github.com/rom6n/create-nft-go/internal/domain/withdrawal"
+	roleservice "github.com/rom6n/create-nft-go/internal/service/role_service"
+	"github.com/rom6n/create-nft-go/internal/utils/contentlink"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// error codes of v2 api
+const (
+	CodeInvalidBody          = "invalid_body"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotEnoughBalance     = "not_enough_balance"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeBadGateway           = "bad_gateway"
+	CodeInternal             = "internal_error"
+)
+
+// ErrorResponse is error envelope of v2 api
+type ErrorResponse struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details,omitempty"` // invalid fields of request body
+}
+
+func sendErrorV2(c *fiber.Ctx, status int, code, message string, details map[string]string) error {
+	return c.Status(status).JSON(ErrorResponse{
+		Code:    code,
+		Message: message,
+		Details: details,
+	})
+}
+
+// sendServiceErrorV2 maps domain errors of services to http status and error code
+func sendServiceErrorV2(c *fiber.Ctx, err error) error {
+	var metadataErr *tep64.Error
+	switch {
+	case errors.As(err, &metadataErr):
+		return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, tep64.ErrInvalidMetadata.Error(), metadataErr.Fields)
+	case errors.Is(err, ErrInvalidUserID):
+		return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, err.Error(), nil)
+	case errors.Is(err, ErrNotAuthorized):
+		return sendErrorV2(c, fiber.StatusUnauthorized, CodeUnauthorized, err.Error(), nil)
+	case errors.Is(err, ErrForeignUser),
+		errors.Is(err, nftcollection.ErrNotCollectionOwner),
+		errors.Is(err, nftitem.ErrNotItemOwner),
+		errors.Is(err, listing.ErrNotSeller),
+		errors.Is(err, auction.ErrNotSeller),
+		errors.Is(err, roleservice.ErrOwnRoleChange):
+		return sendErrorV2(c, fiber.StatusForbidden, CodeForbidden, err.Error(), nil)
+	case errors.Is(err, ledger.ErrNotEnoughBalance):
+		return sendErrorV2(c, fiber.StatusPaymentRequired, CodeNotEnoughBalance, err.Error(), nil)
+	case errors.Is(err, nftitem.ErrRecipientNotFound):
+		return sendErrorV2(c, fiber.StatusNotFound, CodeNotFound, err.Error(), nil)
+	case errors.Is(err, nftitem.ErrCollectionNotFound), errors.Is(err, mongo.ErrNoDocuments):
+		return sendErrorV2(c, fiber.StatusNotFound, CodeNotFound, "not found", nil)
+	case errors.Is(err, nftcollection.ErrNotCustodial),
+		errors.Is(err, nftitem.ErrNotCustodial),
+		errors.Is(err, nftitem.ErrWithdrawPending),
+		errors.Is(err, listing.ErrAlreadyListed),
+		errors.Is(err, listing.ErrNotActive),
+		errors.Is(err, auction.ErrAlreadyOnAuction),
+		errors.Is(err, auction.ErrNotActive),
+		errors.Is(err, auction.ErrEnded),
+		errors.Is(err, auction.ErrHasBids),
+		errors.Is(err, auction.ErrOutbid),
+		errors.Is(err, ledger.ErrEntryAlreadyApplied),
+		errors.Is(err, operation.ErrStatusChanged),
+		errors.Is(err, withdrawal.ErrStatusChanged),
+		errors.Is(err, deposit.ErrStatusChanged),
+		errors.Is(err, deposit.ErrAlreadyProcessed):
+		return sendErrorV2(c, fiber.StatusConflict, CodeConflict, err.Error(), nil)
+	case errors.Is(err, nftitem.ErrInvalidBatchSize),
+		errors.Is(err, nftitem.ErrSelfTransfer),
+		errors.Is(err, listing.ErrOwnListing),
+		errors.Is(err, listing.ErrInvalidPrice),
+		errors.Is(err, auction.ErrOwnAuction),
+		errors.Is(err, auction.ErrBidTooLow),
+		errors.Is(err, auction.ErrInvalidParams),
+		errors.Is(err, hostedmetadata.ErrTooLarge),
+		errors.Is(err, media.ErrInvalidKind),
+		errors.Is(err, media.ErrUnsupportedType),
+		errors.Is(err, media.ErrTooLarge),
+		errors.Is(err, media.ErrInvalidDimensions),
+		errors.Is(err, contentlink.ErrUnsupportedLink),
+		errors.Is(err, contentlink.ErrBlockedAddress),
+		errors.Is(err, user.ErrUnknownRole),
+		errors.Is(err, user.ErrInvalidDepositMemo):
+		return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, err.Error(), nil)
+	case errors.Is(err, contentlink.ErrFetchFailed):
+		// metadata host or gateway is unavailable, request can be retried later
+		return sendErrorV2(c, fiber.StatusBadGateway, CodeBadGateway, err.Error(), nil)
+	default:
+		// internal errors can contain details of infrastructure, they are only logged
+		log.Printf("%v %v: %v\n", c.Method(), c.Path(), err)
+		return sendErrorV2(c, fiber.StatusInternalServerError, CodeInternal, "internal server error", nil)
+	}
+}
+
+// SendError sends error envelope on v2 api and plain text on v1 api, it is used by middlewares shared by both
+func SendError(c *fiber.Ctx, status int, code, message string) error {
+	if strings.HasPrefix(c.Path(), "/api/v2/") {
+		return sendErrorV2(c, status, code, message, nil)
+	}
+	return c.Status(status).SendString(message)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/utils/contentlink"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestSendServiceErrorV2(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{&tep64.Error{Fields: map[string]string{"name": "is required"}}, fiber.StatusBadRequest, CodeValidationFailed},
+		{ErrNotAuthorized, fiber.StatusUnauthorized, CodeUnauthorized},
+		{ErrForeignUser, fiber.StatusForbidden, CodeForbidden},
+		{fmt.Errorf("wrapped: %w", nftitem.ErrNotItemOwner), fiber.StatusForbidden, CodeForbidden},
+		{ledger.ErrNotEnoughBalance, fiber.StatusPaymentRequired, CodeNotEnoughBalance},
+		{mongo.ErrNoDocuments, fiber.StatusNotFound, CodeNotFound},
+		{listing.ErrAlreadyListed, fiber.StatusConflict, CodeConflict},
+		{nftitem.ErrWithdrawPending, fiber.StatusConflict, CodeConflict},
+		{auction.ErrBidTooLow, fiber.StatusBadRequest, CodeValidationFailed},
+		{contentlink.ErrFetchFailed, fiber.StatusBadGateway, CodeBadGateway},
+		{errors.New("mongo is unavailable"), fiber.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			app := newTestApp(func(app *fiber.App) {
+				app.Get("/api/v2/error", func(c *fiber.Ctx) error { return sendServiceErrorV2(c, tt.err) })
+			})
+
+			errResp := send(t, app, http.MethodGet, "/api/v2/error", nil).expectError(t, tt.status, tt.code)
+			if tt.code == CodeInternal && errResp.Message != "internal server error" {
+				t.Fatalf("internal error message = %v, details of infrastructure must not be sent", errResp.Message)
+			}
+		})
+	}
+}
+
+func TestSendServiceErrorV2SendsMetadataFields(t *testing.T) {
+	app := newTestApp(func(app *fiber.App) {
+		app.Get("/api/v2/error", func(c *fiber.Ctx) error {
+			return sendServiceErrorV2(c, &tep64.Error{Fields: map[string]string{"image": "must be a link"}})
+		})
+	})
+
+	errResp := send(t, app, http.MethodGet, "/api/v2/error", nil).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	if errResp.Details["image"] != "must be a link" {
+		t.Fatalf("details = %v", errResp.Details)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	app := newTestApp(func(app *fiber.App) {
+		forbidden := func(c *fiber.Ctx) error { return SendError(c, fiber.StatusForbidden, CodeForbidden, "Forbidden") }
+		app.Get("/api/user/1", forbidden)
+		app.Get("/api/v2/user/royalty-earnings", forbidden)
+	})
+
+	v1 := send(t, app, http.MethodGet, "/api/user/1", nil)
+	v1.expectStatus(t, fiber.StatusForbidden)
+	if string(v1.body) != "Forbidden" {
+		t.Fatalf("v1 body = %s, want plain text", v1.body)
+	}
+
+	send(t, app, http.MethodGet, "/api/v2/user/royalty-earnings", nil).expectError(t, fiber.StatusForbidden, CodeForbidden)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+)
+
+// telegram user requests of test app are authorized as
+const testUserID int64 = 123
+
+const (
+	testNftCollectionAddress = "EQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAM9c"
+	testNftItemAddress       = "EQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAd99"
+	testWalletAddress        = "EQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAu8e"
+)
+
+// anonymous is header of requests which have no telegram user
+var anonymous = map[string]string{"X-Test-Anonymous": "true"}
+
+// newTestApp returns app with routes of register. Requests are authorized as telegram user testUserID
+// the way StrictOriginMiddleware does it, unless they are anonymous
+func newTestApp(register func(app *fiber.App)) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if c.Get("X-Test-Anonymous") == "" {
+			SetInitDataUser(c, &initdata.User{ID: testUserID})
+		}
+		return c.Next()
+	})
+	register(app)
+	return app
+}
+
+type testResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// send sends request to app. Body is sent as it is if it is string, otherwise it is sent as json
+func send(t *testing.T, app *fiber.App, method, target string, body any, headers ...map[string]string) testResponse {
+	t.Helper()
+
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(body)
+	default:
+		rawBody, marshalErr := json.Marshal(body)
+		if marshalErr != nil {
+			t.Fatalf("error encoding request body: %v", marshalErr)
+		}
+		reader = bytes.NewReader(rawBody)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	for _, header := range headers {
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+	}
+
+	resp, testErr := app.Test(req, -1)
+	if testErr != nil {
+		t.Fatalf("%v %v: %v", method, target, testErr)
+	}
+	defer resp.Body.Close()
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		t.Fatalf("error reading response of %v %v: %v", method, target, readErr)
+	}
+
+	return testResponse{status: resp.StatusCode, header: resp.Header, body: respBody}
+}
+
+func (r testResponse) expectStatus(t *testing.T, status int) {
+	t.Helper()
+	if r.status != status {
+		t.Fatalf("status = %v, want %v, body: %s", r.status, status, r.body)
+	}
+}
+
+func (r testResponse) decode(t *testing.T, v any) {
+	t.Helper()
+	if decodeErr := json.Unmarshal(r.body, v); decodeErr != nil {
+		t.Fatalf("error decoding response %s: %v", r.body, decodeErr)
+	}
+}
+
+// expectError checks error envelope of v2 api
+func (r testResponse) expectError(t *testing.T, status int, code string) ErrorResponse {
+	t.Helper()
+	r.expectStatus(t, status)
+
+	var errResp ErrorResponse
+	r.decode(t, &errResp)
+	if errResp.Code != code {
+		t.Fatalf("error code = %v, want %v, message: %v", errResp.Code, code, errResp.Message)
+	}
+	return errResp
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	hostedmetadataservice "github.com/rom6n/create-nft-go/internal/service/hosted_metadata_service"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type HostedMetadataHandler struct {
+	HostedMetadataService hostedmetadataservice.HostedMetadataServiceRepository
+}
+
+// GetMetadataDocument serves hosted metadata json to wallets, explorers and marketplaces.
+// Document never changes under its hash, so it is cached forever
+func (v *HostedMetadataHandler) GetMetadataDocument() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		hash := c.Params("hash")
+		if decoded, decodeErr := hex.DecodeString(hash); decodeErr != nil || len(decoded) != 32 {
+			return c.Status(fiber.StatusBadRequest).SendString("hash must be sha256 hex")
+		}
+
+		etag := `"` + hash + `"`
+		c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+		c.Set(fiber.HeaderETag, etag)
+		if c.Get(fiber.HeaderIfNoneMatch) == etag {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
+		document, documentErr := v.HostedMetadataService.GetDocument(c.Context(), hash)
+		if documentErr != nil {
+			c.Set(fiber.HeaderCacheControl, "no-store")
+			if errors.Is(documentErr, mongo.ErrNoDocuments) {
+				return c.Status(fiber.StatusNotFound).SendString("metadata document not found")
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error getting metadata document: %v", documentErr))
+		}
+
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Status(fiber.StatusOK).Send(document.Content)
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	hostedmetadata "github.com/rom6n/create-nft-go/internal/domain/hosted_metadata"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// fakeHostedMetadataService records the last requested hash and answers with document or err
+type fakeHostedMetadataService struct {
+	document *hostedmetadata.Document
+	err      error
+
+	hash string
+}
+
+func (s *fakeHostedMetadataService) HostNftCollectionMetadata(context.Context, *nftcollection.NftCollectionMetadata) (string, error) {
+	return "", s.err
+}
+
+func (s *fakeHostedMetadataService) HostNftItemMetadata(context.Context, *nftitem.NftItemMetadata) (string, error) {
+	return "", s.err
+}
+
+func (s *fakeHostedMetadataService) GetDocument(_ context.Context, hash string) (*hostedmetadata.Document, error) {
+	s.hash = hash
+	return s.document, s.err
+}
+
+func newHostedMetadataTestApp(service *fakeHostedMetadataService) *fiber.App {
+	h := &HostedMetadataHandler{HostedMetadataService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Get("/api/metadata/:hash", h.GetMetadataDocument())
+	})
+}
+
+func TestGetMetadataDocument(t *testing.T) {
+	hash := strings.Repeat("cd", 32)
+	service := &fakeHostedMetadataService{document: &hostedmetadata.Document{Hash: hash, Content: []byte(`{"name":"item"}`)}}
+	app := newHostedMetadataTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/metadata/"+hash, nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var metadata nftitem.NftItemMetadata
+	resp.decode(t, &metadata)
+	if metadata.Name != "item" || service.hash != hash || resp.header.Get(fiber.HeaderContentType) != fiber.MIMEApplicationJSON {
+		t.Fatalf("metadata = %+v of %v, content type %v", metadata, service.hash, resp.header.Get(fiber.HeaderContentType))
+	}
+
+	etag := resp.header.Get(fiber.HeaderETag)
+	if etag != `"`+hash+`"` {
+		t.Fatalf("etag = %v", etag)
+	}
+	service.hash = ""
+	send(t, app, http.MethodGet, "/api/metadata/"+hash, nil, map[string]string{fiber.HeaderIfNoneMatch: etag}).expectStatus(t, fiber.StatusNotModified)
+	if service.hash != "" {
+		t.Fatalf("document is read for not modified request")
+	}
+}
+
+func TestGetMetadataDocumentErrors(t *testing.T) {
+	hash := strings.Repeat("cd", 32)
+	service := &fakeHostedMetadataService{}
+	app := newHostedMetadataTestApp(service)
+
+	send(t, app, http.MethodGet, "/api/metadata/item.json", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = mongo.ErrNoDocuments
+	resp := send(t, app, http.MethodGet, "/api/metadata/"+hash, nil)
+	resp.expectStatus(t, fiber.StatusNotFound)
+	if resp.header.Get(fiber.HeaderCacheControl) != "no-store" {
+		t.Fatalf("missing document is cached: %v", resp.header.Get(fiber.HeaderCacheControl))
+	}
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/metadata/"+hash, nil).expectStatus(t, fiber.StatusInternalServerError)
+}
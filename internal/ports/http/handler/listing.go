@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	listingservice "github.com/rom6n/create-nft-go/internal/service/listing_service"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+type ListingHandler struct {
+	ListingService listingservice.ListingServiceRepository
+}
+
+// parseListingQuery reads filters of listings search shared by v1 and v2:
+// ?collection-address=EQ...&seller=uuid&is-testnet=true&min-price=0&max-price=0&sort=price_asc&offset=0&limit=20
+func parseListingQuery(c *fiber.Ctx) (listing.Query, error) {
+	query := listing.Query{
+		CollectionAddress: c.Query("collection-address"),
+		Sort:              listing.Sort(c.Query("sort", string(listing.SortNewest))),
+		Offset:            c.QueryInt("offset", 0),
+		Limit:             c.QueryInt("limit", listing.DefaultLimit),
+	}
+
+	if seller := c.Query("seller"); seller != "" {
+		sellerUuid, parseErr := uuid.Parse(seller)
+		if parseErr != nil {
+			return query, errors.New("seller must be an uuid")
+		}
+		query.Seller = &sellerUuid
+	}
+
+	if isTest := c.Query("is-testnet"); isTest != "" {
+		isTestnet, parseBoolErr := strconv.ParseBool(isTest)
+		if parseBoolErr != nil {
+			return query, fmt.Errorf("is-testnet must be bool: %v", parseBoolErr)
+		}
+		query.IsTestnet = &isTestnet
+	}
+
+	for key, target := range map[string]*uint64{"min-price": &query.MinPrice, "max-price": &query.MaxPrice} {
+		if value := c.Query(key); value != "" {
+			price, parseErr := strconv.ParseUint(value, 10, 64)
+			if parseErr != nil {
+				return query, fmt.Errorf("%v must be nano ton", key)
+			}
+			*target = price
+		}
+	}
+
+	if query.Sort != listing.SortNewest && query.Sort != listing.SortPriceAsc && query.Sort != listing.SortPriceDesc {
+		return query, fmt.Errorf("sort must be %v, %v or %v", listing.SortNewest, listing.SortPriceAsc, listing.SortPriceDesc)
+	}
+
+	return query, nil
+}
+
+func (v *ListingHandler) SearchListings() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query, queryErr := parseListingQuery(c)
+		if queryErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(queryErr.Error())
+		}
+
+		listings, searchErr := v.ListingService.SearchListings(c.Context(), query)
+		if searchErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error searching listings: %v", searchErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(listings)
+	}
+}
+
+func (v *ListingHandler) GetListing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		listingID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Listing ID must be an uuid")
+		}
+
+		foundListing, listingErr := v.ListingService.GetListing(c.Context(), listingID)
+		if listingErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error getting listing: %v", listingErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(foundListing)
+	}
+}
+
+// ?nft-item-address=EQ...&price=1000000000&owner-id=123
+func (v *ListingHandler) CreateListing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		nftItemAddress, priceStr, ownerIDStr := c.Query("nft-item-address"), c.Query("price"), c.Query("owner-id")
+		ownerID, authErr := actingUserID(c, ownerIDStr)
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		// nft items are stored by address in the form api returns them
+		if _, parseAddrErr := address.ParseAddr(nftItemAddress); parseAddrErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("nft item is not valid address")
+		}
+
+		price, parseErr := strconv.ParseUint(priceStr, 10, 64)
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse price to uint: %v", parseErr))
+		}
+
+		newListing, createErr := v.ListingService.CreateListing(c.Context(), nftItemAddress, price, ownerID)
+		if createErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error creating listing: %v", createErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(newListing)
+	}
+}
+
+func (v *ListingHandler) CancelListing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, c.Query("owner-id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		listingID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Listing ID must be an uuid")
+		}
+
+		cancelledListing, cancelErr := v.ListingService.CancelListing(c.Context(), listingID, ownerID)
+		if cancelErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error cancelling listing: %v", cancelErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(cancelledListing)
+	}
+}
+
+func (v *ListingHandler) BuyListing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		buyerID, authErr := actingUserID(c, c.Query("owner-id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		listingID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Listing ID must be an uuid")
+		}
+
+		soldListing, buyErr := v.ListingService.BuyListing(c.Context(), listingID, buyerID)
+		if buyErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error buying listing: %v", buyErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(soldListing)
+	}
+}
+
+func (v *ListingHandler) SearchListingsV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		query, queryErr := parseListingQuery(c)
+		if queryErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, queryErr.Error(), nil)
+		}
+
+		listings, searchErr := v.ListingService.SearchListings(c.Context(), query)
+		if searchErr != nil {
+			return sendServiceErrorV2(c, searchErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"items": listings})
+	}
+}
+
+func (v *ListingHandler) GetListingV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		listingID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "listing id must be an uuid", nil)
+		}
+
+		foundListing, listingErr := v.ListingService.GetListing(c.Context(), listingID)
+		if listingErr != nil {
+			return sendServiceErrorV2(c, listingErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(foundListing)
+	}
+}
+
+func (v *ListingHandler) CreateListingV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		var body CreateListingRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		newListing, createErr := v.ListingService.CreateListing(c.Context(), body.NftItemAddress, body.Price, ownerID)
+		if createErr != nil {
+			return sendServiceErrorV2(c, createErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(newListing)
+	}
+}
+
+func (v *ListingHandler) CancelListingV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		listingID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "listing id must be an uuid", nil)
+		}
+
+		cancelledListing, cancelErr := v.ListingService.CancelListing(c.Context(), listingID, ownerID)
+		if cancelErr != nil {
+			return sendServiceErrorV2(c, cancelErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(cancelledListing)
+	}
+}
+
+func (v *ListingHandler) BuyListingV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		buyerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		listingID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "listing id must be an uuid", nil)
+		}
+
+		soldListing, buyErr := v.ListingService.BuyListing(c.Context(), listingID, buyerID)
+		if buyErr != nil {
+			return sendServiceErrorV2(c, buyErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(soldListing)
+	}
+}
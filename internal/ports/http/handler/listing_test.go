@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// fakeListingService records arguments of the last call and answers with listing and listings or err
+type fakeListingService struct {
+	listing  *listing.Listing
+	listings []listing.Listing
+	err      error
+
+	query          listing.Query
+	nftItemAddress string
+	price          uint64
+	id             uuid.UUID
+	userID         int64
+}
+
+func (s *fakeListingService) CreateListing(_ context.Context, nftItemAddress string, price uint64, sellerID int64) (*listing.Listing, error) {
+	s.nftItemAddress, s.price, s.userID = nftItemAddress, price, sellerID
+	return s.listing, s.err
+}
+
+func (s *fakeListingService) CancelListing(_ context.Context, id uuid.UUID, sellerID int64) (*listing.Listing, error) {
+	s.id, s.userID = id, sellerID
+	return s.listing, s.err
+}
+
+func (s *fakeListingService) BuyListing(_ context.Context, id uuid.UUID, buyerID int64) (*listing.Listing, error) {
+	s.id, s.userID = id, buyerID
+	return s.listing, s.err
+}
+
+func (s *fakeListingService) GetListing(_ context.Context, id uuid.UUID) (*listing.Listing, error) {
+	s.id = id
+	return s.listing, s.err
+}
+
+func (s *fakeListingService) SearchListings(_ context.Context, query listing.Query) ([]listing.Listing, error) {
+	s.query = query
+	return s.listings, s.err
+}
+
+func newListingTestApp(service *fakeListingService) *fiber.App {
+	h := &ListingHandler{ListingService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Get("/api/listing/search", h.SearchListings())
+		app.Get("/api/listing/:id", h.GetListing())
+		app.Post("/api/listing/create", h.CreateListing())
+		app.Post("/api/listing/cancel/:id", h.CancelListing())
+		app.Post("/api/listing/buy/:id", h.BuyListing())
+
+		app.Get("/api/v2/listings", h.SearchListingsV2())
+		app.Post("/api/v2/listings", h.CreateListingV2())
+		app.Get("/api/v2/listings/:id", h.GetListingV2())
+		app.Post("/api/v2/listings/:id/cancel", h.CancelListingV2())
+		app.Post("/api/v2/listings/:id/buy", h.BuyListingV2())
+	})
+}
+
+func TestSearchListings(t *testing.T) {
+	service := &fakeListingService{listings: []listing.Listing{{ID: uuid.New()}}}
+	app := newListingTestApp(service)
+	seller := uuid.New()
+
+	resp := send(t, app, http.MethodGet, "/api/listing/search?collection-address=collection&seller="+seller.String()+
+		"&is-testnet=true&min-price=10&max-price=20&sort=price_desc&offset=5&limit=15", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var listings []listing.Listing
+	resp.decode(t, &listings)
+	if len(listings) != 1 {
+		t.Fatalf("listings = %v", listings)
+	}
+
+	query := service.query
+	if query.CollectionAddress != "collection" || *query.Seller != seller || !*query.IsTestnet || query.MinPrice != 10 || query.MaxPrice != 20 ||
+		query.Sort != listing.SortPriceDesc || query.Offset != 5 || query.Limit != 15 {
+		t.Fatalf("query = %+v", query)
+	}
+}
+
+func TestSearchListingsV2(t *testing.T) {
+	service := &fakeListingService{listings: []listing.Listing{{ID: uuid.New()}}}
+	app := newListingTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/v2/listings", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var page struct {
+		Items []listing.Listing `json:"items"`
+	}
+	resp.decode(t, &page)
+	if len(page.Items) != 1 || service.query.Sort != listing.SortNewest || service.query.Limit != listing.DefaultLimit {
+		t.Fatalf("items = %v, query = %+v", page.Items, service.query)
+	}
+}
+
+func TestSearchListingsRejectsInvalidQuery(t *testing.T) {
+	app := newListingTestApp(&fakeListingService{})
+
+	for _, query := range []string{"seller=bob", "is-testnet=maybe", "min-price=-1", "max-price=ton", "sort=ending_soon"} {
+		t.Run(query, func(t *testing.T) {
+			send(t, app, http.MethodGet, "/api/listing/search?"+query, nil).expectStatus(t, fiber.StatusBadRequest)
+			send(t, app, http.MethodGet, "/api/v2/listings?"+query, nil).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+		})
+	}
+}
+
+func TestGetListing(t *testing.T) {
+	id := uuid.New()
+	service := &fakeListingService{listing: &listing.Listing{ID: id}}
+	app := newListingTestApp(service)
+
+	for _, target := range []string{"/api/listing/" + id.String(), "/api/v2/listings/" + id.String()} {
+		resp := send(t, app, http.MethodGet, target, nil)
+		resp.expectStatus(t, fiber.StatusOK)
+
+		var found listing.Listing
+		resp.decode(t, &found)
+		if found.ID != id || service.id != id {
+			t.Fatalf("%v: listing = %v, requested %v", target, found.ID, service.id)
+		}
+	}
+
+	service.err = mongo.ErrNoDocuments
+	send(t, app, http.MethodGet, "/api/listing/"+id.String(), nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodGet, "/api/v2/listings/"+id.String(), nil).expectError(t, fiber.StatusNotFound, CodeNotFound)
+
+	send(t, app, http.MethodGet, "/api/listing/not-uuid", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodGet, "/api/v2/listings/not-uuid", nil).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+}
+
+func TestCreateListing(t *testing.T) {
+	service := &fakeListingService{listing: &listing.Listing{ID: uuid.New()}}
+	app := newListingTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/listing/create?nft-item-address="+testNftItemAddress+"&price=1000000000", nil).expectStatus(t, fiber.StatusOK)
+	if service.nftItemAddress != testNftItemAddress || service.price != 1000000000 || service.userID != testUserID {
+		t.Fatalf("listed %v for %v by %v", service.nftItemAddress, service.price, service.userID)
+	}
+
+	send(t, app, http.MethodPost, "/api/v2/listings", CreateListingRequest{NftItemAddress: testNftItemAddress, Price: listing.MinPrice}).expectStatus(t, fiber.StatusOK)
+	if service.price != listing.MinPrice {
+		t.Fatalf("v2 price = %v", service.price)
+	}
+}
+
+func TestCreateListingErrors(t *testing.T) {
+	service := &fakeListingService{}
+	app := newListingTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/listing/create?nft-item-address=item&price=1", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/listing/create?nft-item-address="+testNftItemAddress+"&price=ton", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/listing/create?nft-item-address="+testNftItemAddress+"&price=1&owner-id=bob", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/listing/create?nft-item-address="+testNftItemAddress+"&price=1", nil, anonymous).expectStatus(t, fiber.StatusUnauthorized)
+
+	errResp := send(t, app, http.MethodPost, "/api/v2/listings", CreateListingRequest{Price: 1}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	if _, ok := errResp.Details["nft_item_address"]; !ok || errResp.Details["price"] == "" {
+		t.Fatalf("details = %v", errResp.Details)
+	}
+
+	service.err = listing.ErrAlreadyListed
+	send(t, app, http.MethodPost, "/api/v2/listings", CreateListingRequest{NftItemAddress: testNftItemAddress, Price: listing.MinPrice}).
+		expectError(t, fiber.StatusConflict, CodeConflict)
+}
+
+func TestCancelListing(t *testing.T) {
+	id := uuid.New()
+	service := &fakeListingService{listing: &listing.Listing{ID: id, Status: listing.StatusCancelled}}
+	app := newListingTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/listing/cancel/"+id.String(), nil).expectStatus(t, fiber.StatusOK)
+	if service.id != id || service.userID != testUserID {
+		t.Fatalf("cancelled %v by %v", service.id, service.userID)
+	}
+	send(t, app, http.MethodPost, "/api/v2/listings/"+id.String()+"/cancel", nil).expectStatus(t, fiber.StatusOK)
+
+	service.err = listing.ErrNotSeller
+	send(t, app, http.MethodPost, "/api/listing/cancel/"+id.String(), nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodPost, "/api/v2/listings/"+id.String()+"/cancel", nil).expectError(t, fiber.StatusForbidden, CodeForbidden)
+
+	send(t, app, http.MethodPost, "/api/listing/cancel/not-uuid", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/v2/listings/not-uuid/cancel", nil).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+}
+
+func TestBuyListing(t *testing.T) {
+	id := uuid.New()
+	service := &fakeListingService{listing: &listing.Listing{ID: id, Status: listing.StatusSold}}
+	app := newListingTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/listing/buy/"+id.String(), nil).expectStatus(t, fiber.StatusOK)
+	if service.id != id || service.userID != testUserID {
+		t.Fatalf("bought %v by %v", service.id, service.userID)
+	}
+	send(t, app, http.MethodPost, "/api/v2/listings/"+id.String()+"/buy", nil).expectStatus(t, fiber.StatusOK)
+
+	service.err = ledger.ErrNotEnoughBalance
+	send(t, app, http.MethodPost, "/api/listing/buy/"+id.String(), nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodPost, "/api/v2/listings/"+id.String()+"/buy", nil).expectError(t, fiber.StatusPaymentRequired, CodeNotEnoughBalance)
+
+	send(t, app, http.MethodPost, "/api/listing/buy/"+id.String()+"?owner-id=1", nil).expectStatus(t, fiber.StatusForbidden)
+	send(t, app, http.MethodPost, "/api/v2/listings/"+id.String()+"/buy", nil, anonymous).expectError(t, fiber.StatusUnauthorized, CodeUnauthorized)
+}
@@ -99,3 +99,53 @@ func (v *MarketplaceContractHandler) WithdrawTonFromMarketContract() fiber.Handl
 		return c.Status(fiber.StatusOK).SendString(fmt.Sprintf("Successfully withdrawed %v TON\n", tlb.FromNanoTONU(amount)))
 	}
 }
+
+func (v *MarketplaceContractHandler) DeployMarketContractV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body DeployMarketRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		if deployErr := v.MarketplaceContractService.DeployMarketplaceContract(c.Context(), *body.IsTestnet); deployErr != nil {
+			return sendServiceErrorV2(c, deployErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"is_testnet": *body.IsTestnet})
+	}
+}
+
+func (v *MarketplaceContractHandler) DepositMarketV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body MarketTonRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		if depositErr := v.MarketplaceContractService.DepositMarketplaceContract(c.Context(), body.Amount, *body.IsTestnet); depositErr != nil {
+			return sendServiceErrorV2(c, depositErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"amount": body.Amount})
+	}
+}
+
+func (v *MarketplaceContractHandler) WithdrawTonFromMarketContractV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var body MarketTonRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		var message []string
+		if body.Message != "" {
+			message = append(message, body.Message)
+		}
+
+		if withdrawErr := v.MarketplaceContractService.WithdrawTonFromMarketplaceContract(c.Context(), body.Amount, *body.IsTestnet, message...); withdrawErr != nil {
+			return sendServiceErrorV2(c, withdrawErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"amount": body.Amount})
+	}
+}
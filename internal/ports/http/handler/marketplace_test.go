@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeMarketplaceContractService records arguments of the last call and answers with err
+type fakeMarketplaceContractService struct {
+	err error
+
+	amount    uint64
+	isTestnet bool
+	message   []string
+}
+
+func (s *fakeMarketplaceContractService) DepositMarketplaceContract(_ context.Context, amount uint64, isTestnet bool) error {
+	s.amount, s.isTestnet = amount, isTestnet
+	return s.err
+}
+
+func (s *fakeMarketplaceContractService) DeployMarketplaceContract(_ context.Context, isTestnet bool, _ ...int32) error {
+	s.isTestnet = isTestnet
+	return s.err
+}
+
+func (s *fakeMarketplaceContractService) WithdrawTonFromMarketplaceContract(_ context.Context, amount uint64, isTestnet bool, textMessage ...string) error {
+	s.amount, s.isTestnet, s.message = amount, isTestnet, textMessage
+	return s.err
+}
+
+func newMarketplaceTestApp(service *fakeMarketplaceContractService) *fiber.App {
+	h := &MarketplaceContractHandler{MarketplaceContractService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Post("/api/market/deploy", h.DeployMarketContract())
+		app.Post("/api/market/deposit", h.DepositMarket())
+		app.Post("/api/market/withdraw", h.WithdrawTonFromMarketContract())
+
+		app.Post("/api/v2/market/deploy", h.DeployMarketContractV2())
+		app.Post("/api/v2/market/deposit", h.DepositMarketV2())
+		app.Post("/api/v2/market/withdraw", h.WithdrawTonFromMarketContractV2())
+	})
+}
+
+func TestDeployMarketContract(t *testing.T) {
+	service := &fakeMarketplaceContractService{}
+	app := newMarketplaceTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/market/deploy?is-testnet=true", nil).expectStatus(t, fiber.StatusOK)
+	if !service.isTestnet {
+		t.Fatalf("deployed to mainnet, want testnet")
+	}
+	send(t, app, http.MethodPost, "/api/market/deploy", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	isTestnet := false
+	resp := send(t, app, http.MethodPost, "/api/v2/market/deploy", DeployMarketRequest{IsTestnet: &isTestnet})
+	resp.expectStatus(t, fiber.StatusOK)
+	var deployed struct {
+		IsTestnet bool `json:"is_testnet"`
+	}
+	resp.decode(t, &deployed)
+	if deployed.IsTestnet || service.isTestnet {
+		t.Fatalf("deployed to testnet, want mainnet")
+	}
+
+	send(t, app, http.MethodPost, "/api/v2/market/deploy", DeployMarketRequest{}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+
+	service.err = errors.New("wallet is unavailable")
+	send(t, app, http.MethodPost, "/api/market/deploy?is-testnet=true", nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodPost, "/api/v2/market/deploy", DeployMarketRequest{IsTestnet: &isTestnet}).expectError(t, fiber.StatusInternalServerError, CodeInternal)
+}
+
+func TestDepositMarket(t *testing.T) {
+	service := &fakeMarketplaceContractService{}
+	app := newMarketplaceTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/market/deposit?amount=1000000000&is-testnet=true", nil).expectStatus(t, fiber.StatusOK)
+	if service.amount != 1000000000 || !service.isTestnet {
+		t.Fatalf("deposited %v, testnet %v", service.amount, service.isTestnet)
+	}
+	send(t, app, http.MethodPost, "/api/market/deposit?is-testnet=true", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/market/deposit?amount=ton&is-testnet=true", nil).expectStatus(t, fiber.StatusInternalServerError)
+
+	isTestnet := true
+	resp := send(t, app, http.MethodPost, "/api/v2/market/deposit", MarketTonRequest{Amount: 5, IsTestnet: &isTestnet})
+	resp.expectStatus(t, fiber.StatusOK)
+	var deposited struct {
+		Amount uint64 `json:"amount"`
+	}
+	resp.decode(t, &deposited)
+	if deposited.Amount != 5 || service.amount != 5 {
+		t.Fatalf("deposited %v", deposited.Amount)
+	}
+
+	errResp := send(t, app, http.MethodPost, "/api/v2/market/deposit", MarketTonRequest{}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	if _, ok := errResp.Details["amount"]; !ok {
+		t.Fatalf("details = %v, want amount", errResp.Details)
+	}
+}
+
+func TestWithdrawTonFromMarketContract(t *testing.T) {
+	service := &fakeMarketplaceContractService{}
+	app := newMarketplaceTestApp(service)
+
+	send(t, app, http.MethodPost, "/api/market/withdraw?amount=7&is-testnet=true&message=payout", nil).expectStatus(t, fiber.StatusOK)
+	if service.amount != 7 || len(service.message) != 1 || service.message[0] != "payout" {
+		t.Fatalf("withdrawn %v with message %v", service.amount, service.message)
+	}
+	send(t, app, http.MethodPost, "/api/market/withdraw?amount=7", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/market/withdraw?amount=7&is-testnet=maybe", nil).expectStatus(t, fiber.StatusInternalServerError)
+
+	isTestnet := true
+	send(t, app, http.MethodPost, "/api/v2/market/withdraw", MarketTonRequest{Amount: 3, IsTestnet: &isTestnet}).expectStatus(t, fiber.StatusOK)
+	if service.amount != 3 || service.message != nil {
+		t.Fatalf("withdrawn %v with message %v", service.amount, service.message)
+	}
+
+	service.err = errors.New("wallet is unavailable")
+	send(t, app, http.MethodPost, "/api/market/withdraw?amount=7&is-testnet=true", nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodPost, "/api/v2/market/withdraw", MarketTonRequest{Amount: 3, IsTestnet: &isTestnet}).expectError(t, fiber.StatusInternalServerError, CodeInternal)
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+	mediaservice "github.com/rom6n/create-nft-go/internal/service/media_service"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type MediaHandler struct {
+	MediaService mediaservice.MediaServiceRepository
+}
+
+// blob keys of media: {hash}.{ext} for original and {hash}_{size}.{ext} for thumbnail
+var mediaFileKey = regexp.MustCompile(`^[0-9a-f]{64}(_[0-9]+)?\.(jpg|png|gif|webp)$`)
+
+// readMediaFile reads file field of multipart form up to media.MaxFileSize
+func readMediaFile(c *fiber.Ctx) ([]byte, error) {
+	fileHeader, formErr := c.FormFile("file")
+	if formErr != nil {
+		return nil, fmt.Errorf("file is required: %v", formErr)
+	}
+	if fileHeader.Size > media.MaxFileSize {
+		return nil, fmt.Errorf("%w: %v bytes, at most %v", media.ErrTooLarge, fileHeader.Size, media.MaxFileSize)
+	}
+
+	file, openErr := fileHeader.Open()
+	if openErr != nil {
+		return nil, fmt.Errorf("error opening file: %v", openErr)
+	}
+	defer file.Close()
+
+	data, readErr := io.ReadAll(io.LimitReader(file, media.MaxFileSize+1))
+	if readErr != nil {
+		return nil, fmt.Errorf("error reading file: %v", readErr)
+	}
+
+	return data, nil
+}
+
+// multipart form with file field
+// ?kind=nft_item_image&owner-id=123
+func (v *MediaHandler) UploadMedia() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uploaderID, authErr := actingUserID(c, c.Query("owner-id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		data, fileErr := readMediaFile(c)
+		if fileErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fileErr.Error())
+		}
+
+		uploadedMedia, uploadErr := v.MediaService.UploadMedia(c.Context(), media.Kind(c.Query("kind")), data, uploaderID)
+		if uploadErr != nil {
+			if errors.Is(uploadErr, media.ErrInvalidKind) ||
+				errors.Is(uploadErr, media.ErrUnsupportedType) ||
+				errors.Is(uploadErr, media.ErrTooLarge) ||
+				errors.Is(uploadErr, media.ErrInvalidDimensions) {
+				return c.Status(fiber.StatusBadRequest).SendString(uploadErr.Error())
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error uploading media: %v", uploadErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(uploadedMedia)
+	}
+}
+
+func (v *MediaHandler) GetMedia() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		hash := c.Params("hash")
+		if decoded, decodeErr := hex.DecodeString(hash); decodeErr != nil || len(decoded) != 32 {
+			return c.Status(fiber.StatusBadRequest).SendString("hash must be sha256 hex")
+		}
+
+		foundMedia, mediaErr := v.MediaService.GetMedia(c.Context(), hash)
+		if mediaErr != nil {
+			if errors.Is(mediaErr, mongo.ErrNoDocuments) {
+				return c.Status(fiber.StatusNotFound).SendString("media not found")
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error getting media: %v", mediaErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(foundMedia)
+	}
+}
+
+// GetMediaFile serves files of local blob store. Files never change under their keys, so they are cached forever
+func (v *MediaHandler) GetMediaFile() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Params("key")
+		if !mediaFileKey.MatchString(key) {
+			return c.Status(fiber.StatusBadRequest).SendString("invalid media file key")
+		}
+
+		etag := `"` + key + `"`
+		c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+		c.Set(fiber.HeaderETag, etag)
+		if c.Get(fiber.HeaderIfNoneMatch) == etag {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
+		data, blobErr := v.MediaService.GetBlob(c.Context(), key)
+		if blobErr != nil {
+			c.Set(fiber.HeaderCacheControl, "no-store")
+			if errors.Is(blobErr, media.ErrBlobNotFound) {
+				return c.Status(fiber.StatusNotFound).SendString("media file not found")
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error getting media file: %v", blobErr))
+		}
+
+		c.Set(fiber.HeaderContentType, media.ContentType(key))
+		return c.Status(fiber.StatusOK).Send(data)
+	}
+}
+
+// multipart form with file and kind fields
+func (v *MediaHandler) UploadMediaV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uploaderID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		data, fileErr := readMediaFile(c)
+		if fileErr != nil {
+			if errors.Is(fileErr, media.ErrTooLarge) {
+				return sendServiceErrorV2(c, fileErr)
+			}
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeInvalidBody, fileErr.Error(), nil)
+		}
+
+		uploadedMedia, uploadErr := v.MediaService.UploadMedia(c.Context(), media.Kind(c.FormValue("kind")), data, uploaderID)
+		if uploadErr != nil {
+			return sendServiceErrorV2(c, uploadErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(uploadedMedia)
+	}
+}
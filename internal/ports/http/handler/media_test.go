@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var testMediaHash = strings.Repeat("ab", 32)
+
+// fakeMediaService records arguments of the last call and answers with media and blob or err
+type fakeMediaService struct {
+	media *media.Media
+	blob  []byte
+	err   error
+
+	kind       media.Kind
+	data       []byte
+	uploaderID int64
+	hash       string
+	key        string
+}
+
+func (s *fakeMediaService) UploadMedia(_ context.Context, kind media.Kind, data []byte, uploaderID int64) (*media.Media, error) {
+	s.kind, s.data, s.uploaderID = kind, data, uploaderID
+	return s.media, s.err
+}
+
+func (s *fakeMediaService) GetMedia(_ context.Context, hash string) (*media.Media, error) {
+	s.hash = hash
+	return s.media, s.err
+}
+
+func (s *fakeMediaService) GetBlob(_ context.Context, key string) ([]byte, error) {
+	s.key = key
+	return s.blob, s.err
+}
+
+func (s *fakeMediaService) GetThumbnails(context.Context, string) ([]media.Thumbnail, error) {
+	return nil, s.err
+}
+
+func newMediaTestApp(service *fakeMediaService) *fiber.App {
+	h := &MediaHandler{MediaService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Post("/api/media/upload", h.UploadMedia())
+		app.Get("/api/media/files/:key", h.GetMediaFile())
+		app.Get("/api/media/:hash", h.GetMedia())
+
+		app.Post("/api/v2/media", h.UploadMediaV2())
+	})
+}
+
+// multipartForm returns multipart body with fields and file, file field is omitted if file is nil
+func multipartForm(t *testing.T, fields map[string]string, file []byte) (string, map[string]string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if writeErr := writer.WriteField(name, value); writeErr != nil {
+			t.Fatalf("WriteField() error = %v", writeErr)
+		}
+	}
+	if file != nil {
+		part, createErr := writer.CreateFormFile("file", "image.png")
+		if createErr != nil {
+			t.Fatalf("CreateFormFile() error = %v", createErr)
+		}
+		part.Write(file)
+	}
+	if closeErr := writer.Close(); closeErr != nil {
+		t.Fatalf("Close() error = %v", closeErr)
+	}
+
+	return body.String(), map[string]string{fiber.HeaderContentType: writer.FormDataContentType()}
+}
+
+func TestUploadMedia(t *testing.T) {
+	service := &fakeMediaService{media: &media.Media{Hash: testMediaHash}}
+	app := newMediaTestApp(service)
+
+	body, header := multipartForm(t, nil, []byte("png"))
+	resp := send(t, app, http.MethodPost, "/api/media/upload?kind=nft_item_image", body, header)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var uploaded media.Media
+	resp.decode(t, &uploaded)
+	if uploaded.Hash != testMediaHash || service.kind != media.KindNftItemImage || string(service.data) != "png" || service.uploaderID != testUserID {
+		t.Fatalf("uploaded %v of kind %v by %v", uploaded.Hash, service.kind, service.uploaderID)
+	}
+
+	body, header = multipartForm(t, nil, nil)
+	send(t, app, http.MethodPost, "/api/media/upload?kind=nft_item_image", body, header).expectStatus(t, fiber.StatusBadRequest)
+
+	body, header = multipartForm(t, nil, []byte("png"))
+	send(t, app, http.MethodPost, "/api/media/upload?kind=nft_item_image&owner-id=1", body, header).expectStatus(t, fiber.StatusForbidden)
+
+	for _, err := range []error{media.ErrInvalidKind, media.ErrUnsupportedType, media.ErrTooLarge, media.ErrInvalidDimensions} {
+		service.err = err
+		send(t, app, http.MethodPost, "/api/media/upload?kind=nft_item_image", body, header).expectStatus(t, fiber.StatusBadRequest)
+	}
+
+	service.err = errors.New("blob store is unavailable")
+	send(t, app, http.MethodPost, "/api/media/upload?kind=nft_item_image", body, header).expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestUploadMediaV2(t *testing.T) {
+	service := &fakeMediaService{media: &media.Media{Hash: testMediaHash}}
+	app := newMediaTestApp(service)
+
+	body, header := multipartForm(t, map[string]string{"kind": "nft_collection_cover"}, []byte("png"))
+	send(t, app, http.MethodPost, "/api/v2/media", body, header).expectStatus(t, fiber.StatusOK)
+	if service.kind != media.KindNftCollectionCover || service.uploaderID != testUserID {
+		t.Fatalf("uploaded kind %v by %v", service.kind, service.uploaderID)
+	}
+
+	send(t, app, http.MethodPost, "/api/v2/media", body, header, anonymous).expectError(t, fiber.StatusUnauthorized, CodeUnauthorized)
+
+	noFile, noFileHeader := multipartForm(t, map[string]string{"kind": "nft_collection_cover"}, nil)
+	send(t, app, http.MethodPost, "/api/v2/media", noFile, noFileHeader).expectError(t, fiber.StatusBadRequest, CodeInvalidBody)
+
+	service.err = media.ErrUnsupportedType
+	send(t, app, http.MethodPost, "/api/v2/media", body, header).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+}
+
+func TestGetMedia(t *testing.T) {
+	service := &fakeMediaService{media: &media.Media{Hash: testMediaHash, Width: 10}}
+	app := newMediaTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/media/"+testMediaHash, nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var found media.Media
+	resp.decode(t, &found)
+	if found.Width != 10 || service.hash != testMediaHash {
+		t.Fatalf("media = %+v, requested %v", found, service.hash)
+	}
+
+	send(t, app, http.MethodGet, "/api/media/abcd", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = mongo.ErrNoDocuments
+	send(t, app, http.MethodGet, "/api/media/"+testMediaHash, nil).expectStatus(t, fiber.StatusNotFound)
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/media/"+testMediaHash, nil).expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestGetMediaFile(t *testing.T) {
+	service := &fakeMediaService{blob: []byte("png")}
+	app := newMediaTestApp(service)
+	key := testMediaHash + "_128.png"
+
+	resp := send(t, app, http.MethodGet, "/api/media/files/"+key, nil)
+	resp.expectStatus(t, fiber.StatusOK)
+	if string(resp.body) != "png" || service.key != key || resp.header.Get(fiber.HeaderContentType) != "image/png" {
+		t.Fatalf("file = %s of %v, content type %v", resp.body, service.key, resp.header.Get(fiber.HeaderContentType))
+	}
+	etag := resp.header.Get(fiber.HeaderETag)
+	if etag != `"`+key+`"` || !strings.Contains(resp.header.Get(fiber.HeaderCacheControl), "immutable") {
+		t.Fatalf("etag = %v, cache control = %v", etag, resp.header.Get(fiber.HeaderCacheControl))
+	}
+
+	service.key = ""
+	send(t, app, http.MethodGet, "/api/media/files/"+key, nil, map[string]string{fiber.HeaderIfNoneMatch: etag}).expectStatus(t, fiber.StatusNotModified)
+	if service.key != "" {
+		t.Fatalf("blob store is read for not modified file")
+	}
+
+	send(t, app, http.MethodGet, "/api/media/files/"+testMediaHash+".exe", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = media.ErrBlobNotFound
+	resp = send(t, app, http.MethodGet, "/api/media/files/"+key, nil)
+	resp.expectStatus(t, fiber.StatusNotFound)
+	if resp.header.Get(fiber.HeaderCacheControl) != "no-store" {
+		t.Fatalf("missing file is cached: %v", resp.header.Get(fiber.HeaderCacheControl))
+	}
+
+	service.err = errors.New("blob store is unavailable")
+	send(t, app, http.MethodGet, "/api/media/files/"+key, nil).expectStatus(t, fiber.StatusInternalServerError)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	changenftcollectioncontent "github.com/rom6n/create-nft-go/internal/service/change_nft_collection_content"
 	deploynftcollection "github.com/rom6n/create-nft-go/internal/service/deploy_nft_collection"
 	nftcollectionservice "github.com/rom6n/create-nft-go/internal/service/nft_collection_service"
 	withdrawnftcollection "github.com/rom6n/create-nft-go/internal/service/withdraw_nft_collection"
@@ -13,20 +14,55 @@ import (
 )
 
 type NftCollectionHandler struct {
-	NftCollectionService         nftcollectionservice.NftCollectionServiceRepository
-	DeployNftCollectionService   deploynftcollection.DeployNftCollectionServiceRepository
-	WithdrawNftCollectionService withdrawnftcollection.WithdrawNftCollectionServiceRepository
+	NftCollectionService              nftcollectionservice.NftCollectionServiceRepository
+	DeployNftCollectionService        deploynftcollection.DeployNftCollectionServiceRepository
+	WithdrawNftCollectionService      withdrawnftcollection.WithdrawNftCollectionServiceRepository
+	ChangeNftCollectionContentService changenftcollectioncontent.ChangeNftCollectionContentServiceRepository
 }
 
 func (v *NftCollectionHandler) DeployNftCollection() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx := c.Context()
 
-		ownerWallet, ownerIDStr, collectionContent, royaltyDividendStr, royaltyDivisorStr, isTest :=
-			c.Query("owner-wallet"), c.Query("owner-id"), c.Query("collection-content"), c.Query("royalty-dividend"), c.Query("royalty-divisor"), c.Query("is-testnet")
+		ownerWallet, ownerIDStr, collectionContent, royaltyDividendStr, royaltyDivisorStr, isTest, onchain :=
+			c.Query("owner-wallet"), c.Query("owner-id"), c.Query("collection-content"), c.Query("royalty-dividend"), c.Query("royalty-divisor"), c.Query("is-testnet"), c.QueryBool("onchain")
+		hosted := c.QueryBool("hosted")
 
-		if ownerIDStr == "" || collectionContent == "" || royaltyDividendStr == "" || royaltyDivisorStr == "" || isTest == "" {
-			return c.Status(fiber.StatusBadRequest).SendString("owner id, is testnet, collection content, royalty dividend, royalty divisor are required")
+		ownerID, authErr := actingUserID(c, ownerIDStr)
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		if (collectionContent == "" && !onchain && !hosted) || royaltyDividendStr == "" || royaltyDivisorStr == "" || isTest == "" {
+			return c.Status(fiber.StatusBadRequest).SendString("is testnet, collection content, royalty dividend, royalty divisor are required")
+		}
+
+		if onchain && hosted {
+			return c.Status(fiber.StatusBadRequest).SendString("onchain and hosted cant be set together")
+		}
+
+		// onchain metadata comes in body as nft collection metadata json
+		var onchainMetadata *nftcollection.NftCollectionMetadata
+		if onchain {
+			onchainMetadata = &nftcollection.NftCollectionMetadata{}
+			if parseErr := c.BodyParser(onchainMetadata); parseErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("onchain metadata is not valid: %v", parseErr))
+			}
+			if onchainMetadata.Name == "" {
+				return c.Status(fiber.StatusBadRequest).SendString("onchain metadata name is required")
+			}
+		}
+
+		// hosted metadata comes in body the same way and is served by the app
+		var hostedMetadata *nftcollection.NftCollectionMetadata
+		if hosted {
+			hostedMetadata = &nftcollection.NftCollectionMetadata{}
+			if parseErr := c.BodyParser(hostedMetadata); parseErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("hosted metadata is not valid: %v", parseErr))
+			}
+			if hostedMetadata.Name == "" {
+				return c.Status(fiber.StatusBadRequest).SendString("hosted metadata name is required")
+			}
 		}
 
 		var ownerAddress *address.Address
@@ -38,20 +74,14 @@ func (v *NftCollectionHandler) DeployNftCollection() fiber.Handler {
 			}
 		}
 
-		// ?owner-wallet=0QDU46qYz4rHAJhszrW9w6imF8p4Cw5dS1GpPTcJ9vqNSmnf&owner-id=5003727541&common-content=https://&collection-content=https://rom6n.github.io/mc1f/nft-c1-collection.json&royalty-dividend=20&royalty-divisor=100&is-testnet=true
 		isTestnet, parseBoolErr := strconv.ParseBool(isTest)
 		if parseBoolErr != nil {
-			c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error parse is-testnet to bool: %v", parseBoolErr))
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse is-testnet to bool: %v", parseBoolErr))
 		}
 		royaltyDividend, parseErr := strconv.ParseUint(royaltyDividendStr, 0, 16)
 		royaltyDivisor, parseErr2 := strconv.ParseUint(royaltyDivisorStr, 0, 16)
 		if parseErr != nil || parseErr2 != nil {
-			c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error parse to uint16: %v. Error 2: %v", parseErr, parseErr2))
-		}
-
-		ownerID, parseErr := strconv.Atoi(ownerIDStr)
-		if parseErr != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error parse to int: %v", parseErr))
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse to uint16: %v. Error 2: %v", parseErr, parseErr2))
 		}
 
 		commonContent := "https://" // common content will always start with https://
@@ -61,13 +91,15 @@ func (v *NftCollectionHandler) DeployNftCollection() fiber.Handler {
 			CollectionContent: collectionContent,
 			RoyaltyDividend:   uint16(royaltyDividend),
 			RoyaltyDivisor:    uint16(royaltyDivisor),
+			OnchainMetadata:   onchainMetadata,
+			HostedMetadata:    hostedMetadata,
 		}
 
 		if v.DeployNftCollectionService == nil || v.NftCollectionService == nil {
 			return c.Status(fiber.StatusInternalServerError).SendString("DeployNftCollectionService or NftCollectionService is not initialized")
 		}
 
-		collection, deployErr := v.DeployNftCollectionService.DeployNftCollection(ctx, collectionCfg, int64(ownerID), isTestnet)
+		collection, deployErr := v.DeployNftCollectionService.DeployNftCollection(ctx, collectionCfg, ownerID, isTestnet)
 		if deployErr != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while deploying nft collection: %v", deployErr))
 		}
@@ -81,13 +113,13 @@ func (v *NftCollectionHandler) WithdrawNftCollection() fiber.Handler {
 		ctx := c.Context()
 
 		collectionAddressStr, WithdrawToAddressStr, ownerIDStr, isTest := c.Params("address"), c.Query("withdraw-to"), c.Query("owner-id"), c.Query("is-testnet")
-		if WithdrawToAddressStr == "" || isTest == "" || ownerIDStr == "" {
-			return c.Status(fiber.StatusBadRequest).SendString("withdraw to, owner id and is testnet are required")
+		ownerID, authErr := actingUserID(c, ownerIDStr)
+		if authErr != nil {
+			return sendAuthError(c, authErr)
 		}
 
-		ownerID, parseErr := strconv.Atoi(ownerIDStr)
-		if parseErr != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error parse to int: %v", parseErr))
+		if WithdrawToAddressStr == "" || isTest == "" {
+			return c.Status(fiber.StatusBadRequest).SendString("withdraw to and is testnet are required")
 		}
 
 		isTestnet, parseBoolErr := strconv.ParseBool(isTest)
@@ -105,10 +137,178 @@ func (v *NftCollectionHandler) WithdrawNftCollection() fiber.Handler {
 			return c.Status(fiber.StatusBadRequest).SendString("new owner is not valid address")
 		}
 
-		if withdrawErr := v.WithdrawNftCollectionService.WithdrawNftCollection(ctx, nftCollectionAddress, newOwnerAddress, int64(ownerID), isTestnet); withdrawErr != nil {
+		if withdrawErr := v.WithdrawNftCollectionService.WithdrawNftCollection(ctx, nftCollectionAddress, newOwnerAddress, ownerID, isTestnet); withdrawErr != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error withdrawing: %v", withdrawErr))
 		}
 
 		return c.Status(fiber.StatusOK).SendString("Successfully withdrawed nft collection")
 	}
 }
+
+func (v *NftCollectionHandler) ChangeNftCollectionContent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		collectionAddressStr, ownerIDStr, collectionContent, royaltyDividendStr, royaltyDivisorStr, royaltyAddressStr, isTest, onchain :=
+			c.Params("address"), c.Query("owner-id"), c.Query("collection-content"), c.Query("royalty-dividend"), c.Query("royalty-divisor"), c.Query("royalty-address"), c.Query("is-testnet"), c.QueryBool("onchain")
+		hosted := c.QueryBool("hosted")
+
+		ownerID, authErr := actingUserID(c, ownerIDStr)
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		if (collectionContent == "" && !onchain && !hosted) || isTest == "" {
+			return c.Status(fiber.StatusBadRequest).SendString("is testnet and collection content are required")
+		}
+
+		if (royaltyDividendStr == "") != (royaltyDivisorStr == "") {
+			return c.Status(fiber.StatusBadRequest).SendString("royalty dividend and royalty divisor must be changed together")
+		}
+
+		if onchain && hosted {
+			return c.Status(fiber.StatusBadRequest).SendString("onchain and hosted cant be set together")
+		}
+
+		// onchain metadata comes in body as nft collection metadata json
+		var onchainMetadata *nftcollection.NftCollectionMetadata
+		if onchain {
+			onchainMetadata = &nftcollection.NftCollectionMetadata{}
+			if parseErr := c.BodyParser(onchainMetadata); parseErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("onchain metadata is not valid: %v", parseErr))
+			}
+			if onchainMetadata.Name == "" {
+				return c.Status(fiber.StatusBadRequest).SendString("onchain metadata name is required")
+			}
+		}
+
+		// hosted metadata comes in body the same way and is served by the app
+		var hostedMetadata *nftcollection.NftCollectionMetadata
+		if hosted {
+			hostedMetadata = &nftcollection.NftCollectionMetadata{}
+			if parseErr := c.BodyParser(hostedMetadata); parseErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("hosted metadata is not valid: %v", parseErr))
+			}
+			if hostedMetadata.Name == "" {
+				return c.Status(fiber.StatusBadRequest).SendString("hosted metadata name is required")
+			}
+		}
+
+		changeCfg := nftcollection.ChangeContentCfg{
+			CommonContent:     "https://", // common content will always start with https://
+			CollectionContent: collectionContent,
+			OnchainMetadata:   onchainMetadata,
+			HostedMetadata:    hostedMetadata,
+		}
+
+		if royaltyDividendStr != "" {
+			royaltyDividend, parseErr := strconv.ParseUint(royaltyDividendStr, 0, 16)
+			royaltyDivisor, parseErr2 := strconv.ParseUint(royaltyDivisorStr, 0, 16)
+			if parseErr != nil || parseErr2 != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse to uint16: %v. Error 2: %v", parseErr, parseErr2))
+			}
+			dividend, divisor := uint16(royaltyDividend), uint16(royaltyDivisor)
+			changeCfg.RoyaltyDividend = &dividend
+			changeCfg.RoyaltyDivisor = &divisor
+		}
+
+		if royaltyAddressStr != "" {
+			royaltyAddress, parseAddrErr := address.ParseAddr(royaltyAddressStr)
+			if parseAddrErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString("royalty address is not valid address")
+			}
+			changeCfg.RoyaltyAddress = royaltyAddress
+		}
+
+		isTestnet, parseBoolErr := strconv.ParseBool(isTest)
+		if parseBoolErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse is-testnet to bool: %v", parseBoolErr))
+		}
+
+		nftCollectionAddress, parseAddrErr := address.ParseAddr(collectionAddressStr)
+		if parseAddrErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("nft collection is not valid address: %v\n%v", collectionAddressStr, parseAddrErr))
+		}
+
+		metadata, changeErr := v.ChangeNftCollectionContentService.ChangeNftCollectionContent(ctx, nftCollectionAddress, changeCfg, ownerID, isTestnet)
+		if changeErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while changing nft collection content: %v", changeErr))
+		}
+
+		// metadata is stored after change is confirmed on chain
+		return c.Status(fiber.StatusAccepted).JSON(metadata)
+	}
+}
+
+func (v *NftCollectionHandler) DeployNftCollectionV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		var body DeployNftCollectionRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		collection, deployErr := v.DeployNftCollectionService.DeployNftCollection(c.Context(), body.cfg(), ownerID, *body.IsTestnet)
+		if deployErr != nil {
+			return sendServiceErrorV2(c, deployErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(collection)
+	}
+}
+
+func (v *NftCollectionHandler) WithdrawNftCollectionV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		nftCollectionAddress, parseAddrErr := address.ParseAddr(c.Params("address"))
+		if parseAddrErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "nft collection is not valid address", nil)
+		}
+
+		var body WithdrawNftRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		if withdrawErr := v.WithdrawNftCollectionService.WithdrawNftCollection(c.Context(), nftCollectionAddress, body.withdrawTo, ownerID, *body.IsTestnet); withdrawErr != nil {
+			return sendServiceErrorV2(c, withdrawErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"address": nftCollectionAddress.String(), "withdraw_to": body.withdrawTo.String()})
+	}
+}
+
+func (v *NftCollectionHandler) ChangeNftCollectionContentV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		nftCollectionAddress, parseAddrErr := address.ParseAddr(c.Params("address"))
+		if parseAddrErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "nft collection is not valid address", nil)
+		}
+
+		var body ChangeNftCollectionContentRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		metadata, changeErr := v.ChangeNftCollectionContentService.ChangeNftCollectionContent(c.Context(), nftCollectionAddress, body.cfg(), ownerID, *body.IsTestnet)
+		if changeErr != nil {
+			return sendServiceErrorV2(c, changeErr)
+		}
+
+		// metadata is stored after change is confirmed on chain
+		return c.Status(fiber.StatusAccepted).JSON(metadata)
+	}
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	"github.com/rom6n/create-nft-go/internal/utils/contentlink"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+type fakeNftCollectionService struct{}
+
+// fakeNftCollectionServices records arguments of the last deploy, withdraw and content change, they answer with err
+type fakeNftCollectionServices struct {
+	err error
+
+	deployCfg            nftcollection.DeployCollectionCfg
+	changeCfg            nftcollection.ChangeContentCfg
+	nftCollectionAddress *address.Address
+	withdrawToAddress    *address.Address
+	ownerID              int64
+	isTestnet            bool
+}
+
+func (s *fakeNftCollectionServices) DeployNftCollection(_ context.Context, deployCfg nftcollection.DeployCollectionCfg, ownerID int64, isTestnet bool) (*nftcollection.NftCollection, error) {
+	s.deployCfg, s.ownerID, s.isTestnet = deployCfg, ownerID, isTestnet
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &nftcollection.NftCollection{Address: testNftCollectionAddress, IsTestnet: isTestnet}, nil
+}
+
+func (s *fakeNftCollectionServices) WithdrawNftCollection(_ context.Context, nftCollectionAddress *address.Address, withdrawToAddress *address.Address, ownerID int64, isTestnet bool) error {
+	s.nftCollectionAddress, s.withdrawToAddress, s.ownerID, s.isTestnet = nftCollectionAddress, withdrawToAddress, ownerID, isTestnet
+	return s.err
+}
+
+func (s *fakeNftCollectionServices) ChangeNftCollectionContent(_ context.Context, nftCollectionAddress *address.Address, cfg nftcollection.ChangeContentCfg, ownerID int64, isTestnet bool) (*nftcollection.NftCollectionMetadata, error) {
+	s.nftCollectionAddress, s.changeCfg, s.ownerID, s.isTestnet = nftCollectionAddress, cfg, ownerID, isTestnet
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &nftcollection.NftCollectionMetadata{Name: "collection"}, nil
+}
+
+func newNftCollectionTestApp(services *fakeNftCollectionServices) *fiber.App {
+	h := &NftCollectionHandler{
+		NftCollectionService:              fakeNftCollectionService{},
+		DeployNftCollectionService:        services,
+		WithdrawNftCollectionService:      services,
+		ChangeNftCollectionContentService: services,
+	}
+	return newTestApp(func(app *fiber.App) {
+		app.Post("/api/nft-collection/deploy", h.DeployNftCollection())
+		app.Post("/api/nft-collection/withdraw/:address", h.WithdrawNftCollection())
+		app.Post("/api/nft-collection/change-content/:address", h.ChangeNftCollectionContent())
+
+		app.Post("/api/v2/nft-collections", h.DeployNftCollectionV2())
+		app.Post("/api/v2/nft-collections/:address/withdraw", h.WithdrawNftCollectionV2())
+		app.Post("/api/v2/nft-collections/:address/content", h.ChangeNftCollectionContentV2())
+	})
+}
+
+func TestDeployNftCollection(t *testing.T) {
+	services := &fakeNftCollectionServices{}
+	app := newNftCollectionTestApp(services)
+
+	resp := send(t, app, http.MethodPost, "/api/nft-collection/deploy?collection-content=https://example.com/collection.json&owner-wallet="+testWalletAddress+
+		"&royalty-dividend=5&royalty-divisor=100&is-testnet=true", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var collection nftcollection.NftCollection
+	resp.decode(t, &collection)
+	if collection.Address != testNftCollectionAddress {
+		t.Fatalf("collection = %v", collection.Address)
+	}
+
+	cfg := services.deployCfg
+	if cfg.CollectionContent != "https://example.com/collection.json" || cfg.CommonContent != "https://" || cfg.OwnerAddress.String() != testWalletAddress ||
+		cfg.RoyaltyDividend != 5 || cfg.RoyaltyDivisor != 100 || services.ownerID != testUserID || !services.isTestnet {
+		t.Fatalf("cfg = %+v of user %v", cfg, services.ownerID)
+	}
+
+	send(t, app, http.MethodPost, "/api/nft-collection/deploy?hosted=true&royalty-dividend=0&royalty-divisor=1&is-testnet=false",
+		nftcollection.NftCollectionMetadata{Name: "collection"}).expectStatus(t, fiber.StatusOK)
+	if services.deployCfg.HostedMetadata == nil || services.deployCfg.HostedMetadata.Name != "collection" || services.isTestnet {
+		t.Fatalf("cfg = %+v", services.deployCfg)
+	}
+}
+
+func TestDeployNftCollectionRejectsInvalidQuery(t *testing.T) {
+	app := newNftCollectionTestApp(&fakeNftCollectionServices{})
+	royalty := "&royalty-dividend=5&royalty-divisor=100"
+
+	tests := map[string]struct {
+		query  string
+		body   any
+		status int
+	}{
+		"no content":           {"is-testnet=true" + royalty, nil, fiber.StatusBadRequest},
+		"no royalty":           {"collection-content=link&is-testnet=true", nil, fiber.StatusBadRequest},
+		"onchain and hosted":   {"onchain=true&hosted=true&is-testnet=true" + royalty, nftcollection.NftCollectionMetadata{Name: "a"}, fiber.StatusBadRequest},
+		"onchain without name": {"onchain=true&is-testnet=true" + royalty, nftcollection.NftCollectionMetadata{}, fiber.StatusBadRequest},
+		"invalid owner wallet": {"collection-content=link&owner-wallet=wallet&is-testnet=true" + royalty, nil, fiber.StatusBadRequest},
+		"invalid network":      {"collection-content=link&is-testnet=maybe" + royalty, nil, fiber.StatusBadRequest},
+		"invalid royalty":      {"collection-content=link&is-testnet=true&royalty-dividend=-1&royalty-divisor=100", nil, fiber.StatusBadRequest},
+		"foreign owner":        {"collection-content=link&is-testnet=true&owner-id=1" + royalty, nil, fiber.StatusForbidden},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			send(t, app, http.MethodPost, "/api/nft-collection/deploy?"+tt.query, tt.body).expectStatus(t, tt.status)
+		})
+	}
+}
+
+func TestDeployNftCollectionV2(t *testing.T) {
+	services := &fakeNftCollectionServices{}
+	app := newNftCollectionTestApp(services)
+	dividend, divisor, isTestnet := uint16(5), uint16(100), true
+
+	send(t, app, http.MethodPost, "/api/v2/nft-collections", DeployNftCollectionRequest{
+		OnchainMetadata: &nftcollection.NftCollectionMetadata{Name: "collection"},
+		RoyaltyDividend: &dividend,
+		RoyaltyDivisor:  &divisor,
+		IsTestnet:       &isTestnet,
+	}).expectStatus(t, fiber.StatusOK)
+
+	cfg := services.deployCfg
+	if cfg.OnchainMetadata.Name != "collection" || cfg.RoyaltyDividend != 5 || cfg.RoyaltyDivisor != 100 || cfg.OwnerAddress != nil || !services.isTestnet {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+}
+
+func TestDeployNftCollectionV2Errors(t *testing.T) {
+	services := &fakeNftCollectionServices{}
+	app := newNftCollectionTestApp(services)
+	dividend, divisor, isTestnet := uint16(101), uint16(100), true
+
+	errResp := send(t, app, http.MethodPost, "/api/v2/nft-collections", DeployNftCollectionRequest{
+		CollectionContent: "ftp://collection.json",
+		RoyaltyDividend:   &dividend,
+		RoyaltyDivisor:    &divisor,
+	}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	for _, field := range []string{"collection_content", "royalty_dividend", "is_testnet"} {
+		if _, ok := errResp.Details[field]; !ok {
+			t.Fatalf("details = %v, want %v", errResp.Details, field)
+		}
+	}
+
+	send(t, app, http.MethodPost, "/api/v2/nft-collections", DeployNftCollectionRequest{}, anonymous).expectError(t, fiber.StatusUnauthorized, CodeUnauthorized)
+
+	services.err = contentlink.ErrFetchFailed
+	dividend = 5
+	send(t, app, http.MethodPost, "/api/v2/nft-collections", DeployNftCollectionRequest{
+		CollectionContent: "https://example.com/collection.json",
+		RoyaltyDividend:   &dividend,
+		RoyaltyDivisor:    &divisor,
+		IsTestnet:         &isTestnet,
+	}).expectError(t, fiber.StatusBadGateway, CodeBadGateway)
+}
+
+func TestWithdrawNftCollection(t *testing.T) {
+	services := &fakeNftCollectionServices{}
+	app := newNftCollectionTestApp(services)
+
+	send(t, app, http.MethodPost, "/api/nft-collection/withdraw/"+testNftCollectionAddress+"?withdraw-to="+testWalletAddress+"&is-testnet=true", nil).
+		expectStatus(t, fiber.StatusOK)
+	if services.nftCollectionAddress.String() != testNftCollectionAddress || services.withdrawToAddress.String() != testWalletAddress ||
+		services.ownerID != testUserID || !services.isTestnet {
+		t.Fatalf("withdrawn %v to %v by %v", services.nftCollectionAddress, services.withdrawToAddress, services.ownerID)
+	}
+
+	isTestnet := false
+	resp := send(t, app, http.MethodPost, "/api/v2/nft-collections/"+testNftCollectionAddress+"/withdraw", WithdrawNftRequest{WithdrawTo: testWalletAddress, IsTestnet: &isTestnet})
+	resp.expectStatus(t, fiber.StatusOK)
+	var withdrawn struct {
+		Address    string `json:"address"`
+		WithdrawTo string `json:"withdraw_to"`
+	}
+	resp.decode(t, &withdrawn)
+	if withdrawn.Address != testNftCollectionAddress || withdrawn.WithdrawTo != testWalletAddress || services.isTestnet {
+		t.Fatalf("response = %+v", withdrawn)
+	}
+}
+
+func TestWithdrawNftCollectionErrors(t *testing.T) {
+	services := &fakeNftCollectionServices{}
+	app := newNftCollectionTestApp(services)
+	isTestnet := true
+
+	send(t, app, http.MethodPost, "/api/nft-collection/withdraw/"+testNftCollectionAddress+"?is-testnet=true", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/nft-collection/withdraw/collection?withdraw-to="+testWalletAddress+"&is-testnet=true", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/nft-collection/withdraw/"+testNftCollectionAddress+"?withdraw-to=wallet&is-testnet=true", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/v2/nft-collections/collection/withdraw", WithdrawNftRequest{WithdrawTo: testWalletAddress, IsTestnet: &isTestnet}).
+		expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+
+	services.err = nftcollection.ErrNotCustodial
+	send(t, app, http.MethodPost, "/api/nft-collection/withdraw/"+testNftCollectionAddress+"?withdraw-to="+testWalletAddress+"&is-testnet=true", nil).
+		expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodPost, "/api/v2/nft-collections/"+testNftCollectionAddress+"/withdraw", WithdrawNftRequest{WithdrawTo: testWalletAddress, IsTestnet: &isTestnet}).
+		expectError(t, fiber.StatusConflict, CodeConflict)
+}
+
+func TestChangeNftCollectionContent(t *testing.T) {
+	services := &fakeNftCollectionServices{}
+	app := newNftCollectionTestApp(services)
+
+	resp := send(t, app, http.MethodPost, "/api/nft-collection/change-content/"+testNftCollectionAddress+
+		"?collection-content=https://example.com/new.json&royalty-dividend=1&royalty-divisor=10&royalty-address="+testWalletAddress+"&is-testnet=true", nil)
+	resp.expectStatus(t, fiber.StatusAccepted)
+
+	var metadata nftcollection.NftCollectionMetadata
+	resp.decode(t, &metadata)
+	if metadata.Name != "collection" {
+		t.Fatalf("metadata = %+v", metadata)
+	}
+
+	cfg := services.changeCfg
+	if services.nftCollectionAddress.String() != testNftCollectionAddress || cfg.CollectionContent != "https://example.com/new.json" ||
+		*cfg.RoyaltyDividend != 1 || *cfg.RoyaltyDivisor != 10 || cfg.RoyaltyAddress.String() != testWalletAddress || services.ownerID != testUserID {
+		t.Fatalf("cfg = %+v", cfg)
+	}
+
+	// royalty is kept if it isnt changed
+	send(t, app, http.MethodPost, "/api/nft-collection/change-content/"+testNftCollectionAddress+"?collection-content=https://example.com/new.json&is-testnet=true", nil).
+		expectStatus(t, fiber.StatusAccepted)
+	if services.changeCfg.RoyaltyDividend != nil || services.changeCfg.RoyaltyAddress != nil {
+		t.Fatalf("cfg = %+v", services.changeCfg)
+	}
+}
+
+func TestChangeNftCollectionContentRejectsInvalidQuery(t *testing.T) {
+	app := newNftCollectionTestApp(&fakeNftCollectionServices{})
+	target := "/api/nft-collection/change-content/" + testNftCollectionAddress + "?"
+
+	tests := map[string]string{
+		"no content":              "is-testnet=true",
+		"half of royalty":         "collection-content=link&is-testnet=true&royalty-dividend=1",
+		"invalid royalty":         "collection-content=link&is-testnet=true&royalty-dividend=1&royalty-divisor=ten",
+		"invalid royalty address": "collection-content=link&is-testnet=true&royalty-address=wallet",
+		"invalid network":         "collection-content=link&is-testnet=maybe",
+	}
+
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			send(t, app, http.MethodPost, target+query, nil).expectStatus(t, fiber.StatusBadRequest)
+		})
+	}
+
+	send(t, app, http.MethodPost, "/api/nft-collection/change-content/collection?collection-content=link&is-testnet=true", nil).expectStatus(t, fiber.StatusBadRequest)
+}
+
+func TestChangeNftCollectionContentV2(t *testing.T) {
+	services := &fakeNftCollectionServices{}
+	app := newNftCollectionTestApp(services)
+	isTestnet := true
+
+	send(t, app, http.MethodPost, "/api/v2/nft-collections/"+testNftCollectionAddress+"/content", ChangeNftCollectionContentRequest{
+		HostedMetadata: &nftcollection.NftCollectionMetadata{Name: "collection"},
+		IsTestnet:      &isTestnet,
+	}).expectStatus(t, fiber.StatusAccepted)
+	if services.changeCfg.HostedMetadata.Name != "collection" || services.changeCfg.RoyaltyDividend != nil {
+		t.Fatalf("cfg = %+v", services.changeCfg)
+	}
+
+	dividend := uint16(1)
+	errResp := send(t, app, http.MethodPost, "/api/v2/nft-collections/"+testNftCollectionAddress+"/content", ChangeNftCollectionContentRequest{
+		CollectionContent: "https://example.com/new.json",
+		RoyaltyDividend:   &dividend,
+		IsTestnet:         &isTestnet,
+	}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	if _, ok := errResp.Details["royalty_divisor"]; !ok {
+		t.Fatalf("details = %v, want royalty_divisor", errResp.Details)
+	}
+
+	send(t, app, http.MethodPost, "/api/v2/nft-collections/collection/content", ChangeNftCollectionContentRequest{}).
+		expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+
+	services.err = nftcollection.ErrNotCollectionOwner
+	send(t, app, http.MethodPost, "/api/v2/nft-collections/"+testNftCollectionAddress+"/content", ChangeNftCollectionContentRequest{
+		CollectionContent: "https://example.com/new.json",
+		IsTestnet:         &isTestnet,
+	}).expectError(t, fiber.StatusForbidden, CodeForbidden)
+}
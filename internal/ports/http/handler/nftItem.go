@@ -2,11 +2,13 @@ package handler
 
 import (
 	"fmt"
+	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
 	mintnftitem "github.com/rom6n/create-nft-go/internal/service/mint_nft_item"
+	transfernftitem "github.com/rom6n/create-nft-go/internal/service/transfer_nft_item"
 	withdrawnftitem "github.com/rom6n/create-nft-go/internal/service/withdraw_nft_item"
 	"github.com/xssnick/tonutils-go/address"
 )
@@ -14,16 +16,37 @@ import (
 type NftItemHandler struct {
 	MintNftItemService     mintnftitem.MintNftItemServiceRepository
 	WithdrawNftItemService withdrawnftitem.WithdrawNftItemServiceRepository
+	TransferNftItemService transfernftitem.TransferNftItemServiceRepository
 }
 
 func (v *NftItemHandler) MintNftItem() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ownerWallet, content, fwdAmount, fwdMsg, nftCollectionAddress, ownerID, isTest := c.Query("owner-wallet"), c.Query("content"), c.Query("forward-amount"), c.Query("forward-message"), c.Query("nft-collection-address"), c.Query("owner-id"), c.Query("is-testnet")
-		if content == "" || nftCollectionAddress == "" || ownerID == "" || isTest == "" {
-			return c.Status(fiber.StatusBadRequest).SendString("content link, is testnet, owner id and nft collection address are required")
+		hosted := c.QueryBool("hosted")
+		ownerIDInt64, authErr := actingUserID(c, ownerID)
+		if authErr != nil {
+			return sendAuthError(c, authErr)
 		}
 
-		// ?owner-wallet=0QDU46qYz4rHAJhszrW9w6imF8p4Cw5dS1GpPTcJ9vqNSmnf&owner-id=5003727541&content=https://rom6n.github.io/mc1f/nft-c1-item-2.json&forward-amount=&forward-message=&nft-collection-address=EQBNQ_nUxOprp6Ak9FUo5HiM5XrW95u1y1QAL4659zi8rWVD&is-testnet=true
+		if c.QueryBool("onchain") {
+			return c.Status(fiber.StatusBadRequest).SendString("onchain metadata " + onchainItemMetadataReason)
+		}
+
+		if (content == "" && !hosted) || nftCollectionAddress == "" || isTest == "" {
+			return c.Status(fiber.StatusBadRequest).SendString("content link, is testnet and nft collection address are required")
+		}
+
+		// hosted metadata comes in body as nft item metadata json and is served by the app
+		var hostedMetadata *nftitem.NftItemMetadata
+		if hosted {
+			hostedMetadata = &nftitem.NftItemMetadata{}
+			if parseErr := c.BodyParser(hostedMetadata); parseErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("hosted metadata is not valid: %v", parseErr))
+			}
+			if hostedMetadata.Name == "" {
+				return c.Status(fiber.StatusBadRequest).SendString("hosted metadata name is required")
+			}
+		}
 
 		var ownerAddress *address.Address
 		if ownerWallet != "" {
@@ -37,7 +60,7 @@ func (v *NftItemHandler) MintNftItem() fiber.Handler {
 
 		isTestnet, parseBoolErr := strconv.ParseBool(isTest)
 		if parseBoolErr != nil {
-			c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error parse is-testnet to bool: %v", parseBoolErr))
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse is-testnet to bool: %v", parseBoolErr))
 		}
 
 		nftCollectionAddr, parseAddrErr := address.ParseAddr(nftCollectionAddress)
@@ -45,11 +68,6 @@ func (v *NftItemHandler) MintNftItem() fiber.Handler {
 			return c.Status(fiber.StatusBadRequest).SendString("nft collection is not valid address")
 		}
 
-		ownerIDInt64, parseErr := strconv.ParseInt(ownerID, 0, 64)
-		if parseErr != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error parsing user id to int64: %v", parseErr))
-		}
-
 		var forvardAmount uint64
 		if fwdAmount != "" {
 			if forvardAmountParsed, parseErr := strconv.ParseUint(fwdAmount, 0, 64); parseErr != nil {
@@ -64,6 +82,7 @@ func (v *NftItemHandler) MintNftItem() fiber.Handler {
 			Content:        content,
 			ForwardAmount:  forvardAmount,
 			ForwardMessage: fwdMsg,
+			HostedMetadata: hostedMetadata,
 		}
 
 		nftItem, mintErr := v.MintNftItemService.MintNftItem(c.Context(), nftCollectionAddr, mintCfg, ownerIDInt64, isTestnet)
@@ -75,18 +94,93 @@ func (v *NftItemHandler) MintNftItem() fiber.Handler {
 	}
 }
 
+func (v *NftItemHandler) BatchMintNftItems() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerWallet, nftCollectionAddress, ownerID, isTest := c.Query("owner-wallet"), c.Query("nft-collection-address"), c.Query("owner-id"), c.Query("is-testnet")
+		ownerIDInt64, authErr := actingUserID(c, ownerID)
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		if nftCollectionAddress == "" || isTest == "" {
+			return c.Status(fiber.StatusBadRequest).SendString("is testnet and nft collection address are required")
+		}
+
+		// items come in body as {"items": [{"content": "..."}, {"hosted_metadata": {...}}]}
+		var body BatchMintItems
+		if parseErr := c.BodyParser(&body); parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("items are not valid: %v", parseErr))
+		}
+
+		if fields := onchainItemMetadataFields(c.Body()); len(fields) > 0 {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("%v %v", fields[0], onchainItemMetadataReason))
+		}
+
+		if len(body.Items) == 0 || len(body.Items) > nftitem.MaxBatchMintItems {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("from 1 to %v items are required", nftitem.MaxBatchMintItems))
+		}
+
+		for i, item := range body.Items {
+			if item.HostedMetadata == nil && item.Content == "" {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("item %v: content link or hosted metadata is required", i))
+			}
+			if item.HostedMetadata != nil && item.HostedMetadata.Name == "" {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("item %v: hosted metadata name is required", i))
+			}
+		}
+
+		var ownerAddress *address.Address
+		if ownerWallet != "" {
+			parsedOwnerAddress, parseAddrErr := address.ParseAddr(ownerWallet)
+			if parseAddrErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString("owner wallet is not valid address")
+			}
+			ownerAddress = parsedOwnerAddress
+		}
+
+		isTestnet, parseBoolErr := strconv.ParseBool(isTest)
+		if parseBoolErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse is-testnet to bool: %v", parseBoolErr))
+		}
+
+		nftCollectionAddr, parseAddrErr := address.ParseAddr(nftCollectionAddress)
+		if parseAddrErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("nft collection is not valid address")
+		}
+
+		batchCfg := nftitem.BatchMintNftItemsCfg{
+			OwnerAddress: ownerAddress,
+			Items:        body.Items,
+		}
+
+		nftItems, mintErr := v.MintNftItemService.BatchMintNftItems(c.Context(), nftCollectionAddr, batchCfg, ownerIDInt64, isTestnet)
+		if mintErr != nil {
+			if len(nftItems) > 0 {
+				// part of items is sent, the rest is refunded
+				return c.Status(fiber.StatusMultiStatus).JSON(fiber.Map{
+					"items": nftItems,
+					"error": mintErr.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error batch minting nft items: %v", mintErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"items": nftItems})
+	}
+}
+
 func (v *NftItemHandler) WithdrawNftItem() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx := c.Context()
 
 		nftItemAddressStr, WithdrawToAddressStr, ownerIDStr, isTest := c.Params("address"), c.Query("withdraw-to"), c.Query("owner-id"), c.Query("is-testnet")
-		if WithdrawToAddressStr == "" || isTest == "" || ownerIDStr == "" {
-			return c.Status(fiber.StatusBadRequest).SendString("withdraw to, owner id and is testnet are required")
+		ownerID, authErr := actingUserID(c, ownerIDStr)
+		if authErr != nil {
+			return sendAuthError(c, authErr)
 		}
 
-		ownerID, parseErr := strconv.Atoi(ownerIDStr)
-		if parseErr != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error parse to int: %v", parseErr))
+		if WithdrawToAddressStr == "" || isTest == "" {
+			return c.Status(fiber.StatusBadRequest).SendString("withdraw to and is testnet are required")
 		}
 
 		isTestnet, parseBoolErr := strconv.ParseBool(isTest)
@@ -104,10 +198,160 @@ func (v *NftItemHandler) WithdrawNftItem() fiber.Handler {
 			return c.Status(fiber.StatusBadRequest).SendString("new owner is not valid address")
 		}
 
-		if withdrawErr := v.WithdrawNftItemService.WithdrawNftItem(ctx, nftItemAddress, newOwnerAddress, int64(ownerID), isTestnet); withdrawErr != nil {
+		if withdrawErr := v.WithdrawNftItemService.WithdrawNftItem(ctx, nftItemAddress, newOwnerAddress, ownerID, isTestnet); withdrawErr != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error withdrawing: %v", withdrawErr))
 		}
 
 		return c.Status(fiber.StatusOK).SendString("Successfully withdrawed nft item")
 	}
 }
+
+func (v *NftItemHandler) TransferNftItem() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		nftItemAddress, toUserIDStr, ownerIDStr := c.Params("address"), c.Query("to-user-id"), c.Query("owner-id")
+		ownerID, authErr := actingUserID(c, ownerIDStr)
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		// nft items are stored by address in the form api returns them
+		if _, parseAddrErr := address.ParseAddr(nftItemAddress); parseAddrErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("nft item is not valid address")
+		}
+
+		toUserID, parseIntErr := strconv.ParseInt(toUserIDStr, 10, 64)
+		if parseIntErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse to-user-id to int: %v", parseIntErr))
+		}
+
+		transfer, transferErr := v.TransferNftItemService.TransferNftItem(c.Context(), nftItemAddress, ownerID, toUserID)
+		if transferErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error transferring nft item: %v", transferErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(transfer)
+	}
+}
+
+func (v *NftItemHandler) GetNftItemHistory() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		transfers, historyErr := v.TransferNftItemService.GetNftItemHistory(c.Context(), c.Params("address"))
+		if historyErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("error getting nft item history: %v", historyErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(transfers)
+	}
+}
+
+func (v *NftItemHandler) MintNftItemV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		var body MintNftItemRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		nftItem, mintErr := v.MintNftItemService.MintNftItem(c.Context(), body.nftCollectionAddress, body.cfg(), ownerID, *body.IsTestnet)
+		if mintErr != nil {
+			return sendServiceErrorV2(c, mintErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(nftItem)
+	}
+}
+
+func (v *NftItemHandler) BatchMintNftItemsV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		var body BatchMintNftItemsRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		nftItems, mintErr := v.MintNftItemService.BatchMintNftItems(c.Context(), body.nftCollectionAddress, body.cfg(), ownerID, *body.IsTestnet)
+		if mintErr != nil {
+			if len(nftItems) > 0 {
+				// part of items is sent, the rest is refunded
+				log.Printf("batch mint is partially sent: %v\n", mintErr)
+				return c.Status(fiber.StatusMultiStatus).JSON(fiber.Map{
+					"items": nftItems,
+					"error": ErrorResponse{Code: CodeInternal, Message: "part of nft items is not minted and refunded"},
+				})
+			}
+			return sendServiceErrorV2(c, mintErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"items": nftItems})
+	}
+}
+
+func (v *NftItemHandler) WithdrawNftItemV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		nftItemAddress, parseAddrErr := address.ParseAddr(c.Params("address"))
+		if parseAddrErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "nft item is not valid address", nil)
+		}
+
+		var body WithdrawNftRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		if withdrawErr := v.WithdrawNftItemService.WithdrawNftItem(c.Context(), nftItemAddress, body.withdrawTo, ownerID, *body.IsTestnet); withdrawErr != nil {
+			return sendServiceErrorV2(c, withdrawErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"address": nftItemAddress.String(), "withdraw_to": body.withdrawTo.String()})
+	}
+}
+
+func (v *NftItemHandler) TransferNftItemV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		// nft items are stored by address in the form api returns them
+		if _, parseAddrErr := address.ParseAddr(c.Params("address")); parseAddrErr != nil {
+			return sendErrorV2(c, fiber.StatusBadRequest, CodeValidationFailed, "nft item is not valid address", nil)
+		}
+
+		var body TransferNftItemRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		transfer, transferErr := v.TransferNftItemService.TransferNftItem(c.Context(), c.Params("address"), ownerID, body.ToUserID)
+		if transferErr != nil {
+			return sendServiceErrorV2(c, transferErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(transfer)
+	}
+}
+
+func (v *NftItemHandler) GetNftItemHistoryV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		transfers, historyErr := v.TransferNftItemService.GetNftItemHistory(c.Context(), c.Params("address"))
+		if historyErr != nil {
+			return sendServiceErrorV2(c, historyErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(transfers)
+	}
+}
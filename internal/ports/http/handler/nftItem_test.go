@@ -0,0 +1,436 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+// fakeMintNftItemService records arguments of the last mint and answers with nftItems or err
+type fakeMintNftItemService struct {
+	nftItems []*nftitem.NftItem
+	err      error
+
+	nftCollectionAddress *address.Address
+	cfg                  nftitem.MintNftItemCfg
+	batchCfg             nftitem.BatchMintNftItemsCfg
+	ownerID              int64
+	isTestnet            bool
+}
+
+func (s *fakeMintNftItemService) MintNftItem(_ context.Context, nftCollectionAddress *address.Address, cfg nftitem.MintNftItemCfg, ownerID int64, isTestnet bool) (*nftitem.NftItem, error) {
+	s.nftCollectionAddress, s.cfg, s.ownerID, s.isTestnet = nftCollectionAddress, cfg, ownerID, isTestnet
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.nftItems[0], nil
+}
+
+func (s *fakeMintNftItemService) BatchMintNftItems(_ context.Context, nftCollectionAddress *address.Address, cfg nftitem.BatchMintNftItemsCfg, ownerID int64, isTestnet bool) ([]*nftitem.NftItem, error) {
+	s.nftCollectionAddress, s.batchCfg, s.ownerID, s.isTestnet = nftCollectionAddress, cfg, ownerID, isTestnet
+	return s.nftItems, s.err
+}
+
+type fakeWithdrawNftItemService struct {
+	err error
+
+	nftItemAddress    *address.Address
+	withdrawToAddress *address.Address
+	ownerID           int64
+	isTestnet         bool
+}
+
+func (s *fakeWithdrawNftItemService) WithdrawNftItem(_ context.Context, nftItemAddress *address.Address, withdrawToAddress *address.Address, ownerID int64, isTestnet bool) error {
+	s.nftItemAddress, s.withdrawToAddress, s.ownerID, s.isTestnet = nftItemAddress, withdrawToAddress, ownerID, isTestnet
+	return s.err
+}
+
+type fakeTransferNftItemService struct {
+	transfers []nftitem.Transfer
+	err       error
+
+	nftItemAddress string
+	ownerID        int64
+	recipientID    int64
+}
+
+func (s *fakeTransferNftItemService) TransferNftItem(_ context.Context, nftItemAddress string, ownerID int64, recipientID int64) (*nftitem.Transfer, error) {
+	s.nftItemAddress, s.ownerID, s.recipientID = nftItemAddress, ownerID, recipientID
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &s.transfers[0], nil
+}
+
+func (s *fakeTransferNftItemService) GetNftItemHistory(_ context.Context, nftItemAddress string) ([]nftitem.Transfer, error) {
+	s.nftItemAddress = nftItemAddress
+	return s.transfers, s.err
+}
+
+func newNftItemTestApp(mint *fakeMintNftItemService, withdraw *fakeWithdrawNftItemService, transfer *fakeTransferNftItemService) *fiber.App {
+	h := &NftItemHandler{MintNftItemService: mint, WithdrawNftItemService: withdraw, TransferNftItemService: transfer}
+	return newTestApp(func(app *fiber.App) {
+		app.Post("/api/nft-item/mint", h.MintNftItem())
+		app.Post("/api/nft-item/withdraw/:address", h.WithdrawNftItem())
+		app.Post("/api/nft-item/batch-mint", h.BatchMintNftItems())
+		app.Post("/api/nft-item/transfer/:address", h.TransferNftItem())
+		app.Get("/api/nft-item/history/:address", h.GetNftItemHistory())
+
+		app.Post("/api/v2/nft-items", h.MintNftItemV2())
+		app.Post("/api/v2/nft-items/batch", h.BatchMintNftItemsV2())
+		app.Post("/api/v2/nft-items/:address/withdraw", h.WithdrawNftItemV2())
+		app.Post("/api/v2/nft-items/:address/transfer", h.TransferNftItemV2())
+		app.Get("/api/v2/nft-items/:address/history", h.GetNftItemHistoryV2())
+	})
+}
+
+func newMintTestApp(mint *fakeMintNftItemService) *fiber.App {
+	return newNftItemTestApp(mint, &fakeWithdrawNftItemService{}, &fakeTransferNftItemService{})
+}
+
+func TestMintNftItem(t *testing.T) {
+	mint := &fakeMintNftItemService{nftItems: []*nftitem.NftItem{{Address: testNftItemAddress}}}
+	app := newMintTestApp(mint)
+
+	resp := send(t, app, http.MethodPost, "/api/nft-item/mint?nft-collection-address="+testNftCollectionAddress+
+		"&content=item.json&owner-wallet="+testWalletAddress+"&forward-amount=1000&forward-message=hi&is-testnet=true", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var minted nftitem.NftItem
+	resp.decode(t, &minted)
+	if minted.Address != testNftItemAddress {
+		t.Fatalf("minted = %v", minted.Address)
+	}
+
+	cfg := mint.cfg
+	if mint.nftCollectionAddress.String() != testNftCollectionAddress || cfg.OwnerAddress.String() != testWalletAddress || cfg.Content != "item.json" ||
+		cfg.ForwardAmount != 1000 || cfg.ForwardMessage != "hi" || cfg.HostedMetadata != nil ||
+		mint.ownerID != testUserID || !mint.isTestnet {
+		t.Fatalf("cfg = %+v of user %v", cfg, mint.ownerID)
+	}
+}
+
+func TestMintNftItemWithMetadataInBody(t *testing.T) {
+	mint := &fakeMintNftItemService{nftItems: []*nftitem.NftItem{{Address: testNftItemAddress}}}
+	app := newMintTestApp(mint)
+	metadata := nftitem.NftItemMetadata{Name: "item", Attributes: []nftitem.Attribute{{TraitType: "color", Value: "red"}}}
+
+	send(t, app, http.MethodPost, "/api/nft-item/mint?nft-collection-address="+testNftCollectionAddress+"&hosted=true&is-testnet=false", metadata).
+		expectStatus(t, fiber.StatusOK)
+	if mint.cfg.HostedMetadata == nil || mint.cfg.HostedMetadata.Attributes[0].Value != "red" || mint.isTestnet {
+		t.Fatalf("cfg = %+v", mint.cfg)
+	}
+}
+
+func TestMintNftItemRejectsInvalidQuery(t *testing.T) {
+	app := newMintTestApp(&fakeMintNftItemService{})
+	collection := "nft-collection-address=" + testNftCollectionAddress
+
+	tests := map[string]struct {
+		query  string
+		body   any
+		status int
+	}{
+		"no content":            {collection + "&is-testnet=true", nil, fiber.StatusBadRequest},
+		"no collection":         {"content=item.json&is-testnet=true", nil, fiber.StatusBadRequest},
+		"no network":            {collection + "&content=item.json", nil, fiber.StatusBadRequest},
+		"onchain metadata":      {collection + "&onchain=true&is-testnet=true", nftitem.NftItemMetadata{Name: "item"}, fiber.StatusBadRequest},
+		"hosted without name":   {collection + "&hosted=true&is-testnet=true", nftitem.NftItemMetadata{}, fiber.StatusBadRequest},
+		"invalid owner wallet":  {collection + "&content=item.json&owner-wallet=wallet&is-testnet=true", nil, fiber.StatusBadRequest},
+		"invalid network":       {collection + "&content=item.json&is-testnet=maybe", nil, fiber.StatusBadRequest},
+		"invalid collection":    {"nft-collection-address=collection&content=item.json&is-testnet=true", nil, fiber.StatusBadRequest},
+		"foreign owner":         {collection + "&content=item.json&is-testnet=true&owner-id=1", nil, fiber.StatusForbidden},
+		"invalid forward value": {collection + "&content=item.json&is-testnet=true&forward-amount=ton", nil, fiber.StatusInternalServerError},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			send(t, app, http.MethodPost, "/api/nft-item/mint?"+tt.query, tt.body).expectStatus(t, tt.status)
+		})
+	}
+}
+
+func TestMintNftItemV2(t *testing.T) {
+	mint := &fakeMintNftItemService{nftItems: []*nftitem.NftItem{{Address: testNftItemAddress}}}
+	app := newMintTestApp(mint)
+	isTestnet := true
+
+	send(t, app, http.MethodPost, "/api/v2/nft-items", MintNftItemRequest{
+		NftCollectionAddress: testNftCollectionAddress,
+		HostedMetadata:       &nftitem.NftItemMetadata{Name: "item"},
+		ForwardAmount:        1000,
+		IsTestnet:            &isTestnet,
+	}).expectStatus(t, fiber.StatusOK)
+
+	if mint.nftCollectionAddress.String() != testNftCollectionAddress || mint.cfg.HostedMetadata.Name != "item" ||
+		mint.cfg.ForwardAmount != 1000 || mint.cfg.OwnerAddress != nil || mint.ownerID != testUserID || !mint.isTestnet {
+		t.Fatalf("cfg = %+v", mint.cfg)
+	}
+}
+
+func TestMintNftItemV2Errors(t *testing.T) {
+	mint := &fakeMintNftItemService{}
+	app := newMintTestApp(mint)
+	isTestnet := true
+
+	errResp := send(t, app, http.MethodPost, "/api/v2/nft-items", MintNftItemRequest{Content: "http://example.com/item.json"}).
+		expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	for _, field := range []string{"nft_collection_address", "content", "is_testnet"} {
+		if _, ok := errResp.Details[field]; !ok {
+			t.Fatalf("details = %v, want %v", errResp.Details, field)
+		}
+	}
+
+	errResp = send(t, app, http.MethodPost, "/api/v2/nft-items", MintNftItemRequest{
+		NftCollectionAddress: testNftCollectionAddress,
+		HostedMetadata:       &nftitem.NftItemMetadata{Name: "item", Image: "ftp://image"},
+		IsTestnet:            &isTestnet,
+	}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	if _, ok := errResp.Details["hosted_metadata.image"]; !ok {
+		t.Fatalf("details = %v, want hosted_metadata.image", errResp.Details)
+	}
+
+	// standard nft collection cant return onchain item content
+	errResp = send(t, app, http.MethodPost, "/api/v2/nft-items", fiber.Map{
+		"nft_collection_address": testNftCollectionAddress,
+		"onchain_metadata":       fiber.Map{"name": "item"},
+		"is_testnet":             true,
+	}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	if reason := errResp.Details["onchain_metadata"]; reason != onchainItemMetadataReason {
+		t.Fatalf("details = %v, want onchain_metadata", errResp.Details)
+	}
+
+	send(t, app, http.MethodPost, "/api/v2/nft-items", "{").expectError(t, fiber.StatusBadRequest, CodeInvalidBody)
+	send(t, app, http.MethodPost, "/api/v2/nft-items", MintNftItemRequest{}, anonymous).expectError(t, fiber.StatusUnauthorized, CodeUnauthorized)
+
+	mint.err = nftcollection.ErrNotCollectionOwner
+	send(t, app, http.MethodPost, "/api/v2/nft-items", MintNftItemRequest{
+		NftCollectionAddress: testNftCollectionAddress,
+		Content:              "ipfs://item.json",
+		IsTestnet:            &isTestnet,
+	}).expectError(t, fiber.StatusForbidden, CodeForbidden)
+}
+
+func TestBatchMintNftItems(t *testing.T) {
+	mint := &fakeMintNftItemService{nftItems: []*nftitem.NftItem{{Address: "first"}, {Address: "second"}}}
+	app := newMintTestApp(mint)
+	body := BatchMintItems{Items: []nftitem.BatchMintItem{{Content: "first.json"}, {HostedMetadata: &nftitem.NftItemMetadata{Name: "second"}}}}
+
+	resp := send(t, app, http.MethodPost, "/api/nft-item/batch-mint?nft-collection-address="+testNftCollectionAddress+"&is-testnet=true", body)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var page struct {
+		Items []nftitem.NftItem `json:"items"`
+	}
+	resp.decode(t, &page)
+	if len(page.Items) != 2 || len(mint.batchCfg.Items) != 2 || mint.batchCfg.Items[1].HostedMetadata.Name != "second" || !mint.isTestnet {
+		t.Fatalf("items = %v, cfg = %+v", page.Items, mint.batchCfg)
+	}
+}
+
+func TestBatchMintNftItemsPartiallySent(t *testing.T) {
+	mint := &fakeMintNftItemService{nftItems: []*nftitem.NftItem{{Address: "first"}}, err: errors.New("lite server is unavailable")}
+	app := newMintTestApp(mint)
+	body := BatchMintItems{Items: []nftitem.BatchMintItem{{Content: "https://example.com/first.json"}, {Content: "https://example.com/second.json"}}}
+
+	resp := send(t, app, http.MethodPost, "/api/nft-item/batch-mint?nft-collection-address="+testNftCollectionAddress+"&is-testnet=true", body)
+	resp.expectStatus(t, fiber.StatusMultiStatus)
+	var partial struct {
+		Items []nftitem.NftItem `json:"items"`
+		Error string            `json:"error"`
+	}
+	resp.decode(t, &partial)
+	if len(partial.Items) != 1 || partial.Error == "" {
+		t.Fatalf("response = %+v", partial)
+	}
+
+	isTestnet := true
+	resp = send(t, app, http.MethodPost, "/api/v2/nft-items/batch", BatchMintNftItemsRequest{
+		NftCollectionAddress: testNftCollectionAddress,
+		Items:                body.Items,
+		IsTestnet:            &isTestnet,
+	})
+	resp.expectStatus(t, fiber.StatusMultiStatus)
+	var partialV2 struct {
+		Items []nftitem.NftItem `json:"items"`
+		Error ErrorResponse     `json:"error"`
+	}
+	resp.decode(t, &partialV2)
+	if len(partialV2.Items) != 1 || partialV2.Error.Code != CodeInternal {
+		t.Fatalf("response = %+v", partialV2)
+	}
+
+	// nothing is sent
+	mint.nftItems = nil
+	send(t, app, http.MethodPost, "/api/nft-item/batch-mint?nft-collection-address="+testNftCollectionAddress+"&is-testnet=true", body).
+		expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodPost, "/api/v2/nft-items/batch", BatchMintNftItemsRequest{
+		NftCollectionAddress: testNftCollectionAddress,
+		Items:                body.Items,
+		IsTestnet:            &isTestnet,
+	}).expectError(t, fiber.StatusInternalServerError, CodeInternal)
+}
+
+func TestBatchMintNftItemsRejectsInvalidItems(t *testing.T) {
+	app := newMintTestApp(&fakeMintNftItemService{})
+	target := "/api/nft-item/batch-mint?nft-collection-address=" + testNftCollectionAddress + "&is-testnet=true"
+
+	tests := map[string]BatchMintItems{
+		"no items":            {},
+		"too many items":      {Items: make([]nftitem.BatchMintItem, nftitem.MaxBatchMintItems+1)},
+		"no content":          {Items: []nftitem.BatchMintItem{{}}},
+		"hosted without name": {Items: []nftitem.BatchMintItem{{HostedMetadata: &nftitem.NftItemMetadata{}}}},
+	}
+
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			send(t, app, http.MethodPost, target, body).expectStatus(t, fiber.StatusBadRequest)
+		})
+	}
+
+	send(t, app, http.MethodPost, "/api/nft-item/batch-mint?is-testnet=true", BatchMintItems{}).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, target, "{").expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, target, fiber.Map{"items": []fiber.Map{{"content": "https://example.com/1.json", "onchain_metadata": fiber.Map{"name": "a"}}}}).
+		expectStatus(t, fiber.StatusBadRequest)
+
+	errResp := send(t, app, http.MethodPost, "/api/v2/nft-items/batch", BatchMintNftItemsRequest{Items: []nftitem.BatchMintItem{{}}}).
+		expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	for _, field := range []string{"nft_collection_address", "items[0].content", "is_testnet"} {
+		if _, ok := errResp.Details[field]; !ok {
+			t.Fatalf("details = %v, want %v", errResp.Details, field)
+		}
+	}
+
+	errResp = send(t, app, http.MethodPost, "/api/v2/nft-items/batch", fiber.Map{"items": []fiber.Map{{"content": "https://example.com/1.json"}, {"onchain_metadata": fiber.Map{"name": "a"}}}}).
+		expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	if _, ok := errResp.Details["items[1].onchain_metadata"]; !ok {
+		t.Fatalf("details = %v, want items[1].onchain_metadata", errResp.Details)
+	}
+}
+
+func TestBatchMintNftItemsV2(t *testing.T) {
+	mint := &fakeMintNftItemService{nftItems: []*nftitem.NftItem{{Address: "first"}}}
+	app := newMintTestApp(mint)
+	isTestnet := false
+
+	resp := send(t, app, http.MethodPost, "/api/v2/nft-items/batch", BatchMintNftItemsRequest{
+		NftCollectionAddress: testNftCollectionAddress,
+		OwnerWallet:          testWalletAddress,
+		Items:                []nftitem.BatchMintItem{{Content: "https://example.com/first.json"}},
+		IsTestnet:            &isTestnet,
+	})
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var page struct {
+		Items []nftitem.NftItem `json:"items"`
+	}
+	resp.decode(t, &page)
+	if len(page.Items) != 1 || mint.batchCfg.OwnerAddress.String() != testWalletAddress || mint.isTestnet {
+		t.Fatalf("items = %v, cfg = %+v", page.Items, mint.batchCfg)
+	}
+}
+
+func TestWithdrawNftItem(t *testing.T) {
+	withdraw := &fakeWithdrawNftItemService{}
+	app := newNftItemTestApp(&fakeMintNftItemService{}, withdraw, &fakeTransferNftItemService{})
+
+	send(t, app, http.MethodPost, "/api/nft-item/withdraw/"+testNftItemAddress+"?withdraw-to="+testWalletAddress+"&is-testnet=true", nil).
+		expectStatus(t, fiber.StatusOK)
+	if withdraw.nftItemAddress.String() != testNftItemAddress || withdraw.withdrawToAddress.String() != testWalletAddress ||
+		withdraw.ownerID != testUserID || !withdraw.isTestnet {
+		t.Fatalf("withdrawn %v to %v by %v", withdraw.nftItemAddress, withdraw.withdrawToAddress, withdraw.ownerID)
+	}
+
+	isTestnet := false
+	resp := send(t, app, http.MethodPost, "/api/v2/nft-items/"+testNftItemAddress+"/withdraw", WithdrawNftRequest{WithdrawTo: testWalletAddress, IsTestnet: &isTestnet})
+	resp.expectStatus(t, fiber.StatusOK)
+	var withdrawn struct {
+		Address    string `json:"address"`
+		WithdrawTo string `json:"withdraw_to"`
+	}
+	resp.decode(t, &withdrawn)
+	if withdrawn.Address != testNftItemAddress || withdrawn.WithdrawTo != testWalletAddress || withdraw.isTestnet {
+		t.Fatalf("response = %+v", withdrawn)
+	}
+}
+
+func TestWithdrawNftItemErrors(t *testing.T) {
+	withdraw := &fakeWithdrawNftItemService{}
+	app := newNftItemTestApp(&fakeMintNftItemService{}, withdraw, &fakeTransferNftItemService{})
+	isTestnet := true
+
+	send(t, app, http.MethodPost, "/api/nft-item/withdraw/"+testNftItemAddress+"?is-testnet=true", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/nft-item/withdraw/item?withdraw-to="+testWalletAddress+"&is-testnet=true", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/nft-item/withdraw/"+testNftItemAddress+"?withdraw-to=wallet&is-testnet=true", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/v2/nft-items/item/withdraw", WithdrawNftRequest{WithdrawTo: testWalletAddress, IsTestnet: &isTestnet}).
+		expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	send(t, app, http.MethodPost, "/api/v2/nft-items/"+testNftItemAddress+"/withdraw", WithdrawNftRequest{}).
+		expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+
+	withdraw.err = nftitem.ErrWithdrawPending
+	send(t, app, http.MethodPost, "/api/nft-item/withdraw/"+testNftItemAddress+"?withdraw-to="+testWalletAddress+"&is-testnet=true", nil).
+		expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodPost, "/api/v2/nft-items/"+testNftItemAddress+"/withdraw", WithdrawNftRequest{WithdrawTo: testWalletAddress, IsTestnet: &isTestnet}).
+		expectError(t, fiber.StatusConflict, CodeConflict)
+}
+
+func TestTransferNftItem(t *testing.T) {
+	transfer := &fakeTransferNftItemService{transfers: []nftitem.Transfer{{ID: uuid.New(), NftItemAddress: testNftItemAddress}}}
+	app := newNftItemTestApp(&fakeMintNftItemService{}, &fakeWithdrawNftItemService{}, transfer)
+
+	send(t, app, http.MethodPost, "/api/nft-item/transfer/"+testNftItemAddress+"?to-user-id=456", nil).expectStatus(t, fiber.StatusOK)
+	if transfer.nftItemAddress != testNftItemAddress || transfer.ownerID != testUserID || transfer.recipientID != 456 {
+		t.Fatalf("transferred %v from %v to %v", transfer.nftItemAddress, transfer.ownerID, transfer.recipientID)
+	}
+
+	resp := send(t, app, http.MethodPost, "/api/v2/nft-items/"+testNftItemAddress+"/transfer", TransferNftItemRequest{ToUserID: 789})
+	resp.expectStatus(t, fiber.StatusOK)
+	var transferred nftitem.Transfer
+	resp.decode(t, &transferred)
+	if transferred.NftItemAddress != testNftItemAddress || transfer.recipientID != 789 {
+		t.Fatalf("transfer = %+v to %v", transferred, transfer.recipientID)
+	}
+}
+
+func TestTransferNftItemErrors(t *testing.T) {
+	transfer := &fakeTransferNftItemService{}
+	app := newNftItemTestApp(&fakeMintNftItemService{}, &fakeWithdrawNftItemService{}, transfer)
+
+	send(t, app, http.MethodPost, "/api/nft-item/transfer/item?to-user-id=456", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/nft-item/transfer/"+testNftItemAddress+"?to-user-id=bob", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/nft-item/transfer/"+testNftItemAddress+"?to-user-id=456", nil, anonymous).expectStatus(t, fiber.StatusUnauthorized)
+	send(t, app, http.MethodPost, "/api/v2/nft-items/item/transfer", TransferNftItemRequest{ToUserID: 456}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	send(t, app, http.MethodPost, "/api/v2/nft-items/"+testNftItemAddress+"/transfer", TransferNftItemRequest{}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+
+	transfer.err = nftitem.ErrRecipientNotFound
+	send(t, app, http.MethodPost, "/api/nft-item/transfer/"+testNftItemAddress+"?to-user-id=456", nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodPost, "/api/v2/nft-items/"+testNftItemAddress+"/transfer", TransferNftItemRequest{ToUserID: 456}).
+		expectError(t, fiber.StatusNotFound, CodeNotFound)
+}
+
+func TestGetNftItemHistory(t *testing.T) {
+	transfer := &fakeTransferNftItemService{transfers: []nftitem.Transfer{{ID: uuid.New()}, {ID: uuid.New()}}}
+	app := newNftItemTestApp(&fakeMintNftItemService{}, &fakeWithdrawNftItemService{}, transfer)
+
+	for _, target := range []string{"/api/nft-item/history/" + testNftItemAddress, "/api/v2/nft-items/" + testNftItemAddress + "/history"} {
+		resp := send(t, app, http.MethodGet, target, nil)
+		resp.expectStatus(t, fiber.StatusOK)
+
+		var transfers []nftitem.Transfer
+		resp.decode(t, &transfers)
+		if len(transfers) != 2 || transfer.nftItemAddress != testNftItemAddress {
+			t.Fatalf("%v: transfers = %v of %v", target, transfers, transfer.nftItemAddress)
+		}
+	}
+
+	transfer.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/nft-item/history/"+testNftItemAddress, nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodGet, "/api/v2/nft-items/"+testNftItemAddress+"/history", nil).expectError(t, fiber.StatusInternalServerError, CodeInternal)
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
+	pricingservice "github.com/rom6n/create-nft-go/internal/service/pricing_service"
+)
+
+type PricingHandler struct {
+	PricingService pricingservice.PricingServiceRepository
+}
+
+// Quote returns price of operation which user is charged, same quote is used by operation itself
+func (v *PricingHandler) Quote() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		isTestnet, parseBoolErr := strconv.ParseBool(c.Query("is-testnet"))
+		if parseBoolErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse is-testnet to bool: %v", parseBoolErr))
+		}
+
+		forwardAmount, parseUintErr := strconv.ParseUint(c.Query("forward-amount", "0"), 10, 64)
+		if parseUintErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse forward-amount to uint: %v", parseUintErr))
+		}
+
+		quote, quoteErr := v.PricingService.Quote(ctx, pricing.QuoteRequest{
+			Operation:     pricing.Operation(c.Query("operation")),
+			Items:         c.QueryInt("items", 1),
+			ForwardAmount: forwardAmount,
+			IsTestnet:     isTestnet,
+		})
+		if quoteErr != nil {
+			if errors.Is(quoteErr, pricing.ErrUnknownOperation) || errors.Is(quoteErr, pricing.ErrInvalidItems) {
+				return c.Status(fiber.StatusBadRequest).SendString(quoteErr.Error())
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while quoting: %v", quoteErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(quote)
+	}
+}
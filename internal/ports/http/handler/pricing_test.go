@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
+)
+
+// fakePricingService records the last quoted request and answers with err or quote of it
+type fakePricingService struct {
+	err error
+
+	req pricing.QuoteRequest
+}
+
+func (s *fakePricingService) Quote(_ context.Context, req pricing.QuoteRequest) (*pricing.Quote, error) {
+	s.req = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &pricing.Quote{Operation: req.Operation, Items: req.Items, IsTestnet: req.IsTestnet, MessageAmount: 50000000}, nil
+}
+
+func newPricingTestApp(service *fakePricingService) *fiber.App {
+	h := &PricingHandler{PricingService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Get("/api/quote", h.Quote())
+	})
+}
+
+func TestQuote(t *testing.T) {
+	service := &fakePricingService{}
+	app := newPricingTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/quote?operation=batch_mint_nft_items&items=3&forward-amount=10&is-testnet=true", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var quote pricing.Quote
+	resp.decode(t, &quote)
+	if quote.Operation != pricing.OperationBatchMintNftItems || quote.Items != 3 || quote.MessageAmount != 50000000 {
+		t.Fatalf("quote = %+v", quote)
+	}
+	if service.req.ForwardAmount != 10 || !service.req.IsTestnet {
+		t.Fatalf("request = %+v", service.req)
+	}
+
+	send(t, app, http.MethodGet, "/api/quote?operation=mint_nft_item&is-testnet=false", nil).expectStatus(t, fiber.StatusOK)
+	if service.req.Items != 1 || service.req.ForwardAmount != 0 {
+		t.Fatalf("default request = %+v", service.req)
+	}
+}
+
+func TestQuoteErrors(t *testing.T) {
+	service := &fakePricingService{}
+	app := newPricingTestApp(service)
+
+	send(t, app, http.MethodGet, "/api/quote?operation=mint_nft_item", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodGet, "/api/quote?operation=mint_nft_item&is-testnet=true&forward-amount=-1", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	for _, err := range []error{pricing.ErrUnknownOperation, pricing.ErrInvalidItems} {
+		service.err = err
+		send(t, app, http.MethodGet, "/api/quote?operation=mint&is-testnet=true", nil).expectStatus(t, fiber.StatusBadRequest)
+	}
+
+	service.err = errors.New("toncenter is unavailable")
+	send(t, app, http.MethodGet, "/api/quote?operation=mint_nft_item&is-testnet=true", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
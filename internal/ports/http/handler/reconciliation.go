@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/reconciliation"
+	reconciliationservice "github.com/rom6n/create-nft-go/internal/service/reconciliation_service"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// default and max count of reconciliation reports in one response
+const (
+	defaultReconciliationReports = 20
+	maxReconciliationReports     = 100
+)
+
+type ReconciliationHandler struct {
+	ReconciliationService reconciliationservice.ReconciliationServiceRepository
+}
+
+func (v *ReconciliationHandler) StartReconciliation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		policy, policyErr := reconciliation.ParsePolicy(c.Query("policy", string(reconciliation.PolicyReport)))
+		if policyErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("policy must be report, remove_stale, restore_missing or repair_all")
+		}
+
+		report, startErr := v.ReconciliationService.Reconcile(ctx, policy)
+		if startErr != nil {
+			if errors.Is(startErr, reconciliation.ErrAlreadyRunning) {
+				return c.Status(fiber.StatusConflict).SendString("Reconciliation is already running")
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while starting reconciliation: %v", startErr))
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(report)
+	}
+}
+
+func (v *ReconciliationHandler) GetReconciliationReports() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		limit := int64(defaultReconciliationReports)
+		if rawLimit := c.Query("limit"); rawLimit != "" {
+			parsed, parseErr := strconv.ParseInt(rawLimit, 10, 64)
+			if parseErr != nil || parsed < 1 || parsed > maxReconciliationReports {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("limit must be from 1 to %v", maxReconciliationReports))
+			}
+			limit = parsed
+		}
+
+		reports, dbErr := v.ReconciliationService.GetReports(ctx, limit)
+		if dbErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while getting reconciliation reports: %v", dbErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(reports)
+	}
+}
+
+func (v *ReconciliationHandler) GetReconciliationReport() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		reportID, parseErr := uuid.Parse(c.Params("id"))
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Report ID must be an uuid")
+		}
+
+		report, dbErr := v.ReconciliationService.GetReport(ctx, reportID)
+		if dbErr != nil {
+			if errors.Is(dbErr, mongo.ErrNoDocuments) {
+				return c.Status(fiber.StatusNotFound).SendString("Reconciliation report is not found")
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while getting reconciliation report: %v", dbErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(report)
+	}
+}
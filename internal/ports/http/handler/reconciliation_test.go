@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/reconciliation"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// fakeReconciliationService records arguments of the last call and answers with report and reports or err
+type fakeReconciliationService struct {
+	report  *reconciliation.Report
+	reports []reconciliation.Report
+	err     error
+
+	policy reconciliation.Policy
+	id     uuid.UUID
+	limit  int64
+}
+
+func (s *fakeReconciliationService) Run(context.Context) {}
+
+func (s *fakeReconciliationService) Reconcile(_ context.Context, policy reconciliation.Policy) (*reconciliation.Report, error) {
+	s.policy = policy
+	return s.report, s.err
+}
+
+func (s *fakeReconciliationService) GetReport(_ context.Context, id uuid.UUID) (*reconciliation.Report, error) {
+	s.id = id
+	return s.report, s.err
+}
+
+func (s *fakeReconciliationService) GetReports(_ context.Context, limit int64) ([]reconciliation.Report, error) {
+	s.limit = limit
+	return s.reports, s.err
+}
+
+func newReconciliationTestApp(service *fakeReconciliationService) *fiber.App {
+	h := &ReconciliationHandler{ReconciliationService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Post("/api/reconciliation", h.StartReconciliation())
+		app.Get("/api/reconciliation/reports", h.GetReconciliationReports())
+		app.Get("/api/reconciliation/reports/:id", h.GetReconciliationReport())
+	})
+}
+
+func TestStartReconciliation(t *testing.T) {
+	id := uuid.New()
+	service := &fakeReconciliationService{report: &reconciliation.Report{ID: id}}
+	app := newReconciliationTestApp(service)
+
+	resp := send(t, app, http.MethodPost, "/api/reconciliation?policy=repair_all", nil)
+	resp.expectStatus(t, fiber.StatusAccepted)
+
+	var report reconciliation.Report
+	resp.decode(t, &report)
+	if report.ID != id || service.policy != reconciliation.PolicyRepairAll {
+		t.Fatalf("report = %v of policy %v", report.ID, service.policy)
+	}
+
+	send(t, app, http.MethodPost, "/api/reconciliation", nil).expectStatus(t, fiber.StatusAccepted)
+	if service.policy != reconciliation.PolicyReport {
+		t.Fatalf("default policy = %v", service.policy)
+	}
+
+	send(t, app, http.MethodPost, "/api/reconciliation?policy=remove_all", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = reconciliation.ErrAlreadyRunning
+	send(t, app, http.MethodPost, "/api/reconciliation", nil).expectStatus(t, fiber.StatusConflict)
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodPost, "/api/reconciliation", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestGetReconciliationReports(t *testing.T) {
+	service := &fakeReconciliationService{reports: []reconciliation.Report{{ID: uuid.New()}}}
+	app := newReconciliationTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/reconciliation/reports?limit=5", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var reports []reconciliation.Report
+	resp.decode(t, &reports)
+	if len(reports) != 1 || service.limit != 5 {
+		t.Fatalf("reports = %v, limit %v", reports, service.limit)
+	}
+
+	send(t, app, http.MethodGet, "/api/reconciliation/reports", nil).expectStatus(t, fiber.StatusOK)
+	if service.limit != defaultReconciliationReports {
+		t.Fatalf("default limit = %v", service.limit)
+	}
+
+	for _, limit := range []string{"0", "101", "all"} {
+		send(t, app, http.MethodGet, "/api/reconciliation/reports?limit="+limit, nil).expectStatus(t, fiber.StatusBadRequest)
+	}
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/reconciliation/reports", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestGetReconciliationReport(t *testing.T) {
+	id := uuid.New()
+	service := &fakeReconciliationService{report: &reconciliation.Report{ID: id}}
+	app := newReconciliationTestApp(service)
+
+	send(t, app, http.MethodGet, "/api/reconciliation/reports/"+id.String(), nil).expectStatus(t, fiber.StatusOK)
+	if service.id != id {
+		t.Fatalf("requested %v, want %v", service.id, id)
+	}
+
+	send(t, app, http.MethodGet, "/api/reconciliation/reports/not-uuid", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = mongo.ErrNoDocuments
+	send(t, app, http.MethodGet, "/api/reconciliation/reports/"+id.String(), nil).expectStatus(t, fiber.StatusNotFound)
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/reconciliation/reports/"+id.String(), nil).expectStatus(t, fiber.StatusInternalServerError)
+}
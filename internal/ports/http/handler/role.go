@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/audit"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	roleservice "github.com/rom6n/create-nft-go/internal/service/role_service"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type RoleHandler struct {
+	RoleService roleservice.RoleServiceRepository
+}
+
+// actorID returns telegram id of super admin, audit.SystemActorID if request has no telegram user
+func actorID(c *fiber.Ctx) int64 {
+	if initDataUser, ok := InitDataUser(c); ok {
+		return initDataUser.ID
+	}
+	return audit.SystemActorID
+}
+
+func sendRoleError(c *fiber.Ctx, roleErr error) error {
+	switch {
+	case errors.Is(roleErr, user.ErrUnknownRole):
+		return c.Status(fiber.StatusBadRequest).SendString(roleErr.Error())
+	case errors.Is(roleErr, roleservice.ErrOwnRoleChange):
+		return c.Status(fiber.StatusForbidden).SendString(roleErr.Error())
+	case errors.Is(roleErr, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).SendString("User is not found")
+	default:
+		return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while changing role: %v", roleErr))
+	}
+}
+
+// ?role=market_admin&reason=
+func (v *RoleHandler) GrantRole() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		userID, parseErr := strconv.ParseInt(c.Params("id"), 0, 64)
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("User ID must be an int")
+		}
+
+		role, reason := c.Query("role"), c.Query("reason")
+		if role == "" || reason == "" {
+			return c.Status(fiber.StatusBadRequest).SendString("role and reason are required")
+		}
+
+		updatedUser, grantErr := v.RoleService.GrantRole(ctx, actorID(c), userID, role, reason)
+		if grantErr != nil {
+			return sendRoleError(c, grantErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(updatedUser)
+	}
+}
+
+// ?reason=
+func (v *RoleHandler) RevokeRole() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		userID, parseErr := strconv.ParseInt(c.Params("id"), 0, 64)
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("User ID must be an int")
+		}
+
+		reason := c.Query("reason")
+		if reason == "" {
+			return c.Status(fiber.StatusBadRequest).SendString("reason is required")
+		}
+
+		updatedUser, revokeErr := v.RoleService.RevokeRole(ctx, actorID(c), userID, reason)
+		if revokeErr != nil {
+			return sendRoleError(c, revokeErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(updatedUser)
+	}
+}
+
+func (v *RoleHandler) GetAuditEntries() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		userID, parseErr := strconv.ParseInt(c.Params("id"), 0, 64)
+		if parseErr != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("User ID must be an int")
+		}
+
+		entries, dbErr := v.RoleService.GetAuditEntries(ctx, userID)
+		if dbErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while getting audit entries: %v", dbErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(entries)
+	}
+}
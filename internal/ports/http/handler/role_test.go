@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/audit"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	roleservice "github.com/rom6n/create-nft-go/internal/service/role_service"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// fakeRoleService records arguments of the last call and answers with entries or err
+type fakeRoleService struct {
+	entries []audit.Entry
+	err     error
+
+	actorID int64
+	userID  int64
+	role    string
+	reason  string
+}
+
+func (s *fakeRoleService) GrantRole(_ context.Context, actorID int64, userID int64, role string, reason string) (*user.User, error) {
+	s.actorID, s.userID, s.role, s.reason = actorID, userID, role, reason
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &user.User{ID: userID, Role: role}, nil
+}
+
+func (s *fakeRoleService) RevokeRole(_ context.Context, actorID int64, userID int64, reason string) (*user.User, error) {
+	s.actorID, s.userID, s.role, s.reason = actorID, userID, user.RoleUser, reason
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &user.User{ID: userID, Role: user.RoleUser}, nil
+}
+
+func (s *fakeRoleService) GetAuditEntries(_ context.Context, userID int64) ([]audit.Entry, error) {
+	s.userID = userID
+	return s.entries, s.err
+}
+
+func newRoleTestApp(service *fakeRoleService) *fiber.App {
+	h := &RoleHandler{RoleService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Post("/api/roles/:id", h.GrantRole())
+		app.Delete("/api/roles/:id", h.RevokeRole())
+		app.Get("/api/roles/:id/audit", h.GetAuditEntries())
+	})
+}
+
+func TestGrantRole(t *testing.T) {
+	service := &fakeRoleService{}
+	app := newRoleTestApp(service)
+
+	resp := send(t, app, http.MethodPost, "/api/roles/42?role=support&reason=reviews+deposits", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var updated user.User
+	resp.decode(t, &updated)
+	if updated.Role != user.RoleSupport || service.actorID != testUserID || service.userID != 42 || service.reason != "reviews deposits" {
+		t.Fatalf("role %v granted to %v by %v", updated.Role, service.userID, service.actorID)
+	}
+
+	send(t, app, http.MethodPost, "/api/roles/42?role=support&reason=import", nil, anonymous).expectStatus(t, fiber.StatusOK)
+	if service.actorID != audit.SystemActorID {
+		t.Fatalf("actor of request without telegram user = %v, want %v", service.actorID, audit.SystemActorID)
+	}
+
+	send(t, app, http.MethodPost, "/api/roles/bob?role=support&reason=import", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/roles/42?role=support", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodPost, "/api/roles/42?reason=import", nil).expectStatus(t, fiber.StatusBadRequest)
+}
+
+func TestRoleErrors(t *testing.T) {
+	service := &fakeRoleService{}
+	app := newRoleTestApp(service)
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{user.ErrUnknownRole, fiber.StatusBadRequest},
+		{roleservice.ErrOwnRoleChange, fiber.StatusForbidden},
+		{mongo.ErrNoDocuments, fiber.StatusNotFound},
+		{errors.New("mongo is unavailable"), fiber.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			service.err = tt.err
+			send(t, app, http.MethodPost, "/api/roles/42?role=support&reason=import", nil).expectStatus(t, tt.status)
+			send(t, app, http.MethodDelete, "/api/roles/42?reason=left", nil).expectStatus(t, tt.status)
+		})
+	}
+}
+
+func TestRevokeRole(t *testing.T) {
+	service := &fakeRoleService{}
+	app := newRoleTestApp(service)
+
+	send(t, app, http.MethodDelete, "/api/roles/42?reason=left", nil).expectStatus(t, fiber.StatusOK)
+	if service.userID != 42 || service.actorID != testUserID || service.reason != "left" {
+		t.Fatalf("role of %v revoked by %v for %v", service.userID, service.actorID, service.reason)
+	}
+
+	send(t, app, http.MethodDelete, "/api/roles/42", nil).expectStatus(t, fiber.StatusBadRequest)
+	send(t, app, http.MethodDelete, "/api/roles/bob?reason=left", nil).expectStatus(t, fiber.StatusBadRequest)
+}
+
+func TestGetAuditEntries(t *testing.T) {
+	service := &fakeRoleService{entries: []audit.Entry{{ID: uuid.New(), TargetUserID: 42, After: user.RoleSupport}}}
+	app := newRoleTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/roles/42/audit", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var entries []audit.Entry
+	resp.decode(t, &entries)
+	if len(entries) != 1 || entries[0].After != user.RoleSupport || service.userID != 42 {
+		t.Fatalf("entries = %v of %v", entries, service.userID)
+	}
+
+	send(t, app, http.MethodGet, "/api/roles/bob/audit", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/roles/42/audit", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
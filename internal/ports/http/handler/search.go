@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+	searchservice "github.com/rom6n/create-nft-go/internal/service/search_service"
+)
+
+type SearchHandler struct {
+	SearchService searchservice.SearchServiceRepository
+}
+
+// ?q=dragon&kind=nft_item&is-testnet=true&attribute=Color:Red&attribute=Eyes:Laser&offset=0&limit=20
+func (v *SearchHandler) Search() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		query := search.Query{
+			Text:   strings.TrimSpace(c.Query("q")),
+			Kind:   search.Kind(c.Query("kind")),
+			Offset: c.QueryInt("offset", 0),
+			Limit:  c.QueryInt("limit", search.DefaultLimit),
+		}
+
+		if isTest := c.Query("is-testnet"); isTest != "" {
+			isTestnet, parseBoolErr := strconv.ParseBool(isTest)
+			if parseBoolErr != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error parse is-testnet to bool: %v", parseBoolErr))
+			}
+			query.IsTestnet = &isTestnet
+		}
+
+		for _, rawAttribute := range ctx.QueryArgs().PeekMulti("attribute") {
+			traitType, value, ok := strings.Cut(string(rawAttribute), ":")
+			if !ok || traitType == "" {
+				return c.Status(fiber.StatusBadRequest).SendString("attribute must be trait_type:value")
+			}
+			query.Attributes = append(query.Attributes, nftitem.Attribute{
+				TraitType: traitType,
+				Value:     value,
+			})
+		}
+
+		if query.Kind != "" && query.Kind != search.KindNftCollection && query.Kind != search.KindNftItem {
+			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("kind must be %v or %v", search.KindNftCollection, search.KindNftItem))
+		}
+
+		result, searchErr := v.SearchService.Search(ctx, query)
+		if searchErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while searching: %v", searchErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(result)
+	}
+}
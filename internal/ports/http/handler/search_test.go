@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+)
+
+// fakeSearchService records the last query and answers with result or err
+type fakeSearchService struct {
+	result *search.Result
+	err    error
+
+	query search.Query
+}
+
+func (s *fakeSearchService) Search(_ context.Context, query search.Query) (*search.Result, error) {
+	s.query = query
+	return s.result, s.err
+}
+
+func newSearchTestApp(service *fakeSearchService) *fiber.App {
+	h := &SearchHandler{SearchService: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Get("/api/search", h.Search())
+	})
+}
+
+func TestSearch(t *testing.T) {
+	service := &fakeSearchService{result: &search.Result{Total: 1, Documents: []search.Document{{Address: testNftItemAddress}}}}
+	app := newSearchTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/search?q=+dragon+&kind=nft_item&is-testnet=true&attribute=Color:Red&attribute=Eyes:Laser&offset=20&limit=10", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var result search.Result
+	resp.decode(t, &result)
+	if result.Total != 1 || len(result.Documents) != 1 || result.Documents[0].Address != testNftItemAddress {
+		t.Fatalf("result = %+v", result)
+	}
+
+	query := service.query
+	if query.Text != "dragon" || query.Kind != search.KindNftItem || !*query.IsTestnet || query.Offset != 20 || query.Limit != 10 ||
+		len(query.Attributes) != 2 || query.Attributes[1].TraitType != "Eyes" || query.Attributes[1].Value != "Laser" {
+		t.Fatalf("query = %+v", query)
+	}
+
+	send(t, app, http.MethodGet, "/api/search", nil).expectStatus(t, fiber.StatusOK)
+	if service.query.IsTestnet != nil || service.query.Kind != "" || service.query.Limit != search.DefaultLimit {
+		t.Fatalf("default query = %+v", service.query)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	service := &fakeSearchService{}
+	app := newSearchTestApp(service)
+
+	for _, query := range []string{"is-testnet=maybe", "attribute=Red", "attribute=:Red", "kind=wallet"} {
+		t.Run(query, func(t *testing.T) {
+			send(t, app, http.MethodGet, "/api/search?"+query, nil).expectStatus(t, fiber.StatusBadRequest)
+		})
+	}
+
+	service.err = errors.New("elasticsearch is unavailable")
+	send(t, app, http.MethodGet, "/api/search?q=dragon", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	royaltyservice "github.com/rom6n/create-nft-go/internal/service/royalty_service"
 	userservice "github.com/rom6n/create-nft-go/internal/service/user_service"
 	"github.com/rom6n/create-nft-go/internal/service/withdraw_user_ton"
 	"github.com/xssnick/tonutils-go/address"
@@ -13,17 +14,16 @@ import (
 type UserHandler struct {
 	UserService         userservice.UserServiceRepository
 	WithdrawUserService withdraw_user_ton.WithdrawUserTonRepository
+	RoyaltyService      royaltyservice.RoyaltyServiceRepository
 }
 
 func (v *UserHandler) GetUserData() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx := c.Context()
 
-		userStrID := c.Params("id")
-
-		userID, parseErr := strconv.ParseInt(userStrID, 0, 64)
-		if parseErr != nil {
-			return c.Status(fiber.StatusBadRequest).SendString("User ID must be an int")
+		userID, authErr := actingUserID(c, c.Params("id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
 		}
 
 		user, dbErr := v.UserService.GetUserByID(ctx, userID)
@@ -39,11 +39,9 @@ func (v *UserHandler) GetUserNftCollections() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx := c.Context()
 
-		userStrID := c.Params("id")
-
-		userID, parseErr := strconv.ParseInt(userStrID, 0, 64)
-		if parseErr != nil {
-			return c.Status(fiber.StatusBadRequest).SendString("User ID must be an int")
+		userID, authErr := actingUserID(c, c.Params("id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
 		}
 
 		nftCollections := v.UserService.GetUserNftCollections(ctx, userID)
@@ -56,11 +54,9 @@ func (v *UserHandler) GetUserNftItems() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx := c.Context()
 
-		userStrID := c.Params("id")
-
-		userID, parseErr := strconv.ParseInt(userStrID, 0, 64)
-		if parseErr != nil {
-			return c.Status(fiber.StatusBadRequest).SendString("User ID must be an int")
+		userID, authErr := actingUserID(c, c.Params("id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
 		}
 
 		nftItems := v.UserService.GetUserNftItems(ctx, userID)
@@ -69,11 +65,56 @@ func (v *UserHandler) GetUserNftItems() fiber.Handler {
 	}
 }
 
+func (v *UserHandler) GetUserBalanceEntries() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		userID, authErr := actingUserID(c, c.Params("id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		entries, dbErr := v.UserService.GetUserBalanceEntries(ctx, userID)
+		if dbErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while getting user balance entries: %v", dbErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(entries)
+	}
+}
+
+// GetUserRoyaltyEarnings returns royalty earned by user from internal sales of his nft collections' items
+func (v *UserHandler) GetUserRoyaltyEarnings() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, authErr := actingUserID(c, c.Params("id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		earnings, dbErr := v.RoyaltyService.GetEarnings(c.Context(), userID)
+		if dbErr != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(fmt.Sprintf("Error while getting user royalty earnings: %v", dbErr))
+		}
+
+		return c.Status(fiber.StatusOK).JSON(earnings)
+	}
+}
+
+func (v *UserHandler) GetUserDepositMemo() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, authErr := actingUserID(c, c.Params("id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"memo": v.UserService.GetUserDepositMemo(userID)})
+	}
+}
+
 func (v *UserHandler) WithdrawUserTON() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx := c.Context()
 
-		userStrID := c.Params("id")
 		withdrawTo := c.Query("withdraw-to")
 		amountStr := c.Query("amount")
 		isTest := c.Query("is-testnet")
@@ -82,9 +123,9 @@ func (v *UserHandler) WithdrawUserTON() fiber.Handler {
 			return c.Status(fiber.StatusBadRequest).SendString("withdraw-to, amount, is-testnet are required")
 		}
 
-		userID, parseErr := strconv.ParseInt(userStrID, 0, 64)
-		if parseErr != nil {
-			return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("Error while parsing user ID: %v", parseErr))
+		userID, authErr := actingUserID(c, c.Params("id"))
+		if authErr != nil {
+			return sendAuthError(c, authErr)
 		}
 
 		withdrawAddress, addrParseErr := address.ParseAddr(withdrawTo)
@@ -112,3 +153,39 @@ func (v *UserHandler) WithdrawUserTON() fiber.Handler {
 		return c.Status(fiber.StatusOK).SendString(fmt.Sprintf("Successfully withdrawed %v TON", amount/1000000000.0))
 	}
 }
+
+func (v *UserHandler) WithdrawUserTONV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		var body WithdrawTonRequest
+		if ok, sendErr := parseBodyV2(c, &body); !ok {
+			return sendErr
+		}
+
+		if withdrawErr := v.WithdrawUserService.Withdraw(c.Context(), userID, body.Amount, body.withdrawTo, *body.IsTestnet); withdrawErr != nil {
+			return sendServiceErrorV2(c, withdrawErr)
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"amount": body.Amount, "withdraw_to": body.withdrawTo.String()})
+	}
+}
+
+func (v *UserHandler) GetUserRoyaltyEarningsV2() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, authErr := actingUserID(c, "")
+		if authErr != nil {
+			return sendServiceErrorV2(c, authErr)
+		}
+
+		earnings, dbErr := v.RoyaltyService.GetEarnings(c.Context(), userID)
+		if dbErr != nil {
+			return sendServiceErrorV2(c, dbErr)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(earnings)
+	}
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/royalty"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+// fakeUserServices records the user of the last call and answers with its fields or err
+type fakeUserServices struct {
+	user        *user.User
+	collections []nftcollection.NftCollection
+	items       []nftitem.NftItem
+	entries     []ledger.BalanceEntry
+	earnings    []royalty.Earnings
+	err         error
+
+	userID     int64
+	amount     uint64
+	withdrawTo *address.Address
+	isTestnet  bool
+}
+
+func (s *fakeUserServices) GetUserByID(_ context.Context, userID int64) (*user.User, error) {
+	s.userID = userID
+	return s.user, s.err
+}
+
+func (s *fakeUserServices) GetUserNftCollections(_ context.Context, userID int64) []nftcollection.NftCollection {
+	s.userID = userID
+	return s.collections
+}
+
+func (s *fakeUserServices) GetUserNftItems(_ context.Context, userID int64) []nftitem.NftItem {
+	s.userID = userID
+	return s.items
+}
+
+func (s *fakeUserServices) GetUserBalanceEntries(_ context.Context, userID int64) ([]ledger.BalanceEntry, error) {
+	s.userID = userID
+	return s.entries, s.err
+}
+
+func (s *fakeUserServices) GetUserDepositMemo(userID int64) string {
+	s.userID = userID
+	return "memo-123"
+}
+
+func (s *fakeUserServices) Withdraw(_ context.Context, userID int64, amount uint64, withdrawToAddress *address.Address, isTestnet bool) error {
+	s.userID, s.amount, s.withdrawTo, s.isTestnet = userID, amount, withdrawToAddress, isTestnet
+	return s.err
+}
+
+func (s *fakeUserServices) WithdrawQueue(context.Context) {}
+
+func (s *fakeUserServices) Calculate(context.Context, royalty.Sale) (*royalty.Accrual, error) {
+	return nil, nil
+}
+
+func (s *fakeUserServices) Accrue(context.Context, *royalty.Accrual) error {
+	return nil
+}
+
+func (s *fakeUserServices) GetEarnings(_ context.Context, creatorID int64) ([]royalty.Earnings, error) {
+	s.userID = creatorID
+	return s.earnings, s.err
+}
+
+func newUserTestApp(services *fakeUserServices) *fiber.App {
+	h := &UserHandler{UserService: services, WithdrawUserService: services, RoyaltyService: services}
+	return newTestApp(func(app *fiber.App) {
+		app.Get("/api/user/:id", h.GetUserData())
+		app.Get("/api/user/nft-collections/:id", h.GetUserNftCollections())
+		app.Get("/api/user/nft-items/:id", h.GetUserNftItems())
+		app.Get("/api/user/balance-entries/:id", h.GetUserBalanceEntries())
+		app.Get("/api/user/deposit-memo/:id", h.GetUserDepositMemo())
+		app.Get("/api/user/royalty-earnings/:id", h.GetUserRoyaltyEarnings())
+		app.Post("/api/user/withdraw/:id", h.WithdrawUserTON())
+
+		app.Post("/api/v2/user/withdraw", h.WithdrawUserTONV2())
+		app.Get("/api/v2/user/royalty-earnings", h.GetUserRoyaltyEarningsV2())
+	})
+}
+
+func TestGetUserData(t *testing.T) {
+	services := &fakeUserServices{user: &user.User{UUID: uuid.New(), ID: testUserID, TestnetNanoTon: 10}}
+	app := newUserTestApp(services)
+
+	resp := send(t, app, http.MethodGet, "/api/user/123", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var found user.User
+	resp.decode(t, &found)
+	if found.ID != testUserID || found.TestnetNanoTon != 10 || services.userID != testUserID {
+		t.Fatalf("user = %+v, requested %v", found, services.userID)
+	}
+
+	services.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/user/123", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestUserRoutesAreOnlyForOwnUser(t *testing.T) {
+	app := newUserTestApp(&fakeUserServices{user: &user.User{}})
+
+	for _, route := range []string{"", "nft-collections/", "nft-items/", "balance-entries/", "deposit-memo/", "royalty-earnings/"} {
+		t.Run(route, func(t *testing.T) {
+			send(t, app, http.MethodGet, "/api/user/"+route+"1", nil).expectStatus(t, fiber.StatusForbidden)
+			send(t, app, http.MethodGet, "/api/user/"+route+"bob", nil).expectStatus(t, fiber.StatusBadRequest)
+			send(t, app, http.MethodGet, "/api/user/"+route+"123", nil, anonymous).expectStatus(t, fiber.StatusUnauthorized)
+		})
+	}
+}
+
+func TestGetUserNfts(t *testing.T) {
+	services := &fakeUserServices{
+		collections: []nftcollection.NftCollection{{Address: testNftCollectionAddress}},
+		items:       []nftitem.NftItem{{Address: testNftItemAddress}},
+	}
+	app := newUserTestApp(services)
+
+	resp := send(t, app, http.MethodGet, "/api/user/nft-collections/123", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+	var collections []nftcollection.NftCollection
+	resp.decode(t, &collections)
+	if len(collections) != 1 || collections[0].Address != testNftCollectionAddress {
+		t.Fatalf("collections = %v", collections)
+	}
+
+	resp = send(t, app, http.MethodGet, "/api/user/nft-items/123", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+	var items []nftitem.NftItem
+	resp.decode(t, &items)
+	if len(items) != 1 || items[0].Address != testNftItemAddress || services.userID != testUserID {
+		t.Fatalf("items = %v", items)
+	}
+}
+
+func TestGetUserBalanceEntries(t *testing.T) {
+	services := &fakeUserServices{entries: []ledger.BalanceEntry{{ID: uuid.New(), Type: ledger.EntryTypeDeposit, Amount: 5}}}
+	app := newUserTestApp(services)
+
+	resp := send(t, app, http.MethodGet, "/api/user/balance-entries/123", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var entries []ledger.BalanceEntry
+	resp.decode(t, &entries)
+	if len(entries) != 1 || entries[0].Amount != 5 {
+		t.Fatalf("entries = %v", entries)
+	}
+
+	services.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/user/balance-entries/123", nil).expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestGetUserDepositMemo(t *testing.T) {
+	services := &fakeUserServices{}
+	app := newUserTestApp(services)
+
+	resp := send(t, app, http.MethodGet, "/api/user/deposit-memo/123", nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var memo struct {
+		Memo string `json:"memo"`
+	}
+	resp.decode(t, &memo)
+	if memo.Memo != "memo-123" || services.userID != testUserID {
+		t.Fatalf("memo = %v of user %v", memo.Memo, services.userID)
+	}
+}
+
+func TestGetUserRoyaltyEarnings(t *testing.T) {
+	services := &fakeUserServices{earnings: []royalty.Earnings{{CollectionAddress: testNftCollectionAddress, Amount: 7}}}
+	app := newUserTestApp(services)
+
+	for _, target := range []string{"/api/user/royalty-earnings/123", "/api/v2/user/royalty-earnings"} {
+		resp := send(t, app, http.MethodGet, target, nil)
+		resp.expectStatus(t, fiber.StatusOK)
+
+		var earnings []royalty.Earnings
+		resp.decode(t, &earnings)
+		if len(earnings) != 1 || earnings[0].Amount != 7 || services.userID != testUserID {
+			t.Fatalf("%v: earnings = %v", target, earnings)
+		}
+	}
+
+	send(t, app, http.MethodGet, "/api/v2/user/royalty-earnings", nil, anonymous).expectError(t, fiber.StatusUnauthorized, CodeUnauthorized)
+
+	services.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/user/royalty-earnings/123", nil).expectStatus(t, fiber.StatusInternalServerError)
+	send(t, app, http.MethodGet, "/api/v2/user/royalty-earnings", nil).expectError(t, fiber.StatusInternalServerError, CodeInternal)
+}
+
+func TestWithdrawUserTON(t *testing.T) {
+	services := &fakeUserServices{}
+	app := newUserTestApp(services)
+
+	send(t, app, http.MethodPost, "/api/user/withdraw/123?withdraw-to="+testWalletAddress+"&amount=2000000000&is-testnet=true", nil).
+		expectStatus(t, fiber.StatusOK)
+	if services.userID != testUserID || services.amount != 2000000000 || services.withdrawTo.String() != testWalletAddress || !services.isTestnet {
+		t.Fatalf("withdrawn %v to %v by %v", services.amount, services.withdrawTo, services.userID)
+	}
+
+	tests := map[string]struct {
+		query  string
+		status int
+	}{
+		"no amount":       {"withdraw-to=" + testWalletAddress + "&is-testnet=true", fiber.StatusBadRequest},
+		"invalid address": {"withdraw-to=wallet&amount=1&is-testnet=true", fiber.StatusBadRequest},
+		"invalid amount":  {"withdraw-to=" + testWalletAddress + "&amount=-1&is-testnet=true", fiber.StatusBadRequest},
+		"zero amount":     {"withdraw-to=" + testWalletAddress + "&amount=0&is-testnet=true", fiber.StatusBadRequest},
+		"invalid network": {"withdraw-to=" + testWalletAddress + "&amount=1&is-testnet=maybe", fiber.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			send(t, app, http.MethodPost, "/api/user/withdraw/123?"+tt.query, nil).expectStatus(t, tt.status)
+		})
+	}
+
+	send(t, app, http.MethodPost, "/api/user/withdraw/1?withdraw-to="+testWalletAddress+"&amount=1&is-testnet=true", nil).expectStatus(t, fiber.StatusForbidden)
+
+	services.err = ledger.ErrNotEnoughBalance
+	send(t, app, http.MethodPost, "/api/user/withdraw/123?withdraw-to="+testWalletAddress+"&amount=1&is-testnet=true", nil).
+		expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestWithdrawUserTONV2(t *testing.T) {
+	services := &fakeUserServices{}
+	app := newUserTestApp(services)
+	isTestnet := false
+
+	resp := send(t, app, http.MethodPost, "/api/v2/user/withdraw", WithdrawTonRequest{WithdrawTo: testWalletAddress, Amount: 3, IsTestnet: &isTestnet})
+	resp.expectStatus(t, fiber.StatusAccepted)
+
+	var withdrawn struct {
+		Amount     uint64 `json:"amount"`
+		WithdrawTo string `json:"withdraw_to"`
+	}
+	resp.decode(t, &withdrawn)
+	if withdrawn.Amount != 3 || withdrawn.WithdrawTo != testWalletAddress || services.amount != 3 || services.isTestnet {
+		t.Fatalf("response = %+v", withdrawn)
+	}
+
+	errResp := send(t, app, http.MethodPost, "/api/v2/user/withdraw", WithdrawTonRequest{WithdrawTo: "wallet"}).expectError(t, fiber.StatusBadRequest, CodeValidationFailed)
+	for _, field := range []string{"withdraw_to", "amount", "is_testnet"} {
+		if _, ok := errResp.Details[field]; !ok {
+			t.Fatalf("details = %v, want %v", errResp.Details, field)
+		}
+	}
+	send(t, app, http.MethodPost, "/api/v2/user/withdraw", "{").expectError(t, fiber.StatusBadRequest, CodeInvalidBody)
+	send(t, app, http.MethodPost, "/api/v2/user/withdraw", WithdrawTonRequest{}, anonymous).expectError(t, fiber.StatusUnauthorized, CodeUnauthorized)
+
+	services.err = ledger.ErrNotEnoughBalance
+	send(t, app, http.MethodPost, "/api/v2/user/withdraw", WithdrawTonRequest{WithdrawTo: testWalletAddress, Amount: 3, IsTestnet: &isTestnet}).
+		expectError(t, fiber.StatusPaymentRequired, CodeNotEnoughBalance)
+}
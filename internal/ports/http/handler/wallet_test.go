@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/wallet"
+)
+
+// fakeWalletService records the last requested wallet address and answers with wallet or err
+type fakeWalletService struct {
+	wallet *wallet.Wallet
+	err    error
+
+	walletAddress string
+}
+
+func (s *fakeWalletService) UpdateWalletNftItems(_ context.Context, walletAddress string) ([]wallet.NftItem, error) {
+	s.walletAddress = walletAddress
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.wallet.NftItems, nil
+}
+
+func (s *fakeWalletService) GetWalletByAddress(_ context.Context, walletAddress string) (*wallet.Wallet, error) {
+	s.walletAddress = walletAddress
+	return s.wallet, s.err
+}
+
+func newWalletTestApp(service *fakeWalletService) *fiber.App {
+	h := &WalletHandler{WalletServiceRepo: service}
+	return newTestApp(func(app *fiber.App) {
+		app.Get("/api/wallet/get-wallet-data", h.GetWalletData())
+		app.Get("/api/wallet/refresh-wallet-nft-items", h.RefreshWalletNftItems())
+	})
+}
+
+func TestGetWalletData(t *testing.T) {
+	service := &fakeWalletService{wallet: &wallet.Wallet{Address: testWalletAddress}}
+	app := newWalletTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/wallet/get-wallet-data?wallet-address="+testWalletAddress, nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var found wallet.Wallet
+	resp.decode(t, &found)
+	if found.Address != testWalletAddress || service.walletAddress != testWalletAddress {
+		t.Fatalf("wallet = %v, requested %v", found.Address, service.walletAddress)
+	}
+
+	send(t, app, http.MethodGet, "/api/wallet/get-wallet-data", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = errors.New("mongo is unavailable")
+	send(t, app, http.MethodGet, "/api/wallet/get-wallet-data?wallet-address="+testWalletAddress, nil).expectStatus(t, fiber.StatusInternalServerError)
+}
+
+func TestRefreshWalletNftItems(t *testing.T) {
+	service := &fakeWalletService{wallet: &wallet.Wallet{NftItems: []wallet.NftItem{{Address: testNftItemAddress}}}}
+	app := newWalletTestApp(service)
+
+	resp := send(t, app, http.MethodGet, "/api/wallet/refresh-wallet-nft-items?wallet-address="+testWalletAddress, nil)
+	resp.expectStatus(t, fiber.StatusOK)
+
+	var items []wallet.NftItem
+	resp.decode(t, &items)
+	if len(items) != 1 || items[0].Address != testNftItemAddress || service.walletAddress != testWalletAddress {
+		t.Fatalf("items = %v", items)
+	}
+
+	send(t, app, http.MethodGet, "/api/wallet/refresh-wallet-nft-items", nil).expectStatus(t, fiber.StatusBadRequest)
+
+	service.err = errors.New("tonapi is unavailable")
+	send(t, app, http.MethodGet, "/api/wallet/refresh-wallet-nft-items?wallet-address="+testWalletAddress, nil).expectStatus(t, fiber.StatusInternalServerError)
+}
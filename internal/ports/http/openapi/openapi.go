@@ -0,0 +1,464 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed openapi.json
+var Spec []byte
+
+type document struct {
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components struct {
+		Schemas    map[string]*schema    `json:"schemas"`
+		Parameters map[string]*parameter `json:"parameters"`
+	} `json:"components"`
+}
+
+type operation struct {
+	Parameters  []*parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]mediaType `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]mediaType `json:"content"`
+	} `json:"responses"`
+}
+
+type parameter struct {
+	Ref    string  `json:"$ref"`
+	Name   string  `json:"name"`
+	In     string  `json:"in"`
+	Schema *schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Type       string             `json:"type"`
+	Properties map[string]*schema `json:"properties"`
+	Items      *schema            `json:"items"`
+	AllOf      []*schema          `json:"allOf"`
+	OneOf      []*schema          `json:"oneOf"`
+}
+
+const schemaRefPrefix = "#/components/schemas/"
+
+var pathParam = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+func parse() (*document, error) {
+	var doc document
+	if decodeErr := json.Unmarshal(Spec, &doc); decodeErr != nil {
+		return nil, fmt.Errorf("error decoding openapi spec: %w", decodeErr)
+	}
+	return &doc, nil
+}
+
+// Handler serves openapi spec
+func Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Status(fiber.StatusOK).Send(Spec)
+	}
+}
+
+// Operation returns operation of route as "method /path/{param}", in form of spec
+func Operation(route fiber.Route) string {
+	path := route.Path
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return strings.ToLower(route.Method) + " " + pathParam.ReplaceAllString(path, "{$1}")
+}
+
+// CheckRoutes returns error if routes registered in app and operations of spec are not the same
+func CheckRoutes(routes []fiber.Route) error {
+	doc, parseErr := parse()
+	if parseErr != nil {
+		return parseErr
+	}
+
+	registered := map[string]bool{}
+	for _, route := range routes {
+		// HEAD is registered by fiber for every GET
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		registered[Operation(route)] = true
+	}
+
+	specified := map[string]bool{}
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			specified[method+" "+path] = true
+		}
+	}
+
+	var drift []string
+	for operation := range registered {
+		if !specified[operation] {
+			drift = append(drift, "not in spec: "+operation)
+		}
+	}
+	for operation := range specified {
+		if !registered[operation] {
+			drift = append(drift, "not registered: "+operation)
+		}
+	}
+
+	if len(drift) == 0 {
+		return nil
+	}
+
+	sort.Strings(drift)
+	return fmt.Errorf("routes drifted from openapi spec: %v", strings.Join(drift, "; "))
+}
+
+// CheckParameters returns error if path parameters of routes or query parameters read by their handlers
+// are not the same as parameters of spec operations. Key of queries is Operation of route
+func CheckParameters(routes []fiber.Route, queries map[string][]string) error {
+	doc, parseErr := parse()
+	if parseErr != nil {
+		return parseErr
+	}
+
+	var drift []string
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		operationKey := Operation(route)
+		op, ok := doc.operation(operationKey)
+		if !ok {
+			// reported by CheckRoutes
+			continue
+		}
+
+		specified := map[string]map[string]bool{"path": {}, "query": {}}
+		for _, param := range op.Parameters {
+			if param.Ref != "" {
+				param = doc.Components.Parameters[strings.TrimPrefix(param.Ref, "#/components/parameters/")]
+				if param == nil {
+					drift = append(drift, fmt.Sprintf("%v: parameter is not in components", operationKey))
+					continue
+				}
+			}
+			if param.Schema == nil {
+				drift = append(drift, fmt.Sprintf("%v: parameter %v has no schema", operationKey, param.Name))
+			}
+			if specified[param.In] != nil {
+				specified[param.In][param.Name] = true
+			}
+		}
+
+		drift = append(drift, compareNames(operationKey+" path parameter", route.Params, specified["path"])...)
+		if query, ok := queries[operationKey]; ok {
+			drift = append(drift, compareNames(operationKey+" query parameter", query, specified["query"])...)
+		}
+	}
+
+	if len(drift) == 0 {
+		return nil
+	}
+
+	sort.Strings(drift)
+	return fmt.Errorf("parameters drifted from openapi spec: %v", strings.Join(drift, "; "))
+}
+
+// compareNames returns drift between names used by code and names specified in spec
+func compareNames(subject string, used []string, specified map[string]bool) []string {
+	var drift []string
+	usedSet := map[string]bool{}
+	for _, name := range used {
+		usedSet[name] = true
+		if !specified[name] {
+			drift = append(drift, fmt.Sprintf("%v %v is not in spec", subject, name))
+		}
+	}
+	for name := range specified {
+		if !usedSet[name] {
+			drift = append(drift, fmt.Sprintf("%v %v is not used", subject, name))
+		}
+	}
+	return drift
+}
+
+func (doc *document) operation(operationKey string) (operation, bool) {
+	method, path, _ := strings.Cut(operationKey, " ")
+	op, ok := doc.Paths[path][method]
+	return op, ok
+}
+
+func (doc *document) resolve(s *schema) (*schema, string) {
+	if s == nil || s.Ref == "" {
+		return s, ""
+	}
+	name := strings.TrimPrefix(s.Ref, schemaRefPrefix)
+	return doc.Components.Schemas[name], name
+}
+
+// CheckResponses returns error if json bodies of spec operations can drift from code unnoticed:
+// every $ref must resolve, every object schema of request or response must be checked by CheckSchemas
+// or be one of maps, and every component schema must be used.
+// Key of maps is Operation of route, value is json keys of fiber.Map responses by status code
+func CheckResponses(schemas map[string]any, maps map[string]map[int][]string) error {
+	doc, parseErr := parse()
+	if parseErr != nil {
+		return parseErr
+	}
+
+	var drift []string
+	used := map[string]bool{}
+	var walk func(subject string, s *schema)
+	walk = func(subject string, s *schema) {
+		if s == nil {
+			return
+		}
+		if s.Ref != "" {
+			resolved, name := doc.resolve(s)
+			if resolved == nil {
+				drift = append(drift, fmt.Sprintf("%v: %v is not in components", subject, s.Ref))
+				return
+			}
+			if used[name] {
+				return
+			}
+			used[name] = true
+			s = resolved
+		}
+		for property, propertySchema := range s.Properties {
+			walk(subject+"."+property, propertySchema)
+		}
+		walk(subject+"[]", s.Items)
+		for _, part := range append(s.AllOf, s.OneOf...) {
+			walk(subject, part)
+		}
+	}
+
+	for path, operations := range doc.Paths {
+		for method, op := range operations {
+			operationKey := method + " " + path
+			hasSuccess := false
+
+			if op.RequestBody != nil {
+				for contentType, media := range op.RequestBody.Content {
+					walk(operationKey+" request", media.Schema)
+					if contentType == fiber.MIMEApplicationJSON {
+						drift = append(drift, doc.checkCovered(operationKey+" request", media.Schema, schemas)...)
+					}
+				}
+			}
+
+			for status, response := range op.Responses {
+				if strings.HasPrefix(status, "2") {
+					hasSuccess = true
+				}
+				code, _ := strconv.Atoi(status)
+				media, ok := response.Content[fiber.MIMEApplicationJSON]
+				if !ok {
+					continue
+				}
+				subject := operationKey + " " + status
+				walk(subject, media.Schema)
+
+				if keys, isMap := maps[operationKey][code]; isMap {
+					resolved, _ := doc.resolve(media.Schema)
+					if resolved == nil || resolved.Type != "object" {
+						drift = append(drift, fmt.Sprintf("%v: response is object with %v", subject, keys))
+						continue
+					}
+					specified := map[string]bool{}
+					for property := range resolved.Properties {
+						specified[property] = true
+					}
+					drift = append(drift, compareNames(subject+" property", keys, specified)...)
+					continue
+				}
+				drift = append(drift, doc.checkCovered(subject, media.Schema, schemas)...)
+			}
+
+			if !hasSuccess {
+				drift = append(drift, fmt.Sprintf("%v has no 2xx response", operationKey))
+			}
+		}
+	}
+
+	for name := range doc.Components.Schemas {
+		if !used[name] {
+			drift = append(drift, fmt.Sprintf("schema %v is not used", name))
+		}
+	}
+
+	if len(drift) == 0 {
+		return nil
+	}
+
+	sort.Strings(drift)
+	return fmt.Errorf("responses drifted from openapi spec: %v", strings.Join(drift, "; "))
+}
+
+// checkCovered returns drift if object schema of body isnt checked against type by CheckSchemas
+func (doc *document) checkCovered(subject string, s *schema, schemas map[string]any) []string {
+	resolved, name := doc.resolve(s)
+	if resolved == nil {
+		return nil
+	}
+	if resolved.Type == "array" {
+		return doc.checkCovered(subject+"[]", resolved.Items, schemas)
+	}
+	// free-form object, like metadata document
+	if resolved.Type != "object" || len(resolved.Properties) == 0 {
+		return nil
+	}
+	if _, ok := schemas[name]; name == "" || !ok {
+		return []string{fmt.Sprintf("%v: object %v isnt checked against type", subject, name)}
+	}
+	return nil
+}
+
+// CheckSchemas returns error if json fields of types and properties of spec schemas are not the same,
+// json types of fields are compared too.
+// Key of schemas is schema name, value is zero value of type which is sent or received as this schema
+func CheckSchemas(schemas map[string]any) error {
+	doc, parseErr := parse()
+	if parseErr != nil {
+		return parseErr
+	}
+
+	var drift []string
+	for name, value := range schemas {
+		schema, ok := doc.Components.Schemas[name]
+		if !ok {
+			drift = append(drift, fmt.Sprintf("schema %v is not in spec", name))
+			continue
+		}
+
+		fields := jsonFields(reflect.TypeOf(value))
+		for field, fieldType := range fields {
+			property, ok := schema.Properties[field]
+			if !ok {
+				drift = append(drift, fmt.Sprintf("%v.%v is not in spec", name, field))
+				continue
+			}
+			if typeDrift := doc.compareType(fieldType, property); typeDrift != "" {
+				drift = append(drift, fmt.Sprintf("%v.%v %v", name, field, typeDrift))
+			}
+		}
+		for property := range schema.Properties {
+			if _, ok := fields[property]; !ok {
+				drift = append(drift, fmt.Sprintf("%v.%v is not in type", name, property))
+			}
+		}
+	}
+
+	if len(drift) == 0 {
+		return nil
+	}
+
+	sort.Strings(drift)
+	return fmt.Errorf("schemas drifted from openapi spec: %v", strings.Join(drift, "; "))
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+
+	return fields
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// jsonType returns json schema type t is encoded as, empty if it is encoded by custom json marshaler
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return ""
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		// bytes are base64 string
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return ""
+	}
+}
+
+// compareType returns drift if type of go field and type of spec property are different
+func (doc *document) compareType(t reflect.Type, property *schema) string {
+	property, _ = doc.resolve(property)
+	if property == nil || len(property.AllOf) != 0 || len(property.OneOf) != 0 {
+		return ""
+	}
+
+	goType := jsonType(t)
+	if goType == "" || property.Type == "" {
+		return ""
+	}
+	if goType != property.Type {
+		return fmt.Sprintf("is %v in type and %v in spec", goType, property.Type)
+	}
+
+	if goType == "array" {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if itemsDrift := doc.compareType(t.Elem(), property.Items); itemsDrift != "" {
+			return "items " + itemsDrift
+		}
+	}
+	return ""
+}
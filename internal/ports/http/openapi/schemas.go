@@ -0,0 +1,66 @@
+package openapi
+
+import (
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/audit"
+	"github.com/rom6n/create-nft-go/internal/domain/deposit"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
+	"github.com/rom6n/create-nft-go/internal/domain/reconciliation"
+	"github.com/rom6n/create-nft-go/internal/domain/royalty"
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"github.com/rom6n/create-nft-go/internal/domain/wallet"
+	"github.com/rom6n/create-nft-go/internal/ports/http/handler"
+)
+
+// Schemas are types which handlers send or receive as json, they are checked against spec by CheckSchemas.
+// Responses built with fiber.Map are not listed here
+var Schemas = map[string]any{
+	"ErrorResponse":                     handler.ErrorResponse{},
+	"Attribute":                         nftitem.Attribute{},
+	"NftCollectionMetadata":             nftcollection.NftCollectionMetadata{},
+	"NftCollectionRoyalty":              nftcollection.Royalty{},
+	"NftItemMetadata":                   nftitem.NftItemMetadata{},
+	"MediaThumbnail":                    media.Thumbnail{},
+	"Media":                             media.Media{},
+	"NftCollection":                     nftcollection.NftCollection{},
+	"NftItem":                           nftitem.NftItem{},
+	"NftItemTransfer":                   nftitem.Transfer{},
+	"Listing":                           listing.Listing{},
+	"Auction":                           auction.Auction{},
+	"AuctionBid":                        auction.Bid{},
+	"User":                              user.User{},
+	"BalanceEntry":                      ledger.BalanceEntry{},
+	"RoyaltyEarnings":                   royalty.Earnings{},
+	"Deposit":                           deposit.Deposit{},
+	"AuditEntry":                        audit.Entry{},
+	"ReconciliationMismatch":            reconciliation.Mismatch{},
+	"ReconciliationReport":              reconciliation.Report{},
+	"Wallet":                            wallet.Wallet{},
+	"WalletNftItem":                     wallet.NftItem{},
+	"WalletNftCollection":               wallet.NftCollection{},
+	"SearchDocument":                    search.Document{},
+	"FacetValue":                        search.FacetValue{},
+	"Facet":                             search.Facet{},
+	"SearchResult":                      search.Result{},
+	"Quote":                             pricing.Quote{},
+	"BatchMintItem":                     nftitem.BatchMintItem{},
+	"DeployNftCollectionRequest":        handler.DeployNftCollectionRequest{},
+	"ChangeNftCollectionContentRequest": handler.ChangeNftCollectionContentRequest{},
+	"MintNftItemRequest":                handler.MintNftItemRequest{},
+	"BatchMintItems":                    handler.BatchMintItems{},
+	"BatchMintNftItemsRequest":          handler.BatchMintNftItemsRequest{},
+	"WithdrawNftRequest":                handler.WithdrawNftRequest{},
+	"TransferNftItemRequest":            handler.TransferNftItemRequest{},
+	"CreateListingRequest":              handler.CreateListingRequest{},
+	"CreateAuctionRequest":              handler.CreateAuctionRequest{},
+	"PlaceBidRequest":                   handler.PlaceBidRequest{},
+	"WithdrawTonRequest":                handler.WithdrawTonRequest{},
+	"DeployMarketRequest":               handler.DeployMarketRequest{},
+	"MarketTonRequest":                  handler.MarketTonRequest{},
+}
@@ -0,0 +1,410 @@
+package auctionservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/royalty"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	royaltyservice "github.com/rom6n/create-nft-go/internal/service/royalty_service"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type AuctionServiceRepository interface {
+	CreateAuction(ctx context.Context, cfg auction.CreateAuctionCfg, sellerID int64) (*auction.Auction, error)
+	// CancelAuction cancels auction which has no bids yet
+	CancelAuction(ctx context.Context, id uuid.UUID, sellerID int64) (*auction.Auction, error)
+	// PlaceBid holds bid on bidder's balance and returns hold of previous bidder
+	PlaceBid(ctx context.Context, id uuid.UUID, amount uint64, bidderID int64) (*auction.Auction, error)
+	GetAuction(ctx context.Context, id uuid.UUID) (*auction.Auction, error)
+	GetAuctionBids(ctx context.Context, id uuid.UUID) ([]auction.Bid, error)
+	SearchAuctions(ctx context.Context, query auction.Query) ([]auction.Auction, error)
+	// Run settles ended auctions until ctx is done
+	Run(ctx context.Context)
+}
+
+type auctionServiceRepo struct {
+	auctionRepo        auction.AuctionRepository
+	listingRepo        listing.ListingRepository
+	nftItemRepo        nftitem.NftItemRepository
+	userRepo           user.UserRepository
+	ledgerRepo         ledger.LedgerRepository
+	royaltyService     royaltyservice.RoyaltyServiceRepository
+	platformFeePercent uint64
+	pollInterval       time.Duration
+	timeout            time.Duration
+}
+
+type AuctionServiceCfg struct {
+	AuctionRepo        auction.AuctionRepository
+	ListingRepo        listing.ListingRepository
+	NftItemRepo        nftitem.NftItemRepository
+	UserRepo           user.UserRepository
+	LedgerRepo         ledger.LedgerRepository
+	RoyaltyService     royaltyservice.RoyaltyServiceRepository
+	PlatformFeePercent uint64        // percent of winning bid kept by platform
+	PollInterval       time.Duration // how often ended auctions are settled
+	Timeout            time.Duration
+}
+
+func New(cfg AuctionServiceCfg) AuctionServiceRepository {
+	return &auctionServiceRepo{
+		auctionRepo:        cfg.AuctionRepo,
+		listingRepo:        cfg.ListingRepo,
+		nftItemRepo:        cfg.NftItemRepo,
+		userRepo:           cfg.UserRepo,
+		ledgerRepo:         cfg.LedgerRepo,
+		royaltyService:     cfg.RoyaltyService,
+		platformFeePercent: cfg.PlatformFeePercent,
+		pollInterval:       cfg.PollInterval,
+		timeout:            cfg.Timeout,
+	}
+}
+
+func (v *auctionServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func validateCreateCfg(cfg *auction.CreateAuctionCfg) error {
+	if cfg.StartPrice < auction.MinStartPrice {
+		return fmt.Errorf("%w: start price must be at least %v nano ton", auction.ErrInvalidParams, auction.MinStartPrice)
+	}
+	if cfg.MinIncrement == 0 {
+		return fmt.Errorf("%w: min increment must be greater than zero", auction.ErrInvalidParams)
+	}
+
+	duration := time.Until(cfg.EndsAt)
+	if duration < auction.MinDuration || duration > auction.MaxDuration {
+		return fmt.Errorf("%w: auction must last from %v to %v", auction.ErrInvalidParams, auction.MinDuration, auction.MaxDuration)
+	}
+
+	if cfg.Extension == 0 {
+		cfg.Extension = auction.DefaultExtension
+	}
+	if cfg.Extension < 0 || cfg.Extension > auction.MaxExtension {
+		return fmt.Errorf("%w: extension must be not greater than %v", auction.ErrInvalidParams, auction.MaxExtension)
+	}
+
+	return nil
+}
+
+func (v *auctionServiceRepo) CreateAuction(ctx context.Context, cfg auction.CreateAuctionCfg, sellerID int64) (*auction.Auction, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if validateErr := validateCreateCfg(&cfg); validateErr != nil {
+		return nil, validateErr
+	}
+
+	sellerAccount, accErr := v.userRepo.GetUserByID(svcCtx, sellerID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	// withdraw marks nft item before checking its auctions, so the mark is checked in transaction with auction creation
+	var newAuction *auction.Auction
+	txErr := v.auctionRepo.WithTransaction(svcCtx, func(txCtx context.Context) error {
+		nftItem, nftItemErr := v.nftItemRepo.GetNftItemByAddress(txCtx, cfg.NftItemAddress)
+		if nftItemErr != nil {
+			return fmt.Errorf("error getting nft item: %w", nftItemErr)
+		}
+
+		if nftItem.Owner != sellerAccount.UUID {
+			return fmt.Errorf("%w to put it on auction", nftitem.ErrNotItemOwner)
+		}
+
+		if nftItem.Withdrawing {
+			return nftitem.ErrWithdrawPending
+		}
+
+		// nft item is sold either by listing or by auction
+		if _, listingErr := v.listingRepo.GetActiveListingByNftItem(txCtx, nftItem.Address); listingErr == nil {
+			return fmt.Errorf("%w, cancel listing to put it on auction", listing.ErrAlreadyListed)
+		} else if !errors.Is(listingErr, mongo.ErrNoDocuments) {
+			return fmt.Errorf("error checking nft item listing: %w", listingErr)
+		}
+
+		newAuction = auction.New(nftItem, cfg.StartPrice, cfg.MinIncrement, cfg.EndsAt, cfg.Extension)
+		return v.auctionRepo.CreateAuction(txCtx, newAuction)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	return newAuction, nil
+}
+
+func (v *auctionServiceRepo) CancelAuction(ctx context.Context, id uuid.UUID, sellerID int64) (*auction.Auction, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	sellerAccount, accErr := v.userRepo.GetUserByID(svcCtx, sellerID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	foundAuction, auctionErr := v.auctionRepo.GetAuctionByID(svcCtx, id)
+	if auctionErr != nil {
+		return nil, fmt.Errorf("error getting auction: %w", auctionErr)
+	}
+
+	if foundAuction.Seller != sellerAccount.UUID {
+		return nil, fmt.Errorf("%w to cancel it", auction.ErrNotSeller)
+	}
+
+	if foundAuction.Status != auction.StatusActive {
+		return nil, auction.ErrNotActive
+	}
+
+	if foundAuction.BidsCount > 0 {
+		return nil, auction.ErrHasBids
+	}
+
+	foundAuction.Status = auction.StatusCancelled
+	if closeErr := v.auctionRepo.CloseAuction(svcCtx, foundAuction); closeErr != nil {
+		if errors.Is(closeErr, auction.ErrNotActive) {
+			return nil, auction.ErrHasBids
+		}
+		return nil, closeErr
+	}
+
+	return foundAuction, nil
+}
+
+func (v *auctionServiceRepo) PlaceBid(ctx context.Context, id uuid.UUID, amount uint64, bidderID int64) (*auction.Auction, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	bidderAccount, accErr := v.userRepo.GetUserByID(svcCtx, bidderID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	foundAuction, auctionErr := v.auctionRepo.GetAuctionByID(svcCtx, id)
+	if auctionErr != nil {
+		return nil, fmt.Errorf("error getting auction: %w", auctionErr)
+	}
+
+	if foundAuction.Status != auction.StatusActive {
+		return nil, auction.ErrNotActive
+	}
+
+	bid := auction.NewBid(foundAuction, bidderAccount.UUID, amount)
+	if !bid.CreatedAt.Before(foundAuction.EndsAt) {
+		return nil, auction.ErrEnded
+	}
+
+	if foundAuction.Seller == bidderAccount.UUID {
+		return nil, auction.ErrOwnAuction
+	}
+
+	if amount < foundAuction.MinBid() {
+		return nil, fmt.Errorf("%w: bid at least %v nano ton", auction.ErrBidTooLow, foundAuction.MinBid())
+	}
+
+	// hold of previous bid is returned before new one is taken
+	entries := []*ledger.BalanceEntry{}
+	available := bidderAccount.Balance(foundAuction.IsTestnet)
+	if foundAuction.Bidder != nil {
+		entries = append(entries, ledger.NewBalanceEntry(*foundAuction.Bidder, ledger.EntryTypeRelease, foundAuction.CurrentBid, foundAuction.CurrentBidID.String(), foundAuction.IsTestnet))
+		if *foundAuction.Bidder == bidderAccount.UUID {
+			available += foundAuction.CurrentBid
+		}
+	}
+	entries = append(entries, ledger.NewBalanceEntry(bidderAccount.UUID, ledger.EntryTypeHold, amount, bid.ID.String(), foundAuction.IsTestnet))
+
+	if available < amount {
+		return nil, fmt.Errorf("%w: need %v more", ledger.ErrNotEnoughBalance, amount-available)
+	}
+
+	endsAt := foundAuction.NextEndsAt(bid.CreatedAt)
+	txErr := v.auctionRepo.WithTransaction(svcCtx, func(txCtx context.Context) error {
+		if bidErr := v.auctionRepo.PlaceBid(txCtx, bid, foundAuction.BidsCount, endsAt); bidErr != nil {
+			return bidErr
+		}
+
+		if holdErr := v.ledgerRepo.Apply(txCtx, entries...); holdErr != nil {
+			return fmt.Errorf("error holding bid: %w", holdErr)
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	foundAuction.CurrentBid = bid.Amount
+	foundAuction.CurrentBidID = &bid.ID
+	foundAuction.Bidder = &bid.Bidder
+	foundAuction.BidsCount++
+	foundAuction.EndsAt = endsAt
+	foundAuction.UpdatedAt = bid.CreatedAt
+
+	return foundAuction, nil
+}
+
+func (v *auctionServiceRepo) GetAuction(ctx context.Context, id uuid.UUID) (*auction.Auction, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.auctionRepo.GetAuctionByID(svcCtx, id)
+}
+
+func (v *auctionServiceRepo) GetAuctionBids(ctx context.Context, id uuid.UUID) ([]auction.Bid, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if _, auctionErr := v.auctionRepo.GetAuctionByID(svcCtx, id); auctionErr != nil {
+		return nil, fmt.Errorf("error getting auction: %w", auctionErr)
+	}
+
+	return v.auctionRepo.GetBids(svcCtx, id)
+}
+
+func (v *auctionServiceRepo) SearchAuctions(ctx context.Context, query auction.Query) ([]auction.Auction, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if query.Limit <= 0 {
+		query.Limit = auction.DefaultLimit
+	}
+	if query.Limit > auction.MaxLimit {
+		query.Limit = auction.MaxLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	return v.auctionRepo.GetAuctions(svcCtx, query)
+}
+
+func (v *auctionServiceRepo) Run(ctx context.Context) {
+	log.Printf("Auction settlement is running")
+
+	ticker := time.NewTicker(v.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Auction settlement is stopped")
+			return
+		case <-ticker.C:
+			v.settleEndedAuctions(ctx)
+		}
+	}
+}
+
+func (v *auctionServiceRepo) settleEndedAuctions(ctx context.Context) {
+	ended, getErr := v.auctionRepo.GetEndedAuctions(ctx, time.Now())
+	if getErr != nil {
+		log.Printf("error getting ended auctions: %v\n", getErr)
+		return
+	}
+
+	for i := range ended {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// auction stays active and is settled again on next check
+		if settleErr := v.settleAuction(ctx, &ended[i]); settleErr != nil {
+			log.Printf("error settling auction %v: %v\n", ended[i].ID, settleErr)
+		}
+	}
+}
+
+// settleAuction transfers nft item to winner, pays seller, royalty and platform fee from winner's hold
+func (v *auctionServiceRepo) settleAuction(ctx context.Context, endedAuction *auction.Auction) error {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if endedAuction.BidsCount == 0 {
+		endedAuction.Status = auction.StatusExpired
+		return v.auctionRepo.CloseAuction(svcCtx, endedAuction)
+	}
+
+	winner := *endedAuction.Bidder
+	referenceID := endedAuction.ID.String()
+
+	royaltyAccrual, royaltyErr := v.royaltyService.Calculate(svcCtx, royalty.Sale{
+		NftItemAddress:    endedAuction.NftItemAddress,
+		CollectionAddress: endedAuction.CollectionAddress,
+		Seller:            endedAuction.Seller,
+		Price:             endedAuction.CurrentBid,
+		ReferenceID:       referenceID,
+		IsTestnet:         endedAuction.IsTestnet,
+	})
+	if royaltyErr != nil {
+		return fmt.Errorf("error calculating royalty: %w", royaltyErr)
+	}
+
+	endedAuction.Status = auction.StatusSettled
+	if royaltyAccrual != nil {
+		endedAuction.RoyaltyAmount = royaltyAccrual.Amount
+	}
+	endedAuction.PlatformFee = endedAuction.CurrentBid * v.platformFeePercent / 100
+	if endedAuction.RoyaltyAmount+endedAuction.PlatformFee > endedAuction.CurrentBid {
+		endedAuction.PlatformFee = endedAuction.CurrentBid - endedAuction.RoyaltyAmount
+	}
+
+	// winner's hold becomes purchase
+	entries := []*ledger.BalanceEntry{
+		ledger.NewBalanceEntry(winner, ledger.EntryTypeRelease, endedAuction.CurrentBid, referenceID, endedAuction.IsTestnet),
+		ledger.NewBalanceEntry(winner, ledger.EntryTypePurchase, endedAuction.CurrentBid, referenceID, endedAuction.IsTestnet),
+	}
+	if sellerAmount := endedAuction.SellerAmount(); sellerAmount > 0 {
+		entries = append(entries, ledger.NewBalanceEntry(endedAuction.Seller, ledger.EntryTypeSale, sellerAmount, referenceID, endedAuction.IsTestnet))
+	}
+
+	transfer := nftitem.NewTransfer(endedAuction.NftItemAddress, endedAuction.Seller, winner, endedAuction.IsTestnet)
+	transfer.Price = endedAuction.CurrentBid
+
+	txErr := v.auctionRepo.WithTransaction(svcCtx, func(txCtx context.Context) error {
+		if closeErr := v.auctionRepo.CloseAuction(txCtx, endedAuction); closeErr != nil {
+			return closeErr
+		}
+
+		if _, transferErr := v.nftItemRepo.TransferNftItem(txCtx, transfer); transferErr != nil {
+			return transferErr
+		}
+
+		if payErr := v.ledgerRepo.Apply(txCtx, entries...); payErr != nil {
+			return fmt.Errorf("error paying for auction: %w", payErr)
+		}
+
+		if royaltyAccrual != nil {
+			if accrueErr := v.royaltyService.Accrue(txCtx, royaltyAccrual); accrueErr != nil {
+				return fmt.Errorf("error accruing royalty: %w", accrueErr)
+			}
+		}
+
+		return nil
+	})
+	if txErr == nil {
+		return nil
+	}
+
+	// nft item left seller, so auction is cancelled and winner gets his hold back
+	if errors.Is(txErr, nftitem.ErrNotItemOwner) {
+		endedAuction.Status = auction.StatusCancelled
+		endedAuction.RoyaltyAmount = 0
+		endedAuction.PlatformFee = 0
+		return v.auctionRepo.WithTransaction(svcCtx, func(txCtx context.Context) error {
+			if closeErr := v.auctionRepo.CloseAuction(txCtx, endedAuction); closeErr != nil {
+				return closeErr
+			}
+			return v.ledgerRepo.Apply(txCtx, ledger.NewBalanceEntry(winner, ledger.EntryTypeRelease, endedAuction.CurrentBid, referenceID, endedAuction.IsTestnet))
+		})
+	}
+
+	return txErr
+}
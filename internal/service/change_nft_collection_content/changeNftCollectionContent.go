@@ -0,0 +1,228 @@
+package changenftcollectioncontent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	hostedmetadataservice "github.com/rom6n/create-nft-go/internal/service/hosted_metadata_service"
+	operationtracker "github.com/rom6n/create-nft-go/internal/service/operation_tracker"
+	pricingservice "github.com/rom6n/create-nft-go/internal/service/pricing_service"
+	nftcollectionutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_collection_utils"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
+	"github.com/rom6n/create-nft-go/internal/utils/tonutil"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/ton"
+	tonnft "github.com/xssnick/tonutils-go/ton/nft"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+)
+
+type ChangeNftCollectionContentServiceRepository interface {
+	// ChangeNftCollectionContent sends change_content message and returns new metadata.
+	// Metadata is stored in database when change is confirmed on chain
+	ChangeNftCollectionContent(ctx context.Context, nftCollectionAddress *address.Address, cfg nftcollection.ChangeContentCfg, ownerID int64, isTestnet bool) (*nftcollection.NftCollectionMetadata, error)
+}
+
+type changeNftCollectionContentServiceRepo struct {
+	nftCollectionRepo     nftcollection.NftCollectionRepository
+	userRepo              user.UserRepository
+	ledgerRepo            ledger.LedgerRepository
+	operationTracker      operationtracker.OperationTrackerRepository
+	pricingService        pricingservice.PricingServiceRepository
+	hostedMetadataService hostedmetadataservice.HostedMetadataServiceRepository
+	testnetLiteClient     *liteclient.ConnectionPool
+	mainnetLiteClient     *liteclient.ConnectionPool
+	testnetLiteApi        ton.APIClientWrapped
+	mainnetLiteApi        ton.APIClientWrapped
+	testnetWallet         *wallet.Wallet
+	mainnetWallet         *wallet.Wallet
+	timeout               time.Duration
+}
+
+type ChangeNftCollectionContentServiceCfg struct {
+	NftCollectionRepo     nftcollection.NftCollectionRepository
+	UserRepo              user.UserRepository
+	LedgerRepo            ledger.LedgerRepository
+	OperationTracker      operationtracker.OperationTrackerRepository
+	PricingService        pricingservice.PricingServiceRepository
+	HostedMetadataService hostedmetadataservice.HostedMetadataServiceRepository
+	TestnetLiteClient     *liteclient.ConnectionPool
+	MainnetLiteClient     *liteclient.ConnectionPool
+	TestnetLiteApi        ton.APIClientWrapped
+	MainnetLiteApi        ton.APIClientWrapped
+	TestnetWallet         *wallet.Wallet
+	MainnetWallet         *wallet.Wallet
+	Timeout               time.Duration
+}
+
+func New(cfg ChangeNftCollectionContentServiceCfg) ChangeNftCollectionContentServiceRepository {
+	return &changeNftCollectionContentServiceRepo{
+		nftCollectionRepo:     cfg.NftCollectionRepo,
+		userRepo:              cfg.UserRepo,
+		ledgerRepo:            cfg.LedgerRepo,
+		operationTracker:      cfg.OperationTracker,
+		pricingService:        cfg.PricingService,
+		hostedMetadataService: cfg.HostedMetadataService,
+		testnetLiteClient:     cfg.TestnetLiteClient,
+		mainnetLiteClient:     cfg.MainnetLiteClient,
+		testnetLiteApi:        cfg.TestnetLiteApi,
+		mainnetLiteApi:        cfg.MainnetLiteApi,
+		testnetWallet:         cfg.TestnetWallet,
+		mainnetWallet:         cfg.MainnetWallet,
+		timeout:               cfg.Timeout,
+	}
+}
+
+func (v *changeNftCollectionContentServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *changeNftCollectionContentServiceRepo) ChangeNftCollectionContent(ctx context.Context, nftCollectionAddress *address.Address, cfg nftcollection.ChangeContentCfg, ownerID int64, isTestnet bool) (*nftcollection.NftCollectionMetadata, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	client := v.testnetLiteClient
+	api := v.testnetLiteApi
+	walletAddress := v.testnetWallet.WalletAddress()
+	w := v.testnetWallet
+	if !isTestnet {
+		client = v.mainnetLiteClient
+		api = v.mainnetLiteApi
+		walletAddress = v.mainnetWallet.WalletAddress()
+		w = v.mainnetWallet
+	}
+
+	apiCtx := client.StickyContext(svcCtx)
+	nftCollectionAddress.SetTestnetOnly(isTestnet)
+
+	quote, quoteErr := v.pricingService.Quote(svcCtx, pricing.QuoteRequest{Operation: pricing.OperationChangeNftCollectionContent, IsTestnet: isTestnet})
+	if quoteErr != nil {
+		return nil, fmt.Errorf("error quoting nft collection content change: %w", quoteErr)
+	}
+
+	ownerAccount, accErr := v.userRepo.GetUserByID(svcCtx, ownerID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	if ownerAccount.Balance(isTestnet) < quote.Total {
+		return nil, fmt.Errorf("%w: need %v more", ledger.ErrNotEnoughBalance, quote.Total-ownerAccount.Balance(isTestnet))
+	}
+
+	nftCollection, collectionErr := v.nftCollectionRepo.GetNftCollectionByAddress(svcCtx, nftCollectionAddress.String())
+	if collectionErr != nil {
+		return nil, fmt.Errorf("error getting nft collection: %w", collectionErr)
+	}
+
+	if nftCollection.Owner != ownerAccount.UUID {
+		return nil, fmt.Errorf("%w to change its content", nftcollection.ErrNotCollectionOwner)
+	}
+
+	block, blockErr := api.GetMasterchainInfo(apiCtx)
+	if blockErr != nil {
+		return nil, fmt.Errorf("error getting masterchain info: %v", blockErr)
+	}
+
+	collectionClient := tonnft.NewCollectionClient(api, nftCollectionAddress)
+	collectionData, methodErr := collectionClient.GetCollectionDataAtBlock(apiCtx, block)
+	if methodErr != nil {
+		return nil, fmt.Errorf("nft collection get data method error: %v", methodErr)
+	}
+
+	// check if wallet is an owner of nft collection
+	if !walletAddress.Equals(collectionData.OwnerAddress) {
+		return nil, fmt.Errorf("%w to change its content", nftcollection.ErrNotCustodial)
+	}
+
+	// change_content replaces both cells, so not changed royalty params are taken from chain
+	if cfg.RoyaltyDividend == nil || cfg.RoyaltyDivisor == nil || cfg.RoyaltyAddress == nil {
+		royaltyParams, royaltyErr := collectionClient.RoyaltyParamsAtBlock(apiCtx, block)
+		if royaltyErr != nil {
+			return nil, fmt.Errorf("nft collection royalty params method error: %v", royaltyErr)
+		}
+
+		if cfg.RoyaltyDividend == nil || cfg.RoyaltyDivisor == nil {
+			cfg.RoyaltyDividend = &royaltyParams.Factor
+			cfg.RoyaltyDivisor = &royaltyParams.Base
+		}
+		if cfg.RoyaltyAddress == nil {
+			cfg.RoyaltyAddress = royaltyParams.Address
+		}
+	}
+
+	if *cfg.RoyaltyDivisor == 0 || *cfg.RoyaltyDividend > *cfg.RoyaltyDivisor {
+		return nil, fmt.Errorf("royalty dividend must be not greater than not zero royalty divisor")
+	}
+
+	if cfg.HostedMetadata != nil {
+		link, hostErr := v.hostedMetadataService.HostNftCollectionMetadata(svcCtx, cfg.HostedMetadata)
+		if hostErr != nil {
+			return nil, fmt.Errorf("error hosting nft collection metadata: %w", hostErr)
+		}
+		cfg.CollectionContent = link
+	}
+
+	content := nftcollectionutils.PackOffchainContentForNftCollection(cfg.CollectionContent, cfg.CommonContent)
+	if cfg.OnchainMetadata != nil {
+		onchainContent, packErr := nftcollectionutils.PackOnchainContentForNftCollection(cfg.OnchainMetadata, cfg.CommonContent)
+		if packErr != nil {
+			return nil, packErr
+		}
+		content = onchainContent
+	}
+
+	// new metadata is fetched before sending so broken content link isnt set on chain
+	nftCollectionMetadata := cfg.OnchainMetadata
+	if nftCollectionMetadata == nil {
+		nftCollectionMetadata = cfg.HostedMetadata
+	}
+	if nftCollectionMetadata == nil {
+		offchainMetadata, metadataErr := nftcollectionutils.GetValidatedNftCollectionOffchainMetadata(cfg.CollectionContent)
+		if metadataErr != nil {
+			return nil, fmt.Errorf("error getting new nft collection metadata: %w", tep64.WithFieldPrefix(metadataErr, "collection_content."))
+		}
+		nftCollectionMetadata = offchainMetadata
+	}
+
+	royaltyParams := nftcollectionutils.PackNftCollectionRoyaltyParams(*cfg.RoyaltyDividend, *cfg.RoyaltyDivisor, cfg.RoyaltyAddress)
+	changeContentMsg := nftcollectionutils.PackChangeContentMsg(nftCollectionAddress, content, royaltyParams, quote.MessageAmount)
+
+	if chargeErr := v.ledgerRepo.Apply(svcCtx,
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeDebit, quote.MessageAmount, nftCollectionAddress.String(), isTestnet),
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeFee, quote.Fee(), nftCollectionAddress.String(), isTestnet),
+	); chargeErr != nil {
+		return nil, fmt.Errorf("error reducing user's balance: %w", chargeErr)
+	}
+
+	msg := &wallet.Message{
+		Mode:            1,
+		InternalMessage: changeContentMsg,
+	}
+
+	// operation is tracked before sending, nft collection metadata is refreshed from chain when change is confirmed
+	changeOperation := operation.New(operation.KindChangeNftCollectionContent, ownerAccount.UUID, nftCollectionAddress.String(), changeContentMsg.Body.Hash(), quote.Total, nftCollectionAddress.String(), isTestnet)
+	if trackErr := v.operationTracker.Track(svcCtx, changeOperation); trackErr != nil {
+		return nil, fmt.Errorf("error tracking nft collection content change: %w", trackErr)
+	}
+
+	if msgErr := tonutil.SendWaitTransaction(apiCtx, api, w, msg); msgErr != nil {
+		if errors.Is(msgErr, tonutil.ErrNotSent) {
+			if failErr := v.operationTracker.Fail(svcCtx, changeOperation, msgErr.Error()); failErr != nil {
+				log.Printf("Error failing nft collection content change operation: %v\n", failErr)
+			}
+			return nil, fmt.Errorf("error sending external message to change nft collection content: %v", msgErr)
+		}
+		// FYI: message can be delivered anyway, tracker settles it on chain
+		log.Printf("Nft collection content change is not confirmed, it is settled by tracker: %v\n", msgErr)
+	}
+
+	return nftCollectionMetadata, nil
+}
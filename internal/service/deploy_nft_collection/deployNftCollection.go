@@ -3,14 +3,23 @@ package deploynftcollection
 import (
 	"context"
 	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
 	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
 	"github.com/rom6n/create-nft-go/internal/domain/user"
+	hostedmetadataservice "github.com/rom6n/create-nft-go/internal/service/hosted_metadata_service"
+	operationtracker "github.com/rom6n/create-nft-go/internal/service/operation_tracker"
+	pricingservice "github.com/rom6n/create-nft-go/internal/service/pricing_service"
 	generalcontractutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/general_contract_utils"
 	nftcollectionutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_collection_utils"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
+	"github.com/rom6n/create-nft-go/internal/utils/tonutil"
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/wallet"
@@ -24,6 +33,10 @@ type DeployNftCollectionServiceRepository interface {
 type deployNftCollectionServiceRepo struct {
 	nftCollectionRepo         nftcollection.NftCollectionRepository
 	userRepo                  user.UserRepository
+	ledgerRepo                ledger.LedgerRepository
+	operationTracker          operationtracker.OperationTrackerRepository
+	pricingService            pricingservice.PricingServiceRepository
+	hostedMetadataService     hostedmetadataservice.HostedMetadataServiceRepository
 	privateKey                ed25519.PrivateKey
 	testnetLiteClient         *liteclient.ConnectionPool
 	mainnetLiteClient         *liteclient.ConnectionPool
@@ -39,6 +52,10 @@ type deployNftCollectionServiceRepo struct {
 type DeployNftCollectionServiceCfg struct {
 	NftCollectionRepo         nftcollection.NftCollectionRepository
 	UserRepo                  user.UserRepository
+	LedgerRepo                ledger.LedgerRepository
+	OperationTracker          operationtracker.OperationTrackerRepository
+	PricingService            pricingservice.PricingServiceRepository
+	HostedMetadataService     hostedmetadataservice.HostedMetadataServiceRepository
 	PrivateKey                ed25519.PrivateKey
 	TestnetLiteClient         *liteclient.ConnectionPool
 	MainnetLiteClient         *liteclient.ConnectionPool
@@ -55,6 +72,10 @@ func New(cfg DeployNftCollectionServiceCfg) DeployNftCollectionServiceRepository
 	return &deployNftCollectionServiceRepo{
 		cfg.NftCollectionRepo,
 		cfg.UserRepo,
+		cfg.LedgerRepo,
+		cfg.OperationTracker,
+		cfg.PricingService,
+		cfg.HostedMetadataService,
 		cfg.PrivateKey,
 		cfg.TestnetLiteClient,
 		cfg.MainnetLiteClient,
@@ -74,30 +95,58 @@ func (v *deployNftCollectionServiceRepo) DeployNftCollection(ctx context.Context
 
 	walletAddress := v.testnetWallet.WalletAddress()
 	client := v.testnetLiteClient
+	api := v.testnetLiteApi
 	w := v.testnetWallet
 
 	if !isTestnet {
 		walletAddress = v.mainnetWallet.WalletAddress()
 		client = v.mainnetLiteClient
+		api = v.mainnetLiteApi
 		w = v.mainnetWallet
 	}
 
 	apiCtx := client.StickyContext(svcCtx)
-	nanoTonForDeploy := uint64(50000000)
-	nanoTonForFees := uint64(15000000)
+
+	quote, quoteErr := v.pricingService.Quote(svcCtx, pricing.QuoteRequest{Operation: pricing.OperationDeployNftCollection, IsTestnet: isTestnet})
+	if quoteErr != nil {
+		return nil, fmt.Errorf("error quoting nft collection deploy: %w", quoteErr)
+	}
 
 	ownerAccount, userErr := v.userRepo.GetUserByID(svcCtx, ownerID)
 	if userErr != nil {
 		return nil, userErr
 	}
 
-	if ownerAccount.NanoTon < (nanoTonForDeploy + nanoTonForFees) { // return if not enough TON
-		return nil, fmt.Errorf("not enough toncoins. Need %v nano ton more", (nanoTonForDeploy + nanoTonForFees - ownerAccount.NanoTon))
+	if ownerAccount.Balance(isTestnet) < quote.Total { // return if not enough TON
+		return nil, fmt.Errorf("%w: need %v nano ton more", ledger.ErrNotEnoughBalance, quote.Total-ownerAccount.Balance(isTestnet))
+	}
+
+	// hosted metadata is deployed as offchain content with link to the app
+	if deployCfg.HostedMetadata != nil {
+		link, hostErr := v.hostedMetadataService.HostNftCollectionMetadata(svcCtx, deployCfg.HostedMetadata)
+		if hostErr != nil {
+			return nil, fmt.Errorf("error hosting nft collection metadata: %w", hostErr)
+		}
+		deployCfg.CollectionContent = link
 	}
 
 	content := nftcollectionutils.PackOffchainContentForNftCollection(deployCfg.CollectionContent, deployCfg.CommonContent)
+	if deployCfg.OnchainMetadata != nil {
+		onchainContent, packErr := nftcollectionutils.PackOnchainContentForNftCollection(deployCfg.OnchainMetadata, deployCfg.CommonContent)
+		if packErr != nil {
+			return nil, packErr
+		}
+		content = onchainContent
+	}
 
 	royaltyParams := nftcollectionutils.PackNftCollectionRoyaltyParams(deployCfg.RoyaltyDividend, deployCfg.RoyaltyDivisor, deployCfg.OwnerAddress)
+	royalty := &nftcollection.Royalty{
+		Dividend: deployCfg.RoyaltyDividend,
+		Divisor:  deployCfg.RoyaltyDivisor,
+	}
+	if deployCfg.OwnerAddress != nil {
+		royalty.Address = deployCfg.OwnerAddress.String()
+	}
 
 	if deployCfg.OwnerAddress == nil {
 		deployCfg.OwnerAddress = walletAddress
@@ -110,18 +159,28 @@ func (v *deployNftCollectionServiceRepo) DeployNftCollection(ctx context.Context
 	toAddress := generalcontractutils.CalculateAddress(0, stateInit)
 	toAddress.SetTestnetOnly(isTestnet)
 
-	deployMsg := generalcontractutils.PackDeployMessage(toAddress, stateInit)
+	deployMsg := generalcontractutils.PackDeployMessage(toAddress, stateInit, quote.MessageAmount)
 
-	nftCollectionMetadata, metadataErr := nftcollectionutils.GetNftCollectionOffchainMetadata(deployCfg.CollectionContent)
-	if metadataErr != nil {
-		return nil, metadataErr
+	nftCollectionMetadata := deployCfg.OnchainMetadata
+	if nftCollectionMetadata == nil {
+		nftCollectionMetadata = deployCfg.HostedMetadata
+	}
+	if nftCollectionMetadata == nil {
+		offchainMetadata, metadataErr := nftcollectionutils.GetValidatedNftCollectionOffchainMetadata(deployCfg.CollectionContent)
+		if metadataErr != nil {
+			return nil, tep64.WithFieldPrefix(metadataErr, "collection_content.")
+		}
+		nftCollectionMetadata = offchainMetadata
 	}
 
-	nftCollection := nftcollection.New(toAddress.String(), ownerAccount.UUID, nftCollectionMetadata, isTestnet)
+	nftCollection := nftcollection.New(toAddress.String(), ownerAccount.UUID, nftCollectionMetadata, royalty, isTestnet)
 
 	// reducing the user's balance before deploy
-	if updErr := v.userRepo.UpdateUserBalance(svcCtx, ownerAccount.UUID, ownerAccount.NanoTon-nanoTonForDeploy-nanoTonForFees); updErr != nil {
-		return nil, fmt.Errorf("error update user's balance before nft collection mint: %v", updErr)
+	if chargeErr := v.ledgerRepo.Apply(svcCtx,
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeDebit, quote.MessageAmount, toAddress.String(), isTestnet),
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeFee, quote.Fee(), toAddress.String(), isTestnet),
+	); chargeErr != nil {
+		return nil, fmt.Errorf("error update user's balance before nft collection deploy: %w", chargeErr)
 	}
 
 	msg := wallet.Message{
@@ -129,22 +188,21 @@ func (v *deployNftCollectionServiceRepo) DeployNftCollection(ctx context.Context
 		InternalMessage: deployMsg,
 	}
 
-	msgErr := w.Send(apiCtx, &msg, true)
+	// operation is tracked before sending, tracker refunds quote total if deploy fails
+	deployOperation := operation.New(operation.KindDeployNftCollection, ownerAccount.UUID, toAddress.String(), deployMsg.Body.Hash(), quote.Total, toAddress.String(), isTestnet)
+	if trackErr := v.operationTracker.Track(svcCtx, deployOperation); trackErr != nil {
+		return nil, fmt.Errorf("error tracking nft collection deploy: %w", trackErr)
+	}
 
-	if msgErr != nil {
-		// FYI: it can fail if not enough balance on contract
-		for i := 0; i < 10; i++ {
-			if updErr := v.userRepo.UpdateUserBalance(svcCtx, ownerAccount.UUID, ownerAccount.NanoTon-uint64(nanoTonForFees)); updErr == nil {
-				break
+	if msgErr := tonutil.SendWaitTransaction(apiCtx, api, w, &msg); msgErr != nil {
+		if errors.Is(msgErr, tonutil.ErrNotSent) {
+			if failErr := v.operationTracker.Fail(svcCtx, deployOperation, msgErr.Error()); failErr != nil {
+				log.Printf("Error failing nft collection deploy operation: %v\n", failErr)
 			}
-			log.Printf("Error returning %v ton to user after nft collection deploy fail, try: %v\n", nanoTonForDeploy, i)
-			if i == 9 {
-				return nil, fmt.Errorf("error returning ton to user & error sending deploy nft collection external message: %v", msgErr)
-			}
-			time.Sleep(1 * time.Second)
+			return nil, fmt.Errorf("error sending deploy nft collection external message: %v", msgErr)
 		}
-
-		return nil, fmt.Errorf("error sending deploy nft collection external message: %v", msgErr)
+		// FYI: message can be delivered anyway, tracker settles it on chain
+		log.Printf("Nft collection deploy is not confirmed, it is settled by tracker: %v\n", msgErr)
 	}
 
 	if deployCfg.OwnerAddress.Equals(walletAddress) {
@@ -0,0 +1,443 @@
+package depositservice
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/deposit"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"github.com/rom6n/create-nft-go/internal/utils/tonutil"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+)
+
+const (
+	// refundFeeNanoTon is kept from refunded deposit to pay transfer fee
+	refundFeeNanoTon uint64 = 10_000_000
+	// refundScanLimit is count of wallet transactions scanned for message of refund
+	refundScanLimit = 300
+
+	// nft contracts answer messages of service wallet with these operations, they are not deposits
+	opExcesses          uint64 = 0xd53276db
+	opOwnershipAssigned uint64 = 0x05138d91
+
+	// stopped treasury listener is restarted after delay doubled on every failure up to max
+	listenRetryDelay    = 1 * time.Second
+	listenMaxRetryDelay = 1 * time.Minute
+)
+
+type DepositServiceRepository interface {
+	// ListenDeposits credits deposits to testnet and mainnet treasuries and settles refunds until ctx is done.
+	// Listening continues from the last processed transaction after restart
+	ListenDeposits(ctx context.Context)
+	GetUnmatchedDeposits(ctx context.Context) ([]deposit.Deposit, error)
+	AssignDeposit(ctx context.Context, depositID uuid.UUID, userID int64) error
+	RefundDeposit(ctx context.Context, depositID uuid.UUID) error
+}
+
+type depositServiceRepo struct {
+	depositRepo            deposit.DepositRepository
+	userRepo               user.UserRepository
+	ledgerRepo             ledger.LedgerRepository
+	testnetLiteClient      *liteclient.ConnectionPool
+	mainnetLiteClient      *liteclient.ConnectionPool
+	testnetLiteApi         ton.APIClientWrapped
+	mainnetLiteApi         ton.APIClientWrapped
+	testnetWallet          *wallet.Wallet
+	mainnetWallet          *wallet.Wallet
+	testnetTreasuryAddress *address.Address
+	mainnetTreasuryAddress *address.Address
+	pollInterval           time.Duration
+	confirmTimeout         time.Duration
+	timeout                time.Duration
+}
+
+type DepositServiceCfg struct {
+	DepositRepo            deposit.DepositRepository
+	UserRepo               user.UserRepository
+	LedgerRepo             ledger.LedgerRepository
+	TestnetLiteClient      *liteclient.ConnectionPool
+	MainnetLiteClient      *liteclient.ConnectionPool
+	TestnetLiteApi         ton.APIClientWrapped
+	MainnetLiteApi         ton.APIClientWrapped
+	TestnetWallet          *wallet.Wallet
+	MainnetWallet          *wallet.Wallet
+	TestnetTreasuryAddress *address.Address
+	MainnetTreasuryAddress *address.Address
+	PollInterval           time.Duration // how often refunding deposits are checked on chain
+	ConfirmTimeout         time.Duration // refund without its message on chain after this time is returned to unmatched
+	Timeout                time.Duration
+}
+
+func New(cfg DepositServiceCfg) DepositServiceRepository {
+	return &depositServiceRepo{
+		depositRepo:            cfg.DepositRepo,
+		userRepo:               cfg.UserRepo,
+		ledgerRepo:             cfg.LedgerRepo,
+		testnetLiteClient:      cfg.TestnetLiteClient,
+		mainnetLiteClient:      cfg.MainnetLiteClient,
+		testnetLiteApi:         cfg.TestnetLiteApi,
+		mainnetLiteApi:         cfg.MainnetLiteApi,
+		testnetWallet:          cfg.TestnetWallet,
+		mainnetWallet:          cfg.MainnetWallet,
+		testnetTreasuryAddress: cfg.TestnetTreasuryAddress,
+		mainnetTreasuryAddress: cfg.MainnetTreasuryAddress,
+		pollInterval:           cfg.PollInterval,
+		confirmTimeout:         cfg.ConfirmTimeout,
+		timeout:                cfg.Timeout,
+	}
+}
+
+func (v *depositServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *depositServiceRepo) ListenDeposits(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		v.keepListeningTreasury(ctx, v.testnetLiteApi, v.testnetTreasuryAddress, true)
+	}()
+
+	go func() {
+		defer wg.Done()
+		v.keepListeningTreasury(ctx, v.mainnetLiteApi, v.mainnetTreasuryAddress, false)
+	}()
+
+	go func() {
+		defer wg.Done()
+		v.settleRefunds(ctx)
+	}()
+
+	wg.Wait()
+}
+
+// keepListeningTreasury restarts listener of treasury with backoff until ctx is done, its failures are transient
+func (v *depositServiceRepo) keepListeningTreasury(ctx context.Context, api ton.APIClientWrapped, treasuryAddress *address.Address, isTestnet bool) {
+	retryDelay := listenRetryDelay
+	for {
+		startedAt := time.Now()
+		listenErr := v.listenTreasury(ctx, api, treasuryAddress, isTestnet)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// listener which worked long enough stopped for new reason
+		if time.Since(startedAt) > listenMaxRetryDelay {
+			retryDelay = listenRetryDelay
+		}
+		log.Printf("Deposits listener %v stopped, restarting in %v: %v\n", networkName(isTestnet), retryDelay, listenErr)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryDelay):
+		}
+		retryDelay = min(retryDelay*2, listenMaxRetryDelay)
+	}
+}
+
+// listenTreasury credits transactions of treasury until ctx is done or subscription stops
+func (v *depositServiceRepo) listenTreasury(ctx context.Context, api ton.APIClientWrapped, treasuryAddress *address.Address, isTestnet bool) error {
+	network := networkName(isTestnet)
+	// same wallet can be treasury in both networks, so cursor is kept per network
+	account := fmt.Sprintf("%v:%v", network, treasuryAddress.String())
+
+	lastProcessedLT, ltErr := v.depositRepo.GetLastProcessedLT(ctx, account)
+	if ltErr != nil {
+		return fmt.Errorf("error getting last processed lt: %w", ltErr)
+	}
+
+	if lastProcessedLT == 0 {
+		// first start, transactions before it are not credited
+		master, err := api.CurrentMasterchainInfo(ctx)
+		if err != nil {
+			return fmt.Errorf("error getting masterchain info: %w", err)
+		}
+
+		acc, err := api.GetAccount(ctx, master, treasuryAddress)
+		if err != nil {
+			return fmt.Errorf("error getting treasury account: %w", err)
+		}
+
+		lastProcessedLT = acc.LastTxLT
+		if saveErr := v.depositRepo.SaveLastProcessedLT(ctx, account, lastProcessedLT); saveErr != nil {
+			log.Printf("Deposits listener %v: %v\n", network, saveErr)
+		}
+	}
+
+	transactions := make(chan *tlb.Transaction)
+
+	log.Printf("ListenDeposits %v запущен с lt %v!\n", network, lastProcessedLT)
+	go api.SubscribeOnTransactions(ctx, treasuryAddress, lastProcessedLT, transactions)
+	for tx := range transactions {
+		v.processTransaction(ctx, tx, isTestnet)
+
+		if saveErr := v.depositRepo.SaveLastProcessedLT(ctx, account, tx.LT); saveErr != nil {
+			log.Printf("Deposits listener %v: %v\n", network, saveErr)
+		}
+	}
+
+	// subscription also stops when lite server has no transaction of lt anymore
+	return fmt.Errorf("transactions subscription stopped")
+}
+
+func networkName(isTestnet bool) string {
+	if isTestnet {
+		return "testnet"
+	}
+	return "mainnet"
+}
+
+func (v *depositServiceRepo) processTransaction(ctx context.Context, tx *tlb.Transaction, isTestnet bool) {
+	if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeInternal {
+		return
+	}
+	ti := tx.IO.In.AsInternal()
+
+	// bounced transfer of service wallet is returned, it is not deposit
+	if ti.Bounced {
+		return
+	}
+
+	if ti.Body != nil {
+		if op, opErr := ti.Body.BeginParse().LoadUInt(32); opErr == nil && (op == opExcesses || op == opOwnershipAssigned) {
+			return
+		}
+	}
+
+	if dsc, ok := tx.Description.(tlb.TransactionDescriptionOrdinary); ok && dsc.BouncePhase != nil {
+		if _, ok = dsc.BouncePhase.Phase.(tlb.BouncePhaseOk); ok {
+			// transaction was bounced, and coins were returned to sender
+			// this can happen mostly on custom contracts
+			return
+		}
+	}
+
+	if ti.Amount.Nano().Sign() <= 0 {
+		return
+	}
+
+	receivedNanoTon, parseErr := strconv.ParseUint(ti.Amount.Nano().String(), 0, 64)
+	if parseErr != nil {
+		log.Printf("Deposits listener: Error parsing received nano ton to uint64: %v\n", parseErr)
+		return
+	}
+
+	newDeposit := deposit.New(hex.EncodeToString(tx.Hash), tx.LT, ti.SrcAddr.String(), receivedNanoTon, ti.Comment(), isTestnet)
+
+	if creditErr := v.creditDeposit(ctx, newDeposit); creditErr != nil {
+		log.Printf("Deposits listener: deposit %v is unmatched: %v\n", newDeposit.TxHash, creditErr)
+		newDeposit.Error = creditErr.Error()
+	}
+
+	for i := 0; i < 10; i++ {
+		svcCtx, cancel := v.getContext(ctx)
+		createErr := v.depositRepo.CreateDeposit(svcCtx, newDeposit)
+		cancel()
+
+		if createErr == nil || errors.Is(createErr, deposit.ErrAlreadyProcessed) {
+			return
+		}
+
+		log.Printf("Deposits listener: Error saving deposit %v, try %v: %v\n", newDeposit.TxHash, i, createErr)
+		time.Sleep(1 * time.Second)
+	}
+}
+
+// creditDeposit adds deposit to balance of user from its memo and marks it as credited
+func (v *depositServiceRepo) creditDeposit(ctx context.Context, newDeposit *deposit.Deposit) error {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	userID, memoErr := user.ParseDepositMemo(newDeposit.Comment)
+	if memoErr != nil {
+		return fmt.Errorf("comment %q: %w", newDeposit.Comment, memoErr)
+	}
+
+	user, getErr := v.userRepo.GetUserByID(svcCtx, userID)
+	if getErr != nil {
+		return fmt.Errorf("error getting user %v: %w", userID, getErr)
+	}
+
+	depositEntry := ledger.NewBalanceEntry(user.UUID, ledger.EntryTypeDeposit, newDeposit.Amount, newDeposit.TxHash, newDeposit.IsTestnet)
+	if applyErr := v.ledgerRepo.Apply(svcCtx, depositEntry); applyErr != nil && !errors.Is(applyErr, ledger.ErrEntryAlreadyApplied) {
+		return fmt.Errorf("error updating user's balance: %w", applyErr)
+	}
+
+	newDeposit.UserUUID = user.UUID
+	newDeposit.Status = deposit.StatusCredited
+	return nil
+}
+
+func (v *depositServiceRepo) GetUnmatchedDeposits(ctx context.Context) ([]deposit.Deposit, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.depositRepo.GetDepositsByStatus(svcCtx, deposit.StatusUnmatched)
+}
+
+func (v *depositServiceRepo) AssignDeposit(ctx context.Context, depositID uuid.UUID, userID int64) error {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	foundDeposit, getErr := v.depositRepo.GetDepositByID(svcCtx, depositID)
+	if getErr != nil {
+		return fmt.Errorf("error getting deposit: %w", getErr)
+	}
+	if foundDeposit.Status != deposit.StatusUnmatched {
+		return deposit.ErrStatusChanged
+	}
+
+	user, getErr := v.userRepo.GetUserByID(svcCtx, userID)
+	if getErr != nil {
+		return fmt.Errorf("error getting user by ID: %w", getErr)
+	}
+
+	if assignErr := v.depositRepo.AssignDeposit(svcCtx, depositID, user.UUID); assignErr != nil {
+		return assignErr
+	}
+
+	// reference is transaction hash, so deposit can't be credited twice
+	depositEntry := ledger.NewBalanceEntry(user.UUID, ledger.EntryTypeDeposit, foundDeposit.Amount, foundDeposit.TxHash, foundDeposit.IsTestnet)
+	if applyErr := v.ledgerRepo.Apply(svcCtx, depositEntry); applyErr != nil {
+		if updErr := v.depositRepo.UpdateDepositStatus(svcCtx, depositID, deposit.StatusAssigned, deposit.StatusUnmatched, applyErr.Error()); updErr != nil {
+			log.Printf("error returning deposit %v to unmatched: %v\n", depositID, updErr)
+		}
+		return fmt.Errorf("error updating user's balance: %w", applyErr)
+	}
+
+	return nil
+}
+
+func (v *depositServiceRepo) RefundDeposit(ctx context.Context, depositID uuid.UUID) error {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	foundDeposit, getErr := v.depositRepo.GetDepositByID(svcCtx, depositID)
+	if getErr != nil {
+		return fmt.Errorf("error getting deposit: %w", getErr)
+	}
+	if foundDeposit.Amount <= refundFeeNanoTon {
+		return fmt.Errorf("deposit is too small to refund")
+	}
+
+	refundTo, parseErr := address.ParseAddr(foundDeposit.FromAddress)
+	if parseErr != nil {
+		return fmt.Errorf("error parsing sender address: %w", parseErr)
+	}
+
+	if claimErr := v.depositRepo.UpdateDepositStatus(svcCtx, depositID, deposit.StatusUnmatched, deposit.StatusRefunding, ""); claimErr != nil {
+		return claimErr
+	}
+
+	client := v.testnetLiteClient
+	api := v.testnetLiteApi
+	w := v.testnetWallet
+	if !foundDeposit.IsTestnet {
+		client = v.mainnetLiteClient
+		api = v.mainnetLiteApi
+		w = v.mainnetWallet
+	}
+	foundDeposit.Status = deposit.StatusRefunding
+	foundDeposit.UpdatedAt = time.Now()
+
+	transfer, buildErr := w.BuildTransfer(refundTo, tlb.FromNanoTONU(foundDeposit.Amount-refundFeeNanoTon), refundTo.IsBounceable(), foundDeposit.RefundComment())
+	if buildErr != nil {
+		v.returnToUnmatched(svcCtx, depositID, buildErr.Error())
+		return fmt.Errorf("error building deposit refund: %w", buildErr)
+	}
+
+	if sendErr := tonutil.SendWaitTransaction(client.StickyContext(svcCtx), api, w, transfer); sendErr != nil {
+		if errors.Is(sendErr, tonutil.ErrNotSent) {
+			v.returnToUnmatched(svcCtx, depositID, sendErr.Error())
+			return fmt.Errorf("error refunding deposit: %w", sendErr)
+		}
+		// transfer can be in blockchain already, deposit stays refunding until its message is found or confirm timeout passes
+		log.Printf("deposit %v refund result is unknown, it will be settled on chain: %v\n", depositID, sendErr)
+		return nil
+	}
+
+	// wallet transaction is found, but its action phase can fail, so refund is confirmed by its message
+	v.settleRefund(svcCtx, foundDeposit)
+	return nil
+}
+
+func (v *depositServiceRepo) settleRefunds(ctx context.Context) {
+	ticker := time.NewTicker(v.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		refunding, getErr := v.depositRepo.GetDepositsByStatus(ctx, deposit.StatusRefunding)
+		if getErr != nil {
+			log.Printf("error getting refunding deposits: %v\n", getErr)
+			continue
+		}
+
+		for i := range refunding {
+			svcCtx, cancel := v.getContext(ctx)
+			v.settleRefund(svcCtx, &refunding[i])
+			cancel()
+		}
+	}
+}
+
+// settleRefund marks deposit as refunded if its refund message is sent by wallet on chain.
+// Refund without message after confirm timeout is returned to unmatched, its external message is expired by then
+func (v *depositServiceRepo) settleRefund(ctx context.Context, foundDeposit *deposit.Deposit) {
+	api := v.testnetLiteApi
+	w := v.testnetWallet
+	if !foundDeposit.IsTestnet {
+		api = v.mainnetLiteApi
+		w = v.mainnetWallet
+	}
+
+	body, commentErr := wallet.CreateCommentCell(foundDeposit.RefundComment())
+	if commentErr != nil {
+		log.Printf("error building deposit %v refund comment: %v\n", foundDeposit.ID, commentErr)
+		return
+	}
+
+	_, findErr := api.FindLastTransactionByOutMsgHash(ctx, w.WalletAddress(), body.Hash(), refundScanLimit)
+	if errors.Is(findErr, ton.ErrTxWasNotFound) {
+		if time.Since(foundDeposit.UpdatedAt) < v.confirmTimeout {
+			return
+		}
+		v.returnToUnmatched(ctx, foundDeposit.ID, fmt.Sprintf("no refund message on chain after %v", v.confirmTimeout))
+		return
+	}
+	if findErr != nil {
+		log.Printf("error looking for deposit %v refund on chain, check it manually if it repeats: %v\n", foundDeposit.ID, findErr)
+		return
+	}
+
+	if updErr := v.depositRepo.UpdateDepositStatus(ctx, foundDeposit.ID, deposit.StatusRefunding, deposit.StatusRefunded, ""); updErr != nil {
+		log.Printf("error marking deposit %v as refunded: %v\n", foundDeposit.ID, updErr)
+	}
+}
+
+// returnToUnmatched is only called when refund transfer can't get to blockchain, so deposit can be refunded again
+func (v *depositServiceRepo) returnToUnmatched(ctx context.Context, depositID uuid.UUID, reason string) {
+	if updErr := v.depositRepo.UpdateDepositStatus(ctx, depositID, deposit.StatusRefunding, deposit.StatusUnmatched, reason); updErr != nil {
+		log.Printf("error returning deposit %v to unmatched: %v\n", depositID, updErr)
+	}
+}
@@ -0,0 +1,93 @@
+package hostedmetadataservice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	hostedmetadata "github.com/rom6n/create-nft-go/internal/domain/hosted_metadata"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
+)
+
+type HostedMetadataServiceRepository interface {
+	// HostNftCollectionMetadata stores metadata and returns link it is served by, link can be used as collection content
+	HostNftCollectionMetadata(ctx context.Context, metadata *nftcollection.NftCollectionMetadata) (string, error)
+	// HostNftItemMetadata stores metadata and returns link it is served by, link can be used as nft item content
+	HostNftItemMetadata(ctx context.Context, metadata *nftitem.NftItemMetadata) (string, error)
+	GetDocument(ctx context.Context, hash string) (*hostedmetadata.Document, error)
+}
+
+type hostedMetadataServiceRepo struct {
+	hostedMetadataRepo hostedmetadata.HostedMetadataRepository
+	baseURL            string
+	timeout            time.Duration
+}
+
+type HostedMetadataServiceCfg struct {
+	HostedMetadataRepo hostedmetadata.HostedMetadataRepository
+	BaseURL            string // public https url of the app, documents are served at BaseURL/api/metadata/{hash}
+	Timeout            time.Duration
+}
+
+func New(cfg HostedMetadataServiceCfg) HostedMetadataServiceRepository {
+	return &hostedMetadataServiceRepo{
+		hostedMetadataRepo: cfg.HostedMetadataRepo,
+		baseURL:            strings.TrimSuffix(cfg.BaseURL, "/"),
+		timeout:            cfg.Timeout,
+	}
+}
+
+func (v *hostedMetadataServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *hostedMetadataServiceRepo) HostNftCollectionMetadata(ctx context.Context, metadata *nftcollection.NftCollectionMetadata) (string, error) {
+	content, marshalErr := json.Marshal(metadata)
+	if marshalErr != nil {
+		return "", fmt.Errorf("error encoding nft collection metadata: %v", marshalErr)
+	}
+	if validateErr := tep64.ValidateNftCollectionMetadata(content); validateErr != nil {
+		return "", validateErr
+	}
+
+	return v.host(ctx, hostedmetadata.KindNftCollection, content)
+}
+
+func (v *hostedMetadataServiceRepo) HostNftItemMetadata(ctx context.Context, metadata *nftitem.NftItemMetadata) (string, error) {
+	content, marshalErr := json.Marshal(metadata)
+	if marshalErr != nil {
+		return "", fmt.Errorf("error encoding nft item metadata: %v", marshalErr)
+	}
+	if validateErr := tep64.ValidateNftItemMetadata(content); validateErr != nil {
+		return "", validateErr
+	}
+
+	return v.host(ctx, hostedmetadata.KindNftItem, content)
+}
+
+func (v *hostedMetadataServiceRepo) host(ctx context.Context, kind hostedmetadata.Kind, content []byte) (string, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if len(content) > hostedmetadata.MaxDocumentSize {
+		return "", fmt.Errorf("%w: %v bytes, at most %v", hostedmetadata.ErrTooLarge, len(content), hostedmetadata.MaxDocumentSize)
+	}
+
+	document := hostedmetadata.NewDocument(kind, content)
+	if saveErr := v.hostedMetadataRepo.SaveDocument(svcCtx, document); saveErr != nil {
+		return "", saveErr
+	}
+
+	return v.baseURL + "/api/metadata/" + document.Hash, nil
+}
+
+func (v *hostedMetadataServiceRepo) GetDocument(ctx context.Context, hash string) (*hostedmetadata.Document, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.hostedMetadataRepo.GetDocument(svcCtx, hash)
+}
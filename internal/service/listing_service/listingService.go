@@ -0,0 +1,266 @@
+package listingservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/royalty"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	royaltyservice "github.com/rom6n/create-nft-go/internal/service/royalty_service"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type ListingServiceRepository interface {
+	CreateListing(ctx context.Context, nftItemAddress string, price uint64, sellerID int64) (*listing.Listing, error)
+	CancelListing(ctx context.Context, id uuid.UUID, sellerID int64) (*listing.Listing, error)
+	// BuyListing moves nft item to buyer, debits buyer and credits seller, nft collection owner royalty in one transaction.
+	// Nothing is sent on chain, nft item stays custodial
+	BuyListing(ctx context.Context, id uuid.UUID, buyerID int64) (*listing.Listing, error)
+	GetListing(ctx context.Context, id uuid.UUID) (*listing.Listing, error)
+	SearchListings(ctx context.Context, query listing.Query) ([]listing.Listing, error)
+}
+
+type listingServiceRepo struct {
+	listingRepo        listing.ListingRepository
+	auctionRepo        auction.AuctionRepository
+	nftItemRepo        nftitem.NftItemRepository
+	userRepo           user.UserRepository
+	ledgerRepo         ledger.LedgerRepository
+	royaltyService     royaltyservice.RoyaltyServiceRepository
+	platformFeePercent uint64
+	timeout            time.Duration
+}
+
+type ListingServiceCfg struct {
+	ListingRepo        listing.ListingRepository
+	AuctionRepo        auction.AuctionRepository
+	NftItemRepo        nftitem.NftItemRepository
+	UserRepo           user.UserRepository
+	LedgerRepo         ledger.LedgerRepository
+	RoyaltyService     royaltyservice.RoyaltyServiceRepository
+	PlatformFeePercent uint64 // percent of price kept by platform on sale
+	Timeout            time.Duration
+}
+
+func New(cfg ListingServiceCfg) ListingServiceRepository {
+	return &listingServiceRepo{
+		listingRepo:        cfg.ListingRepo,
+		auctionRepo:        cfg.AuctionRepo,
+		nftItemRepo:        cfg.NftItemRepo,
+		userRepo:           cfg.UserRepo,
+		ledgerRepo:         cfg.LedgerRepo,
+		royaltyService:     cfg.RoyaltyService,
+		platformFeePercent: cfg.PlatformFeePercent,
+		timeout:            cfg.Timeout,
+	}
+}
+
+func (v *listingServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *listingServiceRepo) CreateListing(ctx context.Context, nftItemAddress string, price uint64, sellerID int64) (*listing.Listing, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if price < listing.MinPrice {
+		return nil, fmt.Errorf("%w: must be at least %v nano ton", listing.ErrInvalidPrice, listing.MinPrice)
+	}
+
+	sellerAccount, accErr := v.userRepo.GetUserByID(svcCtx, sellerID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	// withdraw marks nft item before checking its listings, so the mark is checked in transaction with listing creation
+	var newListing *listing.Listing
+	txErr := v.listingRepo.WithTransaction(svcCtx, func(txCtx context.Context) error {
+		nftItem, nftItemErr := v.nftItemRepo.GetNftItemByAddress(txCtx, nftItemAddress)
+		if nftItemErr != nil {
+			return fmt.Errorf("error getting nft item: %w", nftItemErr)
+		}
+
+		if nftItem.Owner != sellerAccount.UUID {
+			return fmt.Errorf("%w to list it", nftitem.ErrNotItemOwner)
+		}
+
+		if nftItem.Withdrawing {
+			return nftitem.ErrWithdrawPending
+		}
+
+		// nft item is sold either by listing or by auction
+		if _, auctionErr := v.auctionRepo.GetActiveAuctionByNftItem(txCtx, nftItem.Address); auctionErr == nil {
+			return fmt.Errorf("%w, cancel auction to list it", auction.ErrAlreadyOnAuction)
+		} else if !errors.Is(auctionErr, mongo.ErrNoDocuments) {
+			return fmt.Errorf("error checking nft item auction: %w", auctionErr)
+		}
+
+		newListing = listing.New(nftItem, price)
+		return v.listingRepo.CreateListing(txCtx, newListing)
+	})
+	if txErr != nil {
+		return nil, txErr
+	}
+
+	return newListing, nil
+}
+
+func (v *listingServiceRepo) CancelListing(ctx context.Context, id uuid.UUID, sellerID int64) (*listing.Listing, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	sellerAccount, accErr := v.userRepo.GetUserByID(svcCtx, sellerID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	foundListing, listingErr := v.listingRepo.GetListingByID(svcCtx, id)
+	if listingErr != nil {
+		return nil, fmt.Errorf("error getting listing: %w", listingErr)
+	}
+
+	if foundListing.Seller != sellerAccount.UUID {
+		return nil, fmt.Errorf("%w to cancel it", listing.ErrNotSeller)
+	}
+
+	if foundListing.Status != listing.StatusActive {
+		return nil, listing.ErrNotActive
+	}
+
+	foundListing.Status = listing.StatusCancelled
+	if closeErr := v.listingRepo.CloseListing(svcCtx, foundListing); closeErr != nil {
+		return nil, closeErr
+	}
+
+	return foundListing, nil
+}
+
+func (v *listingServiceRepo) BuyListing(ctx context.Context, id uuid.UUID, buyerID int64) (*listing.Listing, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	buyerAccount, accErr := v.userRepo.GetUserByID(svcCtx, buyerID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	foundListing, listingErr := v.listingRepo.GetListingByID(svcCtx, id)
+	if listingErr != nil {
+		return nil, fmt.Errorf("error getting listing: %w", listingErr)
+	}
+
+	if foundListing.Status != listing.StatusActive {
+		return nil, listing.ErrNotActive
+	}
+
+	if foundListing.Seller == buyerAccount.UUID {
+		return nil, listing.ErrOwnListing
+	}
+
+	if buyerAccount.Balance(foundListing.IsTestnet) < foundListing.Price {
+		return nil, fmt.Errorf("%w: need %v more", ledger.ErrNotEnoughBalance, foundListing.Price-buyerAccount.Balance(foundListing.IsTestnet))
+	}
+
+	royaltyAccrual, royaltyErr := v.royaltyService.Calculate(svcCtx, royalty.Sale{
+		NftItemAddress:    foundListing.NftItemAddress,
+		CollectionAddress: foundListing.CollectionAddress,
+		Seller:            foundListing.Seller,
+		Price:             foundListing.Price,
+		ReferenceID:       foundListing.ID.String(),
+		IsTestnet:         foundListing.IsTestnet,
+	})
+	if royaltyErr != nil {
+		return nil, fmt.Errorf("error calculating royalty: %w", royaltyErr)
+	}
+
+	buyer := buyerAccount.UUID
+	foundListing.Status = listing.StatusSold
+	foundListing.Buyer = &buyer
+	if royaltyAccrual != nil {
+		foundListing.RoyaltyAmount = royaltyAccrual.Amount
+	}
+	foundListing.PlatformFee = foundListing.Price * v.platformFeePercent / 100
+	if foundListing.RoyaltyAmount+foundListing.PlatformFee > foundListing.Price {
+		foundListing.PlatformFee = foundListing.Price - foundListing.RoyaltyAmount
+	}
+
+	referenceID := foundListing.ID.String()
+	entries := []*ledger.BalanceEntry{
+		ledger.NewBalanceEntry(buyer, ledger.EntryTypePurchase, foundListing.Price, referenceID, foundListing.IsTestnet),
+	}
+	if sellerAmount := foundListing.SellerAmount(); sellerAmount > 0 {
+		entries = append(entries, ledger.NewBalanceEntry(foundListing.Seller, ledger.EntryTypeSale, sellerAmount, referenceID, foundListing.IsTestnet))
+	}
+
+	transfer := nftitem.NewTransfer(foundListing.NftItemAddress, foundListing.Seller, buyer, foundListing.IsTestnet)
+	transfer.Price = foundListing.Price
+
+	txErr := v.listingRepo.WithTransaction(svcCtx, func(txCtx context.Context) error {
+		if closeErr := v.listingRepo.CloseListing(txCtx, foundListing); closeErr != nil {
+			return closeErr
+		}
+
+		if _, transferErr := v.nftItemRepo.TransferNftItem(txCtx, transfer); transferErr != nil {
+			return transferErr
+		}
+
+		if chargeErr := v.ledgerRepo.Apply(txCtx, entries...); chargeErr != nil {
+			return fmt.Errorf("error paying for listing: %w", chargeErr)
+		}
+
+		if royaltyAccrual != nil {
+			if accrueErr := v.royaltyService.Accrue(txCtx, royaltyAccrual); accrueErr != nil {
+				return fmt.Errorf("error accruing royalty: %w", accrueErr)
+			}
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		// nft item left seller without sale, listing is stale
+		if errors.Is(txErr, nftitem.ErrNotItemOwner) {
+			foundListing.Status = listing.StatusCancelled
+			foundListing.Buyer = nil
+			foundListing.RoyaltyAmount = 0
+			foundListing.PlatformFee = 0
+			if closeErr := v.listingRepo.CloseListing(svcCtx, foundListing); closeErr != nil && !errors.Is(closeErr, listing.ErrNotActive) {
+				return nil, fmt.Errorf("error cancelling stale listing: %w", closeErr)
+			}
+			return nil, listing.ErrNotActive
+		}
+		return nil, txErr
+	}
+
+	return foundListing, nil
+}
+
+func (v *listingServiceRepo) GetListing(ctx context.Context, id uuid.UUID) (*listing.Listing, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.listingRepo.GetListingByID(svcCtx, id)
+}
+
+func (v *listingServiceRepo) SearchListings(ctx context.Context, query listing.Query) ([]listing.Listing, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if query.Limit <= 0 {
+		query.Limit = listing.DefaultLimit
+	}
+	if query.Limit > listing.MaxLimit {
+		query.Limit = listing.MaxLimit
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	return v.listingRepo.GetListings(svcCtx, query)
+}
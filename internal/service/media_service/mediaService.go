@@ -0,0 +1,209 @@
+package mediaservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+type MediaServiceRepository interface {
+	// UploadMedia validates image of kind, stores it with thumbnails and returns it.
+	// File uploaded before isnt stored again, its media is returned
+	UploadMedia(ctx context.Context, kind media.Kind, data []byte, uploaderID int64) (*media.Media, error)
+	GetMedia(ctx context.Context, hash string) (*media.Media, error)
+	// GetBlob returns file of blob store for route serving it
+	GetBlob(ctx context.Context, key string) ([]byte, error)
+	// GetThumbnails returns thumbnails of image url, nil if image isnt uploaded to the app
+	GetThumbnails(ctx context.Context, imageURL string) ([]media.Thumbnail, error)
+}
+
+type mediaServiceRepo struct {
+	mediaRepo media.MediaRepository
+	blobStore media.BlobStore
+	userRepo  user.UserRepository
+	timeout   time.Duration
+}
+
+type MediaServiceCfg struct {
+	MediaRepo media.MediaRepository
+	BlobStore media.BlobStore
+	UserRepo  user.UserRepository
+	Timeout   time.Duration
+}
+
+func New(cfg MediaServiceCfg) MediaServiceRepository {
+	return &mediaServiceRepo{
+		mediaRepo: cfg.MediaRepo,
+		blobStore: cfg.BlobStore,
+		userRepo:  cfg.UserRepo,
+		timeout:   cfg.Timeout,
+	}
+}
+
+func (v *mediaServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *mediaServiceRepo) UploadMedia(ctx context.Context, kind media.Kind, data []byte, uploaderID int64) (*media.Media, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	limits, kindErr := kind.Limits()
+	if kindErr != nil {
+		return nil, kindErr
+	}
+
+	if len(data) > media.MaxFileSize {
+		return nil, fmt.Errorf("%w: %v bytes, at most %v", media.ErrTooLarge, len(data), media.MaxFileSize)
+	}
+
+	// type is sniffed from file, client content type isnt trusted
+	mimeType := http.DetectContentType(data)
+	if _, typeErr := media.Extension(mimeType); typeErr != nil {
+		return nil, typeErr
+	}
+
+	// dimensions are checked on header before decoding, so huge images arent decoded
+	config, _, configErr := image.DecodeConfig(bytes.NewReader(data))
+	if configErr != nil {
+		return nil, fmt.Errorf("%w: file is not valid image: %v", media.ErrUnsupportedType, configErr)
+	}
+	if limitsErr := limits.Check(config.Width, config.Height); limitsErr != nil {
+		return nil, limitsErr
+	}
+
+	uploaderAccount, accErr := v.userRepo.GetUserByID(svcCtx, uploaderID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if storedMedia, mediaErr := v.mediaRepo.GetMediaByHash(svcCtx, hash); mediaErr == nil {
+		return storedMedia, nil
+	} else if !errors.Is(mediaErr, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("error getting media: %w", mediaErr)
+	}
+
+	img, _, decodeErr := image.Decode(bytes.NewReader(data))
+	if decodeErr != nil {
+		return nil, fmt.Errorf("%w: file is not valid image: %v", media.ErrUnsupportedType, decodeErr)
+	}
+
+	newMedia := media.New(hash, mimeType, len(data), config.Width, config.Height, uploaderAccount.UUID)
+
+	for _, size := range media.ThumbnailSizes {
+		if size >= max(config.Width, config.Height) {
+			continue
+		}
+
+		thumbnail, encoded, thumbErr := makeThumbnail(img, size, mimeType)
+		if thumbErr != nil {
+			return nil, thumbErr
+		}
+
+		key := media.ThumbnailKey(hash, size, mimeType)
+		if putErr := v.blobStore.Put(svcCtx, key, encoded, media.ThumbnailMimeType(mimeType)); putErr != nil {
+			return nil, fmt.Errorf("error storing thumbnail: %w", putErr)
+		}
+		thumbnail.URL = v.blobStore.URL(key)
+		newMedia.Thumbnails = append(newMedia.Thumbnails, thumbnail)
+	}
+
+	// original is stored last, media is saved only when all its files are stored
+	key := media.OriginalKey(hash, mimeType)
+	if putErr := v.blobStore.Put(svcCtx, key, data, mimeType); putErr != nil {
+		return nil, fmt.Errorf("error storing media: %w", putErr)
+	}
+	newMedia.URL = v.blobStore.URL(key)
+
+	if saveErr := v.mediaRepo.SaveMedia(svcCtx, newMedia); saveErr != nil {
+		return nil, saveErr
+	}
+
+	return newMedia, nil
+}
+
+// makeThumbnail scales image to fit size x size box keeping aspect ratio
+func makeThumbnail(img image.Image, size int, mimeType string) (media.Thumbnail, []byte, error) {
+	bounds := img.Bounds()
+	width, height := size, size
+	if bounds.Dx() > bounds.Dy() {
+		height = max(1, bounds.Dy()*size/bounds.Dx())
+	} else {
+		width = max(1, bounds.Dx()*size/bounds.Dy())
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+
+	var encoded bytes.Buffer
+	var encodeErr error
+	if media.ThumbnailMimeType(mimeType) == "image/jpeg" {
+		encodeErr = jpeg.Encode(&encoded, scaled, &jpeg.Options{Quality: 85})
+	} else {
+		encodeErr = png.Encode(&encoded, scaled)
+	}
+	if encodeErr != nil {
+		return media.Thumbnail{}, nil, fmt.Errorf("error encoding thumbnail: %v", encodeErr)
+	}
+
+	return media.Thumbnail{Size: size, Width: width, Height: height}, encoded.Bytes(), nil
+}
+
+func (v *mediaServiceRepo) GetMedia(ctx context.Context, hash string) (*media.Media, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.mediaRepo.GetMediaByHash(svcCtx, hash)
+}
+
+func (v *mediaServiceRepo) GetBlob(ctx context.Context, key string) ([]byte, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.blobStore.Get(svcCtx, key)
+}
+
+func (v *mediaServiceRepo) GetThumbnails(ctx context.Context, imageURL string) ([]media.Thumbnail, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	// file name of uploaded media is its hash, url is compared after searching as blob store can be changed
+	hash := strings.TrimSuffix(path.Base(imageURL), path.Ext(imageURL))
+	if decoded, decodeErr := hex.DecodeString(hash); decodeErr != nil || len(decoded) != sha256.Size {
+		return nil, nil
+	}
+
+	foundMedia, mediaErr := v.mediaRepo.GetMediaByHash(svcCtx, hash)
+	if mediaErr != nil {
+		if errors.Is(mediaErr, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting media: %w", mediaErr)
+	}
+
+	if foundMedia.URL != imageURL {
+		return nil, nil
+	}
+
+	return foundMedia.Thumbnails, nil
+}
@@ -0,0 +1,374 @@
+package mintnftitem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nft "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
+	generalcontractutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/general_contract_utils"
+	nftcollectionutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_collection_utils"
+	nftitemutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_item_utils"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
+	"github.com/rom6n/create-nft-go/internal/utils/tonutil"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+	tonnft "github.com/xssnick/tonutils-go/ton/nft"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const (
+	batchMintChunkItems      = 100  // standard collection deploys less than 250 items per message, smaller chunks fit gas limit
+	batchMintChunkCells      = 4000 // message can't have more than 8192 cells
+	batchMintMessagesPerSend = 4    // wallet v4 sends up to 4 messages at once
+	metadataFetchConcurrency = 10
+)
+
+type batchMintChunk struct {
+	firstItemIndex    uint64
+	initContents      []*cell.Cell
+	nftItems          []*nft.NftItem
+	cells             int
+	itemAmount        uint64 // sent to every deployed nft item
+	itemMessageAmount uint64 // item amount and collection fees for every nft item
+	fee               uint64 // share of batch fee, refunded with message amount if chunk fails
+}
+
+func (c *batchMintChunk) messageAmount() uint64 {
+	return uint64(len(c.initContents)) * c.itemMessageAmount
+}
+
+func (c *batchMintChunk) refundAmount() uint64 {
+	return c.messageAmount() + c.fee
+}
+
+func countCells(c *cell.Cell) int {
+	count := 1
+	for i := 0; i < int(c.RefsNum()); i++ {
+		count += countCells(c.MustPeekRef(i))
+	}
+	return count
+}
+
+func (v *mintNftItemServiceRepo) BatchMintNftItems(ctx context.Context, nftCollectionAddress *address.Address, cfg nft.BatchMintNftItemsCfg, ownerID int64, isTestnet bool) ([]*nft.NftItem, error) {
+	if len(cfg.Items) == 0 || len(cfg.Items) > nft.MaxBatchMintItems {
+		return nil, fmt.Errorf("%w: batch must have from 1 to %v nft items", nft.ErrInvalidBatchSize, nft.MaxBatchMintItems)
+	}
+
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	client := v.testnetLiteClient
+	api := v.testnetLiteApi
+	walletAddress := v.testnetWallet.WalletAddress()
+	w := v.testnetWallet
+
+	if !isTestnet {
+		client = v.mainnetLiteClient
+		api = v.mainnetLiteApi
+		walletAddress = v.mainnetWallet.WalletAddress()
+		w = v.mainnetWallet
+	}
+
+	nftCollectionAddress.SetTestnetOnly(isTestnet)
+
+	// checking for nft collection in DB
+	if _, getErr := v.nftCollectionRepo.GetNftCollectionByAddress(svcCtx, nftCollectionAddress.String()); getErr != nil {
+		if errors.Is(getErr, mongo.ErrNoDocuments) {
+			return nil, nft.ErrCollectionNotFound
+		}
+		return nil, fmt.Errorf("find error in database: %v", getErr)
+	}
+
+	apiCtx := client.StickyContext(svcCtx)
+
+	ownerAccount, userErr := v.userRepo.GetUserByID(svcCtx, ownerID)
+	if userErr != nil {
+		return nil, userErr
+	}
+
+	if cfg.OwnerAddress == nil {
+		cfg.OwnerAddress = walletAddress
+	}
+
+	block, bErr := api.GetMasterchainInfo(apiCtx)
+	if bErr != nil {
+		return nil, fmt.Errorf("error getting masterchain info: %v", bErr)
+	}
+
+	collectionClient := tonnft.NewCollectionClient(api, nftCollectionAddress)
+	collectionData, dataErr := collectionClient.GetCollectionDataAtBlock(apiCtx, block)
+	if dataErr != nil {
+		return nil, fmt.Errorf("fail getting nft collection data method: %v", dataErr)
+	}
+
+	// checking for market contract is nft collection owner
+	if !collectionData.OwnerAddress.Equals(walletAddress) {
+		return nil, fmt.Errorf("%w to mint nft item", nftcollection.ErrNotCustodial)
+	}
+
+	nftCollectionMetadata, metaErr := nftcollectionutils.GetNftCollectionMetadata(collectionData.Content)
+	if metaErr != nil {
+		return nil, metaErr
+	}
+
+	// hosted metadata is minted as offchain content with link to the app
+	cfg.Items = slices.Clone(cfg.Items)
+	for i := range cfg.Items {
+		if cfg.Items[i].HostedMetadata == nil {
+			continue
+		}
+		link, hostErr := v.hostedMetadataService.HostNftItemMetadata(svcCtx, cfg.Items[i].HostedMetadata)
+		if hostErr != nil {
+			return nil, fmt.Errorf("error hosting nft item %v metadata: %w", i, hostErr)
+		}
+		cfg.Items[i].Content = link
+	}
+
+	itemsMetadata, metaErr := getBatchItemsMetadata(cfg.Items)
+	if metaErr != nil {
+		return nil, metaErr
+	}
+
+	quote, quoteErr := v.pricingService.Quote(svcCtx, pricing.QuoteRequest{Operation: pricing.OperationBatchMintNftItems, Items: len(cfg.Items), IsTestnet: isTestnet})
+	if quoteErr != nil {
+		return nil, fmt.Errorf("error quoting nft items batch mint: %w", quoteErr)
+	}
+
+	nextItemIndex := collectionData.NextItemIndex.Uint64()
+
+	var chunks []*batchMintChunk
+	for i, item := range cfg.Items {
+		itemIndex := nextItemIndex + uint64(i)
+
+		initContent, packErr := nftcollectionutils.PackNftItemInitContent(nft.MintNftItemCfg{
+			OwnerAddress: cfg.OwnerAddress,
+			Content:      item.Content,
+		})
+		if packErr != nil {
+			return nil, fmt.Errorf("error packing nft item %v: %w", i, packErr)
+		}
+
+		stateInit := generalcontractutils.PackStateInit(v.nftItemCode,
+			cell.BeginCell().
+				MustStoreUInt(itemIndex, 64).
+				MustStoreAddr(nftCollectionAddress).
+				EndCell(),
+		)
+
+		nftItemAddress := generalcontractutils.CalculateAddress(0, stateInit)
+		nftItemAddress.SetTestnetOnly(isTestnet)
+
+		nftItem := nft.New(
+			nftItemAddress.String(),
+			int64(itemIndex),
+			nftCollectionAddress.String(),
+			nftCollectionMetadata.Name,
+			ownerAccount.UUID,
+			itemsMetadata[i],
+			isTestnet,
+		)
+		nftItem.Thumbnails = v.getThumbnails(svcCtx, itemsMetadata[i].Image)
+
+		// every item takes 2 cells in deploy list dictionary
+		itemCells := countCells(initContent) + 2
+
+		if len(chunks) == 0 || len(chunks[len(chunks)-1].initContents) == batchMintChunkItems || chunks[len(chunks)-1].cells+itemCells > batchMintChunkCells {
+			chunks = append(chunks, &batchMintChunk{firstItemIndex: itemIndex, itemAmount: quote.NftItemAmount, itemMessageAmount: quote.ItemMessageAmount()})
+		}
+
+		chunk := chunks[len(chunks)-1]
+		chunk.initContents = append(chunk.initContents, initContent)
+		chunk.nftItems = append(chunk.nftItems, nftItem)
+		chunk.cells += itemCells
+	}
+
+	// fee is split between chunks by their items, the last chunk takes the remainder
+	var assignedFee uint64
+	for i, chunk := range chunks {
+		chunk.fee = quote.Fee() * uint64(len(chunk.initContents)) / uint64(len(cfg.Items))
+		if i == len(chunks)-1 {
+			chunk.fee = quote.Fee() - assignedFee
+		}
+		assignedFee += chunk.fee
+	}
+
+	sendsCount := (len(chunks) + batchMintMessagesPerSend - 1) / batchMintMessagesPerSend
+
+	var nanoTonForMint uint64
+	for _, chunk := range chunks {
+		nanoTonForMint += chunk.messageAmount()
+	}
+
+	// checking for user have enough ton
+	if ownerAccount.Balance(isTestnet) < nanoTonForMint+quote.Fee() {
+		return nil, fmt.Errorf("%w: need %v more", ledger.ErrNotEnoughBalance, nanoTonForMint+quote.Fee()-ownerAccount.Balance(isTestnet))
+	}
+
+	batchID := uuid.New().String()
+
+	// user is charged once for the whole batch
+	if chargeErr := v.ledgerRepo.Apply(svcCtx,
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeDebit, nanoTonForMint, batchID, isTestnet),
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeFee, quote.Fee(), batchID, isTestnet),
+	); chargeErr != nil {
+		return nil, fmt.Errorf("error reducing user's balance for nft items batch mint: %w", chargeErr)
+	}
+
+	var mintedNftItems []*nft.NftItem
+	for sendIndex := 0; sendIndex < sendsCount; sendIndex++ {
+		sendChunks := chunks[sendIndex*batchMintMessagesPerSend : min((sendIndex+1)*batchMintMessagesPerSend, len(chunks))]
+
+		tracked, sendErr := v.sendBatchMintChunks(ctx, api, w, client.StickyContext, nftCollectionAddress, sendChunks, ownerAccount.UUID, batchID, cfg.OwnerAddress.Equals(walletAddress), isTestnet)
+		if sendErr != nil {
+			// tracked chunks are settled by operation tracker, the rest is never sent and refunded here
+			var notSentAmount uint64
+			for _, chunk := range chunks[sendIndex*batchMintMessagesPerSend+tracked:] {
+				notSentAmount += chunk.refundAmount()
+			}
+
+			if notSentAmount != 0 {
+				v.refundBatchMint(svcCtx, ownerAccount.UUID, notSentAmount, batchID, isTestnet)
+			}
+
+			if len(mintedNftItems) == 0 {
+				return nil, sendErr
+			}
+			return mintedNftItems, fmt.Errorf("only %v of %v nft items are minted: %w", len(mintedNftItems), len(cfg.Items), sendErr)
+		}
+
+		for _, chunk := range sendChunks {
+			mintedNftItems = append(mintedNftItems, chunk.nftItems...)
+		}
+	}
+
+	return mintedNftItems, nil
+}
+
+// getBatchItemsMetadata returns hosted metadata of items or downloads offchain one
+func getBatchItemsMetadata(items []nft.BatchMintItem) ([]*nft.NftItemMetadata, error) {
+	metadata := make([]*nft.NftItemMetadata, len(items))
+	errs := make([]error, len(items))
+
+	semaphore := make(chan struct{}, metadataFetchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		if item.HostedMetadata != nil {
+			metadata[i] = item.HostedMetadata
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, link string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			metadata[i], errs[i] = nftitemutils.GetValidatedNftItemOffchainMetadata(link)
+		}(i, item.Content)
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("error parse nft item %v metadata: %w", i, tep64.WithFieldPrefix(err, fmt.Sprintf("items[%v].content.", i)))
+		}
+	}
+
+	return metadata, nil
+}
+
+// sendBatchMintChunks tracks every chunk and sends them by one wallet external message.
+// Returns count of tracked chunks, operation tracker refunds them if they fail
+func (v *mintNftItemServiceRepo) sendBatchMintChunks(ctx context.Context, api ton.APIClientWrapped, w *wallet.Wallet, stickyContext func(context.Context) context.Context, nftCollectionAddress *address.Address, chunks []*batchMintChunk, ownerUuid uuid.UUID, batchID string, isCustodial bool, isTestnet bool) (int, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	messages := make([]*wallet.Message, 0, len(chunks))
+	operations := make([]*operation.Operation, 0, len(chunks))
+
+	for _, chunk := range chunks {
+		batchMsg, packErr := nftcollectionutils.PackBatchDeployNftItemsMessage(nftCollectionAddress, chunk.firstItemIndex, chunk.initContents, chunk.itemAmount, chunk.messageAmount())
+		if packErr != nil {
+			return 0, packErr
+		}
+
+		messages = append(messages, &wallet.Message{
+			Mode:            1,
+			InternalMessage: batchMsg,
+		})
+
+		itemAddresses := make([]string, 0, len(chunk.nftItems))
+		for _, nftItem := range chunk.nftItems {
+			itemAddresses = append(itemAddresses, nftItem.Address)
+		}
+
+		batchOperation := operation.New(operation.KindBatchMintNftItems, ownerUuid, nftCollectionAddress.String(), batchMsg.Body.Hash(), chunk.refundAmount(), batchID, isTestnet)
+		// last item is deployed only if whole chunk is deployed
+		batchOperation.DeployedAddress = itemAddresses[len(itemAddresses)-1]
+		batchOperation.ItemAddresses = itemAddresses
+		operations = append(operations, batchOperation)
+	}
+
+	for i, batchOperation := range operations {
+		if trackErr := v.operationTracker.Track(svcCtx, batchOperation); trackErr != nil {
+			// untracked chunk is refunded by Track, tracked ones are never sent
+			v.failBatchMintOperations(svcCtx, operations[:i], trackErr.Error())
+			return i + 1, fmt.Errorf("error tracking nft items batch mint: %w", trackErr)
+		}
+	}
+
+	if msgErr := tonutil.SendWaitTransaction(stickyContext(svcCtx), api, w, messages...); msgErr != nil {
+		if errors.Is(msgErr, tonutil.ErrNotSent) {
+			v.failBatchMintOperations(svcCtx, operations, msgErr.Error())
+			return len(operations), fmt.Errorf("error sending batch mint nft items by external message: %v", msgErr)
+		}
+		// FYI: messages can be delivered anyway, tracker settles every chunk on chain
+		log.Printf("Nft items batch mint is not confirmed, it is settled by tracker: %v\n", msgErr)
+	}
+
+	if !isCustodial {
+		return len(operations), nil
+	}
+
+	for _, chunk := range chunks {
+		for _, nftItem := range chunk.nftItems {
+			if createErr := v.nftItemRepo.CreateNftItem(svcCtx, nftItem); createErr != nil {
+				log.Printf("Error adding batch minted nft item %v to database: %v\n", nftItem.Address, createErr)
+			}
+		}
+	}
+
+	return len(operations), nil
+}
+
+func (v *mintNftItemServiceRepo) failBatchMintOperations(ctx context.Context, operations []*operation.Operation, reason string) {
+	for _, batchOperation := range operations {
+		if failErr := v.operationTracker.Fail(ctx, batchOperation, reason); failErr != nil {
+			log.Printf("Error failing nft items batch mint operation: %v\n", failErr)
+		}
+	}
+}
+
+func (v *mintNftItemServiceRepo) refundBatchMint(ctx context.Context, ownerUuid uuid.UUID, amount uint64, batchID string, isTestnet bool) {
+	for i := 0; i < 10; i++ {
+		if refundErr := v.ledgerRepo.Apply(ctx, ledger.NewBalanceEntry(ownerUuid, ledger.EntryTypeRefund, amount, batchID, isTestnet)); refundErr == nil {
+			return
+		}
+		log.Printf("Error returning %v ton to user, try: %v\n", amount, i)
+		time.Sleep(1 * time.Second)
+	}
+}
@@ -3,16 +3,27 @@ package mintnftitem
 import (
 	"context"
 	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/media"
 	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
 	nft "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
 	"github.com/rom6n/create-nft-go/internal/domain/user"
+	hostedmetadataservice "github.com/rom6n/create-nft-go/internal/service/hosted_metadata_service"
+	mediaservice "github.com/rom6n/create-nft-go/internal/service/media_service"
+	operationtracker "github.com/rom6n/create-nft-go/internal/service/operation_tracker"
+	pricingservice "github.com/rom6n/create-nft-go/internal/service/pricing_service"
 	generalcontractutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/general_contract_utils"
 	nftcollectionutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_collection_utils"
 	nftitemutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_item_utils"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
+	"github.com/rom6n/create-nft-go/internal/utils/tonutil"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/ton"
@@ -24,52 +35,68 @@ import (
 
 type MintNftItemServiceRepository interface {
 	MintNftItem(ctx context.Context, nftCollectionAddress *address.Address, cfg nft.MintNftItemCfg, ownerID int64, isTestnet bool) (*nft.NftItem, error)
+	BatchMintNftItems(ctx context.Context, nftCollectionAddress *address.Address, cfg nft.BatchMintNftItemsCfg, ownerID int64, isTestnet bool) ([]*nft.NftItem, error)
 }
 
 type mintNftItemServiceRepo struct {
-	nftCollectionRepo nftcollection.NftCollectionRepository
-	nftItemRepo       nft.NftItemRepository
-	userRepo          user.UserRepository
-	nftItemCode       *cell.Cell
-	testnetLiteClient *liteclient.ConnectionPool
-	mainnetLiteClient *liteclient.ConnectionPool
-	testnetLiteApi    ton.APIClientWrapped
-	mainnetLiteApi    ton.APIClientWrapped
-	testnetWallet     *wallet.Wallet
-	mainnetWallet     *wallet.Wallet
-	privateKey        ed25519.PrivateKey
-	timeout           time.Duration
+	nftCollectionRepo     nftcollection.NftCollectionRepository
+	nftItemRepo           nft.NftItemRepository
+	userRepo              user.UserRepository
+	ledgerRepo            ledger.LedgerRepository
+	operationTracker      operationtracker.OperationTrackerRepository
+	pricingService        pricingservice.PricingServiceRepository
+	hostedMetadataService hostedmetadataservice.HostedMetadataServiceRepository
+	mediaService          mediaservice.MediaServiceRepository
+	nftItemCode           *cell.Cell
+	testnetLiteClient     *liteclient.ConnectionPool
+	mainnetLiteClient     *liteclient.ConnectionPool
+	testnetLiteApi        ton.APIClientWrapped
+	mainnetLiteApi        ton.APIClientWrapped
+	testnetWallet         *wallet.Wallet
+	mainnetWallet         *wallet.Wallet
+	privateKey            ed25519.PrivateKey
+	timeout               time.Duration
 }
 
 type MintNftItemServiceCfg struct {
-	NftCollectionRepo nftcollection.NftCollectionRepository
-	NftItemRepo       nft.NftItemRepository
-	UserRepo          user.UserRepository
-	NftItemCode       *cell.Cell
-	TestnetLiteClient *liteclient.ConnectionPool
-	MainnetLiteClient *liteclient.ConnectionPool
-	TestnetLiteApi    ton.APIClientWrapped
-	MainnetLiteApi    ton.APIClientWrapped
-	TestnetWallet     *wallet.Wallet
-	MainnetWallet     *wallet.Wallet
-	PrivateKey        ed25519.PrivateKey
-	Timeout           time.Duration
+	NftCollectionRepo     nftcollection.NftCollectionRepository
+	NftItemRepo           nft.NftItemRepository
+	UserRepo              user.UserRepository
+	LedgerRepo            ledger.LedgerRepository
+	OperationTracker      operationtracker.OperationTrackerRepository
+	PricingService        pricingservice.PricingServiceRepository
+	HostedMetadataService hostedmetadataservice.HostedMetadataServiceRepository
+	MediaService          mediaservice.MediaServiceRepository
+	NftItemCode           *cell.Cell
+	TestnetLiteClient     *liteclient.ConnectionPool
+	MainnetLiteClient     *liteclient.ConnectionPool
+	TestnetLiteApi        ton.APIClientWrapped
+	MainnetLiteApi        ton.APIClientWrapped
+	TestnetWallet         *wallet.Wallet
+	MainnetWallet         *wallet.Wallet
+	PrivateKey            ed25519.PrivateKey
+	Timeout               time.Duration
 }
 
 func New(cfg MintNftItemServiceCfg) MintNftItemServiceRepository {
 	return &mintNftItemServiceRepo{
-		nftCollectionRepo: cfg.NftCollectionRepo,
-		nftItemRepo:       cfg.NftItemRepo,
-		userRepo:          cfg.UserRepo,
-		nftItemCode:       cfg.NftItemCode,
-		testnetLiteClient: cfg.TestnetLiteClient,
-		mainnetLiteClient: cfg.MainnetLiteClient,
-		testnetLiteApi:    cfg.TestnetLiteApi,
-		mainnetLiteApi:    cfg.MainnetLiteApi,
-		testnetWallet:     cfg.TestnetWallet,
-		mainnetWallet:     cfg.MainnetWallet,
-		privateKey:        cfg.PrivateKey,
-		timeout:           cfg.Timeout,
+		nftCollectionRepo:     cfg.NftCollectionRepo,
+		nftItemRepo:           cfg.NftItemRepo,
+		userRepo:              cfg.UserRepo,
+		ledgerRepo:            cfg.LedgerRepo,
+		operationTracker:      cfg.OperationTracker,
+		pricingService:        cfg.PricingService,
+		hostedMetadataService: cfg.HostedMetadataService,
+		mediaService:          cfg.MediaService,
+		nftItemCode:           cfg.NftItemCode,
+		testnetLiteClient:     cfg.TestnetLiteClient,
+		mainnetLiteClient:     cfg.MainnetLiteClient,
+		testnetLiteApi:        cfg.TestnetLiteApi,
+		mainnetLiteApi:        cfg.MainnetLiteApi,
+		testnetWallet:         cfg.TestnetWallet,
+		mainnetWallet:         cfg.MainnetWallet,
+		privateKey:            cfg.PrivateKey,
+		timeout:               cfg.Timeout,
 	}
 }
 
@@ -77,16 +104,19 @@ func (v *mintNftItemServiceRepo) getContext(ctx context.Context) (context.Contex
 	return context.WithTimeout(ctx, v.timeout)
 }
 
+// getThumbnails returns thumbnails of nft item image for galleries, item is minted without them on error
+func (v *mintNftItemServiceRepo) getThumbnails(ctx context.Context, image string) []media.Thumbnail {
+	thumbnails, thumbErr := v.mediaService.GetThumbnails(ctx, image)
+	if thumbErr != nil {
+		log.Printf("Error getting thumbnails of %v: %v\n", image, thumbErr)
+	}
+	return thumbnails
+}
+
 func (v *mintNftItemServiceRepo) MintNftItem(ctx context.Context, nftCollectionAddress *address.Address, cfg nft.MintNftItemCfg, ownerID int64, isTestnet bool) (*nft.NftItem, error) {
 	svcCtx, cancel := v.getContext(ctx)
 	defer cancel()
 
-	nanoTonForMint := uint64(70000000)
-	nanoTonForFees := uint64(5000000)
-	if cfg.ForwardAmount >= 1 {
-		nanoTonForMint += cfg.ForwardAmount
-	}
-
 	client := v.testnetLiteClient
 	api := v.testnetLiteApi
 	walletAddress := v.testnetWallet.WalletAddress()
@@ -96,8 +126,8 @@ func (v *mintNftItemServiceRepo) MintNftItem(ctx context.Context, nftCollectionA
 
 	// checking for nft collection in DB
 	if _, getErr := v.nftCollectionRepo.GetNftCollectionByAddress(svcCtx, nftCollectionAddress.String()); getErr != nil {
-		if getErr == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("nft collection isnt in database")
+		if errors.Is(getErr, mongo.ErrNoDocuments) {
+			return nil, nft.ErrCollectionNotFound
 		}
 		return nil, fmt.Errorf("find error in database: %v", getErr)
 	}
@@ -112,14 +142,19 @@ func (v *mintNftItemServiceRepo) MintNftItem(ctx context.Context, nftCollectionA
 	apiCtx := client.StickyContext(svcCtx)
 	nftCollectionAddress.SetTestnetOnly(isTestnet)
 
+	quote, quoteErr := v.pricingService.Quote(svcCtx, pricing.QuoteRequest{Operation: pricing.OperationMintNftItem, ForwardAmount: cfg.ForwardAmount, IsTestnet: isTestnet})
+	if quoteErr != nil {
+		return nil, fmt.Errorf("error quoting nft item mint: %w", quoteErr)
+	}
+
 	ownerAccount, userErr := v.userRepo.GetUserByID(svcCtx, ownerID)
 	if userErr != nil {
 		return nil, userErr
 	}
 
 	// checking for user have enough ton
-	if ownerAccount.NanoTon < (nanoTonForMint + nanoTonForFees) {
-		return nil, fmt.Errorf("error not enough ton on user's balance. need %v more", nanoTonForMint-ownerAccount.NanoTon)
+	if ownerAccount.Balance(isTestnet) < quote.Total {
+		return nil, fmt.Errorf("%w: need %v more", ledger.ErrNotEnoughBalance, quote.Total-ownerAccount.Balance(isTestnet))
 	}
 
 	if cfg.OwnerAddress == nil {
@@ -138,40 +173,41 @@ func (v *mintNftItemServiceRepo) MintNftItem(ctx context.Context, nftCollectionA
 	}
 
 	nextItemIndex := collectionData.NextItemIndex
-	collectionContent, cellErr := collectionData.Content.ContentCell()
-	if cellErr != nil {
-		return nil, fmt.Errorf("nft collection data method not returned content cell: %v", cellErr)
-	}
-
-	// ✨ ToDo: В будущем можно будет добавить поддержку onchain метадаты ✨
-	collectionContentSlice := collectionContent.BeginParse()
-	if skipOffchainTag, tagErr := collectionContentSlice.LoadUInt(8); skipOffchainTag != 1 || tagErr != nil {
-		return nil, fmt.Errorf("want offchain metadata tag, have other: %v", tagErr)
-	}
-	nftCollectionMetadataLink, offChainMetadataErr := collectionContentSlice.LoadStringSnake()
-
-	if offChainMetadataErr != nil {
-		return nil, fmt.Errorf("want string, have other: %v", offChainMetadataErr)
-	}
 
 	nftCollectionOwnerAddress := collectionData.OwnerAddress
 
 	// checking for market contract is nft collection owner
 	if !nftCollectionOwnerAddress.Equals(walletAddress) {
-		return nil, fmt.Errorf("marketplace contract must be an nft collection's owner to mint nft item")
+		return nil, fmt.Errorf("%w to mint nft item", nftcollection.ErrNotCustodial)
 	}
 
-	nftCollectionMetadata, metaErr := nftcollectionutils.GetNftCollectionOffchainMetadata(nftCollectionMetadataLink)
+	nftCollectionMetadata, metaErr := nftcollectionutils.GetNftCollectionMetadata(collectionData.Content)
 	if metaErr != nil {
 		return nil, metaErr
 	}
 
-	nftItemMetadata, metaErr := nftitemutils.GetNftItemOffchainMetadata(cfg.Content)
-	if metaErr != nil {
-		return nil, fmt.Errorf("error parse nft item metadata: %v", metaErr)
+	// hosted metadata is minted as offchain content with link to the app
+	if cfg.HostedMetadata != nil {
+		link, hostErr := v.hostedMetadataService.HostNftItemMetadata(svcCtx, cfg.HostedMetadata)
+		if hostErr != nil {
+			return nil, fmt.Errorf("error hosting nft item metadata: %w", hostErr)
+		}
+		cfg.Content = link
 	}
 
-	deployNftItemMsg := nftcollectionutils.PackDeployNftItemMessage(nftCollectionAddress, nextItemIndex.Uint64(), cfg)
+	nftItemMetadata := cfg.HostedMetadata
+	if nftItemMetadata == nil {
+		offchainMetadata, metaErr := nftitemutils.GetValidatedNftItemOffchainMetadata(cfg.Content)
+		if metaErr != nil {
+			return nil, fmt.Errorf("error parse nft item metadata: %w", tep64.WithFieldPrefix(metaErr, "content."))
+		}
+		nftItemMetadata = offchainMetadata
+	}
+
+	deployNftItemMsg, packErr := nftcollectionutils.PackDeployNftItemMessage(nftCollectionAddress, nextItemIndex.Uint64(), cfg, quote.MessageAmount, quote.NftItemAmount)
+	if packErr != nil {
+		return nil, packErr
+	}
 
 	stateInit := generalcontractutils.PackStateInit(v.nftItemCode,
 		cell.BeginCell().
@@ -192,11 +228,14 @@ func (v *mintNftItemServiceRepo) MintNftItem(ctx context.Context, nftCollectionA
 		nftItemMetadata,
 		isTestnet,
 	)
+	nftItem.Thumbnails = v.getThumbnails(svcCtx, nftItemMetadata.Image)
 
-	// ДОБАВИТЬ ТРАНЗАКЦИЮ
 	// reducing the user's balance
-	if updErr := v.userRepo.UpdateUserBalance(svcCtx, ownerAccount.UUID, ownerAccount.NanoTon-nanoTonForMint-nanoTonForFees); updErr != nil {
-		return nil, fmt.Errorf("error reducing user's balance for nft item mint: %v", updErr)
+	if chargeErr := v.ledgerRepo.Apply(svcCtx,
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeDebit, quote.MessageAmount, nftItemAddress.String(), isTestnet),
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeFee, quote.Fee(), nftItemAddress.String(), isTestnet),
+	); chargeErr != nil {
+		return nil, fmt.Errorf("error reducing user's balance for nft item mint: %w", chargeErr)
 	}
 
 	msg := &wallet.Message{
@@ -204,21 +243,22 @@ func (v *mintNftItemServiceRepo) MintNftItem(ctx context.Context, nftCollectionA
 		InternalMessage: deployNftItemMsg,
 	}
 
-	msgErr := w.Send(apiCtx, msg, true)
-	if msgErr != nil {
-		// FIY: not enough balance on contract
-		for i := 0; i < 10; i++ {
-			if updErr := v.userRepo.UpdateUserBalance(svcCtx, ownerAccount.UUID, ownerAccount.NanoTon-nanoTonForFees); updErr == nil {
-				break
-			}
-			log.Printf("Error returning %v ton to user, try: %v\n", nanoTonForMint, i)
-			if i == 9 {
-				return nil, fmt.Errorf("error returning ton to user & error sending mint nft item external message: %v", msgErr)
+	// operation is tracked before sending, tracker refunds quote total if mint fails
+	mintOperation := operation.New(operation.KindMintNftItem, ownerAccount.UUID, nftCollectionAddress.String(), deployNftItemMsg.Body.Hash(), quote.Total, nftItemAddress.String(), isTestnet)
+	mintOperation.DeployedAddress = nftItemAddress.String()
+	if trackErr := v.operationTracker.Track(svcCtx, mintOperation); trackErr != nil {
+		return nil, fmt.Errorf("error tracking nft item mint: %w", trackErr)
+	}
+
+	if msgErr := tonutil.SendWaitTransaction(apiCtx, api, w, msg); msgErr != nil {
+		if errors.Is(msgErr, tonutil.ErrNotSent) {
+			if failErr := v.operationTracker.Fail(svcCtx, mintOperation, msgErr.Error()); failErr != nil {
+				log.Printf("Error failing nft item mint operation: %v\n", failErr)
 			}
-			time.Sleep(1 * time.Second)
+			return nil, fmt.Errorf("error sending mint nft item by external message: %v", msgErr)
 		}
-
-		return nil, fmt.Errorf("error sending mint nft item by external message: %v", msgErr)
+		// FYI: message can be delivered anyway, tracker settles it on chain
+		log.Printf("Nft item mint is not confirmed, it is settled by tracker: %v\n", msgErr)
 	}
 
 	if cfg.OwnerAddress.Equals(walletAddress) {
@@ -0,0 +1,444 @@
+package operationtracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	nftcollectionutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_collection_utils"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	tonnft "github.com/xssnick/tonutils-go/ton/nft"
+)
+
+type OperationTrackerRepository interface {
+	// Track saves operation before its message is sent to check its result on chain later.
+	// Operation which isnt saved is refunded, its message must not be sent then
+	Track(ctx context.Context, op *operation.Operation) error
+	// Fail marks tracked operation as failed if its message isnt sent and refunds it
+	Fail(ctx context.Context, op *operation.Operation, reason string) error
+	// Run checks pending operations until ctx is done
+	Run(ctx context.Context)
+}
+
+type operationTrackerRepo struct {
+	operationRepo     operation.OperationRepository
+	ledgerRepo        ledger.LedgerRepository
+	nftCollectionRepo nftcollection.NftCollectionRepository
+	nftItemRepo       nftitem.NftItemRepository
+	testnetLiteApi    ton.APIClientWrapped
+	mainnetLiteApi    ton.APIClientWrapped
+	pollInterval      time.Duration
+	confirmTimeout    time.Duration
+	timeout           time.Duration
+}
+
+type OperationTrackerCfg struct {
+	OperationRepo     operation.OperationRepository
+	LedgerRepo        ledger.LedgerRepository
+	NftCollectionRepo nftcollection.NftCollectionRepository
+	NftItemRepo       nftitem.NftItemRepository
+	TestnetLiteApi    ton.APIClientWrapped
+	MainnetLiteApi    ton.APIClientWrapped
+	PollInterval      time.Duration
+	ConfirmTimeout    time.Duration // operation without destination transaction after this time is failed
+	Timeout           time.Duration
+}
+
+func New(cfg OperationTrackerCfg) OperationTrackerRepository {
+	return &operationTrackerRepo{
+		operationRepo:     cfg.OperationRepo,
+		ledgerRepo:        cfg.LedgerRepo,
+		nftCollectionRepo: cfg.NftCollectionRepo,
+		nftItemRepo:       cfg.NftItemRepo,
+		testnetLiteApi:    cfg.TestnetLiteApi,
+		mainnetLiteApi:    cfg.MainnetLiteApi,
+		pollInterval:      cfg.PollInterval,
+		confirmTimeout:    cfg.ConfirmTimeout,
+		timeout:           cfg.Timeout,
+	}
+}
+
+func (v *operationTrackerRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *operationTrackerRepo) Track(ctx context.Context, op *operation.Operation) error {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	for i := 0; i < 10; i++ {
+		createErr := v.operationRepo.CreateOperation(svcCtx, op)
+		if createErr == nil {
+			return nil
+		}
+		log.Printf("Error adding %v operation to database, try: %v\n", op.Kind, i)
+		if i == 9 {
+			// message of untracked operation is never sent, so it is refunded right away
+			if op.RefundAmount != 0 {
+				refundCtx, refundCancel := v.getContext(context.Background())
+				defer refundCancel()
+				if refundErr := v.ledgerRepo.Apply(refundCtx, ledger.NewBalanceEntry(op.UserUUID, ledger.EntryTypeRefund, op.RefundAmount, op.ID.String(), op.IsTestnet)); refundErr != nil {
+					log.Printf("error returning %v nano ton to user uuid %v after untracked %v: %v\n", op.RefundAmount, op.UserUUID, op.Kind, refundErr)
+				}
+			}
+			return fmt.Errorf("error adding %v operation to database: %v", op.Kind, createErr)
+		}
+		time.Sleep(1 * time.Second)
+	}
+
+	return nil
+}
+
+func (v *operationTrackerRepo) Fail(ctx context.Context, op *operation.Operation, reason string) error {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if updErr := v.operationRepo.UpdateOperationStatus(svcCtx, op.ID, operation.StatusPending, operation.StatusFailed, reason); updErr != nil {
+		return fmt.Errorf("error marking %v operation %v as failed: %w", op.Kind, op.ID, updErr)
+	}
+	op.Status = operation.StatusFailed
+	op.Error = reason
+
+	v.revertFailedOperation(svcCtx, op)
+
+	// refund is retried by Run if it fails now
+	v.refundOperation(svcCtx, op)
+	return nil
+}
+
+func (v *operationTrackerRepo) Run(ctx context.Context) {
+	log.Printf("Operation tracker is running")
+
+	ticker := time.NewTicker(v.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Operation tracker is stopped")
+			return
+		case <-ticker.C:
+			v.checkPendingOperations(ctx)
+			v.refundFailedOperations(ctx)
+		}
+	}
+}
+
+func (v *operationTrackerRepo) checkPendingOperations(ctx context.Context) {
+	pending, getErr := v.operationRepo.GetOperationsByStatus(ctx, operation.StatusPending)
+	if getErr != nil {
+		log.Printf("error getting pending operations: %v\n", getErr)
+		return
+	}
+
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+
+		op := &pending[i]
+
+		svcCtx, cancel := v.getContext(ctx)
+		checkErr := v.checkOperation(svcCtx, op)
+		cancel()
+
+		if errors.Is(checkErr, ton.ErrTxWasNotFound) {
+			if time.Since(op.CreatedAt) < v.confirmTimeout {
+				continue
+			}
+			checkErr = &operationFailure{reason: fmt.Sprintf("no transaction on destination after %v", v.confirmTimeout)}
+		}
+
+		var failure *operationFailure
+		if checkErr != nil && !errors.As(checkErr, &failure) {
+			log.Printf("error checking %v operation %v: %v\n", op.Kind, op.ID, checkErr)
+			continue
+		}
+
+		v.completeOperation(ctx, op, checkErr)
+	}
+}
+
+type operationFailure struct {
+	reason string
+}
+
+func (e *operationFailure) Error() string {
+	return e.reason
+}
+
+// checkOperation returns nil if operation is successful on chain, *operationFailure if it failed,
+// ton.ErrTxWasNotFound if destination transaction is not found yet and other errors if check failed
+func (v *operationTrackerRepo) checkOperation(ctx context.Context, op *operation.Operation) error {
+	api := v.testnetLiteApi
+	if !op.IsTestnet {
+		api = v.mainnetLiteApi
+	}
+
+	destination, parseErr := address.ParseAddr(op.Destination)
+	if parseErr != nil {
+		return &operationFailure{reason: fmt.Sprintf("destination is not valid address: %v", parseErr)}
+	}
+
+	messageHash, decodeErr := hex.DecodeString(op.MessageHash)
+	if decodeErr != nil {
+		return &operationFailure{reason: fmt.Sprintf("message hash is not valid hex: %v", decodeErr)}
+	}
+
+	tx, findErr := findTransactionByInMsgHash(ctx, api, destination, messageHash)
+	if findErr != nil {
+		return findErr
+	}
+
+	if txErr := CheckTransactionSuccess(tx); txErr != nil {
+		return &operationFailure{reason: txErr.Error()}
+	}
+
+	if op.DeployedAddress != "" {
+		deployedAddress, parseErr := address.ParseAddr(op.DeployedAddress)
+		if parseErr != nil {
+			return &operationFailure{reason: fmt.Sprintf("deployed address is not valid address: %v", parseErr)}
+		}
+
+		block, blockErr := api.CurrentMasterchainInfo(ctx)
+		if blockErr != nil {
+			return fmt.Errorf("error getting masterchain info: %v", blockErr)
+		}
+
+		account, accErr := api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, deployedAddress)
+		if accErr != nil {
+			return fmt.Errorf("error getting deployed account: %v", accErr)
+		}
+
+		// internal message to deployed address can be still in flight
+		if !account.IsActive {
+			return ton.ErrTxWasNotFound
+		}
+	}
+
+	return nil
+}
+
+// inMsgScanLimit is how many last transactions of destination are checked for operation message
+const inMsgScanLimit = 300
+
+// findTransactionByInMsgHash returns last transaction of addr with incoming internal message of msgHash.
+// FindLastTransactionByInMsgHash of tonutils-go returns newest transaction even if its message doesnt match, so it isnt used.
+// Scan limit error isnt ton.ErrTxWasNotFound, operation isnt failed by it
+func findTransactionByInMsgHash(ctx context.Context, api ton.APIClientWrapped, addr *address.Address, msgHash []byte) (*tlb.Transaction, error) {
+	block, blockErr := api.CurrentMasterchainInfo(ctx)
+	if blockErr != nil {
+		return nil, fmt.Errorf("error getting masterchain info: %v", blockErr)
+	}
+
+	account, accErr := api.WaitForBlock(block.SeqNo).GetAccount(ctx, block, addr)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting destination account: %v", accErr)
+	}
+
+	lastLt, lastHash := account.LastTxLT, account.LastTxHash
+	for scanned := 0; lastLt != 0; {
+		if scanned >= inMsgScanLimit {
+			return nil, fmt.Errorf("message isnt found in last %v transactions of %v", scanned, addr)
+		}
+
+		txList, listErr := api.ListTransactions(ctx, addr, 15, lastLt, lastHash)
+		if errors.Is(listErr, ton.ErrNoTransactionsWereFound) || (listErr == nil && len(txList) == 0) {
+			break
+		}
+		if listErr != nil {
+			return nil, fmt.Errorf("error listing destination transactions: %v", listErr)
+		}
+
+		// transactions are listed from the oldest to the newest
+		for i := len(txList) - 1; i >= 0; i-- {
+			in := txList[i].IO.In
+			if in == nil || in.MsgType != tlb.MsgTypeInternal || in.Msg.Payload() == nil {
+				continue
+			}
+			if bytes.Equal(in.Msg.Payload().Hash(), msgHash) {
+				return txList[i], nil
+			}
+		}
+
+		lastLt, lastHash = txList[0].PrevTxLT, txList[0].PrevTxHash
+		scanned += len(txList)
+	}
+
+	return nil, ton.ErrTxWasNotFound
+}
+
+// CheckTransactionSuccess returns error if transaction compute or action phase failed or incoming message was bounced
+func CheckTransactionSuccess(tx *tlb.Transaction) error {
+	dsc, ok := tx.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok {
+		return fmt.Errorf("transaction is not ordinary")
+	}
+
+	if dsc.BouncePhase != nil {
+		return fmt.Errorf("message was bounced")
+	}
+
+	computePhase, ok := dsc.ComputePhase.Phase.(tlb.ComputePhaseVM)
+	if !ok {
+		return fmt.Errorf("compute phase was skipped")
+	}
+	if !computePhase.Success {
+		return fmt.Errorf("compute phase failed with exit code %v", computePhase.Details.ExitCode)
+	}
+
+	if dsc.ActionPhase != nil && !dsc.ActionPhase.Success {
+		return fmt.Errorf("action phase failed with result code %v", dsc.ActionPhase.ResultCode)
+	}
+
+	if dsc.Aborted {
+		return fmt.Errorf("transaction was aborted")
+	}
+
+	return nil
+}
+
+func (v *operationTrackerRepo) completeOperation(ctx context.Context, op *operation.Operation, failure error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if failure != nil {
+		log.Printf("%v operation %v failed on chain: %v\n", op.Kind, op.ID, failure)
+
+		v.revertFailedOperation(svcCtx, op)
+
+		if updErr := v.operationRepo.UpdateOperationStatus(svcCtx, op.ID, operation.StatusPending, operation.StatusFailed, failure.Error()); updErr != nil {
+			log.Printf("error marking operation %v as failed: %v\n", op.ID, updErr)
+			return
+		}
+
+		v.refundOperation(svcCtx, op)
+		return
+	}
+
+	// withdrawn entities are not custodial anymore
+	var delErr error
+	switch op.Kind {
+	case operation.KindWithdrawNftCollection:
+		delErr = v.nftCollectionRepo.DeleteNftCollection(svcCtx, op.ReferenceID)
+	case operation.KindWithdrawNftItem:
+		delErr = v.nftItemRepo.DeleteNftItem(svcCtx, op.ReferenceID)
+	case operation.KindChangeNftCollectionContent:
+		// operation stays pending and metadata is refreshed again on next check
+		if refreshErr := v.refreshNftCollectionMetadata(svcCtx, op); refreshErr != nil {
+			log.Printf("error refreshing %v metadata after %v: %v\n", op.ReferenceID, op.Kind, refreshErr)
+			return
+		}
+	}
+	if delErr != nil {
+		log.Printf("error deleting %v from db after %v: %v\n", op.ReferenceID, op.Kind, delErr)
+		return
+	}
+
+	if updErr := v.operationRepo.UpdateOperationStatus(svcCtx, op.ID, operation.StatusPending, operation.StatusConfirmed, ""); updErr != nil {
+		log.Printf("error marking operation %v as confirmed: %v\n", op.ID, updErr)
+	}
+}
+
+// refreshNftCollectionMetadata stores metadata and royalty params of changed nft collection content from chain
+func (v *operationTrackerRepo) refreshNftCollectionMetadata(ctx context.Context, op *operation.Operation) error {
+	api := v.testnetLiteApi
+	if !op.IsTestnet {
+		api = v.mainnetLiteApi
+	}
+
+	collectionAddress, parseErr := address.ParseAddr(op.ReferenceID)
+	if parseErr != nil {
+		return fmt.Errorf("nft collection address is not valid: %v", parseErr)
+	}
+
+	block, blockErr := api.CurrentMasterchainInfo(ctx)
+	if blockErr != nil {
+		return fmt.Errorf("error getting masterchain info: %v", blockErr)
+	}
+
+	collectionClient := tonnft.NewCollectionClient(api, collectionAddress)
+	collectionData, dataErr := collectionClient.GetCollectionDataAtBlock(ctx, block)
+	if dataErr != nil {
+		return fmt.Errorf("fail getting nft collection data method: %v", dataErr)
+	}
+
+	metadata, metaErr := nftcollectionutils.GetNftCollectionMetadata(collectionData.Content)
+	if metaErr != nil {
+		return metaErr
+	}
+
+	// change_content replaces royalty params too
+	royaltyParams, royaltyErr := collectionClient.RoyaltyParamsAtBlock(ctx, block)
+	if royaltyErr != nil {
+		return fmt.Errorf("fail getting nft collection royalty params method: %v", royaltyErr)
+	}
+
+	if updateErr := v.nftCollectionRepo.UpdateNftCollectionMetadata(ctx, op.ReferenceID, metadata); updateErr != nil {
+		return updateErr
+	}
+
+	return v.nftCollectionRepo.UpdateNftCollectionRoyalty(ctx, op.ReferenceID, nftcollectionutils.GetNftCollectionRoyalty(royaltyParams))
+}
+
+// revertFailedOperation removes deployed entities, which never existed, and returns withdrawn nft item to its owner
+func (v *operationTrackerRepo) revertFailedOperation(ctx context.Context, op *operation.Operation) {
+	var revertErr error
+	switch op.Kind {
+	case operation.KindDeployNftCollection:
+		revertErr = v.nftCollectionRepo.DeleteNftCollection(ctx, op.ReferenceID)
+	case operation.KindMintNftItem:
+		revertErr = v.nftItemRepo.DeleteNftItem(ctx, op.ReferenceID)
+	case operation.KindBatchMintNftItems:
+		for _, itemAddress := range op.ItemAddresses {
+			if itemDelErr := v.nftItemRepo.DeleteNftItem(ctx, itemAddress); itemDelErr != nil {
+				revertErr = itemDelErr
+			}
+		}
+	case operation.KindWithdrawNftItem:
+		revertErr = v.nftItemRepo.UnmarkNftItemWithdrawing(ctx, op.ReferenceID)
+	}
+	if revertErr != nil {
+		log.Printf("error reverting %v in db after failed %v: %v\n", op.ReferenceID, op.Kind, revertErr)
+	}
+}
+
+func (v *operationTrackerRepo) refundFailedOperations(ctx context.Context) {
+	failed, getErr := v.operationRepo.GetOperationsByStatus(ctx, operation.StatusFailed)
+	if getErr != nil {
+		log.Printf("error getting failed operations: %v\n", getErr)
+		return
+	}
+
+	for i := range failed {
+		svcCtx, cancel := v.getContext(ctx)
+		v.refundOperation(svcCtx, &failed[i])
+		cancel()
+	}
+}
+
+func (v *operationTrackerRepo) refundOperation(ctx context.Context, op *operation.Operation) {
+	// refund and refunded status are saved together, so operation is never refunded twice
+	txErr := v.operationRepo.WithTransaction(ctx, func(txCtx context.Context) error {
+		if op.RefundAmount != 0 {
+			if refundErr := v.ledgerRepo.Apply(txCtx, ledger.NewBalanceEntry(op.UserUUID, ledger.EntryTypeRefund, op.RefundAmount, op.ID.String(), op.IsTestnet)); refundErr != nil {
+				return fmt.Errorf("error returning %v nano ton to user uuid %v: %w", op.RefundAmount, op.UserUUID, refundErr)
+			}
+		}
+
+		return v.operationRepo.UpdateOperationStatus(txCtx, op.ID, operation.StatusFailed, operation.StatusRefunded, "")
+	})
+	if txErr != nil {
+		log.Printf("error refunding failed %v operation %v: %v\n", op.Kind, op.ID, txErr)
+	}
+}
@@ -0,0 +1,148 @@
+package operationtracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const testDestination = "EQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAM9c"
+
+// fakeApi returns transactions of destination, newest last like lite server does. Not implemented methods panic
+type fakeApi struct {
+	ton.APIClientWrapped
+	transactions []*tlb.Transaction
+	listed       int
+}
+
+func (a *fakeApi) CurrentMasterchainInfo(_ context.Context) (*ton.BlockIDExt, error) {
+	return &ton.BlockIDExt{SeqNo: 1}, nil
+}
+
+func (a *fakeApi) WaitForBlock(_ uint32) ton.APIClientWrapped {
+	return a
+}
+
+func (a *fakeApi) GetAccount(_ context.Context, _ *ton.BlockIDExt, _ *address.Address) (*tlb.Account, error) {
+	if len(a.transactions) == 0 {
+		return &tlb.Account{}, nil
+	}
+	last := a.transactions[len(a.transactions)-1]
+	return &tlb.Account{IsActive: true, LastTxLT: last.LT, LastTxHash: last.Hash}, nil
+}
+
+func (a *fakeApi) ListTransactions(_ context.Context, _ *address.Address, num uint32, lt uint64, _ []byte) ([]*tlb.Transaction, error) {
+	a.listed++
+
+	end := -1
+	for i, tx := range a.transactions {
+		if tx.LT == lt {
+			end = i + 1
+		}
+	}
+	if end == -1 {
+		return nil, ton.ErrNoTransactionsWereFound
+	}
+
+	start := max(end-int(num), 0)
+	return a.transactions[start:end], nil
+}
+
+// newTransactions returns chain of transactions with incoming messages of bodies, the first one is the oldest
+func newTransactions(bodies []*cell.Cell, success bool) []*tlb.Transaction {
+	transactions := make([]*tlb.Transaction, len(bodies))
+	for i, body := range bodies {
+		tx := &tlb.Transaction{
+			LT:   uint64(i + 1),
+			Hash: []byte{byte(i + 1)},
+			IO: struct {
+				In  *tlb.Message      `tlb:"maybe ^"`
+				Out *tlb.MessagesList `tlb:"maybe ^"`
+			}{In: &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: &tlb.InternalMessage{Body: body}}},
+			Description: tlb.TransactionDescriptionOrdinary{ComputePhase: tlb.ComputePhase{Phase: tlb.ComputePhaseVM{Success: success}}},
+		}
+		if i != 0 {
+			tx.PrevTxLT, tx.PrevTxHash = transactions[i-1].LT, transactions[i-1].Hash
+		}
+		transactions[i] = tx
+	}
+	return transactions
+}
+
+func body(op uint64) *cell.Cell {
+	return cell.BeginCell().MustStoreUInt(op, 32).EndCell()
+}
+
+func newTestOperation(messageBody *cell.Cell) *operation.Operation {
+	return operation.New(operation.KindMintNftItem, uuid.New(), testDestination, messageBody.Hash(), 100, "item", true)
+}
+
+func TestCheckOperation(t *testing.T) {
+	operationBody := body(1)
+
+	tests := map[string]struct {
+		transactions []*tlb.Transaction
+		wantNotFound bool
+		wantFailure  bool
+	}{
+		"no transactions":                     {wantNotFound: true},
+		"newest transaction of other message": {transactions: newTransactions([]*cell.Cell{body(2), body(3)}, true), wantNotFound: true},
+		"failed transaction of other message": {transactions: newTransactions([]*cell.Cell{body(2)}, false), wantNotFound: true},
+		"successful operation message":        {transactions: newTransactions([]*cell.Cell{operationBody, body(2)}, true)},
+		"failed operation message":            {transactions: newTransactions([]*cell.Cell{body(2), operationBody, body(3)}, false), wantFailure: true},
+		"operation message in older page":     {transactions: newTransactions(append([]*cell.Cell{operationBody}, repeat(body(2), 20)...), true)},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tracker := &operationTrackerRepo{testnetLiteApi: &fakeApi{transactions: test.transactions}}
+
+			checkErr := tracker.checkOperation(context.Background(), newTestOperation(operationBody))
+
+			var failure *operationFailure
+			switch {
+			case test.wantNotFound:
+				if !errors.Is(checkErr, ton.ErrTxWasNotFound) {
+					t.Fatalf("want ton.ErrTxWasNotFound, have %v", checkErr)
+				}
+			case test.wantFailure:
+				if !errors.As(checkErr, &failure) {
+					t.Fatalf("want operation failure, have %v", checkErr)
+				}
+			case checkErr != nil:
+				t.Fatalf("want success, have %v", checkErr)
+			}
+		})
+	}
+}
+
+func TestCheckOperationScanLimit(t *testing.T) {
+	api := &fakeApi{transactions: newTransactions(repeat(body(2), inMsgScanLimit+15), true)}
+	tracker := &operationTrackerRepo{testnetLiteApi: api}
+
+	checkErr := tracker.checkOperation(context.Background(), newTestOperation(body(1)))
+
+	// operation is left pending, message can be deeper than scanned transactions
+	var failure *operationFailure
+	if checkErr == nil || errors.Is(checkErr, ton.ErrTxWasNotFound) || errors.As(checkErr, &failure) {
+		t.Fatalf("want scan limit error, have %v", checkErr)
+	}
+	if api.listed != inMsgScanLimit/15 {
+		t.Fatalf("want %v listed pages, have %v", inMsgScanLimit/15, api.listed)
+	}
+}
+
+func repeat(c *cell.Cell, count int) []*cell.Cell {
+	cells := make([]*cell.Cell, count)
+	for i := range cells {
+		cells[i] = c
+	}
+	return cells
+}
@@ -0,0 +1,291 @@
+package pricingservice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// estimated usage of operations, message sizes dont count root cell
+const (
+	walletGasPerSend    = 3500 // wallet v4 checks signature and seqno once per external message
+	walletGasPerMessage = 700  // every internal message sent by wallet
+	contentBits         = 2 * 1023
+	contentCells        = 3 // offchain link or small onchain metadata
+	smallMessageBits    = 1023
+	smallMessageCells   = 1
+	batchItemBits       = 1023 + 300 // dict node, coins and content of one item
+	batchItemCells      = 2 + contentCells
+	batchItemsPerMsg    = 100 // same as chunk of batch mint service
+	batchMessagesPerTx  = 4   // wallet v4 sends up to 4 messages at once
+)
+
+type PricingServiceRepository interface {
+	Quote(ctx context.Context, req pricing.QuoteRequest) (*pricing.Quote, error)
+}
+
+type cachedPrices struct {
+	prices    pricing.NetworkPrices
+	fetchedAt time.Time
+}
+
+type pricingServiceRepo struct {
+	testnetLiteApi          ton.APIClientWrapped
+	mainnetLiteApi          ton.APIClientWrapped
+	amounts                 pricing.Amounts
+	marginNanoTon           uint64
+	marginPercent           uint64
+	pricesTTL               time.Duration
+	deployBits, deployCells uint64 // state init of nft collection
+	mu                      sync.Mutex
+	prices                  map[bool]cachedPrices // is testnet -> prices
+	timeout                 time.Duration
+}
+
+type PricingServiceCfg struct {
+	TestnetLiteApi            ton.APIClientWrapped
+	MainnetLiteApi            ton.APIClientWrapped
+	Amounts                   pricing.Amounts
+	MarginNanoTon             uint64 // added to every quote
+	MarginPercent             uint64 // percent of message amount and network fee added to every quote
+	PricesTTL                 time.Duration
+	NftCollectionContractCode *cell.Cell
+	NftItemContractCode       *cell.Cell
+	Timeout                   time.Duration
+}
+
+func New(cfg PricingServiceCfg) PricingServiceRepository {
+	// nft collection state init has code of collection and code of item in data
+	codeBits, codeCells := cellStats(cfg.NftCollectionContractCode, cfg.NftItemContractCode)
+
+	return &pricingServiceRepo{
+		testnetLiteApi: cfg.TestnetLiteApi,
+		mainnetLiteApi: cfg.MainnetLiteApi,
+		amounts:        cfg.Amounts,
+		marginNanoTon:  cfg.MarginNanoTon,
+		marginPercent:  cfg.MarginPercent,
+		pricesTTL:      cfg.PricesTTL,
+		deployBits:     codeBits + contentBits + smallMessageBits,
+		deployCells:    codeCells + contentCells + smallMessageCells,
+		prices:         map[bool]cachedPrices{},
+		timeout:        cfg.Timeout,
+	}
+}
+
+func (v *pricingServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+// cellStats counts bits and unique cells of trees
+func cellStats(roots ...*cell.Cell) (uint64, uint64) {
+	seen := map[string]bool{}
+	var bits, cells uint64
+
+	var walk func(c *cell.Cell)
+	walk = func(c *cell.Cell) {
+		hash := string(c.Hash())
+		if seen[hash] {
+			return
+		}
+		seen[hash] = true
+		bits += uint64(c.BitsSize())
+		cells++
+		for i := 0; i < int(c.RefsNum()); i++ {
+			walk(c.MustPeekRef(i))
+		}
+	}
+
+	for _, root := range roots {
+		if root != nil {
+			walk(root)
+		}
+	}
+
+	return bits, cells
+}
+
+func (v *pricingServiceRepo) Quote(ctx context.Context, req pricing.QuoteRequest) (*pricing.Quote, error) {
+	if !pricing.IsValidOperation(req.Operation) {
+		return nil, fmt.Errorf("%w: %v", pricing.ErrUnknownOperation, req.Operation)
+	}
+
+	items := 1
+	if req.Operation == pricing.OperationBatchMintNftItems {
+		items = req.Items
+	}
+	if items < 1 {
+		return nil, pricing.ErrInvalidItems
+	}
+
+	prices, expiresAt := v.networkPrices(ctx, req.IsTestnet)
+
+	quote := &pricing.Quote{
+		Operation: req.Operation,
+		Items:     items,
+		IsTestnet: req.IsTestnet,
+		ExpiresAt: expiresAt,
+	}
+
+	// messages of operation and their sizes
+	messages, messageBits, messageCells := uint64(1), uint64(smallMessageBits), uint64(smallMessageCells)
+
+	switch req.Operation {
+	case pricing.OperationDeployNftCollection:
+		quote.MessageAmount = v.amounts.DeployNftCollection
+		messageBits, messageCells = v.deployBits, v.deployCells
+	case pricing.OperationMintNftItem:
+		quote.MessageAmount = v.amounts.MintNftItem + req.ForwardAmount
+		quote.NftItemAmount = v.amounts.MintNftItem - v.amounts.MintNftItemGas + req.ForwardAmount
+		quote.ForwardAmount = req.ForwardAmount
+		messageBits, messageCells = smallMessageBits+contentBits, smallMessageCells+contentCells
+	case pricing.OperationBatchMintNftItems:
+		quote.MessageAmount = uint64(items) * (v.amounts.BatchMintNftItem + v.amounts.BatchMintNftItemGas)
+		quote.NftItemAmount = v.amounts.BatchMintNftItem
+		messages = uint64(items+batchItemsPerMsg-1) / batchItemsPerMsg
+		messageBits, messageCells = batchItemBits*batchItemsPerMsg, batchItemCells*batchItemsPerMsg
+		if uint64(items) < batchItemsPerMsg {
+			messageBits, messageCells = batchItemBits*uint64(items), batchItemCells*uint64(items)
+		}
+	case pricing.OperationWithdrawNftCollection:
+		quote.MessageAmount = v.amounts.WithdrawNftCollection
+	case pricing.OperationChangeNftCollectionContent:
+		quote.MessageAmount = v.amounts.ChangeNftCollectionContent
+		messageBits, messageCells = smallMessageBits+contentBits, smallMessageCells+contentCells
+	case pricing.OperationWithdrawNftItem:
+		quote.MessageAmount = v.amounts.WithdrawNftItem
+		quote.ForwardAmount = v.amounts.WithdrawNftItemForward
+		messageBits, messageCells = 2*smallMessageBits, 2*smallMessageCells
+	}
+
+	sends := (messages + batchMessagesPerTx - 1) / batchMessagesPerTx
+	walletGas := sends*walletGasPerSend + messages*walletGasPerMessage
+
+	// message cells are paid on import of external message and on forward of internal one
+	quote.NetworkFee = prices.GasFee(walletGas) + 2*messages*prices.ForwardFee(messageBits, messageCells)
+	quote.ServiceFee = v.marginNanoTon + (quote.MessageAmount+quote.NetworkFee)*v.marginPercent/100
+	quote.Total = quote.MessageAmount + quote.Fee()
+
+	return quote, nil
+}
+
+// networkPrices returns cached prices of network or fetches them from config.
+// Default prices are returned if config cant be fetched and nothing is cached
+func (v *pricingServiceRepo) networkPrices(ctx context.Context, isTestnet bool) (pricing.NetworkPrices, time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	cached, ok := v.prices[isTestnet]
+	if ok && time.Since(cached.fetchedAt) < v.pricesTTL {
+		return cached.prices, cached.fetchedAt.Add(v.pricesTTL)
+	}
+
+	prices, fetchErr := v.fetchNetworkPrices(ctx, isTestnet)
+	if fetchErr != nil {
+		log.Printf("Error fetching network prices, is testnet: %v: %v\n", isTestnet, fetchErr)
+		if ok {
+			return cached.prices, time.Now().Add(time.Minute)
+		}
+		return pricing.DefaultNetworkPrices, time.Now().Add(time.Minute)
+	}
+
+	v.prices[isTestnet] = cachedPrices{prices: *prices, fetchedAt: time.Now()}
+	return *prices, time.Now().Add(v.pricesTTL)
+}
+
+func (v *pricingServiceRepo) fetchNetworkPrices(ctx context.Context, isTestnet bool) (*pricing.NetworkPrices, error) {
+	apiCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	api := v.mainnetLiteApi
+	if isTestnet {
+		api = v.testnetLiteApi
+	}
+
+	block, blockErr := api.CurrentMasterchainInfo(apiCtx)
+	if blockErr != nil {
+		return nil, fmt.Errorf("error getting masterchain info: %w", blockErr)
+	}
+
+	// 21 is basechain gas prices, 25 is basechain message forward prices
+	config, configErr := api.GetBlockchainConfig(apiCtx, block, 21, 25)
+	if configErr != nil {
+		return nil, fmt.Errorf("error getting config params: %w", configErr)
+	}
+
+	prices := &pricing.NetworkPrices{}
+	if parseErr := parseGasPrices(config.Get(21), prices); parseErr != nil {
+		return nil, parseErr
+	}
+	if parseErr := parseForwardPrices(config.Get(25), prices); parseErr != nil {
+		return nil, parseErr
+	}
+
+	return prices, nil
+}
+
+// parseGasPrices parses GasLimitsPrices, gas_flat_pfx prefix is optional
+func parseGasPrices(param *cell.Cell, prices *pricing.NetworkPrices) error {
+	if param == nil {
+		return fmt.Errorf("config param 21 is empty")
+	}
+
+	slice := param.BeginParse()
+	tag, tagErr := slice.LoadUInt(8)
+	if tagErr != nil {
+		return fmt.Errorf("error loading gas prices tag: %w", tagErr)
+	}
+
+	if tag == 0xd1 {
+		prices.FlatGasLimit = slice.MustLoadUInt(64)
+		prices.FlatGasPrice = slice.MustLoadUInt(64)
+		if tag, tagErr = slice.LoadUInt(8); tagErr != nil {
+			return fmt.Errorf("error loading gas prices tag: %w", tagErr)
+		}
+	}
+
+	switch tag {
+	case 0xdd, 0xde:
+		gasPrice, loadErr := slice.LoadUInt(64)
+		if loadErr != nil {
+			return fmt.Errorf("error loading gas price: %w", loadErr)
+		}
+		prices.GasPrice = gasPrice
+	default:
+		return fmt.Errorf("unknown gas prices tag %x", tag)
+	}
+
+	return nil
+}
+
+// parseForwardPrices parses msg_forward_prices
+func parseForwardPrices(param *cell.Cell, prices *pricing.NetworkPrices) error {
+	if param == nil {
+		return fmt.Errorf("config param 25 is empty")
+	}
+
+	slice := param.BeginParse()
+	tag, tagErr := slice.LoadUInt(8)
+	if tagErr != nil {
+		return fmt.Errorf("error loading forward prices tag: %w", tagErr)
+	}
+	if tag != 0xea {
+		return fmt.Errorf("unknown forward prices tag %x", tag)
+	}
+
+	lumpPrice, lumpErr := slice.LoadUInt(64)
+	bitPrice, bitErr := slice.LoadUInt(64)
+	cellPrice, cellErr := slice.LoadUInt(64)
+	if lumpErr != nil || bitErr != nil || cellErr != nil {
+		return fmt.Errorf("error loading forward prices: %v, %v, %v", lumpErr, bitErr, cellErr)
+	}
+
+	prices.LumpPrice, prices.BitPrice, prices.CellPrice = lumpPrice, bitPrice, cellPrice
+
+	return nil
+}
@@ -0,0 +1,544 @@
+package reconciliationservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/rom6n/create-nft-go/internal/domain/reconciliation"
+	nftitemutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_item_utils"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+	tonnft "github.com/xssnick/tonutils-go/ton/nft"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type ReconciliationServiceRepository interface {
+	// Run reconciles custodial nft collections and items with scheduled policy on interval until ctx is done
+	Run(ctx context.Context)
+	// Reconcile starts reconciliation with policy in background and returns its running report.
+	// Returns ErrAlreadyRunning if reconciliation is running
+	Reconcile(ctx context.Context, policy reconciliation.Policy) (*reconciliation.Report, error)
+	GetReport(ctx context.Context, id uuid.UUID) (*reconciliation.Report, error)
+	GetReports(ctx context.Context, limit int64) ([]reconciliation.Report, error)
+}
+
+type reconciliationServiceRepo struct {
+	reconciliationRepo reconciliation.ReconciliationRepository
+	nftCollectionRepo  nftcollection.NftCollectionRepository
+	nftItemRepo        nftitem.NftItemRepository
+	operationRepo      operation.OperationRepository
+	listingRepo        listing.ListingRepository
+	auctionRepo        auction.AuctionRepository
+	testnetLiteApi     ton.APIClientWrapped
+	mainnetLiteApi     ton.APIClientWrapped
+	testnetWallet      *wallet.Wallet
+	mainnetWallet      *wallet.Wallet
+	policy             reconciliation.Policy
+	interval           time.Duration
+	timeout            time.Duration
+
+	mu      sync.Mutex
+	running bool
+}
+
+type ReconciliationServiceCfg struct {
+	ReconciliationRepo reconciliation.ReconciliationRepository
+	NftCollectionRepo  nftcollection.NftCollectionRepository
+	NftItemRepo        nftitem.NftItemRepository
+	OperationRepo      operation.OperationRepository
+	ListingRepo        listing.ListingRepository
+	AuctionRepo        auction.AuctionRepository
+	TestnetLiteApi     ton.APIClientWrapped
+	MainnetLiteApi     ton.APIClientWrapped
+	TestnetWallet      *wallet.Wallet
+	MainnetWallet      *wallet.Wallet
+	Policy             reconciliation.Policy // policy of scheduled reconciliation
+	Interval           time.Duration
+	Timeout            time.Duration // timeout of one database or lite server call
+}
+
+func New(cfg ReconciliationServiceCfg) ReconciliationServiceRepository {
+	return &reconciliationServiceRepo{
+		reconciliationRepo: cfg.ReconciliationRepo,
+		nftCollectionRepo:  cfg.NftCollectionRepo,
+		nftItemRepo:        cfg.NftItemRepo,
+		operationRepo:      cfg.OperationRepo,
+		listingRepo:        cfg.ListingRepo,
+		auctionRepo:        cfg.AuctionRepo,
+		testnetLiteApi:     cfg.TestnetLiteApi,
+		mainnetLiteApi:     cfg.MainnetLiteApi,
+		testnetWallet:      cfg.TestnetWallet,
+		mainnetWallet:      cfg.MainnetWallet,
+		policy:             cfg.Policy,
+		interval:           cfg.Interval,
+		timeout:            cfg.Timeout,
+	}
+}
+
+func (v *reconciliationServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *reconciliationServiceRepo) Run(ctx context.Context) {
+	log.Printf("Reconciliation is running with %v policy", v.policy)
+
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("Reconciliation is stopped")
+			return
+		case <-ticker.C:
+			report, startErr := v.start(ctx, v.policy)
+			if startErr != nil {
+				log.Printf("error starting scheduled reconciliation: %v\n", startErr)
+				continue
+			}
+			v.reconcile(ctx, report)
+		}
+	}
+}
+
+func (v *reconciliationServiceRepo) Reconcile(ctx context.Context, policy reconciliation.Policy) (*reconciliation.Report, error) {
+	report, startErr := v.start(ctx, policy)
+	if startErr != nil {
+		return nil, startErr
+	}
+
+	// report is changed by reconciliation, caller gets its copy
+	started := *report
+
+	// reconciliation takes longer than admin request, calls are limited by timeout one by one
+	go v.reconcile(context.WithoutCancel(ctx), report)
+
+	return &started, nil
+}
+
+// start marks reconciliation as running and saves its report
+func (v *reconciliationServiceRepo) start(ctx context.Context, policy reconciliation.Policy) (*reconciliation.Report, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.running {
+		return nil, reconciliation.ErrAlreadyRunning
+	}
+
+	report := reconciliation.NewReport(policy)
+
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if saveErr := v.reconciliationRepo.SaveReport(svcCtx, report); saveErr != nil {
+		return nil, saveErr
+	}
+
+	v.running = true
+	return report, nil
+}
+
+func (v *reconciliationServiceRepo) reconcile(ctx context.Context, report *reconciliation.Report) {
+	defer func() {
+		v.mu.Lock()
+		v.running = false
+		v.mu.Unlock()
+	}()
+
+	report.Status = reconciliation.StatusCompleted
+	if reconcileErr := v.reconcileNetworks(ctx, report); reconcileErr != nil {
+		log.Printf("reconciliation %v failed: %v\n", report.ID, reconcileErr)
+		report.Status = reconciliation.StatusFailed
+		report.Error = reconcileErr.Error()
+	}
+	report.FinishedAt = time.Now()
+
+	svcCtx, cancel := v.getContext(context.WithoutCancel(ctx))
+	defer cancel()
+
+	if saveErr := v.reconciliationRepo.SaveReport(svcCtx, report); saveErr != nil {
+		log.Printf("error saving reconciliation report %v: %v\n", report.ID, saveErr)
+		return
+	}
+
+	log.Printf("Reconciliation %v is %v: %v mismatches in %v nft collections and %v nft items, %v failed checks",
+		report.ID, report.Status, len(report.Mismatches), report.CheckedNftCollections, report.CheckedNftItems, report.FailedChecks)
+}
+
+func (v *reconciliationServiceRepo) reconcileNetworks(ctx context.Context, report *reconciliation.Report) error {
+	pending, pendingErr := v.getPendingAddresses(ctx)
+	if pendingErr != nil {
+		return pendingErr
+	}
+
+	if testnetErr := v.reconcileNetwork(ctx, report, pending, true); testnetErr != nil {
+		return fmt.Errorf("testnet: %w", testnetErr)
+	}
+
+	if mainnetErr := v.reconcileNetwork(ctx, report, pending, false); mainnetErr != nil {
+		return fmt.Errorf("mainnet: %w", mainnetErr)
+	}
+
+	return nil
+}
+
+// getPendingAddresses returns nft collections and items with operations in flight,
+// database and chain disagree about them until operation is confirmed, so they arent reconciled
+func (v *reconciliationServiceRepo) getPendingAddresses(ctx context.Context) (map[string]bool, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	pendingOperations, getErr := v.operationRepo.GetOperationsByStatus(svcCtx, operation.StatusPending)
+	if getErr != nil {
+		return nil, fmt.Errorf("error getting pending operations: %v", getErr)
+	}
+
+	pending := map[string]bool{}
+	for _, op := range pendingOperations {
+		pending[op.ReferenceID] = true
+		pending[op.DeployedAddress] = true
+		for _, itemAddress := range op.ItemAddresses {
+			pending[itemAddress] = true
+		}
+	}
+
+	return pending, nil
+}
+
+// network is lite api and service wallet of testnet or mainnet at one block
+type network struct {
+	api           ton.APIClientWrapped
+	block         *ton.BlockIDExt
+	walletAddress *address.Address
+	isTestnet     bool
+}
+
+func (v *reconciliationServiceRepo) reconcileNetwork(ctx context.Context, report *reconciliation.Report, pending map[string]bool, isTestnet bool) error {
+	net := network{api: v.testnetLiteApi, walletAddress: v.testnetWallet.WalletAddress(), isTestnet: isTestnet}
+	if !isTestnet {
+		net.api = v.mainnetLiteApi
+		net.walletAddress = v.mainnetWallet.WalletAddress()
+	}
+
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	block, blockErr := net.api.CurrentMasterchainInfo(svcCtx)
+	if blockErr != nil {
+		return fmt.Errorf("error getting masterchain info: %v", blockErr)
+	}
+	net.block = block
+
+	collections, collectionsErr := v.nftCollectionRepo.GetNftCollectionsByNetwork(svcCtx, isTestnet)
+	if collectionsErr != nil {
+		return collectionsErr
+	}
+
+	nftItems, nftItemsErr := v.nftItemRepo.GetNftItemsByNetwork(svcCtx, isTestnet)
+	if nftItemsErr != nil {
+		return nftItemsErr
+	}
+
+	storedItems := make(map[string]bool, len(nftItems))
+	for _, storedItem := range nftItems {
+		storedItems[storedItem.Address] = true
+	}
+
+	for i := range nftItems {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if pending[nftItems[i].Address] {
+			continue
+		}
+		v.reconcileNftItem(ctx, report, net, &nftItems[i])
+	}
+
+	for i := range collections {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if pending[collections[i].Address] {
+			continue
+		}
+		v.reconcileNftCollection(ctx, report, net, &collections[i], storedItems, pending)
+	}
+
+	return nil
+}
+
+// isNotDeployed reports if get method failed because contract isnt active
+func isNotDeployed(err error) bool {
+	var execErr ton.ContractExecError
+	return errors.As(err, &execErr) && execErr.Code == ton.ErrCodeContractNotInitialized
+}
+
+func (v *reconciliationServiceRepo) reconcileNftItem(ctx context.Context, report *reconciliation.Report, net network, storedItem *nftitem.NftItem) {
+	report.CheckedNftItems++
+
+	mismatch := reconciliation.Mismatch{
+		Entity:            reconciliation.EntityNftItem,
+		Address:           storedItem.Address,
+		CollectionAddress: storedItem.CollectionAddress,
+		Index:             storedItem.Index,
+		OwnerUUID:         storedItem.Owner,
+		IsTestnet:         net.isTestnet,
+	}
+
+	nftItemAddress, parseErr := address.ParseAddr(storedItem.Address)
+	if parseErr != nil {
+		log.Printf("nft item %v in database has invalid address: %v\n", storedItem.Address, parseErr)
+		report.FailedChecks++
+		return
+	}
+
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	nftItemData, dataErr := tonnft.NewItemClient(net.api, nftItemAddress).GetNFTDataAtBlock(svcCtx, net.block)
+	switch {
+	case isNotDeployed(dataErr):
+		mismatch.Kind = reconciliation.MismatchMissingOnChain
+	case dataErr != nil:
+		log.Printf("error getting nft item %v data: %v\n", storedItem.Address, dataErr)
+		report.FailedChecks++
+		return
+	case !nftItemData.Initialized:
+		mismatch.Kind = reconciliation.MismatchMissingOnChain
+	case !net.walletAddress.Equals(nftItemData.OwnerAddress):
+		mismatch.Kind = reconciliation.MismatchForeignOwner
+		mismatch.OnchainOwner = nftItemData.OwnerAddress.String()
+	default:
+		return
+	}
+
+	if report.Policy.Repairs(mismatch.Kind) {
+		v.repair(svcCtx, &mismatch, func() error { return v.removeNftItem(svcCtx, storedItem.Address) })
+	}
+	report.Mismatches = append(report.Mismatches, mismatch)
+}
+
+// removeNftItem deletes nft item which isnt custodial anymore, listed or auctioned nft items are left to admin
+func (v *reconciliationServiceRepo) removeNftItem(ctx context.Context, nftItemAddress string) error {
+	if _, listingErr := v.listingRepo.GetActiveListingByNftItem(ctx, nftItemAddress); listingErr == nil {
+		return listing.ErrAlreadyListed
+	} else if !errors.Is(listingErr, mongo.ErrNoDocuments) {
+		return fmt.Errorf("error checking nft item listing: %w", listingErr)
+	}
+
+	if _, auctionErr := v.auctionRepo.GetActiveAuctionByNftItem(ctx, nftItemAddress); auctionErr == nil {
+		return auction.ErrAlreadyOnAuction
+	} else if !errors.Is(auctionErr, mongo.ErrNoDocuments) {
+		return fmt.Errorf("error checking nft item auction: %w", auctionErr)
+	}
+
+	return v.nftItemRepo.DeleteNftItem(ctx, nftItemAddress)
+}
+
+func (v *reconciliationServiceRepo) repair(ctx context.Context, mismatch *reconciliation.Mismatch, repairFunc func() error) {
+	if repairErr := repairFunc(); repairErr != nil {
+		log.Printf("error repairing %v %v %v: %v\n", mismatch.Kind, mismatch.Entity, mismatch.Address, repairErr)
+		mismatch.RepairError = repairErr.Error()
+		return
+	}
+	mismatch.Repaired = true
+}
+
+func (v *reconciliationServiceRepo) reconcileNftCollection(ctx context.Context, report *reconciliation.Report, net network, collection *nftcollection.NftCollection, storedItems map[string]bool, pending map[string]bool) {
+	report.CheckedNftCollections++
+
+	mismatch := reconciliation.Mismatch{
+		Entity:    reconciliation.EntityNftCollection,
+		Address:   collection.Address,
+		OwnerUUID: collection.Owner,
+		IsTestnet: net.isTestnet,
+	}
+
+	collectionAddress, parseErr := address.ParseAddr(collection.Address)
+	if parseErr != nil {
+		log.Printf("nft collection %v in database has invalid address: %v\n", collection.Address, parseErr)
+		report.FailedChecks++
+		return
+	}
+
+	collectionClient := tonnft.NewCollectionClient(net.api, collectionAddress)
+
+	svcCtx, cancel := v.getContext(ctx)
+	collectionData, dataErr := collectionClient.GetCollectionDataAtBlock(svcCtx, net.block)
+	cancel()
+
+	switch {
+	case isNotDeployed(dataErr):
+		mismatch.Kind = reconciliation.MismatchMissingOnChain
+	case dataErr != nil:
+		log.Printf("error getting nft collection %v data: %v\n", collection.Address, dataErr)
+		report.FailedChecks++
+		return
+	case !net.walletAddress.Equals(collectionData.OwnerAddress):
+		mismatch.Kind = reconciliation.MismatchForeignOwner
+		mismatch.OnchainOwner = collectionData.OwnerAddress.String()
+	default:
+		v.findMissingNftItems(ctx, report, net, collection, collectionClient, collectionData.NextItemIndex.Int64(), storedItems, pending)
+		return
+	}
+
+	if report.Policy.Repairs(mismatch.Kind) {
+		svcCtx, cancel := v.getContext(ctx)
+		v.repair(svcCtx, &mismatch, func() error { return v.nftCollectionRepo.DeleteNftCollection(svcCtx, collection.Address) })
+		cancel()
+	}
+	report.Mismatches = append(report.Mismatches, mismatch)
+}
+
+// findMissingNftItems checks nft items of custodial collection which arent in database,
+// the ones owned by service wallet were minted or returned to custody and database missed them
+func (v *reconciliationServiceRepo) findMissingNftItems(ctx context.Context, report *reconciliation.Report, net network, collection *nftcollection.NftCollection, collectionClient *tonnft.CollectionClient, nextItemIndex int64, storedItems map[string]bool, pending map[string]bool) {
+	for index := int64(0); index < nextItemIndex; index++ {
+		if ctx.Err() != nil {
+			return
+		}
+
+		svcCtx, cancel := v.getContext(ctx)
+		v.findMissingNftItem(svcCtx, report, net, collection, collectionClient, index, storedItems, pending)
+		cancel()
+	}
+}
+
+func (v *reconciliationServiceRepo) findMissingNftItem(ctx context.Context, report *reconciliation.Report, net network, collection *nftcollection.NftCollection, collectionClient *tonnft.CollectionClient, index int64, storedItems map[string]bool, pending map[string]bool) {
+	nftItemAddress, addressErr := collectionClient.GetNFTAddressByIndexAtBlock(ctx, big.NewInt(index), net.block)
+	if addressErr != nil {
+		log.Printf("error getting nft item %v address of %v: %v\n", index, collection.Address, addressErr)
+		report.FailedChecks++
+		return
+	}
+
+	// addresses are stored with testnet flag of their network
+	nftItemAddress.SetTestnetOnly(net.isTestnet)
+	if storedItems[nftItemAddress.String()] || pending[nftItemAddress.String()] {
+		return
+	}
+
+	nftItemData, dataErr := tonnft.NewItemClient(net.api, nftItemAddress).GetNFTDataAtBlock(ctx, net.block)
+	if isNotDeployed(dataErr) {
+		// index wasnt minted
+		return
+	}
+	if dataErr != nil {
+		log.Printf("error getting nft item %v data: %v\n", nftItemAddress, dataErr)
+		report.FailedChecks++
+		return
+	}
+	if !nftItemData.Initialized || !net.walletAddress.Equals(nftItemData.OwnerAddress) {
+		return
+	}
+
+	ownerUuid, ownerErr := v.resolveNftItemOwner(ctx, nftItemAddress.String())
+	if ownerErr != nil && !errors.Is(ownerErr, reconciliation.ErrOwnerNotResolved) {
+		log.Printf("error resolving nft item %v owner: %v\n", nftItemAddress, ownerErr)
+		report.FailedChecks++
+		return
+	}
+
+	mismatch := reconciliation.Mismatch{
+		Entity:            reconciliation.EntityNftItem,
+		Kind:              reconciliation.MismatchMissingInDB,
+		Address:           nftItemAddress.String(),
+		CollectionAddress: collection.Address,
+		Index:             index,
+		OwnerUUID:         ownerUuid,
+		OnchainOwner:      nftItemData.OwnerAddress.String(),
+		IsTestnet:         net.isTestnet,
+	}
+
+	if report.Policy.Repairs(mismatch.Kind) {
+		if ownerErr != nil {
+			// nft item isnt given to anyone by guess, admin decides whose it is
+			mismatch.RepairError = ownerErr.Error()
+		} else {
+			v.repair(ctx, &mismatch, func() error {
+				return v.restoreNftItem(ctx, net, collection, collectionClient, nftItemAddress.String(), nftItemData, ownerUuid)
+			})
+		}
+	}
+	report.Mismatches = append(report.Mismatches, mismatch)
+}
+
+// resolveNftItemOwner returns last owner of nft item in the app: recipient of its newest transfer or user of its newest
+// mint or withdraw operation, the newer one. After confirmed withdraw nft item left the app, so its owner isnt resolved.
+// Failed mint was refunded to its user, nft item isnt given to him for free and is left to admin
+func (v *reconciliationServiceRepo) resolveNftItemOwner(ctx context.Context, nftItemAddress string) (uuid.UUID, error) {
+	transfers, transfersErr := v.nftItemRepo.GetNftItemTransfers(ctx, nftItemAddress)
+	if transfersErr != nil {
+		return uuid.Nil, fmt.Errorf("error getting nft item transfers: %w", transfersErr)
+	}
+
+	operations, operationsErr := v.operationRepo.GetOperationsByNftItem(ctx, nftItemAddress)
+	if operationsErr != nil {
+		return uuid.Nil, fmt.Errorf("error getting nft item operations: %w", operationsErr)
+	}
+
+	if len(operations) != 0 && (len(transfers) == 0 || operations[0].CreatedAt.After(transfers[0].CreatedAt)) {
+		lastOperation := operations[0]
+		if lastOperation.Kind == operation.KindWithdrawNftItem && lastOperation.Status == operation.StatusConfirmed {
+			return uuid.Nil, reconciliation.ErrOwnerNotResolved
+		}
+		if lastOperation.Kind != operation.KindWithdrawNftItem && (lastOperation.Status == operation.StatusFailed || lastOperation.Status == operation.StatusRefunded) {
+			return uuid.Nil, reconciliation.ErrOwnerNotResolved
+		}
+		return lastOperation.UserUUID, nil
+	}
+
+	if len(transfers) != 0 {
+		return transfers[0].ToUUID, nil
+	}
+
+	return uuid.Nil, reconciliation.ErrOwnerNotResolved
+}
+
+// restoreNftItem adds nft item of custodial collection to database, it is given to its resolved owner
+func (v *reconciliationServiceRepo) restoreNftItem(ctx context.Context, net network, collection *nftcollection.NftCollection, collectionClient *tonnft.CollectionClient, nftItemAddress string, nftItemData *tonnft.ItemData, ownerUuid uuid.UUID) error {
+	content, contentErr := collectionClient.GetNFTContentAtBlock(ctx, nftItemData.Index, nftItemData.Content, net.block)
+	if contentErr != nil {
+		return fmt.Errorf("error getting nft item content: %v", contentErr)
+	}
+
+	// standard collection returns item content as offchain link
+	offchainContent, ok := content.(*tonnft.ContentOffchain)
+	if !ok {
+		return fmt.Errorf("want offchain nft item content, have %T", content)
+	}
+
+	metadata, metadataErr := nftitemutils.GetNftItemOffchainMetadata(offchainContent.URI)
+	if metadataErr != nil {
+		return fmt.Errorf("error getting nft item metadata: %w", metadataErr)
+	}
+
+	restoredItem := nftitem.New(nftItemAddress, nftItemData.Index.Int64(), collection.Address, collection.Metadata.Name, ownerUuid, metadata, net.isTestnet)
+	return v.nftItemRepo.CreateNftItem(ctx, restoredItem)
+}
+
+func (v *reconciliationServiceRepo) GetReport(ctx context.Context, id uuid.UUID) (*reconciliation.Report, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.reconciliationRepo.GetReport(svcCtx, id)
+}
+
+func (v *reconciliationServiceRepo) GetReports(ctx context.Context, limit int64) ([]reconciliation.Report, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.reconciliationRepo.GetReports(svcCtx, limit)
+}
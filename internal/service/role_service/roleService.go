@@ -0,0 +1,97 @@
+package roleservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/audit"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+)
+
+var ErrOwnRoleChange = errors.New("admin can't change his own role")
+
+type RoleServiceRepository interface {
+	// GrantRole sets admin role to user and writes it to audit trail
+	GrantRole(ctx context.Context, actorID int64, userID int64, role string, reason string) (*user.User, error)
+	// RevokeRole returns user to user role and writes it to audit trail
+	RevokeRole(ctx context.Context, actorID int64, userID int64, reason string) (*user.User, error)
+	GetAuditEntries(ctx context.Context, userID int64) ([]audit.Entry, error)
+}
+
+type roleServiceRepo struct {
+	userRepo  user.UserRepository
+	auditRepo audit.AuditRepository
+	timeout   time.Duration
+}
+
+type RoleServiceCfg struct {
+	UserRepo  user.UserRepository
+	AuditRepo audit.AuditRepository
+	Timeout   time.Duration
+}
+
+func New(cfg RoleServiceCfg) RoleServiceRepository {
+	return &roleServiceRepo{
+		userRepo:  cfg.UserRepo,
+		auditRepo: cfg.AuditRepo,
+		timeout:   cfg.Timeout,
+	}
+}
+
+func (v *roleServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *roleServiceRepo) GrantRole(ctx context.Context, actorID int64, userID int64, role string, reason string) (*user.User, error) {
+	if !user.IsValidRole(role) || role == user.RoleUser {
+		return nil, fmt.Errorf("%w: %v", user.ErrUnknownRole, role)
+	}
+
+	return v.setRole(ctx, audit.ActionGrantRole, actorID, userID, role, reason)
+}
+
+func (v *roleServiceRepo) RevokeRole(ctx context.Context, actorID int64, userID int64, reason string) (*user.User, error) {
+	return v.setRole(ctx, audit.ActionRevokeRole, actorID, userID, user.RoleUser, reason)
+}
+
+func (v *roleServiceRepo) setRole(ctx context.Context, action audit.Action, actorID int64, userID int64, role string, reason string) (*user.User, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	// admin can't lock himself out or raise himself
+	if actorID == userID {
+		return nil, ErrOwnRoleChange
+	}
+
+	// role change and its audit entry are written together, role can't be changed without audit
+	var previous *user.User
+	if txErr := v.auditRepo.WithTransaction(svcCtx, func(txCtx context.Context) error {
+		var updateErr error
+		previous, updateErr = v.userRepo.SetUserRole(txCtx, userID, role)
+		if updateErr != nil {
+			return fmt.Errorf("error changing user's role: %w", updateErr)
+		}
+
+		if createErr := v.auditRepo.CreateEntry(txCtx, audit.NewEntry(action, actorID, userID, previous.Role, role, reason)); createErr != nil {
+			return fmt.Errorf("error adding role change to audit: %v", createErr)
+		}
+
+		return nil
+	}); txErr != nil {
+		return nil, txErr
+	}
+
+	updated := *previous
+	updated.Role = role
+
+	return &updated, nil
+}
+
+func (v *roleServiceRepo) GetAuditEntries(ctx context.Context, userID int64) ([]audit.Entry, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.auditRepo.GetEntriesByTargetUserID(svcCtx, userID)
+}
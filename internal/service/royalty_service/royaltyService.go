@@ -0,0 +1,159 @@
+package royaltyservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	"github.com/rom6n/create-nft-go/internal/domain/royalty"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	nftcollectionutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_collection_utils"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/ton"
+	tonnft "github.com/xssnick/tonutils-go/ton/nft"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type RoyaltyServiceRepository interface {
+	// Calculate returns royalty of sale for nft collection owner or nil if sale pays no royalty.
+	// Nothing is credited until accrual is passed to Accrue
+	Calculate(ctx context.Context, sale royalty.Sale) (*royalty.Accrual, error)
+	// Accrue credits royalty to creator's balance, it joins transaction of ctx if there is one
+	Accrue(ctx context.Context, accrual *royalty.Accrual) error
+	// GetEarnings returns royalty earned by user from every his nft collection
+	GetEarnings(ctx context.Context, creatorID int64) ([]royalty.Earnings, error)
+}
+
+type royaltyServiceRepo struct {
+	royaltyRepo       royalty.RoyaltyRepository
+	nftCollectionRepo nftcollection.NftCollectionRepository
+	userRepo          user.UserRepository
+	ledgerRepo        ledger.LedgerRepository
+	testnetLiteApi    ton.APIClientWrapped
+	mainnetLiteApi    ton.APIClientWrapped
+	timeout           time.Duration
+}
+
+type RoyaltyServiceCfg struct {
+	RoyaltyRepo       royalty.RoyaltyRepository
+	NftCollectionRepo nftcollection.NftCollectionRepository
+	UserRepo          user.UserRepository
+	LedgerRepo        ledger.LedgerRepository
+	TestnetLiteApi    ton.APIClientWrapped
+	MainnetLiteApi    ton.APIClientWrapped
+	Timeout           time.Duration
+}
+
+func New(cfg RoyaltyServiceCfg) RoyaltyServiceRepository {
+	return &royaltyServiceRepo{
+		royaltyRepo:       cfg.RoyaltyRepo,
+		nftCollectionRepo: cfg.NftCollectionRepo,
+		userRepo:          cfg.UserRepo,
+		ledgerRepo:        cfg.LedgerRepo,
+		testnetLiteApi:    cfg.TestnetLiteApi,
+		mainnetLiteApi:    cfg.MainnetLiteApi,
+		timeout:           cfg.Timeout,
+	}
+}
+
+func (v *royaltyServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *royaltyServiceRepo) Calculate(ctx context.Context, sale royalty.Sale) (*royalty.Accrual, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	// nft items without collection and collections not stored in the app pay no royalty
+	if sale.CollectionAddress == "" {
+		return nil, nil
+	}
+
+	nftCollection, collectionErr := v.nftCollectionRepo.GetNftCollectionByAddress(svcCtx, sale.CollectionAddress)
+	if collectionErr != nil {
+		if errors.Is(collectionErr, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting nft collection: %w", collectionErr)
+	}
+
+	// creator doesnt pay royalty to himself
+	if nftCollection.Owner == sale.Seller {
+		return nil, nil
+	}
+
+	collectionRoyalty := nftCollection.Royalty
+	if collectionRoyalty == nil {
+		chainRoyalty, royaltyErr := v.getChainRoyalty(svcCtx, nftCollection)
+		if royaltyErr != nil {
+			return nil, royaltyErr
+		}
+		collectionRoyalty = chainRoyalty
+	}
+
+	amount := collectionRoyalty.Amount(sale.Price)
+	if amount == 0 {
+		return nil, nil
+	}
+
+	return royalty.NewAccrual(sale, nftCollection.Owner, amount), nil
+}
+
+// getChainRoyalty reads royalty params of nft collection stored before they were saved and saves them
+func (v *royaltyServiceRepo) getChainRoyalty(ctx context.Context, nftCollection *nftcollection.NftCollection) (*nftcollection.Royalty, error) {
+	api := v.testnetLiteApi
+	if !nftCollection.IsTestnet {
+		api = v.mainnetLiteApi
+	}
+
+	collectionAddress, parseErr := address.ParseAddr(nftCollection.Address)
+	if parseErr != nil {
+		return nil, fmt.Errorf("nft collection address is not valid: %v", parseErr)
+	}
+
+	block, blockErr := api.CurrentMasterchainInfo(ctx)
+	if blockErr != nil {
+		return nil, fmt.Errorf("error getting masterchain info: %v", blockErr)
+	}
+
+	royaltyParams, royaltyErr := tonnft.NewCollectionClient(api, collectionAddress).RoyaltyParamsAtBlock(ctx, block)
+	if royaltyErr != nil {
+		return nil, fmt.Errorf("nft collection royalty params method error: %v", royaltyErr)
+	}
+
+	collectionRoyalty := nftcollectionutils.GetNftCollectionRoyalty(royaltyParams)
+	if updateErr := v.nftCollectionRepo.UpdateNftCollectionRoyalty(ctx, nftCollection.Address, collectionRoyalty); updateErr != nil {
+		log.Printf("Error saving royalty params of nft collection %v: %v\n", nftCollection.Address, updateErr)
+	}
+
+	return collectionRoyalty, nil
+}
+
+func (v *royaltyServiceRepo) Accrue(ctx context.Context, accrual *royalty.Accrual) error {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	return v.royaltyRepo.WithTransaction(svcCtx, func(txCtx context.Context) error {
+		if creditErr := v.ledgerRepo.Apply(txCtx, ledger.NewBalanceEntry(accrual.Creator, ledger.EntryTypeRoyalty, accrual.Amount, accrual.ReferenceID, accrual.IsTestnet)); creditErr != nil {
+			return fmt.Errorf("error crediting royalty: %w", creditErr)
+		}
+
+		return v.royaltyRepo.CreateAccrual(txCtx, accrual)
+	})
+}
+
+func (v *royaltyServiceRepo) GetEarnings(ctx context.Context, creatorID int64) ([]royalty.Earnings, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	creatorAccount, accErr := v.userRepo.GetUserByID(svcCtx, creatorID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	return v.royaltyRepo.GetEarningsByCreator(svcCtx, creatorAccount.UUID)
+}
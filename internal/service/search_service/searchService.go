@@ -0,0 +1,61 @@
+package searchservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/search"
+)
+
+type SearchServiceRepository interface {
+	Search(ctx context.Context, query search.Query) (*search.Result, error)
+}
+
+type searchServiceRepo struct {
+	searchRepo search.SearchRepository
+	timeout    time.Duration
+}
+
+type SearchServiceCfg struct {
+	SearchRepo search.SearchRepository
+	Timeout    time.Duration
+}
+
+func New(cfg SearchServiceCfg) SearchServiceRepository {
+	return &searchServiceRepo{
+		searchRepo: cfg.SearchRepo,
+		timeout:    cfg.Timeout,
+	}
+}
+
+func (v *searchServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *searchServiceRepo) Search(ctx context.Context, query search.Query) (*search.Result, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if query.Kind != "" && query.Kind != search.KindNftCollection && query.Kind != search.KindNftItem {
+		return nil, fmt.Errorf("unknown search kind: %v", query.Kind)
+	}
+
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = search.DefaultLimit
+	}
+	if query.Limit > search.MaxLimit {
+		query.Limit = search.MaxLimit
+	}
+
+	result, searchErr := v.searchRepo.Search(svcCtx, query)
+	if searchErr != nil {
+		return nil, fmt.Errorf("error searching: %w", searchErr)
+	}
+
+	return result, nil
+}
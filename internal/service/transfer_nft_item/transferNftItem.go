@@ -0,0 +1,117 @@
+package transfernftitem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
+	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type TransferNftItemServiceRepository interface {
+	// TransferNftItem gives custodial nft item to another user of the app for free, nothing is sent on chain
+	TransferNftItem(ctx context.Context, nftItemAddress string, ownerID int64, recipientID int64) (*nftitem.Transfer, error)
+	GetNftItemHistory(ctx context.Context, nftItemAddress string) ([]nftitem.Transfer, error)
+}
+
+type transferNftItemServiceRepo struct {
+	nftItemRepo nftitem.NftItemRepository
+	userRepo    user.UserRepository
+	listingRepo listing.ListingRepository
+	auctionRepo auction.AuctionRepository
+	timeout     time.Duration
+}
+
+type TransferNftItemServiceCfg struct {
+	NftItemRepo nftitem.NftItemRepository
+	UserRepo    user.UserRepository
+	ListingRepo listing.ListingRepository
+	AuctionRepo auction.AuctionRepository
+	Timeout     time.Duration
+}
+
+func New(cfg TransferNftItemServiceCfg) TransferNftItemServiceRepository {
+	return &transferNftItemServiceRepo{
+		nftItemRepo: cfg.NftItemRepo,
+		userRepo:    cfg.UserRepo,
+		listingRepo: cfg.ListingRepo,
+		auctionRepo: cfg.AuctionRepo,
+		timeout:     cfg.Timeout,
+	}
+}
+
+func (v *transferNftItemServiceRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, v.timeout)
+}
+
+func (v *transferNftItemServiceRepo) TransferNftItem(ctx context.Context, nftItemAddress string, ownerID int64, recipientID int64) (*nftitem.Transfer, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if ownerID == recipientID {
+		return nil, nftitem.ErrSelfTransfer
+	}
+
+	ownerAccount, accErr := v.userRepo.GetUserByID(svcCtx, ownerID)
+	if accErr != nil {
+		return nil, fmt.Errorf("error getting user's account: %w", accErr)
+	}
+
+	recipientAccount, recipientErr := v.userRepo.GetUserByID(svcCtx, recipientID)
+	if recipientErr != nil {
+		if errors.Is(recipientErr, mongo.ErrNoDocuments) {
+			return nil, nftitem.ErrRecipientNotFound
+		}
+		return nil, fmt.Errorf("error getting recipient's account: %w", recipientErr)
+	}
+
+	nftItem, nftItemErr := v.nftItemRepo.GetNftItemByAddress(svcCtx, nftItemAddress)
+	if nftItemErr != nil {
+		return nil, fmt.Errorf("error getting nft item: %w", nftItemErr)
+	}
+
+	if nftItem.Owner != ownerAccount.UUID {
+		return nil, fmt.Errorf("%w to transfer it", nftitem.ErrNotItemOwner)
+	}
+
+	// withdrawn nft item stays in database until withdraw is confirmed on chain, transfer checks it again in its transaction
+	if nftItem.Withdrawing {
+		return nil, nftitem.ErrWithdrawPending
+	}
+
+	// listed nft item is transferred only by sale
+	if _, listingErr := v.listingRepo.GetActiveListingByNftItem(svcCtx, nftItem.Address); listingErr == nil {
+		return nil, fmt.Errorf("%w, cancel listing to transfer it", listing.ErrAlreadyListed)
+	} else if !errors.Is(listingErr, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("error checking nft item listing: %w", listingErr)
+	}
+
+	if _, auctionErr := v.auctionRepo.GetActiveAuctionByNftItem(svcCtx, nftItem.Address); auctionErr == nil {
+		return nil, fmt.Errorf("%w, cancel auction to transfer it", auction.ErrAlreadyOnAuction)
+	} else if !errors.Is(auctionErr, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("error checking nft item auction: %w", auctionErr)
+	}
+
+	transfer := nftitem.NewTransfer(nftItem.Address, ownerAccount.UUID, recipientAccount.UUID, nftItem.IsTestnet)
+	if _, transferErr := v.nftItemRepo.TransferNftItem(svcCtx, transfer); transferErr != nil {
+		return nil, transferErr
+	}
+
+	return transfer, nil
+}
+
+func (v *transferNftItemServiceRepo) GetNftItemHistory(ctx context.Context, nftItemAddress string) ([]nftitem.Transfer, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	if _, nftItemErr := v.nftItemRepo.GetNftItemByAddress(svcCtx, nftItemAddress); nftItemErr != nil {
+		return nil, fmt.Errorf("error getting nft item: %w", nftItemErr)
+	}
+
+	return v.nftItemRepo.GetNftItemTransfers(svcCtx, nftItemAddress)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
 	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
 	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
 	"github.com/rom6n/create-nft-go/internal/domain/user"
@@ -16,10 +17,13 @@ type UserServiceRepository interface {
 	GetUserByID(ctx context.Context, userID int64) (*user.User, error)
 	GetUserNftCollections(ctx context.Context, userID int64) []nftcollection.NftCollection
 	GetUserNftItems(ctx context.Context, userID int64) []nftitem.NftItem
+	GetUserBalanceEntries(ctx context.Context, userID int64) ([]ledger.BalanceEntry, error)
+	GetUserDepositMemo(userID int64) string
 }
 
 type userServiceRepo struct {
 	userRepo          user.UserRepository
+	ledgerRepo        ledger.LedgerRepository
 	nftCollectionRepo nftcollection.NftCollectionRepository
 	nftItemRepo       nftitem.NftItemRepository
 	timeout           time.Duration
@@ -27,6 +31,7 @@ type userServiceRepo struct {
 
 type UserServiceCfg struct {
 	UserRepo          user.UserRepository
+	LedgerRepo        ledger.LedgerRepository
 	NftCollectionRepo nftcollection.NftCollectionRepository
 	NftItemRepo       nftitem.NftItemRepository
 	Timeout           time.Duration
@@ -35,6 +40,7 @@ type UserServiceCfg struct {
 func New(cfg UserServiceCfg) UserServiceRepository {
 	return &userServiceRepo{
 		userRepo:          cfg.UserRepo,
+		ledgerRepo:        cfg.LedgerRepo,
 		nftCollectionRepo: cfg.NftCollectionRepo,
 		nftItemRepo:       cfg.NftItemRepo,
 		timeout:           cfg.Timeout,
@@ -53,7 +59,7 @@ func (v *userServiceRepo) GetUserByID(ctx context.Context, userID int64) (*user.
 	if dbErr != nil {
 		if dbErr == mongo.ErrNoDocuments {
 			newUuid := uuid.New()
-			user := user.NewUser(newUuid, userID, 1, "user", 0)
+			user := user.NewUser(newUuid, userID, 1, user.RoleUser, 0, 0)
 			createErr := v.userRepo.CreateUser(svcCtx, &user)
 			return &user, createErr
 		}
@@ -100,3 +106,19 @@ func (v *userServiceRepo) GetUserNftItems(ctx context.Context, userID int64) []n
 
 	return nftItems
 }
+
+func (v *userServiceRepo) GetUserBalanceEntries(ctx context.Context, userID int64) ([]ledger.BalanceEntry, error) {
+	svcCtx, cancel := v.getContext(ctx)
+	defer cancel()
+
+	user, userErr := v.userRepo.GetUserByID(svcCtx, userID)
+	if userErr != nil {
+		return nil, userErr
+	}
+
+	return v.ledgerRepo.GetEntriesByUserUuid(svcCtx, user.UUID)
+}
+
+func (v *userServiceRepo) GetUserDepositMemo(userID int64) string {
+	return user.DepositMemo(userID)
+}
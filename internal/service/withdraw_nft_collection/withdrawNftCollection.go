@@ -3,13 +3,20 @@ package withdrawnftcollection
 import (
 	"context"
 	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
 	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
 	"github.com/rom6n/create-nft-go/internal/domain/user"
+	operationtracker "github.com/rom6n/create-nft-go/internal/service/operation_tracker"
+	pricingservice "github.com/rom6n/create-nft-go/internal/service/pricing_service"
 	nftcollectionutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_collection_utils"
+	"github.com/rom6n/create-nft-go/internal/utils/tonutil"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/ton"
@@ -24,6 +31,9 @@ type WithdrawNftCollectionServiceRepository interface {
 type withdrawNftCollectionServiceRepo struct {
 	nftCollectionRepo nftcollection.NftCollectionRepository
 	userRepo          user.UserRepository
+	ledgerRepo        ledger.LedgerRepository
+	operationTracker  operationtracker.OperationTrackerRepository
+	pricingService    pricingservice.PricingServiceRepository
 	privateKey        ed25519.PrivateKey
 	testnetLiteClient *liteclient.ConnectionPool
 	mainnetLiteClient *liteclient.ConnectionPool
@@ -37,6 +47,9 @@ type withdrawNftCollectionServiceRepo struct {
 type WithdrawNftCollectionServiceCfg struct {
 	NftCollectionRepo nftcollection.NftCollectionRepository
 	UserRepo          user.UserRepository
+	LedgerRepo        ledger.LedgerRepository
+	OperationTracker  operationtracker.OperationTrackerRepository
+	PricingService    pricingservice.PricingServiceRepository
 	PrivateKey        ed25519.PrivateKey
 	TestnetLiteClient *liteclient.ConnectionPool
 	MainnetLiteClient *liteclient.ConnectionPool
@@ -51,6 +64,9 @@ func New(cfg WithdrawNftCollectionServiceCfg) WithdrawNftCollectionServiceReposi
 	return &withdrawNftCollectionServiceRepo{
 		cfg.NftCollectionRepo,
 		cfg.UserRepo,
+		cfg.LedgerRepo,
+		cfg.OperationTracker,
+		cfg.PricingService,
 		cfg.PrivateKey,
 		cfg.TestnetLiteClient,
 		cfg.MainnetLiteClient,
@@ -70,8 +86,6 @@ func (v *withdrawNftCollectionServiceRepo) WithdrawNftCollection(ctx context.Con
 	svcCtx, cancel := v.getContext(ctx)
 	defer cancel()
 
-	nanoTonForWithdraw := uint64(10000000)
-
 	client := v.testnetLiteClient
 	api := v.testnetLiteApi
 	walletAddress := v.testnetWallet.WalletAddress()
@@ -85,22 +99,27 @@ func (v *withdrawNftCollectionServiceRepo) WithdrawNftCollection(ctx context.Con
 
 	apiCtx := client.StickyContext(svcCtx)
 
+	quote, quoteErr := v.pricingService.Quote(svcCtx, pricing.QuoteRequest{Operation: pricing.OperationWithdrawNftCollection, IsTestnet: isTestnet})
+	if quoteErr != nil {
+		return fmt.Errorf("error quoting nft collection withdraw: %w", quoteErr)
+	}
+
 	ownerAccount, accErr := v.userRepo.GetUserByID(svcCtx, ownerID)
 	if accErr != nil {
-		return fmt.Errorf("error getting user's account: %v", accErr)
+		return fmt.Errorf("error getting user's account: %w", accErr)
 	}
 
-	if ownerAccount.NanoTon < nanoTonForWithdraw {
-		return fmt.Errorf("not enough ton, need %v more", nanoTonForWithdraw-ownerAccount.NanoTon)
+	if ownerAccount.Balance(isTestnet) < quote.Total {
+		return fmt.Errorf("%w: need %v more", ledger.ErrNotEnoughBalance, quote.Total-ownerAccount.Balance(isTestnet))
 	}
 
 	nftCollection, collectionErr := v.nftCollectionRepo.GetNftCollectionByAddress(svcCtx, nftCollectionAddress.String())
 	if collectionErr != nil {
-		return fmt.Errorf("error getting nft collection: %v", collectionErr)
+		return fmt.Errorf("error getting nft collection: %w", collectionErr)
 	}
 
 	if nftCollection.Owner != ownerAccount.UUID {
-		return fmt.Errorf("user must be an collection's owner to withdraw it")
+		return fmt.Errorf("%w to withdraw it", nftcollection.ErrNotCollectionOwner)
 	}
 
 	block, blockErr := api.GetMasterchainInfo(apiCtx)
@@ -116,13 +135,16 @@ func (v *withdrawNftCollectionServiceRepo) WithdrawNftCollection(ctx context.Con
 
 	// check if wallet is an owner of nft collection
 	if !walletAddress.Equals(collectionData.OwnerAddress) {
-		return fmt.Errorf("marketplace contract must be an nft collection's owner to withdraw nft collection")
+		return fmt.Errorf("%w to withdraw nft collection", nftcollection.ErrNotCustodial)
 	}
 
-	changeOwnerMsg := nftcollectionutils.PackChangeOwnerMsg(withdrawToAddress, nftCollectionAddress)
+	changeOwnerMsg := nftcollectionutils.PackChangeOwnerMsg(withdrawToAddress, nftCollectionAddress, quote.MessageAmount)
 
-	if updErr := v.userRepo.UpdateUserBalance(svcCtx, ownerAccount.UUID, ownerAccount.NanoTon-nanoTonForWithdraw); updErr != nil {
-		return fmt.Errorf("error reducing user's balance: %v", updErr)
+	if chargeErr := v.ledgerRepo.Apply(svcCtx,
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeDebit, quote.MessageAmount, nftCollectionAddress.String(), isTestnet),
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeFee, quote.Fee(), nftCollectionAddress.String(), isTestnet),
+	); chargeErr != nil {
+		return fmt.Errorf("error reducing user's balance: %w", chargeErr)
 	}
 
 	msg := &wallet.Message{
@@ -130,22 +152,21 @@ func (v *withdrawNftCollectionServiceRepo) WithdrawNftCollection(ctx context.Con
 		InternalMessage: changeOwnerMsg,
 	}
 
-	if msgErr := w.Send(apiCtx, msg, true); msgErr != nil {
-		for i := 0; i < 10; i++ {
-			if updErr := v.userRepo.UpdateUserBalance(svcCtx, ownerAccount.UUID, ownerAccount.NanoTon); updErr == nil {
-				break
-			}
-			log.Printf("Error returning %v ton to user, try: %v\n", nanoTonForWithdraw, i)
-			if i == 9 {
-				return fmt.Errorf("error returning ton to user & error sending withdraw nft collection external message: %v", msgErr)
-			}
-			time.Sleep(1 * time.Second)
-		}
-		return fmt.Errorf("error sending external message to withdraw nft collection: %v", msgErr)
+	// operation is tracked before sending, nft collection is deleted from db when withdraw is confirmed on chain
+	withdrawOperation := operation.New(operation.KindWithdrawNftCollection, ownerAccount.UUID, nftCollectionAddress.String(), changeOwnerMsg.Body.Hash(), quote.Total, nftCollectionAddress.String(), isTestnet)
+	if trackErr := v.operationTracker.Track(svcCtx, withdrawOperation); trackErr != nil {
+		return fmt.Errorf("error tracking nft collection withdraw: %w", trackErr)
 	}
 
-	if delErr := v.nftCollectionRepo.DeleteNftCollection(svcCtx, nftCollectionAddress.String()); delErr != nil {
-		log.Printf("error deleting nft collection from db after withdraw %v\n", delErr)
+	if msgErr := tonutil.SendWaitTransaction(apiCtx, api, w, msg); msgErr != nil {
+		if errors.Is(msgErr, tonutil.ErrNotSent) {
+			if failErr := v.operationTracker.Fail(svcCtx, withdrawOperation, msgErr.Error()); failErr != nil {
+				log.Printf("Error failing nft collection withdraw operation: %v\n", failErr)
+			}
+			return fmt.Errorf("error sending external message to withdraw nft collection: %v", msgErr)
+		}
+		// FYI: message can be delivered anyway, tracker settles it on chain
+		log.Printf("Nft collection withdraw is not confirmed, it is settled by tracker: %v\n", msgErr)
 	}
 
 	return nil
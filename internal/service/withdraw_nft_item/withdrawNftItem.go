@@ -3,18 +3,28 @@ package withdrawnftitem
 import (
 	"context"
 	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/rom6n/create-nft-go/internal/domain/auction"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
+	"github.com/rom6n/create-nft-go/internal/domain/listing"
 	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/domain/operation"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
 	"github.com/rom6n/create-nft-go/internal/domain/user"
+	operationtracker "github.com/rom6n/create-nft-go/internal/service/operation_tracker"
+	pricingservice "github.com/rom6n/create-nft-go/internal/service/pricing_service"
 	nftitemutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_item_utils"
+	"github.com/rom6n/create-nft-go/internal/utils/tonutil"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/nft"
 	"github.com/xssnick/tonutils-go/ton/wallet"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 type WithdrawNftItemServiceRepository interface {
@@ -24,6 +34,11 @@ type WithdrawNftItemServiceRepository interface {
 type withdrawNftItemServiceRepo struct {
 	nftItemRepo       nftitem.NftItemRepository
 	userRepo          user.UserRepository
+	ledgerRepo        ledger.LedgerRepository
+	operationTracker  operationtracker.OperationTrackerRepository
+	pricingService    pricingservice.PricingServiceRepository
+	listingRepo       listing.ListingRepository
+	auctionRepo       auction.AuctionRepository
 	privateKey        ed25519.PrivateKey
 	testnetLiteClient *liteclient.ConnectionPool
 	mainnetLiteClient *liteclient.ConnectionPool
@@ -37,6 +52,11 @@ type withdrawNftItemServiceRepo struct {
 type WithdrawNftItemServiceCfg struct {
 	NftItemRepo       nftitem.NftItemRepository
 	UserRepo          user.UserRepository
+	LedgerRepo        ledger.LedgerRepository
+	OperationTracker  operationtracker.OperationTrackerRepository
+	PricingService    pricingservice.PricingServiceRepository
+	ListingRepo       listing.ListingRepository
+	AuctionRepo       auction.AuctionRepository
 	PrivateKey        ed25519.PrivateKey
 	TestnetLiteClient *liteclient.ConnectionPool
 	MainnetLiteClient *liteclient.ConnectionPool
@@ -51,6 +71,11 @@ func New(cfg WithdrawNftItemServiceCfg) WithdrawNftItemServiceRepository {
 	return &withdrawNftItemServiceRepo{
 		cfg.NftItemRepo,
 		cfg.UserRepo,
+		cfg.LedgerRepo,
+		cfg.OperationTracker,
+		cfg.PricingService,
+		cfg.ListingRepo,
+		cfg.AuctionRepo,
 		cfg.PrivateKey,
 		cfg.TestnetLiteClient,
 		cfg.MainnetLiteClient,
@@ -70,8 +95,6 @@ func (v *withdrawNftItemServiceRepo) WithdrawNftItem(ctx context.Context, nftIte
 	svcCtx, cancel := v.getContext(ctx)
 	defer cancel()
 
-	nanoTonForWithdraw := uint64(30000000)
-
 	client := v.testnetLiteClient
 	api := v.testnetLiteApi
 	walletAddress := v.testnetWallet.WalletAddress()
@@ -85,22 +108,56 @@ func (v *withdrawNftItemServiceRepo) WithdrawNftItem(ctx context.Context, nftIte
 
 	apiCtx := client.StickyContext(svcCtx)
 
+	quote, quoteErr := v.pricingService.Quote(svcCtx, pricing.QuoteRequest{Operation: pricing.OperationWithdrawNftItem, IsTestnet: isTestnet})
+	if quoteErr != nil {
+		return fmt.Errorf("error quoting nft item withdraw: %w", quoteErr)
+	}
+
 	ownerAccount, accErr := v.userRepo.GetUserByID(svcCtx, ownerID)
 	if accErr != nil {
-		return fmt.Errorf("error getting user's account: %v", accErr)
+		return fmt.Errorf("error getting user's account: %w", accErr)
 	}
 
-	if ownerAccount.NanoTon < nanoTonForWithdraw {
-		return fmt.Errorf("not enough ton, need %v more", nanoTonForWithdraw-ownerAccount.NanoTon)
+	if ownerAccount.Balance(isTestnet) < quote.Total {
+		return fmt.Errorf("%w: need %v more", ledger.ErrNotEnoughBalance, quote.Total-ownerAccount.Balance(isTestnet))
 	}
 
 	nftItem, nftItemErr := v.nftItemRepo.GetNftItemByAddress(svcCtx, nftItemAddress.String())
 	if nftItemErr != nil {
-		return fmt.Errorf("error getting nft item: %v", nftItemErr)
+		return fmt.Errorf("error getting nft item: %w", nftItemErr)
 	}
 
 	if nftItem.Owner != ownerAccount.UUID {
-		return fmt.Errorf("user must be an item's owner to withdraw it")
+		return fmt.Errorf("%w to withdraw it", nftitem.ErrNotItemOwner)
+	}
+
+	// nft item is marked before listing and auction checks, so it can't be transferred, listed or sold while withdraw is sent
+	if markErr := v.nftItemRepo.MarkNftItemWithdrawing(svcCtx, nftItem.Address, ownerAccount.UUID); markErr != nil {
+		return fmt.Errorf("error marking nft item as withdrawing: %w", markErr)
+	}
+
+	// tracked withdraw is returned to owner by operation tracker if it fails
+	isTracked := false
+	defer func() {
+		if isTracked {
+			return
+		}
+		if unmarkErr := v.nftItemRepo.UnmarkNftItemWithdrawing(svcCtx, nftItem.Address); unmarkErr != nil {
+			log.Printf("Error unmarking nft item %v as withdrawing: %v\n", nftItem.Address, unmarkErr)
+		}
+	}()
+
+	// listed nft item can be bought while withdraw is confirmed on chain
+	if _, listingErr := v.listingRepo.GetActiveListingByNftItem(svcCtx, nftItem.Address); listingErr == nil {
+		return fmt.Errorf("%w, cancel listing to withdraw it", listing.ErrAlreadyListed)
+	} else if !errors.Is(listingErr, mongo.ErrNoDocuments) {
+		return fmt.Errorf("error checking nft item listing: %w", listingErr)
+	}
+
+	if _, auctionErr := v.auctionRepo.GetActiveAuctionByNftItem(svcCtx, nftItem.Address); auctionErr == nil {
+		return fmt.Errorf("%w, cancel auction to withdraw it", auction.ErrAlreadyOnAuction)
+	} else if !errors.Is(auctionErr, mongo.ErrNoDocuments) {
+		return fmt.Errorf("error checking nft item auction: %w", auctionErr)
 	}
 
 	block, blockErr := api.GetMasterchainInfo(apiCtx)
@@ -115,13 +172,16 @@ func (v *withdrawNftItemServiceRepo) WithdrawNftItem(ctx context.Context, nftIte
 	}
 
 	if !walletAddress.Equals(nftItemData.OwnerAddress) {
-		return fmt.Errorf("marketplace contract must be an nft item's owner to withdraw nft item")
+		return fmt.Errorf("%w to withdraw nft item", nftitem.ErrNotCustodial)
 	}
 
-	changeOwnerMsg := nftitemutils.PackChangeOwnerMsg(withdrawToAddress, walletAddress, nftItemAddress)
+	changeOwnerMsg := nftitemutils.PackChangeOwnerMsg(withdrawToAddress, walletAddress, nftItemAddress, quote.MessageAmount, quote.ForwardAmount)
 
-	if updErr := v.userRepo.UpdateUserBalance(svcCtx, ownerAccount.UUID, ownerAccount.NanoTon-nanoTonForWithdraw); updErr != nil {
-		return fmt.Errorf("error reducing user's balance: %v", updErr)
+	if chargeErr := v.ledgerRepo.Apply(svcCtx,
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeDebit, quote.MessageAmount, nftItemAddress.String(), isTestnet),
+		ledger.NewBalanceEntry(ownerAccount.UUID, ledger.EntryTypeFee, quote.Fee(), nftItemAddress.String(), isTestnet),
+	); chargeErr != nil {
+		return fmt.Errorf("error reducing user's balance: %w", chargeErr)
 	}
 
 	msg := &wallet.Message{
@@ -129,22 +189,22 @@ func (v *withdrawNftItemServiceRepo) WithdrawNftItem(ctx context.Context, nftIte
 		InternalMessage: changeOwnerMsg,
 	}
 
-	if msgErr := w.Send(apiCtx, msg, true); msgErr != nil {
-		for i := 0; i < 10; i++ {
-			if updErr := v.userRepo.UpdateUserBalance(svcCtx, ownerAccount.UUID, ownerAccount.NanoTon); updErr == nil {
-				break
-			}
-			log.Printf("Error returning %v ton to user, try: %v\n", nanoTonForWithdraw, i)
-			if i == 9 {
-				return fmt.Errorf("error returning ton to user & error sending withdraw nft item external message: %v", msgErr)
-			}
-			time.Sleep(1 * time.Second)
-		}
-		return fmt.Errorf("error sending external message to withdraw nft item: %v", msgErr)
+	// operation is tracked before sending, nft item is deleted from db when withdraw is confirmed on chain
+	withdrawOperation := operation.New(operation.KindWithdrawNftItem, ownerAccount.UUID, nftItemAddress.String(), changeOwnerMsg.Body.Hash(), quote.Total, nftItemAddress.String(), isTestnet)
+	if trackErr := v.operationTracker.Track(svcCtx, withdrawOperation); trackErr != nil {
+		return fmt.Errorf("error tracking nft item withdraw: %w", trackErr)
 	}
+	isTracked = true
 
-	if delErr := v.nftItemRepo.DeleteNftItem(svcCtx, nftItemAddress.String()); delErr != nil {
-		log.Printf("error deleting nft item from db after withdraw %v\n", delErr)
+	if msgErr := tonutil.SendWaitTransaction(apiCtx, api, w, msg); msgErr != nil {
+		if errors.Is(msgErr, tonutil.ErrNotSent) {
+			if failErr := v.operationTracker.Fail(svcCtx, withdrawOperation, msgErr.Error()); failErr != nil {
+				log.Printf("Error failing nft item withdraw operation: %v\n", failErr)
+			}
+			return fmt.Errorf("error sending external message to withdraw nft item: %v", msgErr)
+		}
+		// FYI: message can be delivered anyway, tracker settles it on chain
+		log.Printf("Nft item withdraw is not confirmed, it is settled by tracker: %v\n", msgErr)
 	}
 
 	return nil
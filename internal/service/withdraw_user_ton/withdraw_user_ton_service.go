@@ -2,64 +2,80 @@ package withdraw_user_ton
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/rom6n/create-nft-go/internal/domain/ledger"
 	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"github.com/rom6n/create-nft-go/internal/domain/withdrawal"
+	"github.com/rom6n/create-nft-go/internal/utils/tonutil"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/wallet"
 )
 
+// sentScanLimit is count of wallet transactions scanned for message of sent withdrawal
+const sentScanLimit = 300
+
 type WithdrawUserTonRepository interface {
 	Withdraw(ctx context.Context, userID int64, amount uint64, withdrawToAddress *address.Address, isTestnet bool) error
-	WithdrawQueue()
+	// WithdrawQueue sends pending withdrawals one by one until ctx is done. Current transfer is finished before return
+	WithdrawQueue(ctx context.Context)
 }
 
 type withdrawUserTonRepo struct {
 	userRepo          user.UserRepository
+	ledgerRepo        ledger.LedgerRepository
+	withdrawalRepo    withdrawal.WithdrawalRepository
 	testnetLiteClient *liteclient.ConnectionPool
 	mainnetLiteClient *liteclient.ConnectionPool
+	testnetLiteApi    ton.APIClientWrapped
+	mainnetLiteApi    ton.APIClientWrapped
 	testnetWallet     *wallet.Wallet
 	mainnetWallet     *wallet.Wallet
-	queueChannel      chan *WithdrawRequest
+	queueChannel      chan struct{}
+	pollInterval      time.Duration
+	confirmTimeout    time.Duration
 	timeout           time.Duration
 }
 
 type WithdrawUserTonCfg struct {
 	UserRepo          user.UserRepository
+	LedgerRepo        ledger.LedgerRepository
+	WithdrawalRepo    withdrawal.WithdrawalRepository
 	TestnetLiteClient *liteclient.ConnectionPool
 	MainnetLiteClient *liteclient.ConnectionPool
+	TestnetLiteApi    ton.APIClientWrapped
+	MainnetLiteApi    ton.APIClientWrapped
 	TestnetWallet     *wallet.Wallet
 	MainnetWallet     *wallet.Wallet
-	QueueChannel      chan *WithdrawRequest
+	PollInterval      time.Duration // how often queue checks database for pending withdrawals without notification
+	ConfirmTimeout    time.Duration // sent withdrawal without its message on chain after this time is failed
 	Timeout           time.Duration
 }
 
 func New(cfg WithdrawUserTonCfg) WithdrawUserTonRepository {
 	return &withdrawUserTonRepo{
 		userRepo:          cfg.UserRepo,
+		ledgerRepo:        cfg.LedgerRepo,
+		withdrawalRepo:    cfg.WithdrawalRepo,
 		testnetLiteClient: cfg.TestnetLiteClient,
 		mainnetLiteClient: cfg.MainnetLiteClient,
+		testnetLiteApi:    cfg.TestnetLiteApi,
+		mainnetLiteApi:    cfg.MainnetLiteApi,
 		testnetWallet:     cfg.TestnetWallet,
 		mainnetWallet:     cfg.MainnetWallet,
-		queueChannel:      cfg.QueueChannel,
+		queueChannel:      make(chan struct{}, 1),
+		pollInterval:      cfg.PollInterval,
+		confirmTimeout:    cfg.ConfirmTimeout,
 		timeout:           cfg.Timeout,
 	}
 }
 
-type WithdrawRequest struct {
-	Wallet            *wallet.Wallet
-	Ctx               context.Context
-	WithdrawToAddress *address.Address
-	Amount            tlb.Coins
-	UserUUID          uuid.UUID
-	UserNanoTON       uint64
-}
-
 func (v *withdrawUserTonRepo) getContext(ctx context.Context) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(ctx, v.timeout)
 }
@@ -68,59 +84,223 @@ func (v *withdrawUserTonRepo) Withdraw(ctx context.Context, userID int64, amount
 	svcCtx, cancel := v.getContext(ctx)
 	defer cancel()
 
-	//client := v.testnetLiteClient
+	user, getErr := v.userRepo.GetUserByID(svcCtx, userID)
+	if getErr != nil {
+		return fmt.Errorf("error getting user by ID: %w", getErr)
+	}
+	if user.Balance(isTestnet) < amount {
+		return fmt.Errorf("%w: need %v more", ledger.ErrNotEnoughBalance, amount-user.Balance(isTestnet))
+	}
+
+	request := withdrawal.New(user.UUID, amount, withdrawToAddress.String(), isTestnet)
+
+	// balance is reduced only together with saved request, so the queue refunds every debited withdrawal
+	txErr := v.withdrawalRepo.WithTransaction(svcCtx, func(txCtx context.Context) error {
+		if chargeErr := v.ledgerRepo.Apply(txCtx, ledger.NewBalanceEntry(user.UUID, ledger.EntryTypeWithdrawal, amount, request.ID.String(), request.IsTestnet)); chargeErr != nil {
+			return fmt.Errorf("error charging withdrawal: %w", chargeErr)
+		}
+
+		if createErr := v.withdrawalRepo.CreateWithdrawal(txCtx, request); createErr != nil {
+			return fmt.Errorf("error saving withdrawal request: %w", createErr)
+		}
+
+		return nil
+	})
+	if txErr != nil {
+		return txErr
+	}
+
+	// wake up the queue, if it is busy it will find the request in database
+	select {
+	case v.queueChannel <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (v *withdrawUserTonRepo) WithdrawQueue(ctx context.Context) {
+	log.Printf("Withdraw queue is running")
+
+	ticker := time.NewTicker(v.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// withdrawals sent before restart or with unknown result are settled first, so they are never sent twice
+		v.settleSentWithdrawals(ctx)
+		v.processPendingWithdrawals(ctx)
+		// failed withdrawals which were not returned to user's balance are retried
+		v.refundFailedWithdrawals(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Withdraw queue is stopped")
+			return
+		case <-v.queueChannel:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (v *withdrawUserTonRepo) processPendingWithdrawals(ctx context.Context) {
+	pending, getErr := v.withdrawalRepo.GetWithdrawalsByStatus(ctx, withdrawal.StatusPending)
+	if getErr != nil {
+		log.Printf("error getting pending withdrawals: %v\n", getErr)
+		return
+	}
+
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+
+		v.processWithdrawal(&pending[i])
+
+		// waiting for wallet seqno to change before next transfer
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(15 * time.Second):
+		}
+	}
+}
+
+// processWithdrawal is not bound to queue context so shutdown doesn't interrupt the transfer in the middle
+func (v *withdrawUserTonRepo) processWithdrawal(request *withdrawal.Withdrawal) {
+	svcCtx, cancel := v.getContext(context.Background())
+	defer cancel()
+
+	if claimErr := v.withdrawalRepo.UpdateWithdrawalStatus(svcCtx, request.ID, withdrawal.StatusPending, withdrawal.StatusSent, ""); claimErr != nil {
+		log.Printf("error taking withdrawal %v: %v\n", request.ID, claimErr)
+		return
+	}
+	request.Status = withdrawal.StatusSent
+	request.UpdatedAt = time.Now()
+
+	client := v.testnetLiteClient
+	api := v.testnetLiteApi
 	w := v.testnetWallet
-	if !isTestnet {
+	if !request.IsTestnet {
+		client = v.mainnetLiteClient
+		api = v.mainnetLiteApi
 		w = v.mainnetWallet
-		//client = v.mainnetLiteClient
 	}
 
-	//apiCtx := client.StickyContext(svcCtx)
+	withdrawToAddress, parseErr := address.ParseAddr(request.WithdrawTo)
+	if parseErr != nil {
+		log.Printf("error parsing withdrawal %v address: %v", request.ID, parseErr)
+		v.failWithdrawal(svcCtx, request, parseErr.Error())
+		return
+	}
+
+	transfer, buildErr := w.BuildTransfer(withdrawToAddress, tlb.FromNanoTONU(request.Amount), true, request.Comment())
+	if buildErr != nil {
+		log.Printf("error building withdrawal %v transfer: %v", request.ID, buildErr)
+		v.failWithdrawal(svcCtx, request, buildErr.Error())
+		return
+	}
+
+	if sendErr := tonutil.SendWaitTransaction(client.StickyContext(svcCtx), api, w, transfer); sendErr != nil {
+		if errors.Is(sendErr, tonutil.ErrNotSent) {
+			log.Printf("error withdrawing ton: %v", sendErr)
+			v.failWithdrawal(svcCtx, request, sendErr.Error())
+			return
+		}
+		// transfer can be in blockchain already, it stays sent until its message is found or confirm timeout passes
+		log.Printf("withdrawal %v result is unknown, it will be settled on chain: %v\n", request.ID, sendErr)
+		return
+	}
+
+	// wallet transaction is found, but its action phase can fail, so withdrawal is confirmed by its message
+	v.settleWithdrawal(svcCtx, request)
+}
 
-	user, getErr := v.userRepo.GetUserByID(ctx, userID)
+func (v *withdrawUserTonRepo) settleSentWithdrawals(ctx context.Context) {
+	sent, getErr := v.withdrawalRepo.GetWithdrawalsByStatus(ctx, withdrawal.StatusSent)
 	if getErr != nil {
-		return fmt.Errorf("error getting user by ID: %w", getErr)
+		log.Printf("error getting sent withdrawals: %v\n", getErr)
+		return
+	}
+
+	for i := range sent {
+		if ctx.Err() != nil {
+			return
+		}
+
+		svcCtx, cancel := v.getContext(ctx)
+		v.settleWithdrawal(svcCtx, &sent[i])
+		cancel()
 	}
-	if user.NanoTon < amount {
-		return fmt.Errorf("not enough balance")
+}
+
+// settleWithdrawal confirms sent withdrawal if its message is sent by wallet on chain.
+// Withdrawal without message after confirm timeout is failed, its external message is expired by then
+func (v *withdrawUserTonRepo) settleWithdrawal(ctx context.Context, request *withdrawal.Withdrawal) {
+	api := v.testnetLiteApi
+	w := v.testnetWallet
+	if !request.IsTestnet {
+		api = v.mainnetLiteApi
+		w = v.mainnetWallet
 	}
 
-	updErr := v.userRepo.UpdateUserBalance(svcCtx, user.UUID, user.NanoTon-amount)
-	if updErr != nil {
-		return fmt.Errorf("error updating user's balance 2: %w", updErr)
+	body, commentErr := wallet.CreateCommentCell(request.Comment())
+	if commentErr != nil {
+		log.Printf("error building withdrawal %v comment: %v\n", request.ID, commentErr)
+		return
 	}
 
-	go func() {
-		v.queueChannel <- &WithdrawRequest{
-			Wallet:            w,
-			WithdrawToAddress: withdrawToAddress,
-			Ctx:               context.Background(),
-			UserUUID:          user.UUID,
-			UserNanoTON:       user.NanoTon,
-			Amount:            tlb.FromNanoTONU(amount),
+	_, findErr := api.FindLastTransactionByOutMsgHash(ctx, w.WalletAddress(), body.Hash(), sentScanLimit)
+	if errors.Is(findErr, ton.ErrTxWasNotFound) {
+		if time.Since(request.UpdatedAt) < v.confirmTimeout {
+			return
 		}
-	}()
+		v.failWithdrawal(ctx, request, fmt.Sprintf("no transfer message on chain after %v", v.confirmTimeout))
+		return
+	}
+	if findErr != nil {
+		log.Printf("error looking for withdrawal %v transfer on chain, check it manually if it repeats: %v\n", request.ID, findErr)
+		return
+	}
 
-	return nil
+	if updErr := v.withdrawalRepo.UpdateWithdrawalStatus(ctx, request.ID, withdrawal.StatusSent, withdrawal.StatusConfirmed, ""); updErr != nil {
+		log.Printf("error confirming withdrawal %v: %v\n", request.ID, updErr)
+	}
 }
 
-func (v *withdrawUserTonRepo) WithdrawQueue() {
-	log.Printf("Withdraw queue is running")
-	for {
-		select {
-		case request := <-v.queueChannel:
-			if transferErr := request.Wallet.Transfer(request.Ctx, request.WithdrawToAddress, request.Amount, "Thanks for using Build NFT tma"); transferErr != nil {
-				updErr := v.userRepo.UpdateUserBalance(request.Ctx, request.UserUUID, request.UserNanoTON)
-				if updErr != nil {
-					log.Printf("error updating user's balance 2: %v. error withdrawing ton: %v", updErr, transferErr)
-					continue
-				}
-				log.Printf("error withdrawing ton: %v", transferErr)
-				continue
-			}
-			time.Sleep(15 * time.Second)
-		default:
-			continue
+// failWithdrawal is only called when transfer can't get to blockchain
+func (v *withdrawUserTonRepo) failWithdrawal(ctx context.Context, request *withdrawal.Withdrawal, reason string) {
+	if updErr := v.withdrawalRepo.UpdateWithdrawalStatus(ctx, request.ID, withdrawal.StatusSent, withdrawal.StatusFailed, reason); updErr != nil {
+		log.Printf("error marking withdrawal %v as failed: %v\n", request.ID, updErr)
+		return
+	}
+
+	v.returnFailedWithdrawal(ctx, request)
+}
+
+func (v *withdrawUserTonRepo) refundFailedWithdrawals(ctx context.Context) {
+	failed, getErr := v.withdrawalRepo.GetWithdrawalsByStatus(ctx, withdrawal.StatusFailed)
+	if getErr != nil {
+		log.Printf("error getting failed withdrawals: %v\n", getErr)
+		return
+	}
+
+	for i := range failed {
+		svcCtx, cancel := v.getContext(ctx)
+		v.returnFailedWithdrawal(svcCtx, &failed[i])
+		cancel()
+	}
+}
+
+func (v *withdrawUserTonRepo) returnFailedWithdrawal(ctx context.Context, request *withdrawal.Withdrawal) {
+	// refund and refunded status are saved together, so withdrawal is never refunded twice
+	txErr := v.withdrawalRepo.WithTransaction(ctx, func(txCtx context.Context) error {
+		if refundErr := v.ledgerRepo.Apply(txCtx, ledger.NewBalanceEntry(request.UserUUID, ledger.EntryTypeRefund, request.Amount, request.ID.String(), request.IsTestnet)); refundErr != nil {
+			return fmt.Errorf("error returning withdrawal to user's balance: %w", refundErr)
 		}
+
+		return v.withdrawalRepo.UpdateWithdrawalStatus(txCtx, request.ID, withdrawal.StatusFailed, withdrawal.StatusRefunded, "")
+	})
+	if txErr != nil {
+		log.Printf("error refunding withdrawal %v: %v\n", request.ID, txErr)
 	}
 }
@@ -1,3 +1,104 @@
 package storage
 
-//f
\ No newline at end of file
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// ElasticSearchClient is a thin client over Elasticsearch REST API
+type ElasticSearchClient struct {
+	url        string
+	httpClient *http.Client
+}
+
+type ElasticSearchError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ElasticSearchError) Error() string {
+	return fmt.Sprintf("elasticsearch responded with status %v: %v", e.StatusCode, e.Body)
+}
+
+func NewElasticSearchClient() *ElasticSearchClient {
+	url := os.Getenv("ELASTICSEARCH_URL")
+	if url == "" {
+		log.Fatal("Error. Add Elasticsearch URL to env.")
+	}
+
+	return NewElasticSearchClientWithURL(url, &http.Client{Timeout: 15 * time.Second})
+}
+
+func NewElasticSearchClientWithURL(url string, httpClient *http.Client) *ElasticSearchClient {
+	return &ElasticSearchClient{
+		url:        strings.TrimSuffix(url, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// Request sends body to path and decodes response to result if it isnt nil. Not 2xx responses are returned as *ElasticSearchError
+func (c *ElasticSearchClient) Request(ctx context.Context, method string, path string, contentType string, body []byte, result any) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, reqErr := http.NewRequestWithContext(ctx, method, c.url+path, bodyReader)
+	if reqErr != nil {
+		return fmt.Errorf("error creating elasticsearch request: %w", reqErr)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, doErr := c.httpClient.Do(req)
+	if doErr != nil {
+		return fmt.Errorf("error sending elasticsearch request: %w", doErr)
+	}
+	defer resp.Body.Close()
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return fmt.Errorf("error reading elasticsearch response: %w", readErr)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &ElasticSearchError{
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+		}
+	}
+
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+
+	if decodeErr := json.Unmarshal(respBody, result); decodeErr != nil {
+		return fmt.Errorf("error decoding elasticsearch response: %w", decodeErr)
+	}
+
+	return nil
+}
+
+// RequestJSON sends value encoded to json
+func (c *ElasticSearchClient) RequestJSON(ctx context.Context, method string, path string, value any, result any) error {
+	var body []byte
+	if value != nil {
+		encoded, encodeErr := json.Marshal(value)
+		if encodeErr != nil {
+			return fmt.Errorf("error encoding elasticsearch request: %w", encodeErr)
+		}
+		body = encoded
+	}
+
+	return c.Request(ctx, method, path, "application/json", body, result)
+}
@@ -1,8 +1,11 @@
 package storage
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
+	"sync"
 
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -20,3 +23,58 @@ func NewMongoClient() *mongo.Client {
 	}
 	return client
 }
+
+// WithTransaction runs fn in transaction. If ctx already carries a session of outer transaction, fn joins it,
+// so repositories can be combined in one transaction by their caller
+func WithTransaction(ctx context.Context, client *mongo.Client, fn func(txCtx context.Context) error) error {
+	if InTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	session, sessionErr := client.StartSession()
+	if sessionErr != nil {
+		return fmt.Errorf("error starting session: %v", sessionErr)
+	}
+	defer session.EndSession(ctx)
+
+	var commitHooks *afterCommitHooks
+	_, txErr := session.WithTransaction(ctx, func(txCtx context.Context) (any, error) {
+		// transaction can be retried, hooks of aborted attempt are dropped
+		commitHooks = &afterCommitHooks{}
+		return nil, fn(context.WithValue(txCtx, afterCommitKey{}, commitHooks))
+	})
+	if txErr != nil {
+		return txErr
+	}
+
+	for _, hook := range commitHooks.hooks {
+		hook(ctx)
+	}
+	return nil
+}
+
+// InTransaction reports whether ctx carries a session of transaction
+func InTransaction(ctx context.Context) bool {
+	return mongo.SessionFromContext(ctx) != nil
+}
+
+type afterCommitKey struct{}
+
+type afterCommitHooks struct {
+	mu    sync.Mutex
+	hooks []func(ctx context.Context)
+}
+
+// AfterCommit runs hook after transaction of ctx is committed, it is dropped if transaction is aborted.
+// Without transaction hook is run right away
+func AfterCommit(ctx context.Context, hook func(ctx context.Context)) {
+	commitHooks, ok := ctx.Value(afterCommitKey{}).(*afterCommitHooks)
+	if !ok {
+		hook(ctx)
+		return
+	}
+
+	commitHooks.mu.Lock()
+	defer commitHooks.mu.Unlock()
+	commitHooks.hooks = append(commitHooks.hooks, hook)
+}
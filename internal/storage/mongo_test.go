@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAfterCommitWithoutTransaction(t *testing.T) {
+	called := false
+
+	AfterCommit(context.Background(), func(context.Context) { called = true })
+
+	if !called {
+		t.Fatalf("hook isnt run without transaction")
+	}
+}
+
+func TestAfterCommitInTransaction(t *testing.T) {
+	commitHooks := &afterCommitHooks{}
+	txCtx := context.WithValue(newSessionContext(t), afterCommitKey{}, commitHooks)
+	var calls []int
+
+	AfterCommit(txCtx, func(context.Context) { calls = append(calls, 1) })
+	AfterCommit(txCtx, func(context.Context) { calls = append(calls, 2) })
+	if len(calls) != 0 {
+		t.Fatalf("hooks are run before commit")
+	}
+
+	for _, hook := range commitHooks.hooks {
+		hook(context.Background())
+	}
+	if len(calls) != 2 || calls[0] != 1 || calls[1] != 2 {
+		t.Fatalf("hooks are run as %v, want in order of registration", calls)
+	}
+}
+
+func TestInTransaction(t *testing.T) {
+	if InTransaction(context.Background()) {
+		t.Fatalf("InTransaction() = true without session")
+	}
+	if !InTransaction(newSessionContext(t)) {
+		t.Fatalf("InTransaction() = false with session")
+	}
+}
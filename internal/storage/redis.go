@@ -1 +1,87 @@
 package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func NewRedisClient() *redis.Client {
+	uri := os.Getenv("REDIS_URL")
+	if uri == "" {
+		log.Fatal("Error. Add Redis URL to env.")
+	}
+
+	opts, err := redis.ParseURL(uri)
+	if err != nil {
+		log.Fatalf("Error. Redis URL is not valid: %v\n", err)
+	}
+
+	return redis.NewClient(opts)
+}
+
+// values are cached in the same bson form they have in database
+type cacheEnvelope[T any] struct {
+	Value T `bson:"value"`
+}
+
+// GetCached returns cached value of key. Redis errors are logged and treated as cache miss.
+// Inside transaction it always misses, so checks of transaction read database instead of values cached before it
+func GetCached[T any](ctx context.Context, client redis.UniversalClient, key string) (*T, bool) {
+	if InTransaction(ctx) {
+		return nil, false
+	}
+
+	data, getErr := client.Get(ctx, key).Bytes()
+	if getErr != nil {
+		if !errors.Is(getErr, redis.Nil) {
+			log.Printf("Error getting %v from cache: %v\n", key, getErr)
+		}
+		return nil, false
+	}
+
+	var envelope cacheEnvelope[T]
+	if decodeErr := bson.Unmarshal(data, &envelope); decodeErr != nil {
+		log.Printf("Error decoding %v from cache: %v\n", key, decodeErr)
+		return nil, false
+	}
+
+	return &envelope.Value, true
+}
+
+// SetCached caches value of key for ttl. Redis errors are logged only.
+// Values read inside transaction arent cached, transaction can be aborted
+func SetCached[T any](ctx context.Context, client redis.UniversalClient, key string, value T, ttl time.Duration) {
+	if InTransaction(ctx) {
+		return
+	}
+
+	data, encodeErr := bson.Marshal(cacheEnvelope[T]{Value: value})
+	if encodeErr != nil {
+		log.Printf("Error encoding %v for cache: %v\n", key, encodeErr)
+		return
+	}
+
+	if setErr := client.Set(ctx, key, data, ttl).Err(); setErr != nil {
+		log.Printf("Error setting %v to cache: %v\n", key, setErr)
+	}
+}
+
+// DeleteCached removes keys from cache. Redis errors are logged only, stale values live until ttl.
+// Inside transaction keys are removed after commit, otherwise cache could be refilled with values before commit
+func DeleteCached(ctx context.Context, client redis.UniversalClient, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	AfterCommit(ctx, func(ctx context.Context) {
+		if delErr := client.Del(ctx, keys...).Err(); delErr != nil {
+			log.Printf("Error deleting %v from cache: %v\n", keys, delErr)
+		}
+	})
+}
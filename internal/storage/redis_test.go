@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	t.Helper()
+	server, runErr := miniredis.Run()
+	if runErr != nil {
+		t.Fatalf("error running miniredis: %v", runErr)
+	}
+	t.Cleanup(server.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+// newSessionContext returns ctx carrying a session, session doesnt connect to mongo until it is used
+func newSessionContext(t *testing.T) context.Context {
+	t.Helper()
+	client, connectErr := mongo.Connect(options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if connectErr != nil {
+		t.Fatalf("error creating mongo client: %v", connectErr)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+
+	session, sessionErr := client.StartSession()
+	if sessionErr != nil {
+		t.Fatalf("error starting session: %v", sessionErr)
+	}
+	t.Cleanup(func() { session.EndSession(context.Background()) })
+
+	return mongo.NewSessionContext(context.Background(), session)
+}
+
+type cachedValue struct {
+	Name  string `bson:"name"`
+	Count int64  `bson:"count"`
+}
+
+func TestGetCachedMiss(t *testing.T) {
+	_, client := newTestRedis(t)
+
+	if value, ok := GetCached[cachedValue](context.Background(), client, "missing"); ok {
+		t.Fatalf("GetCached() = %v, want miss", value)
+	}
+}
+
+func TestSetCachedThenGetCached(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+
+	SetCached(ctx, client, "value", cachedValue{Name: "item", Count: 3}, time.Minute)
+
+	value, ok := GetCached[cachedValue](ctx, client, "value")
+	if !ok {
+		t.Fatalf("GetCached() missed cached value")
+	}
+	if *value != (cachedValue{Name: "item", Count: 3}) {
+		t.Fatalf("GetCached() = %v", *value)
+	}
+	if ttl := server.TTL("value"); ttl != time.Minute {
+		t.Fatalf("ttl = %v, want %v", ttl, time.Minute)
+	}
+}
+
+func TestGetCachedTreatsUndecodableValueAsMiss(t *testing.T) {
+	server, client := newTestRedis(t)
+	server.Set("value", "not bson")
+
+	if _, ok := GetCached[cachedValue](context.Background(), client, "value"); ok {
+		t.Fatalf("GetCached() decoded invalid value")
+	}
+}
+
+func TestGetCachedTreatsRedisErrorAsMiss(t *testing.T) {
+	server, client := newTestRedis(t)
+	SetCached(context.Background(), client, "value", cachedValue{Name: "item"}, time.Minute)
+	server.Close()
+
+	if _, ok := GetCached[cachedValue](context.Background(), client, "value"); ok {
+		t.Fatalf("GetCached() hit without redis")
+	}
+}
+
+func TestDeleteCached(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+	SetCached(ctx, client, "first", cachedValue{Name: "first"}, time.Minute)
+	SetCached(ctx, client, "second", cachedValue{Name: "second"}, time.Minute)
+
+	DeleteCached(ctx, client, "first", "second")
+
+	if server.Exists("first") || server.Exists("second") {
+		t.Fatalf("keys are still cached: %v", server.Keys())
+	}
+}
+
+func TestCacheIsBypassedInTransaction(t *testing.T) {
+	server, client := newTestRedis(t)
+	SetCached(context.Background(), client, "value", cachedValue{Name: "before"}, time.Minute)
+	txCtx := newSessionContext(t)
+
+	if _, ok := GetCached[cachedValue](txCtx, client, "value"); ok {
+		t.Fatalf("GetCached() hit inside transaction")
+	}
+
+	SetCached(txCtx, client, "other", cachedValue{Name: "uncommitted"}, time.Minute)
+	if server.Exists("other") {
+		t.Fatalf("value read inside transaction is cached")
+	}
+}
+
+func TestDeleteCachedWaitsForCommit(t *testing.T) {
+	server, client := newTestRedis(t)
+	SetCached(context.Background(), client, "value", cachedValue{Name: "before"}, time.Minute)
+
+	commitHooks := &afterCommitHooks{}
+	txCtx := context.WithValue(newSessionContext(t), afterCommitKey{}, commitHooks)
+
+	DeleteCached(txCtx, client, "value")
+	if !server.Exists("value") {
+		t.Fatalf("key is deleted before commit")
+	}
+
+	// WithTransaction runs hooks after commit
+	for _, hook := range commitHooks.hooks {
+		hook(context.Background())
+	}
+	if server.Exists("value") {
+		t.Fatalf("key isnt deleted after commit")
+	}
+}
@@ -0,0 +1,324 @@
+// Package contentlink resolves content links of TON nft metadata. Besides http links metadata and images
+// are linked as ipfs://CID/path and tonstorage://BAG_ID/path, they are fetched through http gateways
+package contentlink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrUnsupportedLink = errors.New("content link must be https, ipfs or tonstorage link")
+	ErrFetchFailed     = errors.New("content link cant be fetched")
+	ErrBlockedAddress  = errors.New("content link points to private address")
+)
+
+const (
+	FetchTimeout   = 10 * time.Second
+	MaxContentSize = 1 << 20 // 1MB
+	MaxRedirects   = 3
+)
+
+const (
+	SchemeHTTPS      = "https://"
+	SchemeHTTP       = "http://"
+	SchemeIPFS       = "ipfs://"
+	SchemeTONStorage = "tonstorage://"
+)
+
+// Gateways are http gateways links are resolved through, in order they are tried.
+// Gateway is url prefix which CID or bag id with path is appended to
+type Gateways struct {
+	IPFS       []string
+	TONStorage []string
+}
+
+var DefaultGateways = Gateways{
+	IPFS:       []string{"https://ipfs.io/ipfs/", "https://dweb.link/ipfs/", "https://gateway.pinata.cloud/ipfs/"},
+	TONStorage: []string{"https://tonbyte.com/gateway/"},
+}
+
+var (
+	gatewaysMu sync.RWMutex
+	gateways   = DefaultGateways
+)
+
+// SetGateways replaces gateways used by Resolve and Fetch
+func SetGateways(newGateways Gateways) {
+	gatewaysMu.Lock()
+	defer gatewaysMu.Unlock()
+
+	gateways = newGateways
+}
+
+// GetGateways returns gateways of IPFS_GATEWAYS and TON_STORAGE_GATEWAYS comma separated env vars, defaults if they arent set
+func GetGateways() Gateways {
+	result := DefaultGateways
+	if rawGateways := os.Getenv("IPFS_GATEWAYS"); rawGateways != "" {
+		result.IPFS = splitGateways(rawGateways)
+	}
+	if rawGateways := os.Getenv("TON_STORAGE_GATEWAYS"); rawGateways != "" {
+		result.TONStorage = splitGateways(rawGateways)
+	}
+
+	for _, gateway := range append(result.IPFS, result.TONStorage...) {
+		if !strings.HasPrefix(gateway, SchemeHTTPS) && !strings.HasPrefix(gateway, SchemeHTTP) {
+			log.Fatalf("content gateway must be http url: %v \n", gateway)
+		}
+	}
+
+	return result
+}
+
+func splitGateways(rawGateways string) []string {
+	var result []string
+	for _, gateway := range strings.Split(rawGateways, ",") {
+		if gateway = strings.TrimSpace(gateway); gateway != "" {
+			result = append(result, gateway)
+		}
+	}
+	return result
+}
+
+// IsSupported reports whether link can be resolved
+func IsSupported(link string) bool {
+	_, resolveErr := Resolve(link)
+	return resolveErr == nil
+}
+
+// Resolve returns http urls link can be fetched from, in order they should be tried.
+// Http links are returned as is
+func Resolve(link string) ([]string, error) {
+	switch {
+	case strings.HasPrefix(link, SchemeHTTPS), strings.HasPrefix(link, SchemeHTTP):
+		if parsedURL, parseErr := url.Parse(link); parseErr != nil || parsedURL.Hostname() == "" {
+			return nil, fmt.Errorf("%w: %v has no host", ErrUnsupportedLink, link)
+		}
+		return []string{link}, nil
+	case strings.HasPrefix(link, SchemeIPFS):
+		// ipfs://ipfs/CID is common mistake of ipfs://CID
+		return gatewayURLs(gatewaysOf(SchemeIPFS), strings.TrimPrefix(strings.TrimPrefix(link, SchemeIPFS), "ipfs/"), link)
+	case strings.HasPrefix(link, SchemeTONStorage):
+		return gatewayURLs(gatewaysOf(SchemeTONStorage), strings.TrimPrefix(link, SchemeTONStorage), link)
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedLink, link)
+	}
+}
+
+func gatewaysOf(scheme string) []string {
+	gatewaysMu.RLock()
+	defer gatewaysMu.RUnlock()
+
+	if scheme == SchemeIPFS {
+		return gateways.IPFS
+	}
+	return gateways.TONStorage
+}
+
+func gatewayURLs(schemeGateways []string, contentPath string, link string) ([]string, error) {
+	if contentPath == "" || strings.HasPrefix(contentPath, "/") {
+		return nil, fmt.Errorf("%w: %v has no content id", ErrUnsupportedLink, link)
+	}
+	if !isCleanContentPath(contentPath) {
+		return nil, fmt.Errorf("%w: %v has query, fragment or dot segments", ErrUnsupportedLink, link)
+	}
+	if len(schemeGateways) == 0 {
+		return nil, fmt.Errorf("%w: no gateways of %v", ErrUnsupportedLink, link)
+	}
+
+	urls := make([]string, len(schemeGateways))
+	for i, gateway := range schemeGateways {
+		urls[i] = strings.TrimSuffix(gateway, "/") + "/" + contentPath
+	}
+	return urls, nil
+}
+
+// isCleanContentPath reports whether path appended to gateway stays under it: gateway url must not be
+// changed by dot segments, query or fragment, escaped ones included
+func isCleanContentPath(contentPath string) bool {
+	unescapedPath, unescapeErr := url.PathUnescape(contentPath)
+	if unescapeErr != nil || strings.ContainsAny(unescapedPath, "?#\\") {
+		return false
+	}
+	for _, segment := range strings.Split(unescapedPath, "/") {
+		if segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// HTTPURL returns url link can be opened by browser: first gateway url of ipfs and tonstorage links.
+// Link which cant be resolved is returned as is
+func HTTPURL(link string) string {
+	urls, resolveErr := Resolve(link)
+	if resolveErr != nil {
+		return link
+	}
+	return urls[0]
+}
+
+// blockedPrefixes are ranges besides loopback, private, link local, multicast and unspecified ones
+// which user links must not reach
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+func isBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockPrivateAddress is called by dialer after DNS resolution, so hostnames resolving to private addresses are blocked too
+func blockPrivateAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, parseErr := netip.ParseAddrPort(address)
+	if parseErr != nil {
+		return fmt.Errorf("%w: %v", ErrBlockedAddress, address)
+	}
+	if isBlockedAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %v", ErrBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// newHTTPClient returns client with limits of Fetch. Client with sameHostRedirects follows redirects only
+// on host of first request
+func newHTTPClient(control func(network, address string, c syscall.RawConn) error, sameHostRedirects bool) *http.Client {
+	dialer := &net.Dialer{Timeout: FetchTimeout, Control: control}
+	return &http.Client{
+		Timeout: FetchTimeout,
+		Transport: &http.Transport{
+			// proxy would connect to user host instead of dialer
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   FetchTimeout,
+			ResponseHeaderTimeout: FetchTimeout,
+			MaxIdleConns:          16,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > MaxRedirects {
+				return fmt.Errorf("more than %v redirects", MaxRedirects)
+			}
+			if req.URL.Scheme != "https" && req.URL.Scheme != "http" {
+				return fmt.Errorf("redirect to %v scheme", req.URL.Scheme)
+			}
+			if sameHostRedirects && req.URL.Host != via[0].URL.Host {
+				return fmt.Errorf("redirect from %v to %v host", via[0].URL.Host, req.URL.Host)
+			}
+			return nil
+		},
+	}
+}
+
+var (
+	// userClient fetches links given by users, it cant reach private addresses
+	userClient = newHTTPClient(blockPrivateAddress, false)
+	// gatewayClient fetches through gateways set by operator, they may be local, so it doesnt leave gateway host
+	gatewayClient = newHTTPClient(nil, true)
+)
+
+// Fetch returns content of link. Gateways are tried in order until one of them answers with 2xx.
+// Fetch is limited by FetchTimeout, MaxContentSize and MaxRedirects, content must be json or text
+func Fetch(link string) ([]byte, error) {
+	urls, resolveErr := Resolve(link)
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+
+	client := gatewayClient
+	if strings.HasPrefix(link, SchemeHTTPS) || strings.HasPrefix(link, SchemeHTTP) {
+		client = userClient
+	}
+
+	var errs []error
+	for _, url := range urls {
+		content, fetchErr := fetchURL(client, url)
+		if fetchErr == nil {
+			return content, nil
+		}
+		errs = append(errs, fetchErr)
+	}
+
+	fetchErr := errors.Join(errs...)
+	if errors.Is(fetchErr, ErrBlockedAddress) {
+		return nil, fmt.Errorf("%w: %v", ErrBlockedAddress, link)
+	}
+	return nil, fmt.Errorf("%w: %v: %v", ErrFetchFailed, link, fetchErr)
+}
+
+func isAllowedContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, parseErr := mime.ParseMediaType(contentType)
+	if parseErr != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "text/plain" || mediaType == "application/octet-stream"
+}
+
+func fetchURL(client *http.Client, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), FetchTimeout)
+	defer cancel()
+
+	req, reqErr := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if reqErr != nil {
+		return nil, reqErr
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, getErr := client.Do(req)
+	if getErr != nil {
+		return nil, getErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%v responded with status %v", url, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !isAllowedContentType(contentType) {
+		return nil, fmt.Errorf("%v responded with %v content type, json expected", url, contentType)
+	}
+	if resp.ContentLength > MaxContentSize {
+		return nil, fmt.Errorf("%v content is larger than %v bytes", url, MaxContentSize)
+	}
+
+	content, readErr := io.ReadAll(io.LimitReader(resp.Body, MaxContentSize+1))
+	if readErr != nil {
+		return nil, fmt.Errorf("reading body of %v failed: %w", url, readErr)
+	}
+	if len(content) > MaxContentSize {
+		return nil, fmt.Errorf("%v content is larger than %v bytes", url, MaxContentSize)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,184 @@
+package contentlink
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func useGateways(t *testing.T, testGateways Gateways) {
+	t.Helper()
+	SetGateways(testGateways)
+	t.Cleanup(func() { SetGateways(DefaultGateways) })
+}
+
+func newGateway(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *[]string) {
+	t.Helper()
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requested
+}
+
+func failingGateway(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+func metadataGateway(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"name":"item"}`))
+}
+
+func TestResolve(t *testing.T) {
+	useGateways(t, Gateways{
+		IPFS:       []string{"https://first.ipfs/ipfs/", "https://second.ipfs/ipfs"},
+		TONStorage: []string{"https://ton.storage/gateway/"},
+	})
+
+	tests := []struct {
+		link    string
+		want    []string
+		wantErr bool
+	}{
+		{link: "https://example.com/1.json", want: []string{"https://example.com/1.json"}},
+		{link: "ipfs://QmCID/1.json", want: []string{"https://first.ipfs/ipfs/QmCID/1.json", "https://second.ipfs/ipfs/QmCID/1.json"}},
+		{link: "ipfs://ipfs/QmCID", want: []string{"https://first.ipfs/ipfs/QmCID", "https://second.ipfs/ipfs/QmCID"}},
+		{link: "tonstorage://BAG/meta.json", want: []string{"https://ton.storage/gateway/BAG/meta.json"}},
+		{link: "https://", wantErr: true},
+		{link: "ftp://example.com/1.json", wantErr: true},
+		{link: "ipfs://", wantErr: true},
+		{link: "ipfs:///QmCID", wantErr: true},
+		{link: "ipfs://QmCID/../other", wantErr: true},
+		{link: "ipfs://QmCID/%2e%2e/other", wantErr: true},
+		{link: "tonstorage://BAG/./meta.json", wantErr: true},
+		{link: "ipfs://QmCID?filename=1.json", wantErr: true},
+		{link: "tonstorage://BAG#meta", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.link, func(t *testing.T) {
+			urls, resolveErr := Resolve(test.link)
+			if test.wantErr {
+				if !errors.Is(resolveErr, ErrUnsupportedLink) {
+					t.Fatalf("Resolve() error = %v, want %v", resolveErr, ErrUnsupportedLink)
+				}
+				return
+			}
+			if resolveErr != nil {
+				t.Fatalf("Resolve() error = %v", resolveErr)
+			}
+			if len(urls) != len(test.want) {
+				t.Fatalf("Resolve() = %v, want %v", urls, test.want)
+			}
+			for i := range urls {
+				if urls[i] != test.want[i] {
+					t.Fatalf("Resolve() = %v, want %v", urls, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchFallsBackToNextGateway(t *testing.T) {
+	tests := []struct {
+		name     string
+		link     string
+		gateways func(first, second string) Gateways
+		wantPath string
+	}{
+		{
+			name: "ipfs",
+			link: "ipfs://QmCID/1.json",
+			gateways: func(first, second string) Gateways {
+				return Gateways{IPFS: []string{first + "/ipfs/", second + "/ipfs/"}}
+			},
+			wantPath: "/ipfs/QmCID/1.json",
+		},
+		{
+			name: "tonstorage",
+			link: "tonstorage://BAG/meta.json",
+			gateways: func(first, second string) Gateways {
+				return Gateways{TONStorage: []string{first + "/gateway", second + "/gateway"}}
+			},
+			wantPath: "/gateway/BAG/meta.json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, firstRequested := newGateway(t, failingGateway)
+			second, secondRequested := newGateway(t, metadataGateway)
+			useGateways(t, test.gateways(first.URL, second.URL))
+
+			content, fetchErr := Fetch(test.link)
+			if fetchErr != nil {
+				t.Fatalf("Fetch() error = %v", fetchErr)
+			}
+			if string(content) != `{"name":"item"}` {
+				t.Fatalf("Fetch() = %s", content)
+			}
+			if len(*firstRequested) != 1 || (*firstRequested)[0] != test.wantPath {
+				t.Fatalf("first gateway requested %v, want %v", *firstRequested, test.wantPath)
+			}
+			if len(*secondRequested) != 1 || (*secondRequested)[0] != test.wantPath {
+				t.Fatalf("second gateway requested %v, want %v", *secondRequested, test.wantPath)
+			}
+		})
+	}
+}
+
+func TestFetchStopsAtFirstAnsweringGateway(t *testing.T) {
+	first, _ := newGateway(t, metadataGateway)
+	second, secondRequested := newGateway(t, metadataGateway)
+	useGateways(t, Gateways{IPFS: []string{first.URL + "/ipfs/", second.URL + "/ipfs/"}})
+
+	if _, fetchErr := Fetch("ipfs://QmCID"); fetchErr != nil {
+		t.Fatalf("Fetch() error = %v", fetchErr)
+	}
+	if len(*secondRequested) != 0 {
+		t.Fatalf("second gateway requested %v after first one answered", *secondRequested)
+	}
+}
+
+func TestFetchFailsWhenAllGatewaysFail(t *testing.T) {
+	first, _ := newGateway(t, failingGateway)
+	second, _ := newGateway(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html></html>"))
+	})
+	useGateways(t, Gateways{IPFS: []string{first.URL + "/ipfs/", second.URL + "/ipfs/"}})
+
+	if _, fetchErr := Fetch("ipfs://QmCID"); !errors.Is(fetchErr, ErrFetchFailed) {
+		t.Fatalf("Fetch() error = %v, want %v", fetchErr, ErrFetchFailed)
+	}
+}
+
+func TestFetchDoesntFollowGatewayRedirectToOtherHost(t *testing.T) {
+	other, otherRequested := newGateway(t, metadataGateway)
+	gateway, _ := newGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+r.URL.Path, http.StatusFound)
+	})
+	useGateways(t, Gateways{IPFS: []string{gateway.URL + "/ipfs/"}})
+
+	if _, fetchErr := Fetch("ipfs://QmCID"); !errors.Is(fetchErr, ErrFetchFailed) {
+		t.Fatalf("Fetch() error = %v, want %v", fetchErr, ErrFetchFailed)
+	}
+	if len(*otherRequested) != 0 {
+		t.Fatalf("redirect to other host was followed: %v", *otherRequested)
+	}
+}
+
+func TestFetchBlocksPrivateUserLinks(t *testing.T) {
+	server, requested := newGateway(t, metadataGateway)
+
+	if _, fetchErr := Fetch(server.URL + "/1.json"); !errors.Is(fetchErr, ErrBlockedAddress) {
+		t.Fatalf("Fetch() error = %v, want %v", fetchErr, ErrBlockedAddress)
+	}
+	if len(*requested) != 0 {
+		t.Fatalf("private address was requested: %v", *requested)
+	}
+}
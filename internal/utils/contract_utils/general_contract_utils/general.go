@@ -48,10 +48,10 @@ func PackDefaultMessage(toAddress *address.Address, amount uint64, text ...strin
 	return msgBuilder.EndCell()
 }
 
-func PackDeployMessage(toAddress *address.Address, stateInit *tlb.StateInit) *tlb.InternalMessage {
+func PackDeployMessage(toAddress *address.Address, stateInit *tlb.StateInit, amount uint64) *tlb.InternalMessage {
 	return &tlb.InternalMessage{
 		Bounce:    true,
-		Amount:    tlb.MustFromTON("0.05"),
+		Amount:    tlb.FromNanoTONU(amount),
 		DstAddr:   toAddress,
 		StateInit: stateInit,
 		Body:      cell.BeginCell().EndCell(),
@@ -0,0 +1,172 @@
+package generalcontractutils
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// TEP-64 content layout prefixes
+const (
+	OnchainContentTag  = 0x00
+	OffchainContentTag = 0x01
+
+	snakeDataTag   = 0x00
+	chunkedDataTag = 0x01
+
+	chunkSize = 127 // bytes in one chunk cell (1023 bits)
+	// values bigger than this are stored in chunked format, smaller in snake format
+	chunkedDataThreshold = 8 * chunkSize
+)
+
+func onchainKey(key string) *cell.Cell {
+	hash := sha256.Sum256([]byte(key))
+	return cell.BeginCell().MustStoreSlice(hash[:], 256).EndCell()
+}
+
+func packSnakeData(data []byte) (*cell.Cell, error) {
+	builder := cell.BeginCell().MustStoreUInt(snakeDataTag, 8)
+	if err := builder.StoreBinarySnake(data); err != nil {
+		return nil, fmt.Errorf("error storing snake data: %w", err)
+	}
+
+	return builder.EndCell(), nil
+}
+
+func packChunkedData(data []byte) (*cell.Cell, error) {
+	chunks := cell.NewDict(32)
+
+	for i := 0; i*chunkSize < len(data); i++ {
+		end := min((i+1)*chunkSize, len(data))
+		chunk := cell.BeginCell().MustStoreSlice(data[i*chunkSize:end], uint(end-i*chunkSize)*8).EndCell()
+
+		if err := chunks.Set(cell.BeginCell().MustStoreUInt(uint64(i), 32).EndCell(), cell.BeginCell().MustStoreRef(chunk).EndCell()); err != nil {
+			return nil, fmt.Errorf("error storing chunk %v: %w", i, err)
+		}
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(chunkedDataTag, 8).
+		MustStoreDict(chunks).
+		EndCell(), nil
+}
+
+// PackOnchainContent packs values into TEP-64 onchain content cell (0x00 tag + sha256(key) => ^ContentData dictionary).
+// Empty values are skipped, big values are stored in chunked format.
+func PackOnchainContent(values map[string][]byte) (*cell.Cell, error) {
+	dict := cell.NewDict(256)
+
+	for key, value := range values {
+		if len(value) == 0 {
+			continue
+		}
+
+		pack := packSnakeData
+		if len(value) > chunkedDataThreshold {
+			pack = packChunkedData
+		}
+
+		data, packErr := pack(value)
+		if packErr != nil {
+			return nil, fmt.Errorf("error packing onchain value %v: %w", key, packErr)
+		}
+
+		if setErr := dict.Set(onchainKey(key), cell.BeginCell().MustStoreRef(data).EndCell()); setErr != nil {
+			return nil, fmt.Errorf("error storing onchain value %v: %w", key, setErr)
+		}
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(OnchainContentTag, 8).
+		MustStoreDict(dict).
+		EndCell(), nil
+}
+
+func loadChunkedData(slice *cell.Slice) ([]byte, error) {
+	chunks, dictErr := slice.LoadDict(32)
+	if dictErr != nil {
+		return nil, fmt.Errorf("error loading chunks dictionary: %w", dictErr)
+	}
+
+	var data []byte
+	for i := 0; ; i++ {
+		value, loadErr := chunks.LoadValue(cell.BeginCell().MustStoreUInt(uint64(i), 32).EndCell())
+		if errors.Is(loadErr, cell.ErrNoSuchKeyInDict) {
+			break
+		}
+		if loadErr != nil {
+			return nil, fmt.Errorf("error loading chunk %v: %w", i, loadErr)
+		}
+
+		chunk, refErr := value.LoadRef()
+		if refErr != nil {
+			return nil, fmt.Errorf("chunk %v is not a ref: %w", i, refErr)
+		}
+
+		chunkData, sliceErr := chunk.LoadSlice(chunk.BitsLeft())
+		if sliceErr != nil {
+			return nil, fmt.Errorf("error loading chunk %v: %w", i, sliceErr)
+		}
+
+		data = append(data, chunkData...)
+	}
+
+	return data, nil
+}
+
+// ParseOnchainContent reads values of given keys from TEP-64 onchain content cell.
+// Keys which are not in content are not in result map.
+func ParseOnchainContent(content *cell.Cell, keys ...string) (map[string][]byte, error) {
+	slice := content.BeginParse()
+
+	if tag, tagErr := slice.LoadUInt(8); tagErr != nil || tag != OnchainContentTag {
+		return nil, fmt.Errorf("want onchain content tag, have %v: %v", tag, tagErr)
+	}
+
+	dict, dictErr := slice.LoadDict(256)
+	if dictErr != nil {
+		return nil, fmt.Errorf("error loading onchain content dictionary: %w", dictErr)
+	}
+
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		value, loadErr := dict.LoadValue(onchainKey(key))
+		if errors.Is(loadErr, cell.ErrNoSuchKeyInDict) {
+			continue
+		}
+		if loadErr != nil {
+			return nil, fmt.Errorf("error loading onchain value %v: %w", key, loadErr)
+		}
+
+		data, refErr := value.LoadRef()
+		if refErr != nil {
+			return nil, fmt.Errorf("onchain value %v is not a ref: %w", key, refErr)
+		}
+
+		tag, tagErr := data.LoadUInt(8)
+		if tagErr != nil {
+			return nil, fmt.Errorf("error loading onchain value %v tag: %w", key, tagErr)
+		}
+
+		var parsed []byte
+		var parseErr error
+		switch tag {
+		case snakeDataTag:
+			parsed, parseErr = data.LoadBinarySnake()
+		case chunkedDataTag:
+			parsed, parseErr = loadChunkedData(data)
+		default:
+			parseErr = fmt.Errorf("unknown data tag %v", tag)
+		}
+
+		if parseErr != nil {
+			return nil, fmt.Errorf("error parsing onchain value %v: %w", key, parseErr)
+		}
+
+		values[key] = parsed
+	}
+
+	return values, nil
+}
@@ -3,17 +3,19 @@ package nftcollectionutils
 import (
 	"encoding/hex"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
 	"strings"
 
 	"github.com/goccy/go-json"
 	nftcollection "github.com/rom6n/create-nft-go/internal/domain/nft_collection"
 	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/utils/contentlink"
+	generalcontractutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/general_contract_utils"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/nft"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
@@ -36,29 +38,49 @@ func GetNftCollectionContractCode() *cell.Cell {
 	return code
 }
 
+// GetNftCollectionOffchainMetadata fetches metadata of https, ipfs or tonstorage link.
+// Image links of metadata are resolved to http urls, so they can be shown
 func GetNftCollectionOffchainMetadata(link string) (*nftcollection.NftCollectionMetadata, error) {
-	body, metadataErr := http.Get(link)
-
-	if metadataErr != nil {
-		return nil, metadataErr
+	rawNftCollectionMetadata, fetchErr := contentlink.Fetch(link)
+	if fetchErr != nil {
+		return nil, fetchErr
 	}
 
-	rawNftCollectionMetadata, err := io.ReadAll(body.Body)
-	defer body.Body.Close()
+	return parseNftCollectionMetadata(rawNftCollectionMetadata)
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("reading body failed: %w", err)
+// GetValidatedNftCollectionOffchainMetadata fetches metadata like GetNftCollectionOffchainMetadata
+// and checks it matches TEP-64, it is used for metadata of new or changed nft collections
+func GetValidatedNftCollectionOffchainMetadata(link string) (*nftcollection.NftCollectionMetadata, error) {
+	rawNftCollectionMetadata, fetchErr := contentlink.Fetch(link)
+	if fetchErr != nil {
+		return nil, fetchErr
 	}
 
+	if validateErr := tep64.ValidateNftCollectionMetadata(rawNftCollectionMetadata); validateErr != nil {
+		return nil, validateErr
+	}
+
+	return parseNftCollectionMetadata(rawNftCollectionMetadata)
+}
+
+func parseNftCollectionMetadata(rawNftCollectionMetadata []byte) (*nftcollection.NftCollectionMetadata, error) {
 	var parseTo nftcollection.NftCollectionMetadata
 
 	if unmarshErr := json.Unmarshal(rawNftCollectionMetadata, &parseTo); unmarshErr != nil {
 		return nil, unmarshErr
 	}
 
+	resolveImages(&parseTo)
+
 	return &parseTo, nil
 }
 
+func resolveImages(metadata *nftcollection.NftCollectionMetadata) {
+	metadata.Image = contentlink.HTTPURL(metadata.Image)
+	metadata.CoverImage = contentlink.HTTPURL(metadata.CoverImage)
+}
+
 func PackOffchainContentForNftCollection(collectionContent string, commonContent string) *cell.Cell {
 	collectionCont := cell.BeginCell().
 		MustStoreUInt(1, 8).
@@ -77,6 +99,120 @@ func PackOffchainContentForNftCollection(collectionContent string, commonContent
 	return content
 }
 
+// nft collection metadata keys in onchain content. Social links are stored as json array
+var nftCollectionOnchainKeys = []string{"uri", "name", "description", "image", "image_data", "cover_image", "external_url", "external_link", "social_links", "marketplace"}
+
+func PackOnchainContentForNftCollection(metadata *nftcollection.NftCollectionMetadata, commonContent string) (*cell.Cell, error) {
+	var socialLinks []byte
+	if len(metadata.SocialLinks) != 0 {
+		marshaled, marshalErr := json.Marshal(metadata.SocialLinks)
+		if marshalErr != nil {
+			return nil, fmt.Errorf("error marshaling nft collection social links: %w", marshalErr)
+		}
+		socialLinks = marshaled
+	}
+
+	collectionCont, packErr := generalcontractutils.PackOnchainContent(map[string][]byte{
+		"name":          []byte(metadata.Name),
+		"description":   []byte(metadata.Description),
+		"image":         []byte(metadata.Image),
+		"image_data":    metadata.ImageData,
+		"cover_image":   []byte(metadata.CoverImage),
+		"external_url":  []byte(metadata.ExternalUrl),
+		"external_link": []byte(metadata.ExternalLink),
+		"social_links":  socialLinks,
+		"marketplace":   []byte(metadata.Marketplace),
+	})
+	if packErr != nil {
+		return nil, fmt.Errorf("error packing onchain nft collection content: %w", packErr)
+	}
+
+	commonCont := cell.BeginCell().
+		MustStoreStringSnake(commonContent).
+		EndCell()
+
+	content := cell.BeginCell().
+		MustStoreRef(collectionCont).
+		MustStoreRef(commonCont).
+		EndCell()
+
+	return content, nil
+}
+
+// GetNftCollectionMetadata returns nft collection metadata from content returned by get_collection_data.
+// Supports offchain, onchain and semichain content
+func GetNftCollectionMetadata(content nft.ContentAny) (*nftcollection.NftCollectionMetadata, error) {
+	if offchain, ok := content.(*nft.ContentOffchain); ok {
+		return GetNftCollectionOffchainMetadata(offchain.URI)
+	}
+
+	contentCell, cellErr := content.ContentCell()
+	if cellErr != nil {
+		return nil, fmt.Errorf("nft collection data method not returned content cell: %w", cellErr)
+	}
+
+	values, parseErr := generalcontractutils.ParseOnchainContent(contentCell, nftCollectionOnchainKeys...)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	// semichain content: offchain metadata is overwritten by onchain values
+	metadata := &nftcollection.NftCollectionMetadata{}
+	if uri := string(values["uri"]); uri != "" {
+		offchainMetadata, metadataErr := GetNftCollectionOffchainMetadata(uri)
+		if metadataErr != nil {
+			return nil, metadataErr
+		}
+		metadata = offchainMetadata
+	}
+
+	if name, ok := values["name"]; ok {
+		metadata.Name = string(name)
+	}
+	if description, ok := values["description"]; ok {
+		metadata.Description = string(description)
+	}
+	if image, ok := values["image"]; ok {
+		metadata.Image = string(image)
+	}
+	if imageData, ok := values["image_data"]; ok {
+		metadata.ImageData = imageData
+	}
+	if coverImage, ok := values["cover_image"]; ok {
+		metadata.CoverImage = string(coverImage)
+	}
+	if externalUrl, ok := values["external_url"]; ok {
+		metadata.ExternalUrl = string(externalUrl)
+	}
+	if externalLink, ok := values["external_link"]; ok {
+		metadata.ExternalLink = string(externalLink)
+	}
+	if marketplace, ok := values["marketplace"]; ok {
+		metadata.Marketplace = string(marketplace)
+	}
+	if socialLinks, ok := values["social_links"]; ok {
+		if unmarshErr := json.Unmarshal(socialLinks, &metadata.SocialLinks); unmarshErr != nil {
+			return nil, fmt.Errorf("error parsing onchain nft collection social links: %w", unmarshErr)
+		}
+	}
+
+	resolveImages(metadata)
+
+	return metadata, nil
+}
+
+// GetNftCollectionRoyalty returns royalty params of nft collection returned by royalty_params get method
+func GetNftCollectionRoyalty(params *nft.CollectionRoyaltyParams) *nftcollection.Royalty {
+	royalty := &nftcollection.Royalty{
+		Dividend: params.Factor,
+		Divisor:  params.Base,
+	}
+	if params.Address != nil {
+		royalty.Address = params.Address.String()
+	}
+	return royalty
+}
+
 func PackNftCollectionRoyaltyParams(royaltyDividend uint16, royaltyDivisor uint16, royaltyAddress *address.Address) *cell.Cell {
 	return cell.BeginCell().
 		MustStoreUInt(uint64(royaltyDividend), 16).
@@ -95,10 +231,19 @@ func PackNftCollectionData(ownerAddress *address.Address, content *cell.Cell, nf
 		EndCell()
 }
 
-func PackDeployNftItemMessage(nftCollectionAddress *address.Address, nextItemIndex uint64, cfg nftitem.MintNftItemCfg) *tlb.InternalMessage {
-	cfg.Content = strings.TrimPrefix(cfg.Content, "https://")
-	content := cell.BeginCell().MustStoreStringSnake(cfg.Content).EndCell()
-	amount := uint64(70000000)
+// PackNftItemInitContent packs content nft item is deployed with: owner, content and optional forward amount with message.
+// Standard collection returns item content as offchain link of https:// common content and item content, so item content
+// is https link without https://. Ipfs and tonstorage links are stored as link of their first gateway
+func PackNftItemInitContent(cfg nftitem.MintNftItemCfg) (*cell.Cell, error) {
+	urls, resolveErr := contentlink.Resolve(cfg.Content)
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	if !strings.HasPrefix(urls[0], contentlink.SchemeHTTPS) {
+		return nil, fmt.Errorf("%w: %v has no https url", contentlink.ErrUnsupportedLink, cfg.Content)
+	}
+
+	content := cell.BeginCell().MustStoreStringSnake(strings.TrimPrefix(urls[0], contentlink.SchemeHTTPS)).EndCell()
 
 	initContent := cell.BeginCell().
 		MustStoreAddr(cfg.OwnerAddress).
@@ -106,7 +251,6 @@ func PackDeployNftItemMessage(nftCollectionAddress *address.Address, nextItemInd
 
 	if cfg.ForwardAmount >= 1 {
 		initContent.MustStoreCoins(cfg.ForwardAmount)
-		amount += cfg.ForwardAmount
 
 		if cfg.ForwardMessage != "" {
 			fwdMsg := cell.BeginCell().
@@ -120,24 +264,61 @@ func PackDeployNftItemMessage(nftCollectionAddress *address.Address, nextItemInd
 		}
 	}
 
+	return initContent.EndCell(), nil
+}
+
+// PackDeployNftItemMessage packs op=1 deploy message. Nft item gets itemAmount nano ton, messageAmount must also cover collection fees
+func PackDeployNftItemMessage(nftCollectionAddress *address.Address, nextItemIndex uint64, cfg nftitem.MintNftItemCfg, messageAmount uint64, itemAmount uint64) (*tlb.InternalMessage, error) {
+	initContent, packErr := PackNftItemInitContent(cfg)
+	if packErr != nil {
+		return nil, packErr
+	}
+
 	return &tlb.InternalMessage{
 		Bounce:  true,
-		Amount:  tlb.MustFromTON("0.07"),
+		Amount:  tlb.FromNanoTONU(messageAmount),
 		DstAddr: nftCollectionAddress,
 		Body: cell.BeginCell().
 			MustStoreUInt(1, 32).
 			MustStoreUInt(0, 64).
 			MustStoreUInt(nextItemIndex, 64).
-			MustStoreCoins(amount - 10000000). // -0.01 TON
-			MustStoreRef(initContent.EndCell()).
+			MustStoreCoins(itemAmount).
+			MustStoreRef(initContent).
 			EndCell(),
+	}, nil
+}
+
+// PackBatchDeployNftItemsMessage packs op=2 batch deploy message for items with indexes from firstItemIndex.
+// Every item gets itemAmount nano ton, messageAmount must also cover collection fees
+func PackBatchDeployNftItemsMessage(nftCollectionAddress *address.Address, firstItemIndex uint64, initContents []*cell.Cell, itemAmount uint64, messageAmount uint64) (*tlb.InternalMessage, error) {
+	deployList := cell.NewDict(64)
+	for i, initContent := range initContents {
+		item := cell.BeginCell().
+			MustStoreCoins(itemAmount).
+			MustStoreRef(initContent).
+			EndCell()
+
+		if setErr := deployList.Set(cell.BeginCell().MustStoreUInt(firstItemIndex+uint64(i), 64).EndCell(), item); setErr != nil {
+			return nil, fmt.Errorf("error storing nft item %v to deploy list: %w", firstItemIndex+uint64(i), setErr)
+		}
 	}
+
+	return &tlb.InternalMessage{
+		Bounce:  true,
+		Amount:  tlb.FromNanoTONU(messageAmount),
+		DstAddr: nftCollectionAddress,
+		Body: cell.BeginCell().
+			MustStoreUInt(2, 32).
+			MustStoreUInt(0, 64).
+			MustStoreRef(deployList.AsCell()).
+			EndCell(),
+	}, nil
 }
 
-func PackChangeOwnerMsg(newOwner *address.Address, nftCollectionAddress *address.Address) *tlb.InternalMessage {
+func PackChangeOwnerMsg(newOwner *address.Address, nftCollectionAddress *address.Address, amount uint64) *tlb.InternalMessage {
 	return &tlb.InternalMessage{
 		Bounce:  true,
-		Amount:  tlb.MustFromTON("0.01"),
+		Amount:  tlb.FromNanoTONU(amount),
 		DstAddr: nftCollectionAddress,
 		Body: cell.BeginCell().
 			MustStoreUInt(3, 32).
@@ -146,3 +327,18 @@ func PackChangeOwnerMsg(newOwner *address.Address, nftCollectionAddress *address
 			EndCell(),
 	}
 }
+
+// PackChangeContentMsg packs op=4 message which replaces nft collection content and royalty params
+func PackChangeContentMsg(nftCollectionAddress *address.Address, content *cell.Cell, royaltyParams *cell.Cell, amount uint64) *tlb.InternalMessage {
+	return &tlb.InternalMessage{
+		Bounce:  true,
+		Amount:  tlb.FromNanoTONU(amount),
+		DstAddr: nftCollectionAddress,
+		Body: cell.BeginCell().
+			MustStoreUInt(4, 32).
+			MustStoreUInt(0, 64).
+			MustStoreRef(content).
+			MustStoreRef(royaltyParams).
+			EndCell(),
+	}
+}
@@ -2,14 +2,13 @@ package nftitemutils
 
 import (
 	"encoding/hex"
-	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"os"
 
 	"github.com/goccy/go-json"
 	nftitem "github.com/rom6n/create-nft-go/internal/domain/nft_item"
+	"github.com/rom6n/create-nft-go/internal/utils/contentlink"
+	"github.com/rom6n/create-nft-go/internal/utils/tep64"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
@@ -34,30 +33,46 @@ func GetNftItemContractCode() *cell.Cell {
 	return code
 }
 
+// GetNftItemOffchainMetadata fetches metadata of https, ipfs or tonstorage link.
+// Image link of metadata is resolved to http url, so it can be shown
 func GetNftItemOffchainMetadata(link string) (*nftitem.NftItemMetadata, error) {
-	body, metadataErr := http.Get(link)
-
-	if metadataErr != nil {
-		return nil, metadataErr
+	rawNftItemMetadata, fetchErr := contentlink.Fetch(link)
+	if fetchErr != nil {
+		return nil, fetchErr
 	}
 
-	rawNftItemMetadata, err := io.ReadAll(body.Body)
-	defer body.Body.Close()
+	return parseNftItemMetadata(rawNftItemMetadata)
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("reading nft item metadata body failed: %w", err)
+// GetValidatedNftItemOffchainMetadata fetches metadata like GetNftItemOffchainMetadata
+// and checks it matches TEP-64, it is used for metadata of new nft items
+func GetValidatedNftItemOffchainMetadata(link string) (*nftitem.NftItemMetadata, error) {
+	rawNftItemMetadata, fetchErr := contentlink.Fetch(link)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	if validateErr := tep64.ValidateNftItemMetadata(rawNftItemMetadata); validateErr != nil {
+		return nil, validateErr
 	}
 
+	return parseNftItemMetadata(rawNftItemMetadata)
+}
+
+func parseNftItemMetadata(rawNftItemMetadata []byte) (*nftitem.NftItemMetadata, error) {
 	var parseTo nftitem.NftItemMetadata
 
 	if unmarshErr := json.Unmarshal(rawNftItemMetadata, &parseTo); unmarshErr != nil {
 		return nil, unmarshErr
 	}
 
+	parseTo.Image = contentlink.HTTPURL(parseTo.Image)
+
 	return &parseTo, nil
 }
 
-func PackChangeOwnerMsg(newOwner *address.Address, walletAddress *address.Address, nftItemAddress *address.Address) *tlb.InternalMessage {
+// PackChangeOwnerMsg packs transfer message, forwardAmount of amount is sent to new owner
+func PackChangeOwnerMsg(newOwner *address.Address, walletAddress *address.Address, nftItemAddress *address.Address, amount uint64, forwardAmount uint64) *tlb.InternalMessage {
 	fwdMsg := cell.BeginCell().
 		MustStoreUInt(0, 32).
 		MustStoreStringSnake("NFT withdraw").
@@ -66,14 +81,14 @@ func PackChangeOwnerMsg(newOwner *address.Address, walletAddress *address.Addres
 	return &tlb.InternalMessage{
 		Bounce:  true,
 		DstAddr: nftItemAddress,
-		Amount:  tlb.MustFromTON("0.03"), // 0.03 TON for nft item contract
+		Amount:  tlb.FromNanoTONU(amount),
 		Body: cell.BeginCell().
 			MustStoreUInt(0x5fcc3d14, 32). // Transfer OP-code
 			MustStoreUInt(93784, 64).      // random query id
 			MustStoreAddr(newOwner).
 			MustStoreAddr(walletAddress).
 			MustStoreInt(0, 1).
-			MustStoreCoins(10000000). // 0.01 TON for forward amount
+			MustStoreCoins(forwardAmount).
 			MustStoreInt(1, 1).
 			MustStoreRef(fwdMsg).
 			EndCell(),
@@ -1,6 +1,7 @@
 package telegutils
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"time"
@@ -17,6 +18,20 @@ func VerifyTelegramInitData(initString string, botToken string) bool {
 	return true
 }
 
+// ParseTelegramInitDataUser returns telegram user from init data. Init data must be verified before
+func ParseTelegramInitDataUser(initString string) (*initdata.User, error) {
+	data, parseErr := initdata.Parse(initString)
+	if parseErr != nil {
+		return nil, fmt.Errorf("error parsing init data: %w", parseErr)
+	}
+
+	if data.User.ID == 0 {
+		return nil, fmt.Errorf("init data has no user")
+	}
+
+	return &data.User, nil
+}
+
 func GetBotToken() string {
 	token := os.Getenv("TELEGRAM_BOT_TOKEN")
 	if token == "" {
@@ -0,0 +1,178 @@
+// Package tep64 validates nft metadata json against TEP-64 token data standard.
+// Errors name every invalid field, so creator can fix metadata before anything is paid
+package tep64
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/rom6n/create-nft-go/internal/utils/contentlink"
+)
+
+var ErrInvalidMetadata = errors.New("metadata doesnt match TEP-64")
+
+// Error is invalid metadata, Fields are field path -> reason
+type Error struct {
+	Fields map[string]string
+}
+
+func (e *Error) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, field := range slices.Sorted(maps.Keys(e.Fields)) {
+		reasons = append(reasons, field+" "+e.Fields[field])
+	}
+	return fmt.Sprintf("%v: %v", ErrInvalidMetadata, strings.Join(reasons, "; "))
+}
+
+func (e *Error) Unwrap() error {
+	return ErrInvalidMetadata
+}
+
+// WithFieldPrefix prefixes fields of metadata error with path of metadata in request, other errors are returned as is
+func WithFieldPrefix(err error, prefix string) error {
+	var metadataErr *Error
+	if !errors.As(err, &metadataErr) {
+		return err
+	}
+
+	fields := make(map[string]string, len(metadataErr.Fields))
+	for field, reason := range metadataErr.Fields {
+		fields[prefix+field] = reason
+	}
+	return &Error{Fields: fields}
+}
+
+type validator struct {
+	values map[string]json.RawMessage
+	fields map[string]string
+}
+
+func newValidator(raw []byte) (*validator, error) {
+	v := &validator{fields: map[string]string{}}
+	if unmarshErr := json.Unmarshal(raw, &v.values); unmarshErr != nil || v.values == nil {
+		return nil, &Error{Fields: map[string]string{"metadata": "must be json object"}}
+	}
+	return v, nil
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &Error{Fields: v.fields}
+}
+
+// string returns value of string field, ok is false if field is missing or invalid
+func (v *validator) string(field string, required bool) (string, bool) {
+	raw, ok := v.values[field]
+	if !ok || string(raw) == "null" {
+		if required {
+			v.fields[field] = "is required"
+		}
+		return "", false
+	}
+
+	var value string
+	if unmarshErr := json.Unmarshal(raw, &value); unmarshErr != nil {
+		v.fields[field] = "must be string"
+		return "", false
+	}
+	if required && strings.TrimSpace(value) == "" {
+		v.fields[field] = "is required"
+		return "", false
+	}
+	return value, true
+}
+
+func (v *validator) link(field string) {
+	if value, ok := v.string(field, false); ok && value != "" && !contentlink.IsSupported(value) {
+		v.fields[field] = "must be https, ipfs or tonstorage link"
+	}
+}
+
+func (v *validator) base64(field string) {
+	if value, ok := v.string(field, false); ok {
+		if _, decodeErr := base64.StdEncoding.DecodeString(value); decodeErr != nil {
+			v.fields[field] = "must be base64"
+		}
+	}
+}
+
+// array returns items of array field, nil if field is missing or invalid
+func (v *validator) array(field string) []json.RawMessage {
+	raw, ok := v.values[field]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+
+	var items []json.RawMessage
+	if unmarshErr := json.Unmarshal(raw, &items); unmarshErr != nil {
+		v.fields[field] = "must be array"
+		return nil
+	}
+	return items
+}
+
+// common checks fields which are the same in nft item and nft collection metadata
+func (v *validator) common() {
+	v.string("name", true)
+	v.string("description", false)
+	v.link("image")
+	v.base64("image_data")
+	v.string("external_url", false)
+}
+
+func ValidateNftItemMetadata(raw []byte) error {
+	v, objectErr := newValidator(raw)
+	if objectErr != nil {
+		return objectErr
+	}
+
+	v.common()
+
+	for i, rawAttribute := range v.array("attributes") {
+		prefix := fmt.Sprintf("attributes[%v]", i)
+
+		var attribute map[string]json.RawMessage
+		if unmarshErr := json.Unmarshal(rawAttribute, &attribute); unmarshErr != nil || attribute == nil {
+			v.fields[prefix] = "must be object with trait_type and value"
+			continue
+		}
+
+		var traitType, value string
+		if json.Unmarshal(attribute["trait_type"], &traitType) != nil || traitType == "" {
+			v.fields[prefix+".trait_type"] = "must be non empty string"
+		}
+		if json.Unmarshal(attribute["value"], &value) != nil {
+			v.fields[prefix+".value"] = "must be string"
+		}
+	}
+
+	return v.err()
+}
+
+func ValidateNftCollectionMetadata(raw []byte) error {
+	v, objectErr := newValidator(raw)
+	if objectErr != nil {
+		return objectErr
+	}
+
+	v.common()
+	v.link("cover_image")
+	v.string("external_link", false)
+	v.string("marketplace", false)
+
+	for i, rawLink := range v.array("social_links") {
+		var link string
+		if json.Unmarshal(rawLink, &link) != nil || !contentlink.IsSupported(link) {
+			v.fields[fmt.Sprintf("social_links[%v]", i)] = "must be https link"
+		}
+	}
+
+	return v.err()
+}
@@ -5,13 +5,10 @@ import (
 	"crypto/ed25519"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 
-	"github.com/rom6n/create-nft-go/internal/domain/user"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
-	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/wallet"
 )
@@ -48,6 +45,32 @@ func GetMainnetWallet(api ton.APIClientWrapped) *wallet.Wallet {
 	return w
 }
 
+// GetTestnetTreasuryAddress returns TESTNET_TREASURY_ADDRESS or testnet wallet address if it is not set
+func GetTestnetTreasuryAddress(w *wallet.Wallet) *address.Address {
+	if addrStr := os.Getenv("TESTNET_TREASURY_ADDRESS"); addrStr != "" {
+		addr, parseErr := address.ParseAddr(addrStr)
+		if parseErr != nil {
+			log.Fatalf("TESTNET_TREASURY_ADDRESS is not valid address: %v", parseErr)
+		}
+		return addr
+	}
+
+	return w.WalletAddress().Testnet(true)
+}
+
+// GetMainnetTreasuryAddress returns MAINNET_TREASURY_ADDRESS or mainnet wallet address if it is not set
+func GetMainnetTreasuryAddress(w *wallet.Wallet) *address.Address {
+	if addrStr := os.Getenv("MAINNET_TREASURY_ADDRESS"); addrStr != "" {
+		addr, parseErr := address.ParseAddr(addrStr)
+		if parseErr != nil {
+			log.Fatalf("MAINNET_TREASURY_ADDRESS is not valid address: %v", parseErr)
+		}
+		return addr
+	}
+
+	return w.WalletAddress()
+}
+
 func GetTestnetLiteClient(ctx context.Context) (*liteclient.ConnectionPool, ton.APIClientWrapped) {
 	client := liteclient.NewConnectionPool()
 	if err := client.AddConnectionsFromConfigUrl(ctx, "https://ton-blockchain.github.io/testnet-global.config.json"); err != nil {
@@ -101,67 +124,3 @@ func GetPrivateKey() ed25519.PrivateKey {
 //	}
 //	return tonapi.NewStreamingAPI(tonapi.WithStreamingEndpoint(tonapi.TestnetTonApiURL), tonapi.WithStreamingToken(token))
 //}
-
-func ListenDeposits(ctx context.Context, api ton.APIClientWrapped, userRepo user.UserRepository) {
-	master, err := api.CurrentMasterchainInfo(context.Background()) // we fetch block just to trigger chain proof check
-	if err != nil {
-		log.Fatalln("get masterchain info err: ", err.Error())
-		return
-	}
-
-	treasuryAddress := address.MustParseAddr("kQDU46qYz4rHAJhszrW9w6imF8p4Cw5dS1GpPTcJ9vqNSjQa")
-
-	acc, err := api.GetAccount(context.Background(), master, treasuryAddress)
-	if err != nil {
-		log.Fatalln("get masterchain info err: ", err.Error())
-		return
-	}
-
-	log.Printf("Account is active: %v", acc.IsActive)
-
-	lastProcessedLT := acc.LastTxLT
-	transactions := make(chan *tlb.Transaction)
-
-	log.Printf("ListenDeposits запущен!\n")
-	go api.SubscribeOnTransactions(ctx, treasuryAddress, lastProcessedLT, transactions)
-	for tx := range transactions {
-		if tx.IO.In != nil && tx.IO.In.MsgType == tlb.MsgTypeInternal {
-			ti := tx.IO.In.AsInternal()
-
-			if dsc, ok := tx.Description.(tlb.TransactionDescriptionOrdinary); ok && dsc.BouncePhase != nil {
-				if _, ok = dsc.BouncePhase.Phase.(tlb.BouncePhaseOk); ok {
-					// transaction was bounced, and coins were returned to sender
-					// this can happen mostly on custom contracts
-					continue
-				}
-			}
-
-			if ti.Amount.Nano().Sign() > 0 && ti.Comment() != "" {
-				userID, parseErr := strconv.ParseInt(ti.Comment(), 0, 64)
-				if parseErr != nil {
-					log.Printf("Deposits listener: Error parsing user id to int64: %v\n", parseErr)
-					continue
-				}
-
-				user, getErr := userRepo.GetUserByID(ctx, userID)
-				if getErr != nil {
-					log.Printf("Deposits listener: Error getting user: %v\n", getErr)
-					continue
-				}
-
-				receivedNanoTon, parseErr := strconv.ParseUint(ti.Amount.Nano().String(), 0, 64)
-				if parseErr != nil {
-					log.Printf("Deposits listener: Error parsing received nano ton to uint64: %v\n", parseErr)
-					continue
-				}
-
-				updErr := userRepo.UpdateUserBalance(ctx, user.UUID, user.NanoTon+receivedNanoTon)
-				if updErr != nil {
-					log.Printf("Deposits listener: Error updating user's balance: %v\n", updErr)
-				}
-			}
-		}
-
-	}
-
-}
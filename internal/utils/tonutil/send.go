@@ -0,0 +1,28 @@
+package tonutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+)
+
+// ErrNotSent is returned by SendWaitTransaction if external message wasn't built, its messages can't get to blockchain
+var ErrNotSent = errors.New("external message is not sent")
+
+// SendWaitTransaction sends messages of w and waits for wallet transaction. Errors not wrapping ErrNotSent are ambiguous:
+// lite server can get external message before timeout or connection error, so messages must be looked for on chain
+func SendWaitTransaction(ctx context.Context, api ton.APIClientWrapped, w *wallet.Wallet, messages ...*wallet.Message) error {
+	ext, buildErr := w.BuildExternalMessageForMany(ctx, messages)
+	if buildErr != nil {
+		return fmt.Errorf("%w: %v", ErrNotSent, buildErr)
+	}
+
+	if _, _, _, sendErr := api.SendExternalMessageWaitTransaction(ctx, ext); sendErr != nil {
+		return fmt.Errorf("error sending external message: %w", sendErr)
+	}
+
+	return nil
+}
@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -13,22 +18,54 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/joho/godotenv"
+	auctionRepo "github.com/rom6n/create-nft-go/internal/domain/auction/storage"
+	"github.com/rom6n/create-nft-go/internal/domain/audit"
+	auditRepo "github.com/rom6n/create-nft-go/internal/domain/audit/storage"
+	depositRepo "github.com/rom6n/create-nft-go/internal/domain/deposit/storage"
+	hostedMetadataRepo "github.com/rom6n/create-nft-go/internal/domain/hosted_metadata/storage"
+	"github.com/rom6n/create-nft-go/internal/domain/idempotency"
+	idempotencyRepo "github.com/rom6n/create-nft-go/internal/domain/idempotency/storage"
+	ledgerRepo "github.com/rom6n/create-nft-go/internal/domain/ledger/storage"
+	listingRepo "github.com/rom6n/create-nft-go/internal/domain/listing/storage"
+	mediaRepo "github.com/rom6n/create-nft-go/internal/domain/media/storage"
 	nftcollectionrepo "github.com/rom6n/create-nft-go/internal/domain/nft_collection/storage"
 	nftitemRepo "github.com/rom6n/create-nft-go/internal/domain/nft_item/storage"
+	operationRepo "github.com/rom6n/create-nft-go/internal/domain/operation/storage"
+	"github.com/rom6n/create-nft-go/internal/domain/pricing"
+	"github.com/rom6n/create-nft-go/internal/domain/reconciliation"
+	reconciliationRepo "github.com/rom6n/create-nft-go/internal/domain/reconciliation/storage"
+	royaltyRepo "github.com/rom6n/create-nft-go/internal/domain/royalty/storage"
+	searchRepo "github.com/rom6n/create-nft-go/internal/domain/search/storage"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
 	userRepo "github.com/rom6n/create-nft-go/internal/domain/user/storage"
 	walletRepo "github.com/rom6n/create-nft-go/internal/domain/wallet/storage"
+	withdrawalRepo "github.com/rom6n/create-nft-go/internal/domain/withdrawal/storage"
 	"github.com/rom6n/create-nft-go/internal/ports/http/api/ton"
 	"github.com/rom6n/create-nft-go/internal/ports/http/handler"
+	auctionservice "github.com/rom6n/create-nft-go/internal/service/auction_service"
+	changenftcollectioncontent "github.com/rom6n/create-nft-go/internal/service/change_nft_collection_content"
 	deploynftcollection "github.com/rom6n/create-nft-go/internal/service/deploy_nft_collection"
+	depositservice "github.com/rom6n/create-nft-go/internal/service/deposit_service"
+	hostedmetadataservice "github.com/rom6n/create-nft-go/internal/service/hosted_metadata_service"
+	listingservice "github.com/rom6n/create-nft-go/internal/service/listing_service"
 	marketplacecontractservice "github.com/rom6n/create-nft-go/internal/service/marketplace_contract_service"
+	mediaservice "github.com/rom6n/create-nft-go/internal/service/media_service"
 	mintnftitem "github.com/rom6n/create-nft-go/internal/service/mint_nft_item"
 	nftcollectionservice "github.com/rom6n/create-nft-go/internal/service/nft_collection_service"
+	operationtracker "github.com/rom6n/create-nft-go/internal/service/operation_tracker"
+	pricingservice "github.com/rom6n/create-nft-go/internal/service/pricing_service"
+	reconciliationservice "github.com/rom6n/create-nft-go/internal/service/reconciliation_service"
+	roleservice "github.com/rom6n/create-nft-go/internal/service/role_service"
+	royaltyservice "github.com/rom6n/create-nft-go/internal/service/royalty_service"
+	searchservice "github.com/rom6n/create-nft-go/internal/service/search_service"
+	transfernftitem "github.com/rom6n/create-nft-go/internal/service/transfer_nft_item"
 	userservice "github.com/rom6n/create-nft-go/internal/service/user_service"
 	walletservice "github.com/rom6n/create-nft-go/internal/service/wallet_service"
 	withdrawnftcollection "github.com/rom6n/create-nft-go/internal/service/withdraw_nft_collection"
 	withdrawnftitem "github.com/rom6n/create-nft-go/internal/service/withdraw_nft_item"
 	"github.com/rom6n/create-nft-go/internal/service/withdraw_user_ton"
 	"github.com/rom6n/create-nft-go/internal/storage"
+	"github.com/rom6n/create-nft-go/internal/utils/contentlink"
 	marketutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/market_utils"
 	nftcollectionutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_collection_utils"
 	nftitemutils "github.com/rom6n/create-nft-go/internal/utils/contract_utils/nft_item_utils"
@@ -57,13 +94,22 @@ func main() {
 	//testnetTonapiClient := ton.NewTestnetTonapiClient()
 	testnetWallet := tonutil.GetTestnetWallet(testnetLiteApi)
 	mainnetWallet := tonutil.GetMainnetWallet(mainnetLiteApi)
+	testnetTreasuryAddress := tonutil.GetTestnetTreasuryAddress(testnetWallet)
+	mainnetTreasuryAddress := tonutil.GetMainnetTreasuryAddress(mainnetWallet)
 	//streamingApi := tonutil.GetStreamingApi()
 	//testnetStreamingApi := tonutil.GetTestnetStreamingApi()
 	botToken := telegutils.GetBotToken()
+	// ipfs and tonstorage content links are fetched through these gateways
+	contentlink.SetGateways(contentlink.GetGateways())
 
 	databaseClient := storage.NewMongoClient()
 	defer databaseClient.Disconnect(ctx)
 
+	redisClient := storage.NewRedisClient()
+	defer redisClient.Close()
+
+	elasticSearchClient := storage.NewElasticSearchClient()
+
 	// ---------------------------------- Repo -------------------------------------------
 
 	walletRepo := walletRepo.NewWalletRepo(databaseClient, walletRepo.WalletRepoCfg{
@@ -72,34 +118,178 @@ func main() {
 		Timeout:        15 * time.Second,
 	})
 
-	nftCollectionRepo := nftcollectionrepo.NewNftCollectionRepo(databaseClient, nftcollectionrepo.NftCollectionRepoCfg{
+	searchRepo := searchRepo.NewSearchRepo(elasticSearchClient, searchRepo.SearchRepoCfg{
+		IndexName: "create-nft-tma-search",
+		Timeout:   15 * time.Second,
+	})
+
+	// created and deleted nft collections and nft items are mirrored to search index
+	nftCollectionRepo := nftcollectionrepo.NewIndexedNftCollectionRepo(nftcollectionrepo.NewCachedNftCollectionRepo(nftcollectionrepo.NewNftCollectionRepo(databaseClient, nftcollectionrepo.NftCollectionRepoCfg{
 		DBName:         "create-nft-tma",
 		CollectionName: "nft-collections",
 		Timeout:        15 * time.Second,
-	})
+	}), redisClient, nftcollectionrepo.NftCollectionCacheCfg{
+		TTL: 10 * time.Minute,
+	}), searchRepo)
 
-	userRepo := userRepo.NewUserRepo(databaseClient, userRepo.UserRepoCfg{
+	userRepo := userRepo.NewCachedUserRepo(userRepo.NewUserRepo(databaseClient, userRepo.UserRepoCfg{
 		DBName:         "create-nft-tma",
 		CollectionName: "users",
 		Timeout:        15 * time.Second,
+	}), redisClient, userRepo.UserCacheCfg{
+		TTL: 5 * time.Minute,
+	})
+
+	nftItemRepo := nftitemRepo.NewIndexedNftItemRepo(nftitemRepo.NewCachedNftItemRepo(nftitemRepo.NewNftItemRepo(databaseClient, nftitemRepo.NftItemRepoCfg{
+		DBName:                  "create-nft-tma",
+		CollectionName:          "nft-items",
+		TransfersCollectionName: "nft_item_transfers",
+		Timeout:                 15 * time.Second,
+	}), redisClient, nftitemRepo.NftItemCacheCfg{
+		TTL: 10 * time.Minute,
+	}), searchRepo)
+
+	// ledger changes users' balances directly in database, so cached users are invalidated after it
+	ledgerRepo := ledgerRepo.NewUserCacheInvalidatingLedgerRepo(ledgerRepo.NewLedgerRepo(databaseClient, ledgerRepo.LedgerRepoCfg{
+		DBName:              "create-nft-tma",
+		CollectionName:      "balance_entries",
+		UsersCollectionName: "users",
+		Timeout:             15 * time.Second,
+	}), redisClient)
+
+	withdrawalRepo := withdrawalRepo.NewWithdrawalRepo(databaseClient, withdrawalRepo.WithdrawalRepoCfg{
+		DBName:         "create-nft-tma",
+		CollectionName: "withdrawals",
+		Timeout:        15 * time.Second,
+	})
+
+	operationRepo := operationRepo.NewOperationRepo(databaseClient, operationRepo.OperationRepoCfg{
+		DBName:         "create-nft-tma",
+		CollectionName: "operations",
+		Timeout:        15 * time.Second,
+	})
+
+	listingRepo := listingRepo.NewListingRepo(databaseClient, listingRepo.ListingRepoCfg{
+		DBName:         "create-nft-tma",
+		CollectionName: "listings",
+		Timeout:        15 * time.Second,
+	})
+
+	auctionRepo := auctionRepo.NewAuctionRepo(databaseClient, auctionRepo.AuctionRepoCfg{
+		DBName:             "create-nft-tma",
+		CollectionName:     "auctions",
+		BidsCollectionName: "auction_bids",
+		Timeout:            15 * time.Second,
+	})
+
+	royaltyRepo := royaltyRepo.NewRoyaltyRepo(databaseClient, royaltyRepo.RoyaltyRepoCfg{
+		DBName:         "create-nft-tma",
+		CollectionName: "royalty_accruals",
+		Timeout:        15 * time.Second,
+	})
+
+	depositRepo := depositRepo.NewDepositRepo(databaseClient, depositRepo.DepositRepoCfg{
+		DBName:                "create-nft-tma",
+		CollectionName:        "deposits",
+		CursorsCollectionName: "deposit_cursors",
+		Timeout:               15 * time.Second,
+	})
+
+	auditRepo := auditRepo.NewAuditRepo(databaseClient, auditRepo.AuditRepoCfg{
+		DBName:         "create-nft-tma",
+		CollectionName: "audit",
+		Timeout:        15 * time.Second,
 	})
 
-	nftItemRepo := nftitemRepo.NewNftItemRepo(databaseClient, nftitemRepo.NftItemRepoCfg{
+	reconciliationRepo := reconciliationRepo.NewReconciliationRepo(databaseClient, reconciliationRepo.ReconciliationRepoCfg{
 		DBName:         "create-nft-tma",
-		CollectionName: "nft-items",
+		CollectionName: "reconciliation_reports",
 		Timeout:        15 * time.Second,
 	})
 
+	hostedMetadataRepo := hostedMetadataRepo.NewHostedMetadataRepo(databaseClient, hostedMetadataRepo.HostedMetadataRepoCfg{
+		DBName:         "create-nft-tma",
+		CollectionName: "hosted_metadata",
+		Timeout:        15 * time.Second,
+	})
+
+	publicBaseURL := GetMetadataBaseURL()
+
+	// media files are kept on local disk and served by the app
+	mediaBlobStore, blobStoreErr := mediaRepo.NewFileSystemBlobStore(mediaRepo.FileSystemBlobStoreCfg{
+		Dir:     GetMediaDir(),
+		BaseURL: publicBaseURL + "/api/media/files",
+	})
+	if blobStoreErr != nil {
+		log.Fatalf("Error: %v \n", blobStoreErr)
+	}
+
+	mediaRepo := mediaRepo.NewMediaRepo(databaseClient, mediaRepo.MediaRepoCfg{
+		DBName:         "create-nft-tma",
+		CollectionName: "media",
+		Timeout:        15 * time.Second,
+	})
+
+	idempotencyRepo := idempotencyRepo.NewIdempotencyRepo(databaseClient, idempotencyRepo.IdempotencyRepoCfg{
+		DBName:         "create-nft-tma",
+		CollectionName: "idempotency_keys",
+		Timeout:        15 * time.Second,
+	})
+
+	operationTrackerRepo := operationtracker.New(operationtracker.OperationTrackerCfg{
+		OperationRepo:     operationRepo,
+		LedgerRepo:        ledgerRepo,
+		NftCollectionRepo: nftCollectionRepo,
+		NftItemRepo:       nftItemRepo,
+		TestnetLiteApi:    testnetLiteApi,
+		MainnetLiteApi:    mainnetLiteApi,
+		PollInterval:      30 * time.Second,
+		ConfirmTimeout:    10 * time.Minute,
+		Timeout:           30 * time.Second,
+	})
+
 	userServiceRepo := userservice.New(userservice.UserServiceCfg{
 		UserRepo:          userRepo,
+		LedgerRepo:        ledgerRepo,
 		NftCollectionRepo: nftCollectionRepo,
 		NftItemRepo:       nftItemRepo,
 		Timeout:           30 * time.Second,
 	})
 
+	pricingMarginNanoTon, pricingMarginPercent := GetPricingMargin()
+
+	pricingServiceRepo := pricingservice.New(pricingservice.PricingServiceCfg{
+		TestnetLiteApi:            testnetLiteApi,
+		MainnetLiteApi:            mainnetLiteApi,
+		Amounts:                   pricing.DefaultAmounts,
+		MarginNanoTon:             pricingMarginNanoTon,
+		MarginPercent:             pricingMarginPercent,
+		PricesTTL:                 10 * time.Minute,
+		NftCollectionContractCode: nftCollectionContractCode,
+		NftItemContractCode:       nftItemContractCode,
+		Timeout:                   15 * time.Second,
+	})
+
+	hostedMetadataServiceRepo := hostedmetadataservice.New(hostedmetadataservice.HostedMetadataServiceCfg{
+		HostedMetadataRepo: hostedMetadataRepo,
+		BaseURL:            publicBaseURL,
+		Timeout:            15 * time.Second,
+	})
+
+	mediaServiceRepo := mediaservice.New(mediaservice.MediaServiceCfg{
+		MediaRepo: mediaRepo,
+		BlobStore: mediaBlobStore,
+		UserRepo:  userRepo,
+		Timeout:   30 * time.Second,
+	})
+
 	deployNftCollectionServiceRepo := deploynftcollection.New(deploynftcollection.DeployNftCollectionServiceCfg{
 		NftCollectionRepo:         nftCollectionRepo,
 		UserRepo:                  userRepo,
+		LedgerRepo:                ledgerRepo,
+		OperationTracker:          operationTrackerRepo,
+		PricingService:            pricingServiceRepo,
+		HostedMetadataService:     hostedMetadataServiceRepo,
 		PrivateKey:                privateKey,
 		TestnetLiteClient:         testnetLiteClient,
 		MainnetLiteClient:         mainnetLiteClient,
@@ -122,18 +312,23 @@ func main() {
 	})
 
 	mintNftItemServiceRepo := mintnftitem.New(mintnftitem.MintNftItemServiceCfg{
-		NftCollectionRepo: nftCollectionRepo,
-		NftItemRepo:       nftItemRepo,
-		UserRepo:          userRepo,
-		NftItemCode:       nftItemContractCode,
-		TestnetLiteClient: testnetLiteClient,
-		MainnetLiteClient: mainnetLiteClient,
-		TestnetLiteApi:    testnetLiteApi,
-		MainnetLiteApi:    mainnetLiteApi,
-		TestnetWallet:     testnetWallet,
-		MainnetWallet:     mainnetWallet,
-		PrivateKey:        privateKey,
-		Timeout:           30 * time.Second,
+		NftCollectionRepo:     nftCollectionRepo,
+		NftItemRepo:           nftItemRepo,
+		UserRepo:              userRepo,
+		LedgerRepo:            ledgerRepo,
+		OperationTracker:      operationTrackerRepo,
+		PricingService:        pricingServiceRepo,
+		HostedMetadataService: hostedMetadataServiceRepo,
+		MediaService:          mediaServiceRepo,
+		NftItemCode:           nftItemContractCode,
+		TestnetLiteClient:     testnetLiteClient,
+		MainnetLiteClient:     mainnetLiteClient,
+		TestnetLiteApi:        testnetLiteApi,
+		MainnetLiteApi:        mainnetLiteApi,
+		TestnetWallet:         testnetWallet,
+		MainnetWallet:         mainnetWallet,
+		PrivateKey:            privateKey,
+		Timeout:               30 * time.Second,
 	})
 
 	marketplaceContractServiceRepo := marketplacecontractservice.New(marketplacecontractservice.MarketplaceContractServiceCfg{
@@ -153,6 +348,9 @@ func main() {
 	withdrawNftCollectionServiceRepo := withdrawnftcollection.New(withdrawnftcollection.WithdrawNftCollectionServiceCfg{
 		NftCollectionRepo: nftCollectionRepo,
 		UserRepo:          userRepo,
+		LedgerRepo:        ledgerRepo,
+		OperationTracker:  operationTrackerRepo,
+		PricingService:    pricingServiceRepo,
 		PrivateKey:        privateKey,
 		TestnetLiteClient: testnetLiteClient,
 		MainnetLiteClient: mainnetLiteClient,
@@ -163,9 +361,30 @@ func main() {
 		Timeout:           30 * time.Second,
 	})
 
+	changeNftCollectionContentServiceRepo := changenftcollectioncontent.New(changenftcollectioncontent.ChangeNftCollectionContentServiceCfg{
+		NftCollectionRepo:     nftCollectionRepo,
+		UserRepo:              userRepo,
+		LedgerRepo:            ledgerRepo,
+		OperationTracker:      operationTrackerRepo,
+		PricingService:        pricingServiceRepo,
+		HostedMetadataService: hostedMetadataServiceRepo,
+		TestnetLiteClient:     testnetLiteClient,
+		MainnetLiteClient:     mainnetLiteClient,
+		TestnetLiteApi:        testnetLiteApi,
+		MainnetLiteApi:        mainnetLiteApi,
+		TestnetWallet:         testnetWallet,
+		MainnetWallet:         mainnetWallet,
+		Timeout:               30 * time.Second,
+	})
+
 	withdrawNftItemServiceRepo := withdrawnftitem.New(withdrawnftitem.WithdrawNftItemServiceCfg{
 		NftItemRepo:       nftItemRepo,
 		UserRepo:          userRepo,
+		LedgerRepo:        ledgerRepo,
+		OperationTracker:  operationTrackerRepo,
+		PricingService:    pricingServiceRepo,
+		ListingRepo:       listingRepo,
+		AuctionRepo:       auctionRepo,
 		PrivateKey:        privateKey,
 		TestnetLiteClient: testnetLiteClient,
 		MainnetLiteClient: mainnetLiteClient,
@@ -176,22 +395,139 @@ func main() {
 		Timeout:           30 * time.Second,
 	})
 
+	transferNftItemServiceRepo := transfernftitem.New(transfernftitem.TransferNftItemServiceCfg{
+		NftItemRepo: nftItemRepo,
+		UserRepo:    userRepo,
+		ListingRepo: listingRepo,
+		AuctionRepo: auctionRepo,
+		Timeout:     30 * time.Second,
+	})
+
+	royaltyServiceRepo := royaltyservice.New(royaltyservice.RoyaltyServiceCfg{
+		RoyaltyRepo:       royaltyRepo,
+		NftCollectionRepo: nftCollectionRepo,
+		UserRepo:          userRepo,
+		LedgerRepo:        ledgerRepo,
+		TestnetLiteApi:    testnetLiteApi,
+		MainnetLiteApi:    mainnetLiteApi,
+		Timeout:           30 * time.Second,
+	})
+
+	listingServiceRepo := listingservice.New(listingservice.ListingServiceCfg{
+		ListingRepo:        listingRepo,
+		AuctionRepo:        auctionRepo,
+		NftItemRepo:        nftItemRepo,
+		UserRepo:           userRepo,
+		LedgerRepo:         ledgerRepo,
+		RoyaltyService:     royaltyServiceRepo,
+		PlatformFeePercent: GetMarketplaceFeePercent(),
+		Timeout:            30 * time.Second,
+	})
+
+	auctionServiceRepo := auctionservice.New(auctionservice.AuctionServiceCfg{
+		AuctionRepo:        auctionRepo,
+		ListingRepo:        listingRepo,
+		NftItemRepo:        nftItemRepo,
+		UserRepo:           userRepo,
+		LedgerRepo:         ledgerRepo,
+		RoyaltyService:     royaltyServiceRepo,
+		PlatformFeePercent: GetMarketplaceFeePercent(),
+		PollInterval:       15 * time.Second,
+		Timeout:            30 * time.Second,
+	})
+
 	withdrawUserRepo := withdraw_user_ton.New(withdraw_user_ton.WithdrawUserTonCfg{
 		UserRepo:          userRepo,
+		LedgerRepo:        ledgerRepo,
+		WithdrawalRepo:    withdrawalRepo,
 		TestnetLiteClient: testnetLiteClient,
 		MainnetLiteClient: mainnetLiteClient,
+		TestnetLiteApi:    testnetLiteApi,
+		MainnetLiteApi:    mainnetLiteApi,
 		TestnetWallet:     testnetWallet,
 		MainnetWallet:     mainnetWallet,
-		QueueChannel:      make(chan *withdraw_user_ton.WithdrawRequest),
+		PollInterval:      1 * time.Minute,
+		ConfirmTimeout:    10 * time.Minute,
 		Timeout:           30 * time.Second,
 	})
 
-	go withdrawUserRepo.WithdrawQueue()
+	withdrawQueueCtx, stopWithdrawQueue := context.WithCancel(ctx)
+	withdrawQueueDone := make(chan struct{})
+	go func() {
+		withdrawUserRepo.WithdrawQueue(withdrawQueueCtx)
+		close(withdrawQueueDone)
+	}()
+
+	depositServiceRepo := depositservice.New(depositservice.DepositServiceCfg{
+		DepositRepo:            depositRepo,
+		UserRepo:               userRepo,
+		LedgerRepo:             ledgerRepo,
+		TestnetLiteClient:      testnetLiteClient,
+		MainnetLiteClient:      mainnetLiteClient,
+		TestnetLiteApi:         testnetLiteApi,
+		MainnetLiteApi:         mainnetLiteApi,
+		TestnetWallet:          testnetWallet,
+		MainnetWallet:          mainnetWallet,
+		TestnetTreasuryAddress: testnetTreasuryAddress,
+		MainnetTreasuryAddress: mainnetTreasuryAddress,
+		PollInterval:           1 * time.Minute,
+		ConfirmTimeout:         10 * time.Minute,
+		Timeout:                30 * time.Second,
+	})
+
+	operationTrackerCtx, stopOperationTracker := context.WithCancel(ctx)
+	go operationTrackerRepo.Run(operationTrackerCtx)
+
+	auctionCtx, stopAuctions := context.WithCancel(ctx)
+	go auctionServiceRepo.Run(auctionCtx)
+
+	reconciliationServiceRepo := reconciliationservice.New(reconciliationservice.ReconciliationServiceCfg{
+		ReconciliationRepo: reconciliationRepo,
+		NftCollectionRepo:  nftCollectionRepo,
+		NftItemRepo:        nftItemRepo,
+		OperationRepo:      operationRepo,
+		ListingRepo:        listingRepo,
+		AuctionRepo:        auctionRepo,
+		TestnetLiteApi:     testnetLiteApi,
+		MainnetLiteApi:     mainnetLiteApi,
+		TestnetWallet:      testnetWallet,
+		MainnetWallet:      mainnetWallet,
+		Policy:             GetReconciliationPolicy(),
+		Interval:           6 * time.Hour,
+		Timeout:            30 * time.Second,
+	})
+
+	reconciliationCtx, stopReconciliation := context.WithCancel(ctx)
+	go reconciliationServiceRepo.Run(reconciliationCtx)
 
-	tonApiRepo := ton.NewTonApiRepo(tonapiClient, 30*time.Second)
+	tonApiRepo := ton.NewCachedTonApiRepo(ton.NewTonApiRepo(tonapiClient, 30*time.Second), redisClient, ton.TonApiCacheCfg{
+		TTL: 1 * time.Minute,
+	})
 
 	walletServiceRepo := walletservice.New(tonApiRepo, walletRepo)
 
+	roleServiceRepo := roleservice.New(roleservice.RoleServiceCfg{
+		UserRepo:  userRepo,
+		AuditRepo: auditRepo,
+		Timeout:   15 * time.Second,
+	})
+
+	// first super admin is set from env, next roles are granted by super admins
+	if superAdminID := GetSuperAdminID(); superAdminID != 0 {
+		if superAdmin, getErr := userRepo.GetUserByID(ctx, superAdminID); getErr != nil {
+			log.Printf("Error getting super admin %v: %v\n", superAdminID, getErr)
+		} else if superAdmin.Role != user.RoleSuperAdmin {
+			if _, grantErr := roleServiceRepo.GrantRole(ctx, audit.SystemActorID, superAdminID, user.RoleSuperAdmin, "SUPER_ADMIN_ID env var"); grantErr != nil {
+				log.Printf("Error granting super admin role to %v: %v\n", superAdminID, grantErr)
+			}
+		}
+	}
+
+	searchServiceRepo := searchservice.New(searchservice.SearchServiceCfg{
+		SearchRepo: searchRepo,
+		Timeout:    15 * time.Second,
+	})
+
 	// -------------------------------- Handlers -----------------------------------------------
 
 	walletHandler := handler.WalletHandler{
@@ -201,74 +537,104 @@ func main() {
 	userHandler := handler.UserHandler{
 		UserService:         userServiceRepo,
 		WithdrawUserService: withdrawUserRepo,
+		RoyaltyService:      royaltyServiceRepo,
 	}
 
 	nftCollectionHandler := handler.NftCollectionHandler{
-		NftCollectionService:         nftCollectionServiceRepo,
-		DeployNftCollectionService:   deployNftCollectionServiceRepo,
-		WithdrawNftCollectionService: withdrawNftCollectionServiceRepo,
+		NftCollectionService:              nftCollectionServiceRepo,
+		DeployNftCollectionService:        deployNftCollectionServiceRepo,
+		WithdrawNftCollectionService:      withdrawNftCollectionServiceRepo,
+		ChangeNftCollectionContentService: changeNftCollectionContentServiceRepo,
 	}
 
 	nftItemHandler := handler.NftItemHandler{
 		MintNftItemService:     mintNftItemServiceRepo,
 		WithdrawNftItemService: withdrawNftItemServiceRepo,
+		TransferNftItemService: transferNftItemServiceRepo,
+	}
+
+	listingHandler := handler.ListingHandler{
+		ListingService: listingServiceRepo,
+	}
+
+	auctionHandler := handler.AuctionHandler{
+		AuctionService: auctionServiceRepo,
 	}
 
 	marketplaceHandler := handler.MarketplaceContractHandler{
 		MarketplaceContractService: marketplaceContractServiceRepo,
 	}
 
+	depositHandler := handler.DepositHandler{
+		DepositService: depositServiceRepo,
+	}
+
+	hostedMetadataHandler := handler.HostedMetadataHandler{
+		HostedMetadataService: hostedMetadataServiceRepo,
+	}
+
+	mediaHandler := handler.MediaHandler{
+		MediaService: mediaServiceRepo,
+	}
+
+	roleHandler := handler.RoleHandler{
+		RoleService: roleServiceRepo,
+	}
+
+	searchHandler := handler.SearchHandler{
+		SearchService: searchServiceRepo,
+	}
+
+	pricingHandler := handler.PricingHandler{
+		PricingService: pricingServiceRepo,
+	}
+
+	reconciliationHandler := handler.ReconciliationHandler{
+		ReconciliationService: reconciliationServiceRepo,
+	}
+
 	// ------------------------------- App & Routes --------------------------------------
 
-	go tonutil.ListenDeposits(ctx, testnetLiteApi, userRepo)
+	go depositServiceRepo.ListenDeposits(ctx)
 	//go tonutil.ListenDeposits(ctx, streamingApi, tonapiClient, userRepo)
 
 	app := fiber.New(fiber.Config{
 		JSONEncoder: json.Marshal,
 		JSONDecoder: json.Unmarshal,
 	})
+	app.Server().HeaderReceived = mediaUploadBodyLimit
 
 	app.Use(logger.New())
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowMethods: "GET,POST,HEAD,PUT,DELETE,PATCH",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Init-Data",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Init-Data, Idempotency-Key",
 	}))
 
-	app.Get("/ping", func(c *fiber.Ctx) error {
-		return c.SendString("pong")
-	})
-
-	app.Get("/favicon.ico", func(c *fiber.Ctx) error {
-		return c.SendStatus(fiber.StatusNoContent)
+	registerRoutes(app, &routeHandlers{
+		Wallet:         walletHandler,
+		User:           userHandler,
+		NftCollection:  nftCollectionHandler,
+		NftItem:        nftItemHandler,
+		Listing:        listingHandler,
+		Auction:        auctionHandler,
+		Marketplace:    marketplaceHandler,
+		Deposit:        depositHandler,
+		HostedMetadata: hostedMetadataHandler,
+		Media:          mediaHandler,
+		Role:           roleHandler,
+		Search:         searchHandler,
+		Pricing:        pricingHandler,
+		Reconciliation: reconciliationHandler,
+	}, &routeMiddlewares{
+		StrictOrigin: StrictOriginMiddleware("https://rom6n.github.io", botToken),
+		Support:      RoleMiddleware(userRepo, user.RoleSupport),
+		MarketAdmin:  RoleMiddleware(userRepo, user.RoleMarketAdmin),
+		SuperAdmin:   RoleMiddleware(userRepo, user.RoleSuperAdmin),
+		Idempotent:   IdempotencyMiddleware(idempotencyRepo),
 	})
 
-	api := app.Group("/api")
-	walletApi := api.Group("/wallet")
-	userApi := api.Group("/user")
-	nftCollectionApi := api.Group("/nft-collection", StrictOriginMiddleware("https://rom6n.github.io", botToken))
-	nftItemApi := api.Group("/nft-item", StrictOriginMiddleware("https://rom6n.github.io", botToken))
-	marketApi := api.Group("/market", StrictOriginMiddleware("https://rom6n.github.io", botToken))
-
-	walletApi.Get("/get-wallet-data", walletHandler.GetWalletData())
-	walletApi.Post("/refresh-wallet-nft-items", walletHandler.RefreshWalletNftItems())
-
-	marketApi.Post("/deploy", marketplaceHandler.DeployMarketContract())
-	marketApi.Post("/deposit", marketplaceHandler.DepositMarket())
-	marketApi.Post("/withdraw", marketplaceHandler.WithdrawTonFromMarketContract())
-
-	userApi.Get("/:id", userHandler.GetUserData())
-	userApi.Get("/nft-collections/:id", userHandler.GetUserNftCollections())
-	userApi.Get("/nft-items/:id", userHandler.GetUserNftItems())
-	userApi.Post("/withdraw/:id", StrictOriginMiddleware("https://rom6n.github.io", botToken), userHandler.WithdrawUserTON())
-
-	nftCollectionApi.Post("/deploy", nftCollectionHandler.DeployNftCollection())              // В будущем поменять на POST
-	nftCollectionApi.Post("/withdraw/:address", nftCollectionHandler.WithdrawNftCollection()) // В будущем поменять на POST
-
-	nftItemApi.Post("/mint", nftItemHandler.MintNftItem())                  // В будущем поменять на POST
-	nftItemApi.Post("/withdraw/:address", nftItemHandler.WithdrawNftItem()) // В будущем поменять на POST
-
 	go func() {
 		port := os.Getenv("PORT")
 		if port == "" {
@@ -296,6 +662,19 @@ func main() {
 		log.Fatalf("Error shutting down server: %v. Forced shutdown", shotdownErr)
 	}
 
+	stopOperationTracker()
+	stopAuctions()
+	stopReconciliation()
+
+	// finishing current withdrawal, pending ones stay in database until next start
+	stopWithdrawQueue()
+	select {
+	case <-withdrawQueueDone:
+		log.Println("✅ Withdraw queue drained")
+	case <-ctxShutdown.Done():
+		log.Println("Withdraw queue is not drained in time")
+	}
+
 	for i := shutdownTime; i > 0; i -= 1 {
 		log.Printf("🕒 Shutting down in %v seconds...\n", i)
 		time.Sleep(1 * time.Second)
@@ -310,18 +689,206 @@ func StrictOriginMiddleware(allowedOrigin, botToken string) fiber.Handler {
 		initData := c.Get("X-Init-Data", "")
 		if initData == "" {
 			log.Printf("Error: No X-Init-Data header \n")
-			return c.Status(fiber.StatusForbidden).SendString("Forbidden: no init data")
+			return handler.SendError(c, fiber.StatusForbidden, handler.CodeForbidden, "Forbidden: no init data")
 		}
 
 		if origin != allowedOrigin {
 			log.Printf("Error: Not supported origin \n")
-			return c.Status(fiber.StatusForbidden).SendString("Forbidden: invalid origin")
+			return handler.SendError(c, fiber.StatusForbidden, handler.CodeForbidden, "Forbidden: invalid origin")
 		}
 
 		if !telegutils.VerifyTelegramInitData(initData, botToken) {
-			return c.Status(fiber.StatusForbidden).SendString("Forbidden: wrong init data")
+			return handler.SendError(c, fiber.StatusForbidden, handler.CodeForbidden, "Forbidden: wrong init data")
+		}
+
+		// handlers act on behalf of this user only
+		initDataUser, parseErr := telegutils.ParseTelegramInitDataUser(initData)
+		if parseErr != nil {
+			log.Printf("Error: %v \n", parseErr)
+			return handler.SendError(c, fiber.StatusForbidden, handler.CodeForbidden, "Forbidden: no user in init data")
 		}
+		handler.SetInitDataUser(c, initDataUser)
 
 		return c.Next()
 	}
 }
+
+// RoleMiddleware allows users with any of roles. It must be used after StrictOriginMiddleware
+func RoleMiddleware(userRepo user.UserRepository, roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		initDataUser, ok := handler.InitDataUser(c)
+		if !ok {
+			return handler.SendError(c, fiber.StatusUnauthorized, handler.CodeUnauthorized, "Unauthorized: no telegram user")
+		}
+
+		actingUser, userErr := userRepo.GetUserByID(c.Context(), initDataUser.ID)
+		if userErr != nil {
+			log.Printf("Error: getting role of user %v: %v \n", initDataUser.ID, userErr)
+			return handler.SendError(c, fiber.StatusForbidden, handler.CodeForbidden, "Forbidden: user has no role")
+		}
+
+		if !actingUser.HasRole(roles...) {
+			log.Printf("Error: user %v with role %v is not allowed \n", initDataUser.ID, actingUser.Role)
+			return handler.SendError(c, fiber.StatusForbidden, handler.CodeForbidden, "Forbidden: not enough rights")
+		}
+
+		return c.Next()
+	}
+}
+
+// IdempotencyMiddleware stores the first response of request with Idempotency-Key header and replays it on retries.
+// It must be used after StrictOriginMiddleware, keys are unique per telegram user
+func IdempotencyMiddleware(idempotencyRepo idempotency.IdempotencyRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get("Idempotency-Key")
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return handler.SendError(c, fiber.StatusBadRequest, handler.CodeValidationFailed, "Idempotency-Key must be at most 255 characters")
+		}
+
+		initDataUser, ok := handler.InitDataUser(c)
+		if !ok {
+			return handler.SendError(c, fiber.StatusUnauthorized, handler.CodeUnauthorized, "Unauthorized: no telegram user")
+		}
+
+		requestHash := sha256.New()
+		requestHash.Write([]byte(c.Method() + " " + c.Path() + "?" + string(c.Request().URI().QueryString()) + "\n"))
+		requestHash.Write(c.Body())
+
+		record := idempotency.New(initDataUser.ID, key, hex.EncodeToString(requestHash.Sum(nil)))
+		createErr := idempotencyRepo.CreateRecord(c.Context(), record)
+		if createErr != nil && !errors.Is(createErr, idempotency.ErrKeyExists) {
+			log.Printf("Error: creating idempotency record: %v \n", createErr)
+			return handler.SendError(c, fiber.StatusInternalServerError, handler.CodeInternal, "Error while checking Idempotency-Key")
+		}
+
+		if errors.Is(createErr, idempotency.ErrKeyExists) {
+			storedRecord, getErr := idempotencyRepo.GetRecord(c.Context(), initDataUser.ID, key)
+			if getErr != nil {
+				log.Printf("Error: getting idempotency record: %v \n", getErr)
+				return handler.SendError(c, fiber.StatusInternalServerError, handler.CodeInternal, "Error while checking Idempotency-Key")
+			}
+
+			switch {
+			case storedRecord.RequestHash != record.RequestHash:
+				return handler.SendError(c, fiber.StatusUnprocessableEntity, handler.CodeIdempotencyKeyReused, "Idempotency-Key is already used for another request")
+			case storedRecord.Status == idempotency.StatusInProgress:
+				return handler.SendError(c, fiber.StatusConflict, handler.CodeConflict, "Request with this Idempotency-Key is in progress")
+			}
+
+			c.Set("Idempotent-Replayed", "true")
+			if storedRecord.ContentType != "" {
+				c.Set(fiber.HeaderContentType, storedRecord.ContentType)
+			}
+			return c.Status(storedRecord.StatusCode).Send(storedRecord.Body)
+		}
+
+		// record is saved even if client is gone, so a retry doesnt charge again
+		if nextErr := c.Next(); nextErr != nil {
+			if deleteErr := idempotencyRepo.DeleteRecord(context.Background(), initDataUser.ID, key); deleteErr != nil {
+				log.Printf("Error: releasing Idempotency-Key %v of user %v: %v \n", key, initDataUser.ID, deleteErr)
+			}
+			return nextErr
+		}
+
+		response := c.Response()
+		body := append([]byte(nil), response.Body()...)
+		if completeErr := idempotencyRepo.CompleteRecord(context.Background(), initDataUser.ID, key, response.StatusCode(), string(response.Header.ContentType()), body); completeErr != nil {
+			// key stays in progress until it expires, retries get 409 instead of being charged again
+			log.Printf("Error: storing response of Idempotency-Key %v of user %v: %v \n", key, initDataUser.ID, completeErr)
+		}
+
+		return nil
+	}
+}
+
+// GetSuperAdminID returns telegram id of user who gets super admin role on start, 0 if SUPER_ADMIN_ID isnt set
+func GetSuperAdminID() int64 {
+	rawID := os.Getenv("SUPER_ADMIN_ID")
+	if rawID == "" {
+		return 0
+	}
+
+	id, parseErr := strconv.ParseInt(rawID, 10, 64)
+	if parseErr != nil {
+		log.Fatalf("SUPER_ADMIN_ID env var is not valid: %v \n", parseErr)
+	}
+
+	return id
+}
+
+// GetMetadataBaseURL returns public url of the app which links of hosted metadata and media start with.
+// Nft item content is stored without https:// common content, so url must be https
+func GetMetadataBaseURL() string {
+	baseURL := os.Getenv("METADATA_BASE_URL")
+	if !strings.HasPrefix(baseURL, "https://") {
+		log.Fatalf("METADATA_BASE_URL env var must be https url: %v \n", baseURL)
+	}
+
+	return baseURL
+}
+
+// GetMediaDir returns directory of uploaded media files
+func GetMediaDir() string {
+	dir := os.Getenv("MEDIA_DIR")
+	if dir == "" {
+		dir = "./media"
+	}
+
+	return dir
+}
+
+// GetReconciliationPolicy returns repair policy of scheduled reconciliation, mismatches are only reported by default
+func GetReconciliationPolicy() reconciliation.Policy {
+	rawPolicy := os.Getenv("RECONCILIATION_POLICY")
+	if rawPolicy == "" {
+		return reconciliation.PolicyReport
+	}
+
+	policy, policyErr := reconciliation.ParsePolicy(rawPolicy)
+	if policyErr != nil {
+		log.Fatalf("RECONCILIATION_POLICY env var is not valid: %v \n", policyErr)
+	}
+
+	return policy
+}
+
+// GetPricingMargin returns service margin added to quotes, fixed nano ton and percent of operation price
+func GetPricingMargin() (uint64, uint64) {
+	marginNanoTon, marginPercent := uint64(5000000), uint64(0)
+
+	if rawMargin := os.Getenv("PRICING_MARGIN_NANO_TON"); rawMargin != "" {
+		parsed, parseErr := strconv.ParseUint(rawMargin, 10, 64)
+		if parseErr != nil {
+			log.Fatalf("PRICING_MARGIN_NANO_TON env var is not valid: %v \n", parseErr)
+		}
+		marginNanoTon = parsed
+	}
+
+	if rawPercent := os.Getenv("PRICING_MARGIN_PERCENT"); rawPercent != "" {
+		parsed, parseErr := strconv.ParseUint(rawPercent, 10, 64)
+		if parseErr != nil {
+			log.Fatalf("PRICING_MARGIN_PERCENT env var is not valid: %v \n", parseErr)
+		}
+		marginPercent = parsed
+	}
+
+	return marginNanoTon, marginPercent
+}
+
+// GetMarketplaceFeePercent returns percent of listing price or winning bid kept by platform on internal sales
+func GetMarketplaceFeePercent() uint64 {
+	feePercent := uint64(2)
+
+	if rawPercent := os.Getenv("MARKETPLACE_FEE_PERCENT"); rawPercent != "" {
+		parsed, parseErr := strconv.ParseUint(rawPercent, 10, 64)
+		if parseErr != nil || parsed > 100 {
+			log.Fatalf("MARKETPLACE_FEE_PERCENT env var is not valid percent: %v \n", rawPercent)
+		}
+		feePercent = parsed
+	}
+
+	return feePercent
+}
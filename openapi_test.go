@@ -0,0 +1,219 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/user"
+	"github.com/rom6n/create-nft-go/internal/ports/http/openapi"
+)
+
+const handlerDir = "internal/ports/http/handler"
+
+// handlerMethod matches name of closure returned by handler method, like handler.(*NftItemHandler).MintNftItem.func1
+var handlerMethod = regexp.MustCompile(`/handler\.\(?\*?(\w+)\)?\.(\w+)\.func\d+$`)
+
+// responseStatuses are status codes of fiber.Map responses
+var responseStatuses = map[string]int{
+	"StatusOK":          fiber.StatusOK,
+	"StatusCreated":     fiber.StatusCreated,
+	"StatusAccepted":    fiber.StatusAccepted,
+	"StatusMultiStatus": fiber.StatusMultiStatus,
+}
+
+func newTestApp() *fiber.App {
+	app := fiber.New()
+	registerRoutes(app, &routeHandlers{}, &routeMiddlewares{
+		StrictOrigin: StrictOriginMiddleware("https://rom6n.github.io", "token"),
+		Support:      RoleMiddleware(nil, user.RoleSupport),
+		MarketAdmin:  RoleMiddleware(nil, user.RoleMarketAdmin),
+		SuperAdmin:   RoleMiddleware(nil, user.RoleSuperAdmin),
+		Idempotent:   IdempotencyMiddleware(nil),
+	})
+	return app
+}
+
+// handlerSource reads what handlers of package handler do with request and response
+type handlerSource struct {
+	t       *testing.T
+	methods map[string]*ast.FuncDecl // "Receiver.Method"
+	funcs   map[string]*ast.FuncDecl
+}
+
+func parseHandlerSource(t *testing.T) *handlerSource {
+	t.Helper()
+	packages, parseErr := parser.ParseDir(token.NewFileSet(), handlerDir, nil, 0)
+	if parseErr != nil {
+		t.Fatalf("error parsing handlers: %v", parseErr)
+	}
+
+	source := &handlerSource{t: t, methods: map[string]*ast.FuncDecl{}, funcs: map[string]*ast.FuncDecl{}}
+	for _, file := range packages["handler"].Files {
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			if funcDecl.Recv == nil {
+				source.funcs[funcDecl.Name.Name] = funcDecl
+				continue
+			}
+			receiver := funcDecl.Recv.List[0].Type
+			if star, isPointer := receiver.(*ast.StarExpr); isPointer {
+				receiver = star.X
+			}
+			source.methods[receiver.(*ast.Ident).Name+"."+funcDecl.Name.Name] = funcDecl
+		}
+	}
+	return source
+}
+
+// endpoint returns source of handler route ends with, nil if route isnt handled by package handler
+func (s *handlerSource) endpoint(route fiber.Route) *ast.FuncDecl {
+	endpoint := route.Handlers[len(route.Handlers)-1]
+	name := runtime.FuncForPC(reflect.ValueOf(endpoint).Pointer()).Name()
+	match := handlerMethod.FindStringSubmatch(name)
+	if match == nil {
+		return nil
+	}
+	method, ok := s.methods[match[1]+"."+match[2]]
+	if !ok {
+		s.t.Fatalf("source of %v isnt found", name)
+	}
+	return method
+}
+
+// read returns query parameters read by handler and json keys of its fiber.Map responses by status code
+func (s *handlerSource) read(handler *ast.FuncDecl) ([]string, map[int][]string) {
+	var queries []string
+	maps := map[int][]string{}
+	visited := map[*ast.FuncDecl]bool{}
+	seen := map[string]bool{}
+
+	var walk func(funcDecl *ast.FuncDecl)
+	walk = func(funcDecl *ast.FuncDecl) {
+		if visited[funcDecl] {
+			return
+		}
+		visited[funcDecl] = true
+
+		// keys of literals ranged over, like for key := range map[string]*uint64{"min-price": ...}
+		rangeKeys := map[string][]string{}
+		ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.RangeStmt:
+				key, isIdent := node.Key.(*ast.Ident)
+				literal, isLiteral := node.X.(*ast.CompositeLit)
+				if isIdent && isLiteral {
+					for _, element := range literal.Elts {
+						if keyValue, ok := element.(*ast.KeyValueExpr); ok {
+							rangeKeys[key.Name] = append(rangeKeys[key.Name], stringLiteral(keyValue.Key))
+						}
+					}
+				}
+			case *ast.CallExpr:
+				switch fun := node.Fun.(type) {
+				case *ast.Ident:
+					if helper, ok := s.funcs[fun.Name]; ok {
+						walk(helper)
+					}
+				case *ast.SelectorExpr:
+					switch fun.Sel.Name {
+					case "Query", "QueryInt", "QueryBool", "QueryFloat", "PeekMulti":
+						names := []string{stringLiteral(node.Args[0])}
+						if ident, ok := node.Args[0].(*ast.Ident); ok {
+							names = rangeKeys[ident.Name]
+						}
+						for _, name := range names {
+							if name != "" && !seen[name] {
+								seen[name] = true
+								queries = append(queries, name)
+							}
+						}
+					case "JSON":
+						if status, keys, ok := s.mapResponse(fun.X, node.Args[0]); ok {
+							maps[status] = keys
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	walk(handler)
+
+	return queries, maps
+}
+
+// mapResponse returns status code and keys of c.Status(fiber.StatusX).JSON(fiber.Map{...})
+func (s *handlerSource) mapResponse(receiver, body ast.Expr) (int, []string, bool) {
+	literal, ok := body.(*ast.CompositeLit)
+	if !ok {
+		return 0, nil, false
+	}
+	if mapType, ok := literal.Type.(*ast.SelectorExpr); !ok || mapType.Sel.Name != "Map" {
+		return 0, nil, false
+	}
+
+	status := fiber.StatusOK
+	if statusCall, ok := receiver.(*ast.CallExpr); ok {
+		statusName := statusCall.Args[0].(*ast.SelectorExpr).Sel.Name
+		if status, ok = responseStatuses[statusName]; !ok {
+			s.t.Fatalf("status %v of fiber.Map response isnt known", statusName)
+		}
+	}
+
+	var keys []string
+	for _, element := range literal.Elts {
+		keys = append(keys, stringLiteral(element.(*ast.KeyValueExpr).Key))
+	}
+	return status, keys, true
+}
+
+func stringLiteral(expr ast.Expr) string {
+	literal, ok := expr.(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return ""
+	}
+	value, _ := strconv.Unquote(literal.Value)
+	return value
+}
+
+func TestOpenapiSpecDescribesRoutes(t *testing.T) {
+	routes := newTestApp().GetRoutes(true)
+	source := parseHandlerSource(t)
+
+	queries := map[string][]string{}
+	maps := map[string]map[int][]string{}
+	for _, route := range routes {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		handler := source.endpoint(route)
+		if handler == nil {
+			continue
+		}
+		operation := openapi.Operation(route)
+		queries[operation], maps[operation] = source.read(handler)
+	}
+
+	if specErr := openapi.CheckRoutes(routes); specErr != nil {
+		t.Errorf("%v", specErr)
+	}
+	if specErr := openapi.CheckParameters(routes, queries); specErr != nil {
+		t.Errorf("%v", specErr)
+	}
+	if specErr := openapi.CheckSchemas(openapi.Schemas); specErr != nil {
+		t.Errorf("%v", specErr)
+	}
+	if specErr := openapi.CheckResponses(openapi.Schemas, maps); specErr != nil {
+		t.Errorf("%v", specErr)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+	"github.com/rom6n/create-nft-go/internal/ports/http/handler"
+	"github.com/rom6n/create-nft-go/internal/ports/http/openapi"
+	"github.com/valyala/fasthttp"
+)
+
+// mediaUploadPaths are routes receiving media file, only they accept body bigger than default body limit of the app
+var mediaUploadPaths = []string{"/api/media/upload", "/api/v2/media"}
+
+// routeHandlers are handlers of all routes of the app
+type routeHandlers struct {
+	Wallet         handler.WalletHandler
+	User           handler.UserHandler
+	NftCollection  handler.NftCollectionHandler
+	NftItem        handler.NftItemHandler
+	Listing        handler.ListingHandler
+	Auction        handler.AuctionHandler
+	Marketplace    handler.MarketplaceContractHandler
+	Deposit        handler.DepositHandler
+	HostedMetadata handler.HostedMetadataHandler
+	Media          handler.MediaHandler
+	Role           handler.RoleHandler
+	Search         handler.SearchHandler
+	Pricing        handler.PricingHandler
+	Reconciliation handler.ReconciliationHandler
+}
+
+// routeMiddlewares are middlewares routes are grouped by
+type routeMiddlewares struct {
+	StrictOrigin fiber.Handler // mini app routes, they need telegram init data
+	Support      fiber.Handler
+	MarketAdmin  fiber.Handler
+	SuperAdmin   fiber.Handler
+	Idempotent   fiber.Handler // paid routes charge user's balance, so retries with the same Idempotency-Key replay the first response
+}
+
+// registerRoutes registers routes of v1 and v2 api, all of them are described in openapi spec
+func registerRoutes(app *fiber.App, h *routeHandlers, m *routeMiddlewares) {
+	app.Get("/ping", func(c *fiber.Ctx) error {
+		return c.SendString("pong")
+	})
+
+	app.Get("/favicon.ico", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	api := app.Group("/api")
+	walletApi := api.Group("/wallet")
+	userApi := api.Group("/user", m.StrictOrigin)
+	nftCollectionApi := api.Group("/nft-collection", m.StrictOrigin)
+	nftItemApi := api.Group("/nft-item", m.StrictOrigin)
+	listingApi := api.Group("/listing", m.StrictOrigin)
+	auctionApi := api.Group("/auction", m.StrictOrigin)
+	supportApi := api.Group("/support", m.StrictOrigin, m.Support)
+	marketApi := api.Group("/market", m.StrictOrigin, m.MarketAdmin)
+	rolesApi := api.Group("/roles", m.StrictOrigin, m.SuperAdmin)
+	reconciliationApi := api.Group("/reconciliation", m.StrictOrigin, m.SuperAdmin)
+
+	apiV2 := app.Group("/api/v2")
+	nftCollectionApiV2 := apiV2.Group("/nft-collections", m.StrictOrigin)
+	nftItemApiV2 := apiV2.Group("/nft-items", m.StrictOrigin)
+	userApiV2 := apiV2.Group("/user", m.StrictOrigin)
+	listingApiV2 := apiV2.Group("/listings", m.StrictOrigin)
+	auctionApiV2 := apiV2.Group("/auctions", m.StrictOrigin)
+	mediaApiV2 := apiV2.Group("/media", m.StrictOrigin)
+	marketApiV2 := apiV2.Group("/market", m.StrictOrigin, m.MarketAdmin)
+
+	api.Get("/openapi.json", openapi.Handler())
+	api.Get("/search", h.Search.Search())
+	api.Get("/quote", h.Pricing.Quote())
+	// hosted metadata is fetched by wallets and indexers, so it is public
+	api.Get("/metadata/:hash", h.HostedMetadata.GetMetadataDocument())
+	api.Get("/media/files/:key", h.Media.GetMediaFile())
+	api.Get("/media/:hash", h.Media.GetMedia())
+	api.Post("/media/upload", m.StrictOrigin, h.Media.UploadMedia())
+
+	walletApi.Get("/get-wallet-data", h.Wallet.GetWalletData())
+	walletApi.Post("/refresh-wallet-nft-items", h.Wallet.RefreshWalletNftItems())
+
+	marketApi.Post("/deploy", h.Marketplace.DeployMarketContract())
+	marketApi.Post("/deposit", h.Marketplace.DepositMarket())
+	marketApi.Post("/withdraw", h.Marketplace.WithdrawTonFromMarketContract())
+
+	userApi.Get("/:id", h.User.GetUserData())
+	userApi.Get("/nft-collections/:id", h.User.GetUserNftCollections())
+	userApi.Get("/nft-items/:id", h.User.GetUserNftItems())
+	userApi.Get("/balance-entries/:id", h.User.GetUserBalanceEntries())
+	userApi.Get("/deposit-memo/:id", h.User.GetUserDepositMemo())
+	userApi.Get("/royalty-earnings/:id", h.User.GetUserRoyaltyEarnings())
+	userApi.Post("/withdraw/:id", m.Idempotent, h.User.WithdrawUserTON())
+
+	nftCollectionApi.Post("/deploy", m.Idempotent, h.NftCollection.DeployNftCollection())              // В будущем поменять на POST
+	nftCollectionApi.Post("/withdraw/:address", m.Idempotent, h.NftCollection.WithdrawNftCollection()) // В будущем поменять на POST
+	nftCollectionApi.Post("/change-content/:address", m.Idempotent, h.NftCollection.ChangeNftCollectionContent())
+
+	nftItemApi.Post("/mint", m.Idempotent, h.NftItem.MintNftItem())                  // В будущем поменять на POST
+	nftItemApi.Post("/withdraw/:address", m.Idempotent, h.NftItem.WithdrawNftItem()) // В будущем поменять на POST
+	nftItemApi.Post("/batch-mint", m.Idempotent, h.NftItem.BatchMintNftItems())
+	nftItemApi.Post("/transfer/:address", h.NftItem.TransferNftItem())
+	nftItemApi.Get("/history/:address", h.NftItem.GetNftItemHistory())
+
+	listingApi.Get("/search", h.Listing.SearchListings())
+	listingApi.Get("/:id", h.Listing.GetListing())
+	listingApi.Post("/create", h.Listing.CreateListing())
+	listingApi.Post("/cancel/:id", h.Listing.CancelListing())
+	listingApi.Post("/buy/:id", m.Idempotent, h.Listing.BuyListing())
+
+	auctionApi.Get("/search", h.Auction.SearchAuctions())
+	auctionApi.Get("/:id", h.Auction.GetAuction())
+	auctionApi.Get("/bids/:id", h.Auction.GetAuctionBids())
+	auctionApi.Post("/create", h.Auction.CreateAuction())
+	auctionApi.Post("/cancel/:id", h.Auction.CancelAuction())
+	auctionApi.Post("/bid/:id", m.Idempotent, h.Auction.PlaceBid())
+
+	supportApi.Get("/deposits/unmatched", h.Deposit.GetUnmatchedDeposits())
+	supportApi.Post("/deposits/:id/assign", h.Deposit.AssignDeposit())
+	supportApi.Post("/deposits/:id/refund", h.Deposit.RefundDeposit())
+
+	reconciliationApi.Post("/", h.Reconciliation.StartReconciliation())
+	reconciliationApi.Get("/reports", h.Reconciliation.GetReconciliationReports())
+	reconciliationApi.Get("/reports/:id", h.Reconciliation.GetReconciliationReport())
+
+	rolesApi.Post("/:id", h.Role.GrantRole())
+	rolesApi.Delete("/:id", h.Role.RevokeRole())
+	rolesApi.Get("/:id/audit", h.Role.GetAuditEntries())
+
+	// v2 api takes json bodies and answers errors with handler.ErrorResponse
+	nftCollectionApiV2.Post("/", m.Idempotent, h.NftCollection.DeployNftCollectionV2())
+	nftCollectionApiV2.Post("/:address/withdraw", m.Idempotent, h.NftCollection.WithdrawNftCollectionV2())
+	nftCollectionApiV2.Post("/:address/content", m.Idempotent, h.NftCollection.ChangeNftCollectionContentV2())
+
+	nftItemApiV2.Post("/", m.Idempotent, h.NftItem.MintNftItemV2())
+	nftItemApiV2.Post("/batch", m.Idempotent, h.NftItem.BatchMintNftItemsV2())
+	nftItemApiV2.Post("/:address/withdraw", m.Idempotent, h.NftItem.WithdrawNftItemV2())
+	nftItemApiV2.Post("/:address/transfer", h.NftItem.TransferNftItemV2())
+	nftItemApiV2.Get("/:address/history", h.NftItem.GetNftItemHistoryV2())
+
+	listingApiV2.Get("/", h.Listing.SearchListingsV2())
+	listingApiV2.Post("/", h.Listing.CreateListingV2())
+	listingApiV2.Get("/:id", h.Listing.GetListingV2())
+	listingApiV2.Post("/:id/cancel", h.Listing.CancelListingV2())
+	listingApiV2.Post("/:id/buy", m.Idempotent, h.Listing.BuyListingV2())
+
+	auctionApiV2.Get("/", h.Auction.SearchAuctionsV2())
+	auctionApiV2.Post("/", h.Auction.CreateAuctionV2())
+	auctionApiV2.Get("/:id", h.Auction.GetAuctionV2())
+	auctionApiV2.Get("/:id/bids", h.Auction.GetAuctionBidsV2())
+	auctionApiV2.Post("/:id/cancel", h.Auction.CancelAuctionV2())
+	auctionApiV2.Post("/:id/bids", m.Idempotent, h.Auction.PlaceBidV2())
+
+	userApiV2.Post("/withdraw", m.Idempotent, h.User.WithdrawUserTONV2())
+	userApiV2.Get("/royalty-earnings", h.User.GetUserRoyaltyEarningsV2())
+
+	mediaApiV2.Post("/", h.Media.UploadMediaV2())
+
+	marketApiV2.Post("/deploy", h.Marketplace.DeployMarketContractV2())
+	marketApiV2.Post("/deposit", h.Marketplace.DepositMarketV2())
+	marketApiV2.Post("/withdraw", h.Marketplace.WithdrawTonFromMarketContractV2())
+}
+
+// mediaUploadBodyLimit is called by server before body is read, it raises body limit of media upload routes only
+func mediaUploadBodyLimit(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+	if !header.IsPost() {
+		return fasthttp.RequestConfig{}
+	}
+
+	path, _, _ := strings.Cut(string(header.RequestURI()), "?")
+	path = strings.TrimSuffix(path, "/")
+	for _, uploadPath := range mediaUploadPaths {
+		if strings.EqualFold(path, uploadPath) {
+			return fasthttp.RequestConfig{MaxRequestBodySize: media.MaxFileSize + 1024*1024} // multipart form overhead
+		}
+	}
+
+	return fasthttp.RequestConfig{}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rom6n/create-nft-go/internal/domain/media"
+	"github.com/valyala/fasthttp"
+)
+
+func TestMediaUploadBodyLimit(t *testing.T) {
+	app := fiber.New()
+	app.Server().HeaderReceived = mediaUploadBodyLimit
+	ok := func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	app.Post("/api/media/upload", ok)
+	app.Post("/api/v2/media", ok)
+	app.Post("/api/nft-item/mint", ok)
+	app.Put("/api/v2/media", ok)
+
+	tests := map[string]struct {
+		method       string
+		target       string
+		size         int
+		wantTooLarge bool
+	}{
+		"media file on v1 upload":                 {method: fiber.MethodPost, target: "/api/media/upload?kind=nft_item_image", size: media.MaxFileSize},
+		"media file on v2 upload":                 {method: fiber.MethodPost, target: "/api/v2/media/", size: media.MaxFileSize},
+		"body over media limit":                   {method: fiber.MethodPost, target: "/api/v2/media", size: media.MaxFileSize + 1024*1024 + 1, wantTooLarge: true},
+		"body over default limit of other route":  {method: fiber.MethodPost, target: "/api/nft-item/mint", size: fiber.DefaultBodyLimit + 1, wantTooLarge: true},
+		"body over default limit of other method": {method: fiber.MethodPut, target: "/api/v2/media", size: fiber.DefaultBodyLimit + 1, wantTooLarge: true},
+		"body under default limit of other route": {method: fiber.MethodPost, target: "/api/nft-item/mint", size: fiber.DefaultBodyLimit},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.target, bytes.NewReader(make([]byte, test.size)))
+
+			// server rejects too large body before app handles it, so test app returns error of server
+			resp, testErr := app.Test(req, -1)
+			if test.wantTooLarge {
+				if !errors.Is(testErr, fasthttp.ErrBodyTooLarge) {
+					t.Fatalf("want fasthttp.ErrBodyTooLarge, have %v", testErr)
+				}
+				return
+			}
+			if testErr != nil {
+				t.Fatalf("request error: %v", testErr)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("want status %v, have %v", fiber.StatusOK, resp.StatusCode)
+			}
+		})
+	}
+}